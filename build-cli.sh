@@ -14,15 +14,18 @@ fi
 
 
 UNAME=$(uname -s)
+GIT_COMMIT=$(git rev-parse --short HEAD 2>/dev/null || echo unknown)
+BUILD_DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)
+LDFLAGS="-X main.version=$VERSION -X main.gitCommit=$GIT_COMMIT -X main.buildDate=$BUILD_DATE"
 
-vendor/go-bindata-${UNAME} -pkg constants -o constants/assets.go ./schema/0.1.0/
+vendor/go-bindata-${UNAME} -pkg constants -o constants/assets.go ./schema/0.1.0/ ./schema/0.1.0/templates/
 echo Building cross platform Seed CLI.
 echo Building for Linux...
-CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -a -ldflags "-X main.version=$VERSION -extldflags=\"-static\"" -o output/seed-linux-amd64
+CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -a -ldflags "$LDFLAGS -extldflags=\"-static\"" -o output/seed-linux-amd64
 echo Building for OSX...
-CGO_ENABLED=0 GOOS=darwin GOARCH=amd64 go build -a -ldflags "-X main.version=$VERSION -extldflags=\"-static\"" -o output/seed-darwin-amd64
+CGO_ENABLED=0 GOOS=darwin GOARCH=amd64 go build -a -ldflags "$LDFLAGS -extldflags=\"-static\"" -o output/seed-darwin-amd64
 echo Building for Windows...
-CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -a -ldflags "-X main.version=$VERSION -extldflags=\"-static\"" -o output/seed-windows-amd64
+CGO_ENABLED=0 GOOS=windows GOARCH=amd64 go build -a -ldflags "$LDFLAGS -extldflags=\"-static\"" -o output/seed-windows-amd64
 echo CLI build complete
 
 echo Building example images.................................................................
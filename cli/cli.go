@@ -0,0 +1,1946 @@
+//Package cli implements the seed command line. Run parses the arguments of a seed command and
+// runs it, so the CLI can be embedded in other programs and tests.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/commands"
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
+	"github.com/ngageoint/seed-cli/util"
+	"strconv"
+	"fmt"
+)
+
+var batchCmd *flag.FlagSet
+var buildCmd *flag.FlagSet
+var completionCmd *flag.FlagSet
+var diffCmd *flag.FlagSet
+var doctorCmd *flag.FlagSet
+var initCmd *flag.FlagSet
+var listCmd *flag.FlagSet
+var loginCmd *flag.FlagSet
+var logoutCmd *flag.FlagSet
+var manifestStatsCmd *flag.FlagSet
+var manifestExtractCmd *flag.FlagSet
+
+// manifestExtractArgs holds the positional arguments of seed manifest extract, which may be
+// followed by flags
+var manifestExtractArgs []string
+var pipelineCmd *flag.FlagSet
+var pruneCmd *flag.FlagSet
+var scanCmd *flag.FlagSet
+var publishCmd *flag.FlagSet
+var pullCmd *flag.FlagSet
+var runCmd *flag.FlagSet
+var searchCmd *flag.FlagSet
+var validateCmd *flag.FlagSet
+var versionCmd *flag.FlagSet
+
+// configFile is the file of default flag values given with -config
+var configFile string
+
+// The version of the CLI and the git commit and date it was built from, set with SetVersion
+var version string
+var gitCommit string
+var buildDate string
+
+//SetVersion sets the version of the CLI and the git commit and date it was built from, reported
+// by seed version
+func SetVersion(cliVersion, commit, date string) {
+	version, gitCommit, buildDate = cliVersion, commit, date
+}
+
+//versionInfo is the version of the CLI and the seed spec printed by seed version -output json
+type versionInfo struct {
+	CliVersion            string   `json:"cliVersion"`
+	SpecVersion           string   `json:"specVersion"`
+	SupportedSpecVersions []string `json:"supportedSpecVersions"`
+	GitCommit             string   `json:"gitCommit"`
+	BuildDate             string   `json:"buildDate"`
+}
+
+// cliArgs holds the command line arguments of the current Run, including the program name
+var cliArgs []string
+
+// machineSummary is whether Run prints a SEED_RESULT line for log parsers when the command ends,
+// and commandFlags the parsed flags of the command, from which the fields of the line are taken
+var machineSummary bool
+var commandFlags *flag.FlagSet
+
+// jsonErrors is whether Run prints a failed command's error as a JSON object on stderr instead of
+// as text, and commandErr the error the command failed with, if it returned one
+var jsonErrors bool
+var commandErr error
+
+// summaryFlags are the flags reported in the SEED_RESULT line, by field name, for the commands
+// that define them
+var summaryFlags = []struct{ field, flag string }{
+	{"image", constants.ImgNameFlag},
+	{"directory", constants.JobDirectoryFlag},
+	{"outputDir", constants.JobOutputDirFlag},
+	{"registry", constants.RegistryFlag},
+	{"org", constants.OrgFlag},
+}
+
+//Run parses the command line arguments, including the program name, and runs the seed command
+// they specify. Returns the exit code of the command. Commands exit by panicking with a
+// util.Exit, which Run recovers, so deferred functions are called and seed may be run more than
+// once in the same process.
+func Run(args []string) (code int) {
+	defer func() {
+		if e := recover(); e != nil {
+			exit, ok := e.(util.Exit)
+			if !ok {
+				panic(e)
+			}
+			code = exit.Code
+		}
+		if jsonErrors && code != 0 {
+			fmt.Fprintln(os.Stderr, ErrorJson(commandName(), code, commandErr, util.LastError()))
+		}
+		if machineSummary && len(cliArgs) > 1 {
+			fmt.Fprintln(os.Stderr, MachineSummary(commandName(), code, commandFlags))
+		}
+	}()
+
+	util.Reset()
+	configFile = ""
+	machineSummary = false
+	commandFlags = nil
+	jsonErrors = false
+	commandErr = nil
+	manifestExtractArgs = nil
+	cliArgs = append([]string{}, args...)
+
+	// Parse input flags
+	DefineFlags()
+
+	// seed init: Create example seed.manifest.json. Does not require docker unless
+	// generating the manifest from an image
+	if initCmd.Parsed() {
+		if initCmd.Lookup(constants.ListTemplatesFlag).Value.String() == constants.TrueString {
+			fmt.Print(commands.FormatInitTemplates())
+			panic(util.Exit{0})
+		}
+		dir := initCmd.Lookup(constants.JobDirectoryFlag).Value.String()
+		fromImage := initCmd.Lookup(constants.FromImageFlag).Value.String()
+		if fromImage != "" {
+			util.CheckSudo()
+		}
+		err := commands.SeedInit(dir, commands.InitOptions{
+			Name:       initCmd.Lookup(constants.JobNameFlag).Value.String(),
+			JobVersion: initCmd.Lookup(constants.JobVersionFlag).Value.String(),
+			Maintainer: initCmd.Lookup(constants.MaintainerFlag).Value.String(),
+			FromImage:  fromImage,
+			Template:   initCmd.Lookup(constants.TemplateFlag).Value.String(),
+		})
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed validate: Validate seed.manifest.json. Does not require docker
+	if validateCmd.Parsed() {
+		schemaFile := validateCmd.Lookup(constants.SchemaFlag).Value.String()
+		var dirs []string
+		if d := validateCmd.Lookup(constants.JobDirectoryFlag).Value.String(); d != "" {
+			dirs = strings.Split(d, ",")
+		}
+		dirs = append(dirs, validateCmd.Args()...)
+		jobs, err := strconv.Atoi(validateCmd.Lookup(constants.JobsFlag).Value.String())
+		if err != nil {
+			util.Errorf("Error reading jobs flag: %s\n", err.Error())
+			panic(util.Exit{1})
+		}
+		maxWarnings, err := strconv.Atoi(validateCmd.Lookup(constants.MaxWarningsFlag).Value.String())
+		if err != nil {
+			util.Errorf("Error reading max-warnings flag: %s\n", err.Error())
+			panic(util.Exit{1})
+		}
+		if validateCmd.Lookup(constants.FailOnWarningFlag).Value.String() == constants.TrueString {
+			maxWarnings = 0
+		}
+		listInputs := validateCmd.Lookup(constants.ListInputsFlag).Value.String() == constants.TrueString
+		listOutputs := validateCmd.Lookup(constants.ListOutputsFlag).Value.String() == constants.TrueString
+		fromStdin := validateCmd.Lookup(constants.FromStdinFlag).Value.String() == constants.TrueString
+		options := commands.ValidateOptions{
+			SchemaFile:    schemaFile,
+			SchemaVersion: validateCmd.Lookup(constants.SchemaVersionFlag).Value.String(),
+			Jobs:          jobs,
+			MaxWarnings:   maxWarnings,
+			ListInputs:    listInputs,
+			ListOutputs:   listOutputs,
+			Fix:           validateCmd.Lookup(constants.FixFlag).Value.String() == constants.TrueString,
+			FixOutput:     validateCmd.Lookup(constants.OutputFileFlag).Value.String(),
+			CheckImage:    validateCmd.Lookup(constants.CheckImageFlag).Value.String(),
+			Output:        validateCmd.Lookup(constants.OutputFlag).Value.String(),
+			ReportFile:    validateCmd.Lookup(constants.ReportFileFlag).Value.String(),
+		}
+		if fromStdin || (len(dirs) == 1 && dirs[0] == "-") {
+			if len(dirs) > 0 && !(len(dirs) == 1 && dirs[0] == "-") {
+				util.Errorf("-%s cannot be used with manifest paths\n", constants.FromStdinFlag)
+				panic(util.Exit{1})
+			}
+			err = commands.ValidateReader(os.Stdin, "stdin", options)
+		} else {
+			err = commands.Validate(dirs, options)
+		}
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed search: Searches registry for seed images. Does not require docker
+	if searchCmd.Parsed() {
+		url := searchCmd.Lookup(constants.RegistryFlag).Value.String()
+		orgs := strings.Split(searchCmd.Lookup(constants.OrgFlag).Value.String(), ",")
+		filter := searchCmd.Lookup(constants.FilterFlag).Value.String()
+		username := searchCmd.Lookup(constants.UserFlag).Value.String()
+		password := searchCmd.Lookup(constants.PassFlag).Value.String()
+		output := searchCmd.Lookup(constants.OutputFlag).Value.String()
+		seedOnly := searchCmd.Lookup(constants.SeedOnlyFlag).Value.String() == constants.TrueString
+		util.SetNoColor(searchCmd.Lookup(constants.NoColorFlag).Value.String() == constants.TrueString)
+		sortBy := searchCmd.Lookup(constants.SortFlag).Value.String()
+		reverse := searchCmd.Lookup(constants.ReverseFlag).Value.String() == constants.TrueString
+		if err := commands.CheckSearchOutput(output); err != nil {
+			exitWithError(err)
+		}
+		if err := commands.CheckSearchSort(sortBy); err != nil {
+			exitWithError(err)
+		}
+		results, err := commands.SearchImages(url, orgs, filter, username, password, seedOnly)
+		if err != nil {
+			exitWithError(err)
+		}
+		commands.SortSearchResults(url, username, password, results, sortBy, reverse)
+		if err := commands.PrintSearchResults(results, orgs, output); err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed login: Stores credentials for a registry. Does not require docker
+	if loginCmd.Parsed() {
+		registry := loginCmd.Lookup(constants.RegistryFlag).Value.String()
+		user := loginCmd.Lookup(constants.UserFlag).Value.String()
+		err := commands.SeedLogin(registry, user, "")
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed logout: Removes stored credentials for a registry. Does not require docker
+	if logoutCmd.Parsed() {
+		registry := logoutCmd.Lookup(constants.RegistryFlag).Value.String()
+		err := commands.SeedLogout(registry)
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed completion: Prints a shell completion script. Does not require docker
+	if completionCmd.Parsed() {
+		err := commands.SeedCompletion(completionCmd.Arg(0), CompletionCommands())
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed diff: Compares two seed manifests. Does not require docker
+	if diffCmd.Parsed() {
+		if diffCmd.NArg() != 2 {
+			util.PrintUtil("seed diff requires two manifests to compare\n")
+			commands.PrintDiffUsage()
+		}
+		output := diffCmd.Lookup(constants.OutputFlag).Value.String()
+		err := commands.SeedDiff(diffCmd.Arg(0), diffCmd.Arg(1), output)
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed doctor: Checks the environment seed needs. Checks for sudo itself
+	if doctorCmd.Parsed() {
+		if _, err := commands.SeedDoctor(); err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed manifest stats: Summarizes a seed manifest. Does not require docker
+	if manifestStatsCmd.Parsed() {
+		path := "."
+		if manifestStatsCmd.NArg() > 0 {
+			path = manifestStatsCmd.Arg(0)
+		}
+		output := manifestStatsCmd.Lookup(constants.OutputFlag).Value.String()
+		err := commands.ManifestStatsSummary(path, output)
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed list -r: Lists seed compliant images on a remote registry. Does not require docker
+	if listCmd.Parsed() {
+		util.SetNoColor(listCmd.Lookup(constants.NoColorFlag).Value.String() == constants.TrueString)
+	}
+	if listCmd.Parsed() && listCmd.Lookup(constants.RegistryFlag).Value.String() != "" {
+		registry := listCmd.Lookup(constants.RegistryFlag).Value.String()
+		org := listCmd.Lookup(constants.OrgFlag).Value.String()
+		user := listCmd.Lookup(constants.UserFlag).Value.String()
+		pass := listCmd.Lookup(constants.PassFlag).Value.String()
+		output := listCmd.Lookup(constants.OutputFlag).Value.String()
+		filters := strings.Split(listCmd.Lookup(constants.FilterFlag).Value.String(), ",")
+		_, err := commands.DockerListRegistry(registry, org, user, pass, output, filters)
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// Checks if Docker requires sudo access. Prints error message if so.
+	util.CheckSudo()
+
+	// seed list: Lists all seed compliant images on (default) local machine
+	if listCmd.Parsed() {
+		output := listCmd.Lookup(constants.OutputFlag).Value.String()
+		filters := strings.Split(listCmd.Lookup(constants.FilterFlag).Value.String(), ",")
+		_, err := commands.DockerList(output, filters)
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed prune: Removes old versions of local seed images
+	if pruneCmd.Parsed() {
+		org := pruneCmd.Lookup(constants.OrgFlag).Value.String()
+		dryRun := pruneCmd.Lookup(constants.DryRunFlag).Value.String() == constants.TrueString
+		keep, err := strconv.Atoi(pruneCmd.Lookup(constants.KeepFlag).Value.String())
+		if err != nil {
+			util.Errorf("Error reading keep flag: must be a number of versions\n")
+			panic(util.Exit{1})
+		}
+		_, err = commands.DockerPrune(org, keep, dryRun)
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed build: Build Docker image
+	if buildCmd.Parsed() {
+		jobDirectory := buildCmd.Lookup(constants.JobDirectoryFlag).Value.String()
+		user := buildCmd.Lookup(constants.UserFlag).Value.String()
+		pass := buildCmd.Lookup(constants.PassFlag).Value.String()
+		noCache := buildCmd.Lookup(constants.NoCacheFlag).Value.String() == constants.TrueString
+		pull := buildCmd.Lookup(constants.PullFlag).Value.String() == constants.TrueString
+		if buildCmd.Lookup(constants.QuietFlag).Value.String() == constants.TrueString {
+			util.SetQuiet(true)
+		}
+		if err := util.SetProgressMode(buildCmd.Lookup(constants.ProgressFlag).Value.String()); err != nil {
+			util.Errorf("%s\n", err.Error())
+			panic(util.Exit{1})
+		}
+		compress := buildCmd.Lookup(constants.CompressFlag).Value.String() == constants.TrueString
+		contextLimit, err := strconv.Atoi(buildCmd.Lookup(constants.ContextLimitFlag).Value.String())
+		if err != nil {
+			util.Errorf("Error reading context-limit flag: %s\n", err.Error())
+			panic(util.Exit{1})
+		}
+		platform := buildCmd.Lookup(constants.PlatformFlag).Value.String()
+		tags := strings.Split(buildCmd.Lookup(constants.TagFlag).Value.String(), ",")
+		cacheFrom := strings.Split(buildCmd.Lookup(constants.CacheFromFlag).Value.String(), ",")
+		// secrets contain commas, so are not split from the joined flag value
+		secrets := *buildCmd.Lookup(constants.SecretFlag).Value.(*objects.ArrayFlags)
+		err = commands.DockerBuild(commands.BuildOptions{
+			JobDirectory: jobDirectory,
+			Username:     user,
+			Password:     pass,
+			NoCache:      noCache,
+			Pull:         pull,
+			Compress:     compress,
+			ContextLimit: contextLimit,
+			Platform:     platform,
+			Tags:         tags,
+			Squash:       buildCmd.Lookup(constants.SquashFlag).Value.String() == constants.TrueString,
+			Target:       buildCmd.Lookup(constants.TargetFlag).Value.String(),
+			CacheFrom:    cacheFrom,
+			PullCache:    buildCmd.Lookup(constants.PullCacheFlag).Value.String() == constants.TrueString,
+			Manifest:     buildCmd.Lookup(constants.ManifestFlag).Value.String(),
+			Dockerfile:   buildCmd.Lookup(constants.DockerfileFlag).Value.String(),
+			Secrets:      secrets,
+			Force:        buildCmd.Lookup(constants.ForceFlag).Value.String() == constants.TrueString,
+		})
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed batch: Run Docker image on all files in directory
+	if batchCmd.Parsed() {
+		batchDir := batchCmd.Lookup(constants.JobDirectoryFlag).Value.String()
+		batchFile := batchCmd.Lookup(constants.BatchFlag).Value.String()
+		imageName := batchCmd.Lookup(constants.ImgNameFlag).Value.String()
+		settings := strings.Split(batchCmd.Lookup(constants.SettingFlag).Value.String(), ",")
+		mounts := strings.Split(batchCmd.Lookup(constants.MountFlag).Value.String(), ",")
+		outputDir := batchCmd.Lookup(constants.JobOutputDirFlag).Value.String()
+		rmFlag := batchCmd.Lookup(constants.RmFlag).Value.String() == constants.TrueString
+		metadataSchema := batchCmd.Lookup(constants.SchemaFlag).Value.String()
+		err := commands.BatchRun(batchDir, batchFile, imageName, outputDir, metadataSchema, settings, mounts, rmFlag)
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed scan: Checks a built image is seed compliant
+	if scanCmd.Parsed() {
+		if scanCmd.NArg() != 1 {
+			util.PrintUtil("seed scan requires an image name\n")
+			commands.PrintScanUsage()
+		}
+		_, err := commands.SeedScan(scanCmd.Arg(0))
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed manifest extract: Writes the manifest of a built image to a file
+	if manifestExtractCmd.Parsed() {
+		if len(manifestExtractArgs) != 1 {
+			util.PrintUtil("seed manifest extract requires an image name\n")
+			commands.PrintManifestExtractUsage()
+		}
+		outputFile := manifestExtractCmd.Lookup(constants.OutputFileFlag).Value.String()
+		force := manifestExtractCmd.Lookup(constants.ForceFlag).Value.String() == constants.TrueString
+		err := commands.ManifestExtract(manifestExtractArgs[0], outputFile, force)
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed pipeline: Runs a pipeline of seed images in order
+	if pipelineCmd.Parsed() {
+		if pipelineCmd.NArg() != 1 {
+			util.PrintUtil("seed pipeline requires a pipeline file\n")
+			commands.PrintPipelineUsage()
+		}
+		outputDir := pipelineCmd.Lookup(constants.JobOutputDirFlag).Value.String()
+		rmFlag := pipelineCmd.Lookup(constants.RmFlag).Value.String() == constants.TrueString
+		err := commands.SeedPipeline(pipelineCmd.Arg(0), outputDir, rmFlag)
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed run: Runs docker image provided or found in seed manifest
+	if runCmd.Parsed() {
+		imageName := runCmd.Lookup(constants.ImgNameFlag).Value.String()
+		var inputs []string
+		if i := runCmd.Lookup(constants.InputsFlag).Value.String(); i != "" {
+			inputs = strings.Split(i, ",")
+		}
+		inputDir := runCmd.Lookup(constants.InputDirFlag).Value.String()
+		// URLs may contain commas, so are not split from the joined flag value
+		inputURLs := *runCmd.Lookup(constants.InputURLFlag).Value.(*objects.ArrayFlags)
+		directoryInputs := *runCmd.Lookup(constants.DirectoryInputFlag).Value.(*objects.ArrayFlags)
+		strictInputs := runCmd.Lookup(constants.StrictInputsFlag).Value.String() == constants.TrueString
+		settings := strings.Split(runCmd.Lookup(constants.SettingFlag).Value.String(), ",")
+		mounts := strings.Split(runCmd.Lookup(constants.MountFlag).Value.String(), ",")
+		ports := strings.Split(runCmd.Lookup(constants.PublishPortFlag).Value.String(), ",")
+		securityOpts := strings.Split(runCmd.Lookup(constants.SecurityOptFlag).Value.String(), ",")
+		addHosts := strings.Split(runCmd.Lookup(constants.AddHostFlag).Value.String(), ",")
+		// tmpfs options contain commas, so are not split from the joined flag value
+		tmpfs := *runCmd.Lookup(constants.TmpfsFlag).Value.(*objects.ArrayFlags)
+		outputDir := runCmd.Lookup(constants.JobOutputDirFlag).Value.String()
+		rmFlag := runCmd.Lookup(constants.RmFlag).Value.String() == constants.TrueString
+		keepFailed := runCmd.Lookup(constants.KeepFailedFlag).Value.String() == constants.TrueString
+		restarts, err := strconv.Atoi(runCmd.Lookup(constants.RestartOnFailureFlag).Value.String())
+		if err != nil || restarts < 0 {
+			util.Errorf("Error reading restart-on-failure flag: must be a number of restarts\n")
+			panic(util.Exit{1})
+		}
+		quiet := runCmd.Lookup(constants.QuietFlag).Value.String() == constants.TrueString
+		metadataSchema := runCmd.Lookup(constants.SchemaFlag).Value.String()
+		relativeTo := runCmd.Lookup(constants.InputsRelativeToFlag).Value.String()
+		jobDirectory := runCmd.Lookup(constants.JobDirectoryFlag).Value.String()
+		user := runCmd.Lookup(constants.UserFlag).Value.String()
+		hostUser := runCmd.Lookup(constants.HostUserFlag).Value.String() == constants.TrueString
+		captureLogs := runCmd.Lookup(constants.CaptureLogsFlag).Value.String() == constants.TrueString
+		jsonLogs := runCmd.Lookup(constants.JsonLogsFlag).Value.String() == constants.TrueString
+		mountRO := runCmd.Lookup(constants.MountReadOnlyFlag).Value.String() == constants.TrueString
+		outputTimestamp := runCmd.Lookup(constants.OutputTimestampFlag).Value.String() == constants.TrueString
+		readonlyRootfs := runCmd.Lookup(constants.ReadonlyRootfsFlag).Value.String() == constants.TrueString
+		outputJson := runCmd.Lookup(constants.OutputJsonFlag).Value.String() == constants.TrueString
+		resultJson := runCmd.Lookup(constants.ResultJsonFlag).Value.String() == constants.TrueString
+		outputUpload := runCmd.Lookup(constants.OutputUploadFlag).Value.String()
+		uploadOnFailure := runCmd.Lookup(constants.UploadOnFailureFlag).Value.String() == constants.TrueString
+		gpus := runCmd.Lookup(constants.GpusFlag).Value.String()
+		network := runCmd.Lookup(constants.NetworkFlag).Value.String()
+		pid := runCmd.Lookup(constants.PidFlag).Value.String()
+		ipc := runCmd.Lookup(constants.IpcFlag).Value.String()
+		cpus, err := strconv.ParseFloat(runCmd.Lookup(constants.CpusFlag).Value.String(), 64)
+		if err != nil {
+			util.Errorf("Error reading cpus flag: %s\n", err.Error())
+			panic(util.Exit{1})
+		}
+		workDir := runCmd.Lookup(constants.WorkDirFlag).Value.String()
+		settingsFile := runCmd.Lookup(constants.SettingsFileFlag).Value.String()
+		useDefaults := runCmd.Lookup(constants.UseDefaultsFlag).Value.String() == constants.TrueString
+		envFile := runCmd.Lookup(constants.EnvFileFlag).Value.String()
+		saveInputs := runCmd.Lookup(constants.SaveInputManifestFlag).Value.String() == constants.TrueString
+		maxOutputSize, err := strconv.Atoi(runCmd.Lookup(constants.MaxOutputSizeFlag).Value.String())
+		if err != nil || maxOutputSize < 0 {
+			util.Errorf("Error reading max-output-size flag: must be a size in MiB\n")
+			panic(util.Exit{1})
+		}
+
+		repeat := runCmd.Lookup(constants.RepeatFlag).Value.String()
+		reps, err := strconv.Atoi(repeat)
+		if err != nil {
+			util.Errorf("Error reading repeat flag: %s\n", err.Error())
+			panic(util.Exit{1})
+		}
+
+		for i := 0; i < reps; i++ {
+			outputDirRep := outputDir
+			if outputDir != "" {
+				outputDirRep = outputDir + fmt.Sprintf("-%d", i)
+			}
+			_, err := commands.DockerRun(commands.RunOptions{
+				ImageName:         imageName,
+				OutputDir:         outputDirRep,
+				MetadataSchema:    metadataSchema,
+				Inputs:            inputs,
+				InputDir:          inputDir,
+				DirectoryInputs:   directoryInputs,
+				InputURLs:         inputURLs,
+				StrictInputs:      strictInputs,
+				Settings:          settings,
+				Mounts:            mounts,
+				RmDir:             rmFlag,
+				KeepFailed:        keepFailed,
+				RestartOnFailure:  restarts,
+				Quiet:             quiet,
+				InputsRelativeTo:  relativeTo,
+				JobDirectory:      jobDirectory,
+				User:              user,
+				HostUser:          hostUser,
+				CaptureLogs:       captureLogs,
+				JsonLogs:          jsonLogs,
+				MountReadOnly:     mountRO,
+				OutputTimestamp:   outputTimestamp,
+				ReadonlyRootfs:    readonlyRootfs,
+				OutputJson:        outputJson,
+				ResultJson:        resultJson,
+				OutputUpload:      outputUpload,
+				UploadOnFailure:   uploadOnFailure,
+				Gpus:              gpus,
+				Network:           network,
+				Pid:               pid,
+				Ipc:               ipc,
+				Ports:             ports,
+				SecurityOpts:      securityOpts,
+				AddHosts:          addHosts,
+				Tmpfs:             tmpfs,
+				Cpus:              cpus,
+				WorkDir:           workDir,
+				SettingsFile:      settingsFile,
+				NoDefaults:        !useDefaults,
+				EnvFile:           envFile,
+				SaveInputManifest: saveInputs,
+				MaxOutputSize:     maxOutputSize,
+			})
+			if err != nil {
+				commandErr = err
+				util.PrintError(err)
+				if errors.Is(err, commands.ErrOutputTooLarge) {
+					panic(util.Exit{constants.OutputTooLargeExitCode})
+				}
+				panic(util.Exit{1})
+			}
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed publish: Publishes a seed compliant image
+	if publishCmd.Parsed() {
+		if publishCmd.Lookup(constants.QuietFlag).Value.String() == constants.TrueString {
+			util.SetQuiet(true)
+		}
+
+		err := commands.DockerPublish(commands.PublishOptions{
+			ImageName:    publishCmd.Lookup(constants.ImgNameFlag).Value.String(),
+			Registry:     publishCmd.Lookup(constants.RegistryFlag).Value.String(),
+			Org:          publishCmd.Lookup(constants.OrgFlag).Value.String(),
+			Username:     publishCmd.Lookup(constants.UserFlag).Value.String(),
+			Password:     publishCmd.Lookup(constants.PassFlag).Value.String(),
+			JobDirectory: publishCmd.Lookup(constants.JobDirectoryFlag).Value.String(),
+			Force:        publishCmd.Lookup(constants.ForcePublishFlag).Value.String() == constants.TrueString,
+			PkgMajor:     publishCmd.Lookup(constants.PkgVersionMajor).Value.String() == constants.TrueString,
+			PkgMinor:     publishCmd.Lookup(constants.PkgVersionMinor).Value.String() == constants.TrueString,
+			PkgPatch:     publishCmd.Lookup(constants.PkgVersionPatch).Value.String() == constants.TrueString,
+			JobMajor:     publishCmd.Lookup(constants.JobVersionMajor).Value.String() == constants.TrueString,
+			JobMinor:     publishCmd.Lookup(constants.JobVersionMinor).Value.String() == constants.TrueString,
+			JobPatch:     publishCmd.Lookup(constants.JobVersionPatch).Value.String() == constants.TrueString,
+			Verify:       publishCmd.Lookup(constants.VerifyFlag).Value.String() == constants.TrueString,
+			DryRun:       publishCmd.Lookup(constants.DryRunFlag).Value.String() == constants.TrueString,
+			RetagOnly:    publishCmd.Lookup(constants.RetagOnlyFlag).Value.String() == constants.TrueString,
+			Tag:          publishCmd.Lookup(constants.TagFlag).Value.String(),
+			To:           publishCmd.Lookup(constants.ToFlag).Value.String(),
+			DigestFile:   publishCmd.Lookup(constants.DigestFileFlag).Value.String(),
+			Sign: util.SignOptions{
+				Sign:       publishCmd.Lookup(constants.SignFlag).Value.String() == constants.TrueString,
+				PrivateKey: publishCmd.Lookup(constants.PrivateKeyFlag).Value.String(),
+			},
+			Build: commands.BuildOptions{
+				Dockerfile: publishCmd.Lookup(constants.DockerfileFlag).Value.String(),
+				Secrets:    *publishCmd.Lookup(constants.SecretFlag).Value.(*objects.ArrayFlags),
+			},
+		})
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	// seed pull: Pulls a remote image and tags it as a local image
+	if pullCmd.Parsed() {
+		imageName := pullCmd.Lookup(constants.ImgNameFlag).Value.String()
+		registry := pullCmd.Lookup(constants.RegistryFlag).Value.String()
+		org := pullCmd.Lookup(constants.OrgFlag).Value.String()
+		user := pullCmd.Lookup(constants.UserFlag).Value.String()
+		pass := pullCmd.Lookup(constants.PassFlag).Value.String()
+		if pullCmd.Lookup(constants.QuietFlag).Value.String() == constants.TrueString {
+			util.SetQuiet(true)
+		}
+		if err := util.SetProgressMode(pullCmd.Lookup(constants.ProgressFlag).Value.String()); err != nil {
+			util.Errorf("%s\n", err.Error())
+			panic(util.Exit{1})
+		}
+
+		requireSignature := pullCmd.Lookup(constants.RequireSignatureFlag).Value.String() == constants.TrueString
+		verify := util.VerifyOptions{
+			Verify:           pullCmd.Lookup(constants.VerifySignatureFlag).Value.String() == constants.TrueString || requireSignature,
+			PublicKey:        pullCmd.Lookup(constants.PublicKeyFlag).Value.String(),
+			RequireSignature: requireSignature,
+		}
+
+		retries, err := strconv.Atoi(pullCmd.Lookup(constants.RetriesFlag).Value.String())
+		if err != nil || retries < 0 {
+			util.Errorf("Error reading retries flag: must be a number of retries\n")
+			panic(util.Exit{1})
+		}
+
+		jobs, err := strconv.Atoi(pullCmd.Lookup(constants.JobsFlag).Value.String())
+		if err != nil || jobs < 1 {
+			util.Errorf("Error reading jobs flag: must be a number of concurrent pulls\n")
+			panic(util.Exit{1})
+		}
+
+		var images []string
+		if imageName != "" {
+			images = append(images, imageName)
+		}
+		images = append(images, pullCmd.Args()...)
+		err = commands.DockerPull(images, registry, org, user, pass, retries, jobs, verify)
+		if err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+	}
+
+	return 0
+}
+
+//DefineBuildFlags defines the flags for the seed build command
+func DefineBuildFlags() {
+	// build command flags
+	buildCmd = flag.NewFlagSet(constants.BuildCommand, flag.ContinueOnError)
+	var directory string
+	buildCmd.StringVar(&directory, constants.JobDirectoryFlag, ".",
+		"Directory of seed spec and Dockerfile (default is current directory).")
+	buildCmd.StringVar(&directory, constants.ShortJobDirectoryFlag, ".",
+		"Directory of seed spec and Dockerfile (default is current directory).")
+
+	var force bool
+	buildCmd.BoolVar(&force, constants.ForceFlag, false,
+		"Build even if nothing has changed since the last successful build")
+
+	var dockerfile string
+	buildCmd.StringVar(&dockerfile, constants.DockerfileFlag, util.DockerfileName,
+		"Dockerfile to build with, relative to the job directory (default is Dockerfile).")
+	buildCmd.StringVar(&dockerfile, constants.ShortDockerfileFlag, util.DockerfileName,
+		"Dockerfile to build with, relative to the job directory (default is Dockerfile).")
+
+	var manifest string
+	buildCmd.StringVar(&manifest, constants.ManifestFlag, "",
+		"Seed manifest to build with instead of the one in the job directory.")
+
+	var user string
+	buildCmd.StringVar(&user, constants.UserFlag, "",
+		"Optional username to use if dockerfile pulls images from private repository (default is anonymous).")
+	buildCmd.StringVar(&user, constants.ShortUserFlag, "",
+		"Optional username to use if dockerfile pulls images from private repository (default is anonymous).")
+
+	var password string
+	buildCmd.StringVar(&password, constants.PassFlag, "",
+		"Optional password if dockerfile pulls images from private repository (default is empty).")
+	buildCmd.StringVar(&password, constants.ShortPassFlag, "",
+		"Optional password if dockerfile pulls images from private repository (default is empty).")
+
+	var noCache bool
+	buildCmd.BoolVar(&noCache, constants.NoCacheFlag, false,
+		"Do not use the docker layer cache when building the image")
+
+	var pull bool
+	buildCmd.BoolVar(&pull, constants.PullFlag, false,
+		"Always attempt to pull a newer version of the base image")
+
+	var contextLimit int
+	buildCmd.IntVar(&contextLimit, constants.ContextLimitFlag, 0,
+		"Fail if the build context exceeds this size in MiB (default is no limit)")
+
+	var compress bool
+	buildCmd.BoolVar(&compress, constants.CompressFlag, false,
+		"Gzip the build context before sending it to the docker daemon")
+
+	var platform string
+	buildCmd.StringVar(&platform, constants.PlatformFlag, "",
+		"Build for the given platform, i.e. linux/amd64 (requires docker buildx)")
+
+	var squash bool
+	buildCmd.BoolVar(&squash, constants.SquashFlag, false,
+		"Squash the new layers of the image into one (requires an experimental docker daemon)")
+
+	var target string
+	buildCmd.StringVar(&target, constants.TargetFlag, "",
+		"Build the named stage of a multi-stage Dockerfile instead of the last stage")
+
+	var cacheFrom objects.ArrayFlags
+	buildCmd.Var(&cacheFrom, constants.CacheFromFlag, "Reuse the layers of this image; may be repeated")
+
+	var secrets objects.ArrayFlags
+	buildCmd.Var(&secrets, constants.SecretFlag,
+		"BuildKit secret given as id=ID,src=PATH or id=ID,env=VARIABLE; may be repeated")
+
+	var pullCache bool
+	buildCmd.BoolVar(&pullCache, constants.PullCacheFlag, false, "Pull the cache-from images before building")
+
+	var tags objects.ArrayFlags
+	buildCmd.Var(&tags, constants.TagFlag, "Apply an extra tag or image reference to the image; may be repeated")
+	buildCmd.Var(&tags, constants.ShortTagFlag, "Apply an extra tag or image reference to the image; may be repeated")
+
+	var quiet bool
+	buildCmd.BoolVar(&quiet, constants.QuietFlag, false,
+		"Suppress docker build progress output")
+	buildCmd.BoolVar(&quiet, constants.ShortQuietFlag, false,
+		"Suppress docker build progress output")
+
+	var progress string
+	buildCmd.StringVar(&progress, constants.ProgressFlag, constants.ProgressPlain,
+		"Docker build progress display: plain output or a consolidated progress bar")
+
+	// Print usage function
+	buildCmd.Usage = func() {
+		commands.PrintBuildUsage()
+	}
+}
+
+//DefineInitFlags defines the flags for the seed init command
+func DefineInitFlags() {
+	// build command flags
+	initCmd = flag.NewFlagSet(constants.InitCommand, flag.ContinueOnError)
+	var directory string
+	initCmd.StringVar(&directory, constants.JobDirectoryFlag, ".",
+		"Directory to place example seed.manifest.json (default is current directory).")
+	initCmd.StringVar(&directory, constants.ShortJobDirectoryFlag, ".",
+		"Directory to place example seed.manifest.json (default is current directory).")
+
+	var name string
+	initCmd.StringVar(&name, constants.JobNameFlag, "", "Job name (default is my-job).")
+
+	var jobVersion string
+	initCmd.StringVar(&jobVersion, constants.JobVersionFlag, "", "Job version (default is 1.0.0).")
+
+	var maintainer string
+	initCmd.StringVar(&maintainer, constants.MaintainerFlag, "",
+		"Maintainer name, optionally with email, i.e. \"Jane Doe <jdoe@example.com>\".")
+
+	var fromImage string
+	initCmd.StringVar(&fromImage, constants.FromImageFlag, "",
+		"Generate the manifest from the configuration of an existing image.")
+
+	var template string
+	initCmd.StringVar(&template, constants.TemplateFlag, "",
+		"Built in manifest template to start from (default is single-file).")
+
+	var listTemplates bool
+	initCmd.BoolVar(&listTemplates, constants.ListTemplatesFlag, false,
+		"List the built in manifest templates.")
+
+	// Print usage function
+	initCmd.Usage = func() {
+		commands.PrintInitUsage()
+	}
+}
+
+//DefineRunFlags defines the flags for the seed run command
+func DefineBatchFlags() {
+	batchCmd = flag.NewFlagSet(constants.BatchCommand, flag.ContinueOnError)
+
+	var directory string
+	batchCmd.StringVar(&directory, constants.JobDirectoryFlag, ".",
+		"Directory of files to batch process (default is current directory)")
+	batchCmd.StringVar(&directory, constants.ShortJobDirectoryFlag, ".",
+		"Directory of files to batch process (default is current directory)")
+
+	var batchFile string
+	batchCmd.StringVar(&batchFile, constants.BatchFlag, "",
+		"File specifying input keys and file mapping for batch processing")
+	batchCmd.StringVar(&batchFile, constants.ShortBatchFlag, "",
+		"File specifying input keys and file mapping for batch processing")
+
+	var imgNameFlag string
+	batchCmd.StringVar(&imgNameFlag, constants.ImgNameFlag, "",
+		"Name of Docker image to run")
+	batchCmd.StringVar(&imgNameFlag, constants.ShortImgNameFlag, "",
+		"Name of Docker image to run")
+
+	var settings objects.ArrayFlags
+	batchCmd.Var(&settings, constants.SettingFlag,
+		"Defines the value to be applied to setting")
+	batchCmd.Var(&settings, constants.ShortSettingFlag,
+		"Defines the value to be applied to setting")
+
+	var mounts objects.ArrayFlags
+	batchCmd.Var(&mounts, constants.MountFlag,
+		"Defines the full path to be mapped via mount")
+	batchCmd.Var(&mounts, constants.ShortMountFlag,
+		"Defines the full path to be mapped via mount")
+
+	var outdir string
+	batchCmd.StringVar(&outdir, constants.JobOutputDirFlag, "",
+		"Full path to the job output directory")
+	batchCmd.StringVar(&outdir, constants.ShortJobOutputDirFlag, "",
+		"Full path to the job output directory")
+
+	var rmVar bool
+	batchCmd.BoolVar(&rmVar, constants.RmFlag, false,
+		"Specifying the -rm flag automatically removes the image after executing docker run")
+
+	var metadataSchema string
+	batchCmd.StringVar(&metadataSchema, constants.SchemaFlag, "",
+		"Metadata schema file to override built in schema in validating side-car metadata files")
+	batchCmd.StringVar(&metadataSchema, constants.ShortSchemaFlag, "",
+		"Metadata schema file to override built in schema in validating side-car metadata files")
+
+	// Run usage function
+	batchCmd.Usage = func() {
+		commands.PrintBatchUsage()
+	}
+}
+
+//DefineRunFlags defines the flags for the seed run command
+func DefineRunFlags() {
+	runCmd = flag.NewFlagSet(constants.RunCommand, flag.ContinueOnError)
+
+	var imgNameFlag string
+	runCmd.StringVar(&imgNameFlag, constants.ImgNameFlag, "",
+		"Name of Docker image to run")
+	runCmd.StringVar(&imgNameFlag, constants.ShortImgNameFlag, "",
+		"Name of Docker image to run")
+
+	var inputs objects.ArrayFlags
+	runCmd.Var(&inputs, constants.InputsFlag,
+		"Defines the full path to any input data arguments")
+	runCmd.Var(&inputs, constants.ShortInputsFlag,
+		"Defines the full path to input data arguments")
+
+	var strictInputs bool
+	runCmd.BoolVar(&strictInputs, constants.StrictInputsFlag, false,
+		"Fail if an input file is not of a media type declared in the manifest")
+
+	var inputDir string
+	runCmd.StringVar(&inputDir, constants.InputDirFlag, "",
+		"Directory of input files matched to the manifest inputs by name or media type")
+
+	var directoryInputs objects.ArrayFlags
+	runCmd.Var(&directoryInputs, constants.DirectoryInputFlag,
+		"Name of an input that is given a directory rather than a file")
+
+	var inputURLs objects.ArrayFlags
+	runCmd.Var(&inputURLs, constants.InputURLFlag,
+		"Input downloaded from a URL before the run, as NAME=URL")
+
+	var settings objects.ArrayFlags
+	runCmd.Var(&settings, constants.SettingFlag,
+		"Defines the value to be applied to setting")
+	runCmd.Var(&settings, constants.ShortSettingFlag,
+		"Defines the value to be applied to setting")
+
+	var settingsFile string
+	runCmd.StringVar(&settingsFile, constants.SettingsFileFlag, "",
+		"File of settings, either a JSON object or KEY=VALUE lines. -e values override the file")
+
+	var useDefaults bool
+	runCmd.BoolVar(&useDefaults, constants.UseDefaultsFlag, true,
+		"Use the image ENV value of settings that are not given")
+
+	var envFile string
+	runCmd.StringVar(&envFile, constants.EnvFileFlag, "",
+		"File of KEY=VALUE lines set in the container environment. -e settings take precedence")
+
+	var maxOutputSize int
+	runCmd.IntVar(&maxOutputSize, constants.MaxOutputSizeFlag, 0,
+		"Stop the job and fail the run if the output directory exceeds this size in MiB (default is no limit)")
+
+	var saveInputs bool
+	runCmd.BoolVar(&saveInputs, constants.SaveInputManifestFlag, false,
+		"Write "+constants.InputsResolvedFileName+" to the output directory recording the file bound to each input")
+
+	var mounts objects.ArrayFlags
+	runCmd.Var(&mounts, constants.MountFlag,
+		"Defines the full path to be mapped via mount")
+	runCmd.Var(&mounts, constants.ShortMountFlag,
+		"Defines the full path to be mapped via mount")
+
+	var ports objects.ArrayFlags
+	runCmd.Var(&ports, constants.PublishPortFlag,
+		"Publish a container port on the host as HOST_PORT:CONTAINER_PORT; may be repeated")
+
+	var securityOpts objects.ArrayFlags
+	runCmd.Var(&securityOpts, constants.SecurityOptFlag,
+		"Security option passed to docker run --security-opt as KEY=VALUE; may be repeated")
+
+	var tmpfs objects.ArrayFlags
+	runCmd.Var(&tmpfs, constants.TmpfsFlag,
+		"Mount an in-memory scratch directory in the container as PATH[:OPTIONS]; may be repeated")
+
+	var addHosts objects.ArrayFlags
+	runCmd.Var(&addHosts, constants.AddHostFlag,
+		"Add a NAME:IP entry to /etc/hosts of the container; may be repeated")
+
+	var outdir string
+	runCmd.StringVar(&outdir, constants.JobOutputDirFlag, "",
+		"Full path to the job output directory")
+	runCmd.StringVar(&outdir, constants.ShortJobOutputDirFlag, "",
+		"Full path to the job output directory")
+
+	var rmVar bool
+	runCmd.BoolVar(&rmVar, constants.RmFlag, false,
+		"Specifying the -rm flag automatically removes the image after executing docker run")
+
+	var restarts int
+	runCmd.IntVar(&restarts, constants.RestartOnFailureFlag, 0,
+		"Number of times to restart the container if it exits non-zero")
+
+	var keepFailed bool
+	runCmd.BoolVar(&keepFailed, constants.KeepFailedFlag, false,
+		"Keep the container if it exits non-zero, even with -rm")
+
+	var quiet bool
+	runCmd.BoolVar(&quiet, constants.QuietFlag, false,
+		"Specifying the -q flag disables output from the docker image being run")
+	runCmd.BoolVar(&quiet, constants.ShortQuietFlag, false,
+		"Specifying the -q flag disables output from the docker image being run")
+
+	var metadataSchema string
+	runCmd.StringVar(&metadataSchema, constants.SchemaFlag, "",
+		"Metadata schema file to override built in schema in validating side-car metadata files")
+	runCmd.StringVar(&metadataSchema, constants.ShortSchemaFlag, "",
+		"Metadata schema file to override built in schema in validating side-car metadata files")
+
+	var repeat int
+	runCmd.IntVar(&repeat, constants.RepeatFlag, 1,
+		"Run the docker image the specified number of times")
+	runCmd.IntVar(&repeat, constants.ShortRepeatFlag, 1,
+		"Run the docker image the specified number of times")
+
+	var outputTimestamp bool
+	runCmd.BoolVar(&outputTimestamp, constants.OutputTimestampFlag, false,
+		"Write the outputs of each run to a new timestamped subdirectory of the output directory")
+
+	var mountRO bool
+	runCmd.BoolVar(&mountRO, constants.MountReadOnlyFlag, false,
+		"Mount inputs read-only so the job cannot modify source data")
+
+	var readonlyRootfs bool
+	runCmd.BoolVar(&readonlyRootfs, constants.ReadonlyRootfsFlag, false,
+		"Run the container with a read-only root filesystem")
+
+	var jsonLogs bool
+	runCmd.BoolVar(&jsonLogs, constants.JsonLogsFlag, false,
+		"Print container output as newline delimited JSON entries with time, stream, image and container id")
+
+	var captureLogs bool
+	runCmd.BoolVar(&captureLogs, constants.CaptureLogsFlag, false,
+		"Write the container stdout and stderr to stdout.log and stderr.log in the output directory")
+	runCmd.BoolVar(&captureLogs, constants.EntrypointLogFlag, false,
+		"Write the container stdout and stderr to stdout.log and stderr.log in the output directory")
+
+	var gpus string
+	runCmd.StringVar(&gpus, constants.GpusFlag, "",
+		"GPUs to expose to the container: all, a count or a device spec (default is the manifest gpus resource)")
+
+	var cpus float64
+	runCmd.Float64Var(&cpus, constants.CpusFlag, 0,
+		"Limit the CPUs the container may use, i.e. 1.5 (default is no limit)")
+
+	var network string
+	runCmd.StringVar(&network, constants.NetworkFlag, "",
+		"Docker network to connect the container to: bridge, host, none or a network name (default is bridge)")
+
+	var pid string
+	runCmd.StringVar(&pid, constants.PidFlag, "",
+		"PID namespace of the container: host or container:NAME")
+
+	var ipc string
+	runCmd.StringVar(&ipc, constants.IpcFlag, "",
+		"IPC namespace of the container: host, private, shareable, none or container:NAME")
+
+	var workDir string
+	runCmd.StringVar(&workDir, constants.WorkDirFlag, "",
+		"Working directory of the container (default is the WORKDIR of the image)")
+
+	var outputJson bool
+	runCmd.BoolVar(&outputJson, constants.OutputJsonFlag, false,
+		"Print the values of the manifest's output JSON to stdout")
+
+	var resultJson bool
+	runCmd.BoolVar(&resultJson, constants.ResultJsonFlag, false,
+		"Print the exit code, duration and output files of the run to stdout as JSON")
+
+	var outputUpload string
+	runCmd.StringVar(&outputUpload, constants.OutputUploadFlag, "",
+		"s3, http or https URL the output directory is uploaded to as a .tar.gz after a successful run")
+
+	var uploadOnFailure bool
+	runCmd.BoolVar(&uploadOnFailure, constants.UploadOnFailureFlag, false,
+		"Upload the outputs with -output-upload even if the run fails")
+
+	var relativeTo string
+	runCmd.StringVar(&relativeTo, constants.InputsRelativeToFlag, constants.RelativeToCwd,
+		"Resolve relative input paths against the current directory (cwd) or the seed manifest directory (manifest)")
+
+	var directory string
+	runCmd.StringVar(&directory, constants.JobDirectoryFlag, ".",
+		"Directory of seed spec used when resolving inputs relative to the manifest (default is current directory)")
+	runCmd.StringVar(&directory, constants.ShortJobDirectoryFlag, ".",
+		"Directory of seed spec used when resolving inputs relative to the manifest (default is current directory)")
+
+	var user string
+	runCmd.StringVar(&user, constants.UserFlag, "",
+		"User to run the container as (default is the image user)")
+	runCmd.StringVar(&user, constants.ShortUserFlag, "",
+		"User to run the container as (default is the image user)")
+	var hostUser bool
+	runCmd.BoolVar(&hostUser, constants.HostUserFlag, false,
+		"Run the container as the uid:gid of the current user so output files are owned by them")
+
+	// Run usage function
+	runCmd.Usage = func() {
+		commands.PrintRunUsage()
+	}
+}
+
+//DefineListFlags defines the flags for the seed list command
+func DefineListFlags() {
+	listCmd = flag.NewFlagSet(constants.ListCommand, flag.ContinueOnError)
+	var registry string
+	listCmd.StringVar(&registry, constants.RegistryFlag, "", "Specifies registry to list (default is the local system).")
+	listCmd.StringVar(&registry, constants.ShortRegistryFlag, "", "Specifies registry to list (default is the local system).")
+
+	var org string
+	listCmd.StringVar(&org, constants.OrgFlag, "", "Specifies organization to filter (default is no filter).")
+	listCmd.StringVar(&org, constants.ShortOrgFlag, "", "Specifies organization to filter (default is no filter).")
+
+	var user string
+	listCmd.StringVar(&user, constants.UserFlag, "", "Specifies username to use for authorization (default is anonymous).")
+	listCmd.StringVar(&user, constants.ShortUserFlag, "", "Specifies username to use for authorization (default is anonymous).")
+
+	var password string
+	listCmd.StringVar(&password, constants.PassFlag, "", "Specifies password to use for authorization (default is empty).")
+	listCmd.StringVar(&password, constants.ShortPassFlag, "", "Specifies password to use for authorization (default is empty).")
+
+	var filters objects.ArrayFlags
+	listCmd.Var(&filters, constants.FilterFlag, "List only images whose field contains the value, as FIELD=VALUE; may be repeated")
+	listCmd.Var(&filters, constants.ShortFilterFlag, "List only images whose field contains the value, as FIELD=VALUE; may be repeated")
+
+	var output string
+	listCmd.StringVar(&output, constants.OutputFlag, constants.OutputText, "Output format, text or json (default is text).")
+
+	var noColor bool
+	listCmd.BoolVar(&noColor, constants.NoColorFlag, false, "Do not color the table of images.")
+
+	listCmd.Usage = func() {
+		commands.PrintListUsage()
+	}
+}
+
+//DefineLoginFlags defines the flags for the seed login and logout commands
+func DefineLoginFlags() {
+	loginCmd = flag.NewFlagSet(constants.LoginCommand, flag.ContinueOnError)
+	var registry string
+	loginCmd.StringVar(&registry, constants.RegistryFlag, "", "Specifies registry to login to (default is index.docker.io).")
+	loginCmd.StringVar(&registry, constants.ShortRegistryFlag, "", "Specifies registry to login to (default is index.docker.io).")
+
+	var user string
+	loginCmd.StringVar(&user, constants.UserFlag, "", "Specifies username to login with (default is to prompt).")
+	loginCmd.StringVar(&user, constants.ShortUserFlag, "", "Specifies username to login with (default is to prompt).")
+
+	loginCmd.Usage = func() {
+		commands.PrintLoginUsage()
+	}
+
+	logoutCmd = flag.NewFlagSet(constants.LogoutCommand, flag.ContinueOnError)
+	var logoutRegistry string
+	logoutCmd.StringVar(&logoutRegistry, constants.RegistryFlag, "", "Specifies registry to logout of (default is index.docker.io).")
+	logoutCmd.StringVar(&logoutRegistry, constants.ShortRegistryFlag, "", "Specifies registry to logout of (default is index.docker.io).")
+
+	logoutCmd.Usage = func() {
+		commands.PrintLogoutUsage()
+	}
+}
+
+//DefineCompletionFlags defines the flags for the seed completion command
+func DefineCompletionFlags() {
+	completionCmd = flag.NewFlagSet(constants.CompletionCommand, flag.ContinueOnError)
+	completionCmd.Usage = func() {
+		commands.PrintCompletionUsage()
+	}
+}
+
+//CompletionCommands returns the seed commands and their flags used to generate shell
+// completion scripts. New commands must be added here to be completed.
+func CompletionCommands() []commands.CompletionCommand {
+	manifestCmd := commands.CompletionCommand{Name: constants.ManifestCommand,
+		Subcommands: []commands.CompletionCommand{commands.NewCompletionCommand(manifestStatsCmd),
+			commands.NewCompletionCommand(manifestExtractCmd)}}
+	completion := commands.NewCompletionCommand(completionCmd)
+	completion.Args = constants.CompletionShells
+
+	return []commands.CompletionCommand{
+		commands.NewCompletionCommand(batchCmd),
+		commands.NewCompletionCommand(buildCmd),
+		completion,
+		commands.NewCompletionCommand(diffCmd),
+		commands.NewCompletionCommand(doctorCmd),
+		commands.NewCompletionCommand(initCmd),
+		commands.NewCompletionCommand(listCmd),
+		commands.NewCompletionCommand(loginCmd),
+		commands.NewCompletionCommand(logoutCmd),
+		manifestCmd,
+		commands.NewCompletionCommand(pipelineCmd),
+		commands.NewCompletionCommand(pruneCmd),
+		commands.NewCompletionCommand(scanCmd),
+		commands.NewCompletionCommand(publishCmd),
+		commands.NewCompletionCommand(pullCmd),
+		commands.NewCompletionCommand(runCmd),
+		commands.NewCompletionCommand(searchCmd),
+		commands.NewCompletionCommand(validateCmd),
+		commands.NewCompletionCommand(versionCmd),
+	}
+}
+
+//DefineDiffFlags defines the flags for the seed diff command
+func DefineDiffFlags() {
+	diffCmd = flag.NewFlagSet(constants.DiffCommand, flag.ContinueOnError)
+	var output string
+	diffCmd.StringVar(&output, constants.OutputFlag, constants.OutputText,
+		"Output format, text or json (default is text).")
+	diffCmd.StringVar(&output, constants.ShortOutputFlag, constants.OutputText,
+		"Output format, text or json (default is text).")
+
+	diffCmd.Usage = func() {
+		commands.PrintDiffUsage()
+	}
+}
+
+//DefineDoctorFlags defines the flags for the seed doctor command
+func DefineDoctorFlags() {
+	doctorCmd = flag.NewFlagSet(constants.DoctorCommand, flag.ContinueOnError)
+	doctorCmd.Usage = func() {
+		commands.PrintDoctorUsage()
+	}
+}
+
+//DefineScanFlags defines the flags for the seed scan command
+func DefineScanFlags() {
+	scanCmd = flag.NewFlagSet(constants.ScanCommand, flag.ContinueOnError)
+	scanCmd.Usage = func() {
+		commands.PrintScanUsage()
+	}
+}
+
+//DefinePruneFlags defines the flags for the seed prune command
+func DefinePruneFlags() {
+	pruneCmd = flag.NewFlagSet(constants.PruneCommand, flag.ContinueOnError)
+	var org string
+	pruneCmd.StringVar(&org, constants.OrgFlag, "", "Only prune images of this organization (default is all).")
+	pruneCmd.StringVar(&org, constants.ShortOrgFlag, "", "Only prune images of this organization (default is all).")
+
+	var keep int
+	pruneCmd.IntVar(&keep, constants.KeepFlag, constants.DefaultPruneKeep,
+		"Number of versions of each job to keep")
+
+	var dryRun bool
+	pruneCmd.BoolVar(&dryRun, constants.DryRunFlag, false,
+		"List the images that would be removed without removing them")
+
+	pruneCmd.Usage = func() {
+		commands.PrintPruneUsage()
+	}
+}
+
+//DefinePipelineFlags defines the flags for the seed pipeline command
+func DefinePipelineFlags() {
+	pipelineCmd = flag.NewFlagSet(constants.PipelineCommand, flag.ContinueOnError)
+
+	var outdir string
+	pipelineCmd.StringVar(&outdir, constants.JobOutputDirFlag, "",
+		"Directory the stage output directories are created in")
+	pipelineCmd.StringVar(&outdir, constants.ShortJobOutputDirFlag, "",
+		"Directory the stage output directories are created in")
+
+	var rmVar bool
+	pipelineCmd.BoolVar(&rmVar, constants.RmFlag, false,
+		"Specifying the -rm flag automatically removes each container after it exits")
+
+	pipelineCmd.Usage = func() {
+		commands.PrintPipelineUsage()
+	}
+}
+
+//DefineManifestFlags defines the flags for the seed manifest subcommands
+func DefineManifestFlags() {
+	manifestStatsCmd = flag.NewFlagSet(constants.ManifestStatsCommand, flag.ContinueOnError)
+	var output string
+	manifestStatsCmd.StringVar(&output, constants.OutputFlag, constants.OutputText,
+		"Output format, text or json (default is text).")
+	manifestStatsCmd.StringVar(&output, constants.ShortOutputFlag, constants.OutputText,
+		"Output format, text or json (default is text).")
+
+	manifestStatsCmd.Usage = func() {
+		commands.PrintManifestStatsUsage()
+	}
+
+	manifestExtractCmd = flag.NewFlagSet(constants.ManifestExtractCommand, flag.ContinueOnError)
+	var outputFile string
+	manifestExtractCmd.StringVar(&outputFile, constants.OutputFileFlag, constants.SeedFileName,
+		"File to write the manifest to, or - for stdout (default is seed.manifest.json).")
+	manifestExtractCmd.StringVar(&outputFile, constants.ShortOutputFlag, constants.SeedFileName,
+		"File to write the manifest to, or - for stdout (default is seed.manifest.json).")
+	var force bool
+	manifestExtractCmd.BoolVar(&force, constants.ForceFlag, false, "Overwrite the file if it exists.")
+
+	manifestExtractCmd.Usage = func() {
+		commands.PrintManifestExtractUsage()
+	}
+}
+
+//DefineSearchFlags defines the flags for the seed search command
+func DefineSearchFlags() {
+	// Search command
+	searchCmd = flag.NewFlagSet(constants.SearchCommand, flag.ContinueOnError)
+	var registry string
+	searchCmd.StringVar(&registry, constants.RegistryFlag, "", "Specifies registry to search (default is index.docker.io).")
+	searchCmd.StringVar(&registry, constants.ShortRegistryFlag, "", "Specifies registry to search (default is index.docker.io).")
+
+	var orgs objects.ArrayFlags
+	searchCmd.Var(&orgs, constants.OrgFlag, "Specifies organization to filter; may be repeated (default is no filter, search all orgs).")
+	searchCmd.Var(&orgs, constants.ShortOrgFlag, "Specifies organization to filter; may be repeated (default is no filter, search all orgs).")
+
+	var filter string
+	searchCmd.StringVar(&filter, constants.FilterFlag, "", "Specifies filter to apply (default is no filter).")
+	searchCmd.StringVar(&filter, constants.ShortFilterFlag, "", "Specifies filter to apply (default is no filter).")
+
+	var user string
+	searchCmd.StringVar(&user, constants.UserFlag, "", "Specifies username to use for authorization (default is anonymous).")
+	searchCmd.StringVar(&user, constants.ShortUserFlag, "", "Specifies username to use for authorization (default is anonymous).")
+
+	var password string
+	searchCmd.StringVar(&password, constants.PassFlag, "", "Specifies password to use for authorization (default is empty).")
+	searchCmd.StringVar(&password, constants.ShortPassFlag, "", "Specifies password to use for authorization (default is empty).")
+
+	var output string
+	searchCmd.StringVar(&output, constants.OutputFlag, constants.OutputText, "Output format, text or json (default is text).")
+
+	var noColor bool
+	searchCmd.BoolVar(&noColor, constants.NoColorFlag, false, "Do not color the table of images.")
+
+	var seedOnly bool
+	searchCmd.BoolVar(&seedOnly, constants.SeedOnlyFlag, false,
+		"Only list images labeled with a valid seed manifest (inspects every image found).")
+
+	var sortBy string
+	searchCmd.StringVar(&sortBy, constants.SortFlag, constants.SortName,
+		"Sort results by name, updated or tag (default is name).")
+
+	var reverse bool
+	searchCmd.BoolVar(&reverse, constants.ReverseFlag, false, "Reverse the sort order.")
+
+	searchCmd.Usage = func() {
+		commands.PrintSearchUsage()
+	}
+}
+
+//DefinePublishFlags defines the flags for the seed publish command
+func DefinePublishFlags() {
+	publishCmd = flag.NewFlagSet(constants.PublishCommand, flag.ContinueOnError)
+	var registry string
+	publishCmd.StringVar(&registry, constants.RegistryFlag, "", "Specifies registry to publish image to.")
+	publishCmd.StringVar(&registry, constants.ShortRegistryFlag, "", "Specifies registry to publish image to.")
+
+	var org string
+	publishCmd.StringVar(&org, constants.OrgFlag, "", "Specifies organization to publish image to.")
+	publishCmd.StringVar(&org, constants.ShortOrgFlag, "", "Specifies organization to publish image to.")
+
+	var imgNameFlag string
+	publishCmd.StringVar(&imgNameFlag, constants.ImgNameFlag, "",
+		"Name of Docker image to publish")
+	publishCmd.StringVar(&imgNameFlag, constants.ShortImgNameFlag, "",
+		"Name of Docker image to publish")
+
+	var d string
+	publishCmd.StringVar(&d, constants.JobDirectoryFlag, ".",
+		"Directory of seed spec and Dockerfile (default is current directory).")
+	publishCmd.StringVar(&d, constants.ShortJobDirectoryFlag, ".",
+		"Directory of seed spec and Dockerfile (default is current directory).")
+
+	var b bool
+	publishCmd.BoolVar(&b, constants.ForcePublishFlag, false,
+		"Force publish, do not deconflict")
+	var dryRun bool
+	publishCmd.BoolVar(&dryRun, constants.DryRunFlag, false,
+		"Print the resolved tag and planned action, including any version bump, without pushing")
+	var retagOnly bool
+	publishCmd.BoolVar(&retagOnly, constants.RetagOnlyFlag, false,
+		"Tag the local image for the registry and org and push it without rebuilding")
+	var targetTag string
+	publishCmd.StringVar(&targetTag, constants.TagFlag, "",
+		"Publish with this tag instead of the package version, bypassing the version bump")
+	var to string
+	publishCmd.StringVar(&to, constants.ToFlag, "",
+		"Publish to this full image reference instead of the registry and org flags")
+	var pPatch bool
+	publishCmd.BoolVar(&pPatch, constants.PkgVersionPatch, false,
+		"Patch version bump of 'packageVersion' in manifest on disk, will auto rebuild and push")
+	var pMin bool
+	publishCmd.BoolVar(&pMin, constants.PkgVersionMinor, false,
+		"Minor version bump of 'packageVersion' in manifest on disk, will auto rebuild and push")
+	var pMaj bool
+	publishCmd.BoolVar(&pMaj, constants.PkgVersionMajor, false,
+		"Major version bump of 'packageVersion' in manifest on disk, will auto rebuild and push")
+	var jPatch bool
+	publishCmd.BoolVar(&jPatch, constants.JobVersionPatch, false,
+		"Patch version bump of 'jobVersion' in manifest on disk, will auto rebuild and push")
+	var jMin bool
+	publishCmd.BoolVar(&jMin, constants.JobVersionMinor, false,
+		"Minor version bump of 'jobVersion' in manifest on disk, will auto rebuild and push")
+	var jMaj bool
+	publishCmd.BoolVar(&jMaj, constants.JobVersionMajor, false,
+		"Major version bump of 'jobVersion' in manifest on disk, will auto rebuild and push")
+
+	var digestFile string
+	publishCmd.StringVar(&digestFile, constants.DigestFileFlag, "",
+		"Write the digest of the pushed image to the given file")
+
+	var verify bool
+	publishCmd.BoolVar(&verify, constants.VerifyFlag, false,
+		"Verify the digest and seed manifest of the image on the registry after pushing")
+
+	var sign bool
+	publishCmd.BoolVar(&sign, constants.SignFlag, false,
+		"Sign the pushed digest with cosign and push the signature to the registry")
+
+	var privateKey string
+	publishCmd.StringVar(&privateKey, constants.PrivateKeyFlag, "",
+		"Cosign private key file or KMS URI used to sign the image")
+
+	var quiet bool
+	publishCmd.BoolVar(&quiet, constants.QuietFlag, false,
+		"Suppress docker build and push progress output")
+	publishCmd.BoolVar(&quiet, constants.ShortQuietFlag, false,
+		"Suppress docker build and push progress output")
+
+	var user string
+	publishCmd.StringVar(&user, constants.UserFlag, "", "Specifies username to use for authorization (default is anonymous).")
+	publishCmd.StringVar(&user, constants.ShortUserFlag, "", "Specifies username to use for authorization (default is anonymous).")
+
+	var password string
+	publishCmd.StringVar(&password, constants.PassFlag, "", "Specifies password to use for authorization (default is empty).")
+	publishCmd.StringVar(&password, constants.ShortPassFlag, "", "Specifies password to use for authorization (default is empty).")
+
+	var dockerfile string
+	publishCmd.StringVar(&dockerfile, constants.DockerfileFlag, util.DockerfileName,
+		"Dockerfile to rebuild with, relative to the job directory (default is Dockerfile).")
+
+	var secrets objects.ArrayFlags
+	publishCmd.Var(&secrets, constants.SecretFlag,
+		"BuildKit secret of the rebuild given as id=ID,src=PATH or id=ID,env=VARIABLE; may be repeated")
+
+	publishCmd.Usage = func() {
+		commands.PrintPublishUsage()
+	}
+}
+
+//DefinePullFlags defines the flags for the seed pull command
+func DefinePullFlags() {
+	// Search command
+	pullCmd = flag.NewFlagSet(constants.PullCommand, flag.ContinueOnError)
+
+	var imgNameFlag string
+	pullCmd.StringVar(&imgNameFlag, constants.ImgNameFlag, "",
+		"Name of Docker image to pull")
+	pullCmd.StringVar(&imgNameFlag, constants.ShortImgNameFlag, "",
+		"Name of Docker image to pull")
+
+	var registry string
+	pullCmd.StringVar(&registry, constants.RegistryFlag, "", "Specifies registry to pull image from (default is index.docker.io).")
+	pullCmd.StringVar(&registry, constants.ShortRegistryFlag, "", "Specifies registry to pull image from (default is index.docker.io).")
+
+	var org string
+	pullCmd.StringVar(&org, constants.OrgFlag, "", "Specifies organization to pull image from (default is geoint).")
+	pullCmd.StringVar(&org, constants.ShortOrgFlag, "", "Specifies organization to pull image from (default is geoint).")
+
+	var user string
+	pullCmd.StringVar(&user, constants.UserFlag, "", "Specifies username to use for authorization (default is anonymous).")
+	pullCmd.StringVar(&user, constants.ShortUserFlag, "", "Specifies username to use for authorization (default is anonymous).")
+
+	var password string
+	pullCmd.StringVar(&password, constants.PassFlag, "", "Specifies password to use for authorization (default is empty).")
+	pullCmd.StringVar(&password, constants.ShortPassFlag, "", "Specifies password to use for authorization (default is empty).")
+
+	var quiet bool
+	pullCmd.BoolVar(&quiet, constants.QuietFlag, false, "Suppress docker pull progress output")
+	pullCmd.BoolVar(&quiet, constants.ShortQuietFlag, false, "Suppress docker pull progress output")
+
+	var progress string
+	pullCmd.StringVar(&progress, constants.ProgressFlag, constants.ProgressPlain,
+		"Docker pull progress display: plain output or a consolidated progress bar")
+
+	var jobs int
+	pullCmd.IntVar(&jobs, constants.JobsFlag, constants.DefaultPullJobs, "Number of images to pull concurrently")
+	pullCmd.IntVar(&jobs, constants.ShortJobsFlag, constants.DefaultPullJobs, "Number of images to pull concurrently")
+
+	var retries int
+	pullCmd.IntVar(&retries, constants.RetriesFlag, constants.DefaultPullRetries,
+		"Number of times to retry the pull after a network or registry failure")
+
+	var verify bool
+	pullCmd.BoolVar(&verify, constants.VerifySignatureFlag, false,
+		"Verify the cosign signature of the image before tagging it")
+
+	var publicKey string
+	pullCmd.StringVar(&publicKey, constants.PublicKeyFlag, "",
+		"Cosign public key file or KMS URI to verify the image signature against")
+
+	var requireSignature bool
+	pullCmd.BoolVar(&requireSignature, constants.RequireSignatureFlag, false,
+		"Fail verification of images without a signature")
+
+	pullCmd.Usage = func() {
+		commands.PrintPullUsage()
+	}
+}
+
+//DefineValidateFlags defines the flags for the validate command
+func DefineValidateFlags() {
+	var directories objects.ArrayFlags
+	validateCmd = flag.NewFlagSet(constants.ValidateCommand, flag.ContinueOnError)
+	validateCmd.Var(&directories, constants.JobDirectoryFlag,
+		"Location of the seed.manifest.json spec to validate")
+	validateCmd.Var(&directories, constants.ShortJobDirectoryFlag,
+		"Location of the seed.manifest.json spec to validate")
+	var schema string
+	validateCmd.StringVar(&schema, constants.SchemaFlag, "",
+		"JSON schema file to validate seed against.")
+	validateCmd.StringVar(&schema, constants.ShortSchemaFlag, "",
+		"JSON schema file to validate seed against.")
+	var schemaVersion string
+	validateCmd.StringVar(&schemaVersion, constants.SchemaVersionFlag, "",
+		"Seed spec version of the built in schema to validate seed against.")
+	var jobs int
+	validateCmd.IntVar(&jobs, constants.JobsFlag, 1,
+		"Number of manifests to validate concurrently")
+	validateCmd.IntVar(&jobs, constants.ShortJobsFlag, 1,
+		"Number of manifests to validate concurrently")
+	var maxWarnings int
+	validateCmd.IntVar(&maxWarnings, constants.MaxWarningsFlag, -1,
+		"Fail if more than this number of warnings are found (default is no limit)")
+	var failOnWarning bool
+	validateCmd.BoolVar(&failOnWarning, constants.FailOnWarningFlag, false,
+		"Fail if any warnings are found (same as -max-warnings 0)")
+	var listInputs bool
+	validateCmd.BoolVar(&listInputs, constants.ListInputsFlag, false,
+		"Print the inputs, settings and mounts of each valid manifest")
+	var listOutputs bool
+	validateCmd.BoolVar(&listOutputs, constants.ListOutputsFlag, false,
+		"Print the outputs of each valid manifest")
+
+	var fix bool
+	validateCmd.BoolVar(&fix, constants.FixFlag, false,
+		"Apply safe automatic corrections and write the manifest back before validating.")
+	var fixOutput string
+	validateCmd.StringVar(&fixOutput, constants.OutputFileFlag, "",
+		"Write the fixed manifest to this file instead of over the manifest.")
+
+	var fromStdin bool
+	validateCmd.BoolVar(&fromStdin, constants.FromStdinFlag, false,
+		"Read the manifest to validate from stdin. The same as giving - as the path.")
+
+	var checkImage string
+	validateCmd.StringVar(&checkImage, constants.CheckImageFlag, "",
+		"Cross-check the manifest against this built image.")
+
+	var output string
+	validateCmd.StringVar(&output, constants.OutputFlag, constants.OutputText,
+		"Output format, text or junit (default is text).")
+
+	var reportFile string
+	validateCmd.StringVar(&reportFile, constants.ReportFileFlag, "",
+		"Write the JUnit report to this file instead of stdout.")
+
+	validateCmd.Usage = func() {
+		commands.PrintValidateUsage()
+	}
+}
+
+//DefineFlags defines the flags available for the seed runner.
+func DefineFlags() {
+	// Seed subcommand flags
+	DefineBatchFlags()
+	DefineBuildFlags()
+	DefineCompletionFlags()
+	DefineDiffFlags()
+	DefineDoctorFlags()
+	DefineInitFlags()
+	DefineRunFlags()
+	DefineListFlags()
+	DefineLoginFlags()
+	DefineManifestFlags()
+	DefinePipelineFlags()
+	DefinePruneFlags()
+	DefineScanFlags()
+	DefineSearchFlags()
+	DefinePublishFlags()
+	DefinePullFlags()
+	DefineValidateFlags()
+	DefineVersionFlags()
+
+	// Print usage if no command given
+	if len(cliArgs) == 1 {
+		PrintUsage()
+	}
+
+	// Global flags given before the command, i.e. seed -q build
+	ParseGlobalFlags()
+	if len(cliArgs) == 1 {
+		PrintUsage()
+	}
+
+	var cmd *flag.FlagSet
+	minArgs := 2
+
+	// Parse commands
+	switch cliArgs[1] {
+
+	case constants.BatchCommand:
+		cmd = batchCmd
+		minArgs = 3
+
+	case constants.BuildCommand:
+		cmd = buildCmd
+
+		// Check for seed manifest in current directory. If found, add current directory arg
+		if len(cliArgs) == 2 {
+			if _, exist, err := util.GetSeedFileName("."); err == nil && exist {
+				cliArgs = append(cliArgs, ".")
+			}
+		}
+		minArgs = 3
+
+	case constants.CompletionCommand:
+		cmd = completionCmd
+		minArgs = 3
+
+	case constants.DiffCommand:
+		cmd = diffCmd
+		minArgs = 4
+
+	case constants.DoctorCommand:
+		cmd = doctorCmd
+		minArgs = 2
+
+	case constants.InitCommand:
+		cmd = initCmd
+		minArgs = 2
+
+	case constants.PipelineCommand:
+		cmd = pipelineCmd
+		minArgs = 3
+
+	case constants.PruneCommand:
+		cmd = pruneCmd
+		minArgs = 2
+
+	case constants.RunCommand:
+		cmd = runCmd
+		minArgs = 3
+
+	case constants.ScanCommand:
+		cmd = scanCmd
+		minArgs = 3
+
+	case constants.SearchCommand:
+		cmd = searchCmd
+		minArgs = 2
+
+	case constants.ListCommand:
+		cmd = listCmd
+		minArgs = 2
+
+	case constants.LoginCommand:
+		cmd = loginCmd
+		minArgs = 2
+
+	case constants.LogoutCommand:
+		cmd = logoutCmd
+		minArgs = 2
+
+	case constants.ManifestCommand:
+		if len(cliArgs) < 3 {
+			commands.PrintManifestUsage()
+		}
+		switch cliArgs[2] {
+		case constants.ManifestStatsCommand:
+			commandFlags = manifestStatsCmd
+			parseFlags(manifestStatsCmd, cliArgs[3:])
+			ApplyConfig(manifestStatsCmd)
+		case constants.ManifestExtractCommand:
+			commandFlags = manifestExtractCmd
+			manifestExtractArgs = ParseInterleaved(manifestExtractCmd, cliArgs[3:])
+			ApplyConfig(manifestExtractCmd)
+		default:
+			util.PrintUtil("%q is not a valid manifest command.\n", cliArgs[2])
+			commands.PrintManifestUsage()
+		}
+
+	case constants.PublishCommand:
+		cmd = publishCmd
+		minArgs = 3
+
+	case constants.PullCommand:
+		cmd = pullCmd
+		minArgs = 3
+
+	case constants.ValidateCommand:
+		cmd = validateCmd
+		minArgs = 3
+
+	case constants.VersionCommand:
+		commandFlags = versionCmd
+		parseFlags(versionCmd, cliArgs[2:])
+		output := versionCmd.Lookup(constants.OutputFlag).Value.String()
+		if versionCmd.Lookup(constants.JsonFlag).Value.String() == constants.TrueString {
+			output = constants.OutputJson
+		}
+		if err := PrintVersion(output); err != nil {
+			exitWithError(err)
+		}
+		panic(util.Exit{0})
+
+	default:
+		util.PrintUtil( "%q is not a valid command.\n", cliArgs[1])
+		PrintUsage()
+		panic(util.Exit{0})
+	}
+
+	if cmd != nil {
+		commandFlags = cmd
+		parseFlags(cmd, cliArgs[2:])
+		ApplyConfig(cmd)
+		if len(cliArgs) < minArgs {
+			cmd.Usage()
+		}
+	}
+}
+
+//ParseInterleaved parses flags given before or after the positional arguments of a command,
+// i.e. IMAGE -o FILE, and returns the positional arguments
+func ParseInterleaved(cmd *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		parseFlags(cmd, args)
+		args = cmd.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+//parseFlags parses the flags of a command. -h or -help prints the command usage, and an invalid
+// flag, reported by the flag package, fails the command with exit code 2.
+func parseFlags(cmd *flag.FlagSet, args []string) {
+	usage := cmd.Usage
+	cmd.Usage = func() {}
+	err := cmd.Parse(args)
+	cmd.Usage = usage
+	if err == flag.ErrHelp {
+		cmd.Usage()
+	} else if err != nil {
+		util.PrintUtil("Run seed %s -h for usage.\n", cmd.Name())
+		commandErr = err
+		panic(util.Exit{2})
+	}
+}
+
+//ApplyConfig sets the flags of the parsed command that were not given on the command line to
+// the defaults in the config file given with -config, or ~/.seed/config.yaml
+func ApplyConfig(cmd *flag.FlagSet) {
+	fileName := configFile
+	if fileName == "" {
+		fileName = util.DefaultConfigFile()
+	}
+	config, err := util.ReadConfig(fileName, configFile != "")
+	if err != nil {
+		util.Errorf("Error reading config file: %s\n", err.Error())
+		panic(util.Exit{1})
+	}
+	if err := util.ApplyConfig(cmd, config); err != nil {
+		util.Errorf("%s\n", err.Error())
+		panic(util.Exit{1})
+	}
+}
+
+//ParseGlobalFlags applies and removes the global -q, -log-level and -config flags given before the command
+func ParseGlobalFlags() {
+	for len(cliArgs) > 1 && strings.HasPrefix(cliArgs[1], "-") {
+		name := strings.TrimLeft(cliArgs[1], "-")
+		value := ""
+		if i := strings.Index(name, "="); i >= 0 {
+			name, value = name[:i], name[i+1:]
+		}
+
+		switch name {
+		case constants.QuietFlag, constants.ShortQuietFlag:
+			util.SetQuiet(true)
+			cliArgs = append(cliArgs[:1], cliArgs[2:]...)
+		case constants.LogLevelFlag:
+			cliArgs = append(cliArgs[:1], cliArgs[2:]...)
+			if value == "" && len(cliArgs) > 1 {
+				value = cliArgs[1]
+				cliArgs = append(cliArgs[:1], cliArgs[2:]...)
+			}
+			level, err := util.ParseLogLevel(value)
+			if err != nil {
+				util.Errorf("%s\n", err.Error())
+				panic(util.Exit{1})
+			}
+			util.SetLogLevel(level)
+		case constants.MachineSummaryFlag:
+			machineSummary = true
+			cliArgs = append(cliArgs[:1], cliArgs[2:]...)
+		case constants.JsonErrorsFlag:
+			jsonErrors = true
+			util.SetJsonErrors(true)
+			cliArgs = append(cliArgs[:1], cliArgs[2:]...)
+		case constants.ConfigFlag:
+			cliArgs = append(cliArgs[:1], cliArgs[2:]...)
+			if value == "" && len(cliArgs) > 1 {
+				value = cliArgs[1]
+				cliArgs = append(cliArgs[:1], cliArgs[2:]...)
+			}
+			configFile = value
+		default:
+			return
+		}
+	}
+}
+
+//exitWithError records the error a command failed with, for -json-errors, and exits with code 1
+func exitWithError(err error) {
+	commandErr = err
+	panic(util.Exit{1})
+}
+
+//commandName returns the name of the command being run, with the subcommand of seed manifest,
+// i.e. manifest-stats
+func commandName() string {
+	if len(cliArgs) < 2 {
+		return ""
+	}
+	command := cliArgs[1]
+	if command == constants.ManifestCommand && len(cliArgs) > 2 {
+		command += "-" + cliArgs[2]
+	}
+	return command
+}
+
+//ErrorJson returns the JSON object describing a failed command for -json-errors, i.e.
+// {"command":"run","error":"...","code":1,"kind":"job-failed"}. The error is that the command
+// returned, or else the last error message logged. The kind is given for the errors the
+// commands package classifies.
+func ErrorJson(command string, code int, err error, lastError string) string {
+	e := struct {
+		Command string `json:"command"`
+		Error   string `json:"error"`
+		Code    int    `json:"code"`
+		Kind    string `json:"kind,omitempty"`
+	}{Command: command, Error: lastError, Code: code}
+	if err != nil {
+		e.Error = strings.TrimSpace(strings.TrimPrefix(err.Error(), "ERROR:"))
+		e.Kind = commands.ErrorKind(err)
+	}
+	if e.Error == "" {
+		e.Error = fmt.Sprintf("%s failed with exit code %d", command, code)
+	}
+	bytes, _ := json.Marshal(e)
+	return string(bytes)
+}
+
+//MachineSummary returns the line summarizing a command for log parsers, i.e.
+// SEED_RESULT command=run status=success exitCode=0 image=my-job-0.1.0-seed:0.1.0, with the
+// image, directory, output directory, registry and org flags of the command that were given.
+// Values containing spaces, quotes or equals signs are quoted.
+func MachineSummary(command string, code int, cmd *flag.FlagSet) string {
+	status := "success"
+	if code != 0 {
+		status = "failure"
+	}
+	line := fmt.Sprintf("SEED_RESULT command=%s status=%s exitCode=%d", command, status, code)
+	if cmd == nil {
+		return line
+	}
+	for _, f := range summaryFlags {
+		fl := cmd.Lookup(f.flag)
+		if fl == nil || fl.Value.String() == "" {
+			continue
+		}
+		value := fl.Value.String()
+		if strings.ContainsAny(value, " \t\"=") {
+			value = strconv.Quote(value)
+		}
+		line += " " + f.field + "=" + value
+	}
+	return line
+}
+
+//PrintUsage prints the seed usage arguments
+func PrintUsage() {
+	util.PrintUtil( "\nUsage:\tseed [-q] [-log-level LEVEL] [-config FILE] [-machine-summary] [-json-errors] COMMAND\n\n")
+	util.PrintUtil( "A test runner for seed spec compliant algorithms\n\n")
+	util.PrintUtil( "Commands:\n")
+	util.PrintUtil( "  build \tBuilds Seed compliant Docker image\n")
+	util.PrintUtil("  completion\tPrints a shell completion script for bash, zsh or fish\n")
+	util.PrintUtil("  diff  \tCompares two seed manifests\n")
+	util.PrintUtil("  doctor\tChecks that docker is installed and usable by seed\n")
+	util.PrintUtil( "  init  \tInitialize new project with example seed.manifest.json file\n")
+	util.PrintUtil( "  list  \tAllows for listing of all Seed compliant images residing on the local system\n")
+	util.PrintUtil("  login \tStores credentials for a remote Docker registry\n")
+	util.PrintUtil("  logout\tRemoves stored credentials for a remote Docker registry\n")
+	util.PrintUtil("  manifest\tSummarizes a seed manifest or extracts the manifest of a built image\n")
+	util.PrintUtil("  pipeline\tRuns a pipeline of Seed compliant images, passing outputs of each stage to the next\n")
+	util.PrintUtil("  prune \tRemoves old versions of local Seed images\n")
+	util.PrintUtil( "  publish\tAllows for publish of Seed compliant images to remote Docker registry\n")
+	util.PrintUtil( "  pull\tAllows for pulling Seed compliant images from remote Docker registry\n")
+	util.PrintUtil( "  run   \tExecutes Seed compliant Docker docker image\n")
+	util.PrintUtil("  scan  \tChecks a built image for the seed manifest and common issues\n")
+	util.PrintUtil( "  search\tAllows for discovery of Seed compliant images hosted within a Docker registry (default is docker.io)\n")
+	util.PrintUtil( "  validate\tValidates a Seed spec\n")
+	util.PrintUtil( "  version\tPrints the version of Seed spec\n")
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s -%s\tSuppress progress output from docker; errors and final results are still printed\n",
+		constants.ShortQuietFlag, constants.QuietFlag)
+	util.PrintUtil("  -%s LEVEL\tMinimum level of messages to print: debug, info, warn or error (default is info)\n",
+		constants.LogLevelFlag)
+	util.PrintUtil("  -%s FILE\tDefault flag values, one \"flag: value\" per line (default is ~/%s/%s)\n",
+		constants.ConfigFlag, constants.SeedDir, constants.ConfigFileName)
+	util.PrintUtil("  -%s\tPrint a SEED_RESULT line with the status, exit code and image to stderr when the command ends\n",
+		constants.MachineSummaryFlag)
+	util.PrintUtil("  -%s\tPrint errors as a JSON object with the command, error, exit code and kind on stderr\n",
+		constants.JsonErrorsFlag)
+	util.PrintUtil( "\nRun 'seed COMMAND --help' for more information on a command.\n")
+	panic(util.Exit{0})
+}
+
+//DefineVersionFlags defines the flags for the seed version command
+func DefineVersionFlags() {
+	versionCmd = flag.NewFlagSet(constants.VersionCommand, flag.ContinueOnError)
+	var output string
+	versionCmd.StringVar(&output, constants.OutputFlag, constants.OutputText,
+		"Output format, text or json (default is text).")
+	var jsonOutput bool
+	versionCmd.BoolVar(&jsonOutput, constants.JsonFlag, false, "Print the versions as json; the same as -output json.")
+	versionCmd.Usage = func() {
+		PrintVersionUsage()
+	}
+}
+
+//PrintVersionUsage prints the seed version usage, then exits the program
+func PrintVersionUsage() {
+	util.PrintUtil( "\nUsage:\tseed version [-output json]\n")
+	util.PrintUtil( "\nOutputs the version of the Seed CLI and specification.\n")
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s\tOutput format, %s or %s (default is %s). The json output gives the cliVersion,\n"+
+		"\t\tspecVersion, supportedSpecVersions, gitCommit and buildDate.\n", constants.OutputFlag,
+		constants.OutputText, constants.OutputJson, constants.OutputText)
+	util.PrintUtil("  -%s\t\tThe same as -%s %s\n", constants.JsonFlag, constants.OutputFlag, constants.OutputJson)
+	panic(util.Exit{0})
+}
+
+//PrintVersion prints the seed CLI version and the seed spec versions it supports, as text or as
+// a json versionInfo written to stdout. Returns an error if the output format is unknown.
+func PrintVersion(output string) error {
+	info := versionInfo{
+		CliVersion:            version,
+		SpecVersion:           constants.DefaultSchemaVersion,
+		SupportedSpecVersions: commands.BundledSchemaVersions(),
+		GitCommit:             gitCommit,
+		BuildDate:             buildDate,
+	}
+
+	switch output {
+	case constants.OutputJson:
+		bytes, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			util.Errorf("Error marshalling version: %s\n", err.Error())
+			return err
+		}
+		fmt.Fprintln(os.Stdout, string(bytes))
+	case constants.OutputText, "":
+		util.PrintUtil( "Seed v%s\n", info.CliVersion)
+		if info.GitCommit != "" || info.BuildDate != "" {
+			util.PrintUtil("Built from commit %s on %s\n", info.GitCommit, info.BuildDate)
+		}
+		util.PrintUtil("Seed spec version: %s\n", info.SpecVersion)
+		util.PrintUtil( "Supported schema versions: %s\n", info.SupportedSpecVersions)
+	default:
+		err := fmt.Errorf("Unknown -%s value %q. Expected %s or %s", constants.OutputFlag, output,
+			constants.OutputText, constants.OutputJson)
+		util.Errorf("%s\n", err.Error())
+		return &commands.CommandError{Kind: commands.ErrInvalidArgument, Err: err}
+	}
+	return nil
+}
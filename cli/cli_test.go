@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/commands"
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+func TestRun(t *testing.T) {
+	cases := []struct {
+		args         []string
+		expectedCode int
+	}{
+		{[]string{"seed"}, 0},
+		{[]string{"seed", "version"}, 0},
+		{[]string{"seed", "-q", "version"}, 0},
+		{[]string{"seed", "version", "-output", "json"}, 0},
+		{[]string{"seed", "version", "-json"}, 0},
+		{[]string{"seed", "version", "-output", "yaml"}, 1},
+		{[]string{"seed", "-log-level", "loud", "version"}, 1},
+		{[]string{"seed", "validate", "../testdata/complete/"}, 0},
+		{[]string{"seed", "validate", "../testdata/invalid-missing-job/"}, 1},
+		{[]string{"seed", "validate", "-schema-version", "9.9.9", "../testdata/complete/"}, 1},
+		{[]string{"seed", "validate", "../testdata/complete/", "../testdata/invalid-reserved-name/"}, 1},
+		{[]string{"seed", "init", "-list-templates"}, 0},
+		{[]string{"seed", "completion", "bash"}, 0},
+		{[]string{"seed", "completion", "tcsh"}, 1},
+		{[]string{"seed", "-machine-summary", "version"}, 0},
+		{[]string{"seed", "-machine-summary", "validate", "../testdata/invalid-missing-job/"}, 1},
+		{[]string{"seed", "-json-errors", "validate", "../testdata/invalid-missing-job/"}, 1},
+		{[]string{"seed", "-json-errors", "version"}, 0},
+		{[]string{"seed", "validate", "-bogus", "../testdata/complete/"}, 2},
+		{[]string{"seed", "validate", "-h"}, 0},
+		{[]string{"seed", "search", "-output", "yaml"}, 1},
+	}
+
+	for _, c := range cases {
+		code := Run(c.args)
+		if code != c.expectedCode {
+			t.Errorf("Run(%q) == %d, expected %d", c.args, code, c.expectedCode)
+		}
+	}
+
+	// Global state set by one run must not leak into the next
+	Run([]string{"seed", "-q", "version"})
+	Run([]string{"seed", "version"})
+	if util.IsQuiet() {
+		t.Errorf("Run left quiet mode set from the previous run")
+	}
+}
+
+func TestCaptureLogsFlags(t *testing.T) {
+	for _, name := range []string{constants.CaptureLogsFlag, constants.EntrypointLogFlag} {
+		DefineRunFlags()
+		if err := runCmd.Parse([]string{"-" + name}); err != nil {
+			t.Errorf("Parsing -%s returned %v", name, err)
+		}
+		if value := runCmd.Lookup(constants.CaptureLogsFlag).Value.String(); value != constants.TrueString {
+			t.Errorf("-%s set -%s to %s, expected %s", name, constants.CaptureLogsFlag, value, constants.TrueString)
+		}
+	}
+}
+
+func TestPublishBuildFlags(t *testing.T) {
+	DefinePublishFlags()
+	args := []string{"-" + constants.ForcePublishFlag, "-" + constants.DockerfileFlag, "Dockerfile.seed",
+		"-" + constants.SecretFlag, "id=token,env=TOKEN", "-" + constants.SecretFlag, "id=key,src=key.pem"}
+	if err := publishCmd.Parse(args); err != nil {
+		t.Fatalf("Parsing %v returned %v", args, err)
+	}
+	if force := publishCmd.Lookup(constants.ForcePublishFlag).Value.String(); force != constants.TrueString {
+		t.Errorf("-%s set -%s to %s, expected %s", constants.ForcePublishFlag, constants.ForcePublishFlag, force,
+			constants.TrueString)
+	}
+	if dockerfile := publishCmd.Lookup(constants.DockerfileFlag).Value.String(); dockerfile != "Dockerfile.seed" {
+		t.Errorf("-%s == %s, expected Dockerfile.seed", constants.DockerfileFlag, dockerfile)
+	}
+	if secrets := publishCmd.Lookup(constants.SecretFlag).Value.String(); secrets != "id=token,env=TOKEN,id=key,src=key.pem" {
+		t.Errorf("-%s == %s, expected both secrets", constants.SecretFlag, secrets)
+	}
+}
+
+func TestMachineSummary(t *testing.T) {
+	runCmd := flag.NewFlagSet(constants.RunCommand, flag.ContinueOnError)
+	runCmd.String(constants.ImgNameFlag, "", "")
+	runCmd.String(constants.JobOutputDirFlag, "", "")
+	runCmd.String(constants.OrgFlag, "", "")
+	runCmd.Parse([]string{"-" + constants.ImgNameFlag, "my-job-0.1.0-seed:0.1.0",
+		"-" + constants.JobOutputDirFlag, "/tmp/my outputs"})
+
+	cases := []struct {
+		command  string
+		code     int
+		cmd      *flag.FlagSet
+		expected string
+	}{
+		{"version", 0, nil, "SEED_RESULT command=version status=success exitCode=0"},
+		{"run", 0, runCmd, "SEED_RESULT command=run status=success exitCode=0 " +
+			"image=my-job-0.1.0-seed:0.1.0 outputDir=\"/tmp/my outputs\""},
+		{"run", 3, runCmd, "SEED_RESULT command=run status=failure exitCode=3 " +
+			"image=my-job-0.1.0-seed:0.1.0 outputDir=\"/tmp/my outputs\""},
+	}
+
+	for _, c := range cases {
+		if result := MachineSummary(c.command, c.code, c.cmd); result != c.expected {
+			t.Errorf("MachineSummary(%q, %d) == %q, expected %q", c.command, c.code, result, c.expected)
+		}
+	}
+}
+
+func TestErrorJson(t *testing.T) {
+	validation := commands.Validate([]string{"../testdata/missing"}, commands.ValidateOptions{})
+
+	cases := []struct {
+		command   string
+		code      int
+		err       error
+		lastError string
+		expected  string
+	}{
+		{"validate", 1, validation, "", `{"command":"validate","error":"` + validation.Error() +
+			`","code":1,"kind":"manifest-not-found"}`},
+		{"run", 1, errors.New("docker: not found"), "ignored",
+			`{"command":"run","error":"docker: not found","code":1}`},
+		{"run", 1, errors.New("ERROR: No input image specified.\n"), "",
+			`{"command":"run","error":"No input image specified.","code":1}`},
+		{"build", 1, nil, "Error executing docker build.", `{"command":"build","error":"Error executing docker build.","code":1}`},
+		{"list", 2, nil, "", `{"command":"list","error":"list failed with exit code 2","code":2}`},
+	}
+
+	for _, c := range cases {
+		if result := ErrorJson(c.command, c.code, c.err, c.lastError); result != c.expected {
+			t.Errorf("ErrorJson(%q, %d, %v) == %s, expected %s", c.command, c.code, c.err, result, c.expected)
+		}
+	}
+
+	// The error message is printed only as JSON
+	runs := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"seed", "-json-errors", "validate", "-" + constants.SchemaFlag, "schema.json",
+			"-" + constants.SchemaVersionFlag, "1.0.0", "../testdata/complete/"},
+			`{"command":"validate","error":"-schema and -schema-version cannot be used together",` +
+				`"code":1,"kind":"invalid-argument"}`},
+		{[]string{"seed", "-json-errors", "version", "-output", "yaml"},
+			`{"command":"version","error":"Unknown -output value \"yaml\". Expected text or json",` +
+				`"code":1,"kind":"invalid-argument"}`},
+	}
+	for _, c := range runs {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("Error creating pipe for ErrorJson test: %v", err)
+		}
+		stderr := os.Stderr
+		os.Stderr = w
+		code := Run(c.args)
+		os.Stderr = stderr
+		w.Close()
+		output, _ := ioutil.ReadAll(r)
+		if code != 1 || string(output) != c.expected+"\n" {
+			t.Errorf("Run(%q) exited with %d and printed %q, expected 1 and %q", c.args, code, output,
+				c.expected+"\n")
+		}
+	}
+}
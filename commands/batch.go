@@ -34,23 +34,26 @@ func BatchRun(batchDir, batchFile, imageName, outputDir, metadataSchema string,
 
 	batchDir = util.GetFullPath(batchDir, "")
 
-	seed := objects.SeedFromImageLabel(imageName)
+	seed, err := objects.SeedFromImageLabel(imageName)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
 
 	outdir := getOutputDir(outputDir, imageName)
 
 	var inputs []BatchIO
-	var err error
 
 	if batchFile != "" {
 		inputs, err = ProcessBatchFile(seed, batchFile, outdir)
 		if err != nil {
-			util.PrintUtil( "ERROR: Error processing batch file: %s\n", err.Error())
+			util.Errorf("Error processing batch file: %s\n", err.Error())
 			return err
 		}
 	} else {
 		inputs, err = ProcessDirectory(seed, batchDir, outdir)
 		if err != nil {
-			util.PrintUtil( "ERROR: Error processing batch directory: %s\n", err.Error())
+			util.Errorf("Error processing batch directory: %s\n", err.Error())
 			return err
 		}
 	}
@@ -58,7 +61,16 @@ func BatchRun(batchDir, batchFile, imageName, outputDir, metadataSchema string,
 
 	out := "Results: \n"
 	for _, in := range inputs {
-		exitCode, err := DockerRun(imageName, in.Outdir, metadataSchema, in.Inputs, settings, mounts, rmFlag, true)
+		exitCode, err := DockerRun(RunOptions{
+			ImageName:      imageName,
+			OutputDir:      in.Outdir,
+			MetadataSchema: metadataSchema,
+			Inputs:         in.Inputs,
+			Settings:       settings,
+			Mounts:         mounts,
+			RmDir:          rmFlag,
+			Quiet:          true,
+		})
 
 		//trim inputs to print only the key values and filenames
 		truncatedInputs := []string{}
@@ -37,7 +37,10 @@ func TestProcessDirectory(t *testing.T) {
 	for _, c := range cases {
 		os.Mkdir(c.outDir, os.ModePerm)
 		defer os.Remove(c.outDir)
-		seed := objects.SeedFromManifestFile(c.manifestFile)
+		seed, err := objects.SeedFromManifestFile(c.manifestFile)
+		if err != nil {
+			t.Fatalf("Error reading seed manifest: %v", err)
+		}
 		out, err := ProcessDirectory(seed, c.batchDir, c.outDir)
 		outstr := fmt.Sprintf("%v", out)
 		if outstr != c.expected {
@@ -73,7 +76,10 @@ func TestProcessBatchFile(t *testing.T) {
 	for _, c := range cases {
 		os.Mkdir(c.outDir, os.ModePerm)
 		defer os.Remove(c.outDir)
-		seed := objects.SeedFromManifestFile(c.manifestFile)
+		seed, err := objects.SeedFromManifestFile(c.manifestFile)
+		if err != nil {
+			t.Fatalf("Error reading seed manifest: %v", err)
+		}
 		out, err := ProcessBatchFile(seed, c.batchFile, c.outDir)
 		outstr := fmt.Sprintf("%v", out)
 		fmt.Println(outstr)
@@ -2,11 +2,16 @@ package commands
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/ngageoint/seed-cli/constants"
@@ -14,87 +19,701 @@ import (
 	"github.com/ngageoint/seed-cli/util"
 )
 
-//DockerBuild Builds the docker image with the given image tag.
-func DockerBuild(jobDirectory, username, password string) error {
-	if username != "" {
-		//set config dir so we don't stomp on other users' logins with sudo
-		configDir := constants.DockerConfigDir + time.Now().Format(time.RFC3339)
-		os.Setenv(constants.DockerConfigKey, configDir)
-		defer util.RemoveAllFiles(configDir)
-		defer os.Unsetenv(constants.DockerConfigKey)
+//platformRegex matches a docker platform, i.e. linux/amd64 or linux/arm/v7
+var platformRegex = regexp.MustCompile(`^[a-z0-9]+/[a-z0-9_]+(/[a-z0-9]+)?$`)
 
-		registry, err := util.DockerfileBaseRegistry(jobDirectory)
-		if err != nil {
-			util.PrintUtil("Error getting registry from dockerfile: %s\n", err.Error())
-		}
-		err = util.Login(registry, username, password)
-		if err != nil {
-			util.PrintUtil("Error calling docker login: %s\n", err.Error())
-		}
-	}
+//BuildOptions defines the job directory and docker build flags of seed build
+type BuildOptions struct {
+	JobDirectory string
+	Username     string
+	Password     string
+	NoCache      bool
+	Pull         bool
+	Compress     bool
 
-	seedFileName, err := util.SeedFileName(jobDirectory)
-	if err != nil && !os.IsNotExist(err) {
-		util.PrintUtil( "ERROR: %s\n", err.Error())
+	// ContextLimit fails the build if the build context exceeds this size in MiB. Zero is no limit.
+	ContextLimit int
+
+	// Platform, i.e. linux/amd64, builds the image for that platform with BuildKit and records
+	// it in an image label
+	Platform string
+
+	// Tags are applied to the image in addition to the seed image name. A tag without a
+	// registry, organization or repository, i.e. latest, tags the seed image repository.
+	Tags []string
+
+	// Squash flattens the layers of the image into one with the legacy builder. Requires a
+	// docker daemon with experimental features enabled.
+	Squash bool
+
+	// Target builds the named stage of a multi-stage Dockerfile rather than the last stage. The
+	// image is still labeled with the manifest and tagged with the seed image name.
+	Target string
+
+	// CacheFrom are images whose layers docker build may reuse, i.e. the last published version
+	// of the job. PullCache pulls them before building, as docker only uses local images.
+	CacheFrom []string
+	PullCache bool
+
+	// Manifest is the path of the seed manifest, or a directory containing one, to build with
+	// instead of the manifest in JobDirectory, which is still the build context
+	Manifest string
+
+	// Dockerfile is the Dockerfile to build with, relative to JobDirectory unless absolute.
+	// Empty is the Dockerfile in JobDirectory.
+	Dockerfile string
+
+	// Secrets are BuildKit build secrets in the docker build --secret form id=ID,src=PATH or
+	// id=ID,env=VARIABLE. RUN --mount=type=secret steps can read them, but they are not stored
+	// in the image. Building with secrets requires BuildKit.
+	Secrets []string
+
+	// Force builds the image even if nothing has changed since the last successful build, as
+	// recorded in the .seed directory of JobDirectory. NoCache and Pull also always build.
+	Force bool
+}
+
+//DockerBuild Builds the docker image with the given image tag and any extra tags.
+func DockerBuild(options BuildOptions) error {
+	jobDirectory := options.JobDirectory
+	platform := options.Platform
+	seedFileName, err := BuildManifestFile(jobDirectory, options.Manifest)
+	if os.IsNotExist(err) {
+		return wrapError(ErrManifestNotFound, err)
+	} else if err != nil {
+		util.Errorf("%s\n", err.Error())
 		return err
 	}
 
 	// Validate seed file
 	err = ValidateSeedFile("", seedFileName, constants.SchemaManifest)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "ERROR: seed file could not be validated. See errors for details.")
-		util.PrintUtil( "%s", err.Error())
+		util.Errorf("seed file could not be validated. See errors for details.\n")
+		util.PrintError(err)
 		util.PrintUtil( "Exiting seed...\n")
-		return err
+		return wrapError(ErrValidation, err)
 	}
 
 	// retrieve seed from seed manifest
-	seed := objects.SeedFromManifestFile(seedFileName)
+	seed, err := objects.SeedFromManifestFile(seedFileName)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrValidation, err)
+	}
 
 	// Retrieve docker image name
 	imageName := objects.BuildImageName(&seed)
+	if !util.IsValidImageReference(imageName) {
+		err = fmt.Errorf("Image name %s built from the job name, job version and package version in %s "+
+			"is not a valid docker image name. Names may not start or end with a separator and versions "+
+			"may not contain build metadata (+)", imageName, seedFileName)
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrValidation, err)
+	}
+
+	// Check the Dockerfile before handing the job directory to docker
+	dockerfile := util.DockerfilePath(jobDirectory, options.Dockerfile)
+	if err := checkDockerfile(jobDirectory, dockerfile); err != nil {
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrValidation, err)
+	}
+
+	// The target must be a stage of the Dockerfile
+	if options.Target != "" {
+		if err := checkTarget(dockerfile, options.Target); err != nil {
+			util.Errorf("%s\n", err.Error())
+			return wrapError(ErrInvalidArgument, err)
+		}
+	}
+
+	// Check the size of the build context sent to the docker daemon
+	if err := checkContextSize(jobDirectory, dockerfile, options.ContextLimit); err != nil {
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrValidation, err)
+	}
+
+	// Extra tags must be valid image references
+	tags, err := ResolveBuildTags(imageName, options.Tags)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrInvalidArgument, err)
+	}
+
+	// Validate the -cache-from images
+	cacheFrom, err := ResolveCacheFrom(options.CacheFrom)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrInvalidArgument, err)
+	}
+
+	// Validate build secrets; they require BuildKit
+	secrets, err := ResolveBuildSecrets(options.Secrets)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrInvalidArgument, err)
+	}
+	if len(secrets) > 0 {
+		if err := checkBuildKit(options.Squash); err != nil {
+			util.Errorf("%s\n", err.Error())
+			return wrapError(ErrInvalidArgument, err)
+		}
+	}
+
+	// Squashing needs the experimental legacy builder
+	if options.Squash {
+		if err := checkSquash(platform); err != nil {
+			util.Errorf("%s\n", err.Error())
+			return wrapError(ErrInvalidArgument, err)
+		}
+	}
+
+	// Cross-platform builds need BuildKit
+	if platform != "" {
+		if err := checkPlatform(platform); err != nil {
+			util.Errorf("%s\n", err.Error())
+			return wrapError(ErrInvalidArgument, err)
+		}
+	}
+
+	// Skip the build if the manifest, Dockerfile, context and options are those of the last
+	// successful build and its image is still present. The hash is recorded after this build.
+	buildHash, err := BuildHash(jobDirectory, seedFileName, dockerfile, []string{
+		"tags=" + strings.Join(tags, ","), "platform=" + platform, "target=" + options.Target,
+		fmt.Sprintf("squash=%t", options.Squash), "secrets=" + strings.Join(secrets, ","),
+	})
+	if err != nil {
+		util.Warnf("Could not hash the build inputs; building without the build cache: %s\n", err.Error())
+	} else if !options.Force && !options.NoCache && !options.Pull && buildUpToDate(jobDirectory, buildHash, imageName) {
+		util.PrintUtil("Nothing has changed since the last build of %s; skipping the build. Use -%s to "+
+			"build anyway\n", imageName, constants.ForceFlag)
+		return nil
+	}
+
+	registry, err := util.DockerfileBaseRegistry(dockerfile)
+	if err != nil {
+		util.Warnf("Error getting registry from dockerfile: %s\n", err.Error())
+	}
+	username, password := util.ResolveCredentials(registry, options.Username, options.Password)
+	if username != "" {
+		//set config dir so we don't stomp on other users' logins with sudo
+		configDir := constants.DockerConfigDir + time.Now().Format(time.RFC3339)
+		os.Setenv(constants.DockerConfigKey, configDir)
+		defer util.RemoveAllFiles(configDir)
+		defer os.Unsetenv(constants.DockerConfigKey)
+
+		err = util.Login(registry, username, password)
+		if err != nil {
+			util.Warnf("Error calling docker login: %s\n", err.Error())
+		}
+	}
+
+	// Pull the cache images, after logging in so they may be private. A missing cache image
+	// only makes the build slower.
+	if options.PullCache {
+		for _, image := range cacheFrom {
+			if _, err := pull(image); err != nil {
+				util.Warnf("Could not pull cache image %s; building without its layers: %s\n", image,
+					err.Error())
+			}
+		}
+	}
 
 	// Build Docker image
-	util.PrintUtil( "INFO: Building %s\n", imageName)
-	buildArgs := []string{"build", "-t", imageName, jobDirectory}
+	util.Infof("Building %s\n", imageName)
+
+	// The build cache directory is left out of the build context, so recording a build does not
+	// invalidate the layers copying the job directory. docker only leaves out what the
+	// .dockerignore excludes, so unless it excludes the directory the context is sent on stdin.
+	sendContext := buildCacheInContext(jobDirectory)
+	context := jobDirectory
+	if sendContext {
+		context = "-"
+	}
+	buildArgs := []string{"build", "-t", imageName, context}
+	for _, tag := range tags {
+		buildArgs = append(buildArgs, "-t", tag)
+	}
+	if sendContext {
+		// docker resolves -f against the context read from stdin
+		buildArgs = append(buildArgs, "-f", util.ContextDockerfile(jobDirectory, dockerfile))
+	} else if dockerfile != util.DockerfilePath(jobDirectory, "") {
+		// docker resolves -f against the working directory rather than the context
+		buildArgs = append(buildArgs, "-f", dockerfile)
+	}
+	if options.NoCache {
+		buildArgs = append(buildArgs, "--no-cache")
+	}
+	if options.Pull {
+		buildArgs = append(buildArgs, "--pull")
+	}
+	if options.Compress {
+		buildArgs = append(buildArgs, "--compress")
+		if util.GetLogLevel() == util.LevelDebug {
+			raw, compressed, elapsed, err := util.ContextCompression(jobDirectory)
+			if err != nil {
+				util.Debugf("Error measuring build context compression: %s\n", err.Error())
+			} else if compressed > 0 {
+				util.Debugf("Build context compresses from %d to %d bytes (ratio %.2f) in %s\n",
+					raw, compressed, float64(raw)/float64(compressed), elapsed)
+			}
+		}
+	}
+	if platform != "" {
+		buildArgs = append(buildArgs, "--platform", platform, "--label", constants.PlatformLabel+"="+platform)
+	}
+	if options.Squash {
+		buildArgs = append(buildArgs, "--squash")
+	}
+	if options.Target != "" {
+		buildArgs = append(buildArgs, "--target", options.Target)
+	}
+	for _, image := range cacheFrom {
+		buildArgs = append(buildArgs, "--cache-from", image)
+	}
+	for _, secret := range secrets {
+		buildArgs = append(buildArgs, "--secret", secret)
+	}
 	if util.DockerVersionHasLabel() {
 		// Set the seed.manifest.json contents as an image label
-		label := "com.ngageoint.seed.manifest=" + objects.GetManifestLabel(seedFileName)
+		label := constants.ManifestLabel + "=" + objects.GetManifestLabel(seedFileName)
 		buildArgs = append(buildArgs, "--label", label)
 	}
-	cmd := exec.Command("docker", buildArgs...)
+	util.DebugCommand("docker", buildArgs)
+	cmd := util.DockerCommand{Args: buildArgs}
+	buildKit := platform != "" || len(secrets) > 0
+	if buildKit {
+		cmd.Env = []string{"DOCKER_BUILDKIT=1"}
+	} else if options.Squash {
+		// BuildKit ignores --squash
+		cmd.Env = []string{"DOCKER_BUILDKIT=0"}
+	}
 	var errs bytes.Buffer
-	cmd.Stderr = io.MultiWriter(os.Stderr, &errs)
-	cmd.Stdout = os.Stderr
+	progress, finishProgress := util.DockerProgress("Building")
+	cmd.Stderr = io.MultiWriter(progress, &errs)
+	cmd.Stdout = progress
+
+	// Write the context archive to docker as it reads it
+	var contextReader *io.PipeReader
+	contextErr := make(chan error, 1)
+	if sendContext {
+		var contextWriter *io.PipeWriter
+		contextReader, contextWriter = io.Pipe()
+		cmd.Stdin = contextReader
+		go func() {
+			err := util.WriteBuildContext(contextWriter, jobDirectory, dockerfile)
+			contextWriter.CloseWithError(err)
+			contextErr <- err
+		}()
+	}
 
 	// Run docker build
-	if err := cmd.Run(); err != nil {
-		util.PrintUtil( "ERROR: Error executing docker build. %s\n",
+	buildTime := time.Now()
+	defer func() { util.Debugf("docker build took %s\n", time.Since(buildTime)) }()
+	err = util.RunDocker(cmd)
+	if contextReader != nil {
+		// Stop writing the context if docker exited without reading all of it
+		contextReader.Close()
+		if cerr := <-contextErr; cerr != nil && cerr != io.ErrClosedPipe {
+			err = fmt.Errorf("Error sending the build context: %s", cerr.Error())
+		}
+	}
+	finishProgress(err)
+	if err != nil {
+		util.Errorf("Error executing docker build. %s\n",
 			err.Error())
-		return err
+		return wrapError(ErrDockerExec, err)
 	}
 
-	// check for errors on stderr
-	if errs.String() != "" {
-		util.PrintUtil( "ERROR: Error building image '%s':\n%s\n",
+	// check for errors on stderr. BuildKit writes its progress to stderr, so only the exit
+	// status is meaningful for platform and secret builds
+	if errs.String() != "" && !buildKit {
+		util.Errorf("Error building image '%s':\n%s\n",
 			imageName, errs.String())
 		util.PrintUtil( "Exiting seed...\n")
-		return errors.New(errs.String())
+		return wrapError(ErrDockerExec, errors.New(errs.String()))
 	}
 
+	// The manifest label is part of the image config, which squashing keeps
+	if options.Squash && util.DockerVersionHasLabel() {
+		if label, err := util.ImageLabel(imageName, constants.ManifestLabel); err != nil || label == "" {
+			err = fmt.Errorf("The %s label of %s was lost when squashing the image", constants.ManifestLabel,
+				imageName)
+			util.Errorf("%s\n", err.Error())
+			return wrapError(ErrDockerExec, err)
+		}
+	}
+
+	util.PrintUtil("Successfully built %s\n", imageName)
+	for _, tag := range tags {
+		util.PrintUtil("Tagged %s\n", tag)
+	}
+	if buildHash != "" {
+		if err := writeBuildHash(jobDirectory, buildHash); err != nil {
+			util.Warnf("Could not record the build in %s: %s\n", constants.BuildCacheDir, err.Error())
+		}
+	}
+	return nil
+}
+
+//BuildHash returns a hash of what a build of the job in jobDirectory depends on: the contents of
+// the manifest and Dockerfile, the files of the build context, less the build cache directory,
+// and inputs describing the options that change the image built
+func BuildHash(jobDirectory, seedFileName, dockerfile string, inputs []string) (string, error) {
+	h := sha256.New()
+	for _, file := range []string{seedFileName, dockerfile} {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %d\n", filepath.Base(file), len(data))
+		h.Write(data)
+	}
+	context, err := util.BuildContextHash(jobDirectory)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "context %s\n", context)
+	for _, input := range inputs {
+		fmt.Fprintf(h, "%s\n", input)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+//buildHashFile returns the path of the file recording the hash of the last successful build of
+// the job in jobDirectory
+func buildHashFile(jobDirectory string) string {
+	return filepath.Join(jobDirectory, constants.BuildCacheDir, constants.BuildHashFileName)
+}
+
+//buildUpToDate returns whether hash is that of the last successful build of the job in
+// jobDirectory and the image it built is still present
+func buildUpToDate(jobDirectory, hash, imageName string) bool {
+	last, err := ioutil.ReadFile(buildHashFile(jobDirectory))
+	if err != nil || strings.TrimSpace(string(last)) != hash {
+		return false
+	}
+	exists, _ := util.ImageExists(imageName)
+	return exists
+}
+
+//buildCacheInContext returns whether docker would send the build cache directory of the job in
+// jobDirectory with the build context: it exists and the .dockerignore does not exclude it
+func buildCacheInContext(jobDirectory string) bool {
+	info, err := os.Stat(filepath.Join(jobDirectory, constants.BuildCacheDir))
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	patterns, err := util.ReadDockerignore(jobDirectory)
+	return err != nil || !util.DockerignoreMatches(constants.BuildCacheDir, patterns)
+}
+
+//writeBuildHash records hash as that of the last successful build of the job in jobDirectory
+func writeBuildHash(jobDirectory, hash string) error {
+	if err := os.MkdirAll(filepath.Join(jobDirectory, constants.BuildCacheDir), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(buildHashFile(jobDirectory), []byte(hash+"\n"), 0644)
+}
+
+//BuildManifestFile returns the path of the seed manifest to build with: the manifest given, which
+// may be a file or a directory containing seed.manifest.json, or else the one in the job directory
+func BuildManifestFile(jobDirectory, manifest string) (string, error) {
+	if manifest == "" {
+		return util.SeedFileName(jobDirectory)
+	}
+	info, err := os.Stat(manifest)
+	if err == nil && info.IsDir() {
+		return util.SeedFileName(manifest)
+	}
+	seedFileName := util.GetFullPath(manifest, "")
+	if os.IsNotExist(err) {
+		util.Errorf("%s cannot be found.\nMake sure you have specified the correct -%s path.\n", seedFileName,
+			constants.ManifestFlag)
+	}
+	return seedFileName, err
+}
+
+//ResolveBuildTags returns the image references for the extra tags of a seed build. Tags without
+// a registry, organization or repository, i.e. latest, are applied to the repository of the seed
+// image name. Returns an error for tags that are not valid image references.
+func ResolveBuildTags(imageName string, tags []string) ([]string, error) {
+	image, err := util.ParseReference(imageName)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	for _, tag := range tags {
+		if tag == "" {
+			continue
+		}
+		ref := tag
+		if !strings.ContainsAny(tag, ":/") {
+			ref = image.WithTag(tag).String()
+		}
+		if !util.IsValidImageReference(ref) {
+			return nil, fmt.Errorf("Invalid tag %s. Tags are either a tag of the seed image, i.e. latest, or "+
+				"an image reference, i.e. registry.example.com/org/my-job:latest", tag)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+//checkDockerfile verifies the dockerfile of the job in jobDirectory exists and that its first
+// instruction is a FROM with a well formed base image
+func checkDockerfile(jobDirectory, dockerfile string) error {
+	if info, err := os.Stat(dockerfile); os.IsNotExist(err) {
+		if dockerfile != util.DockerfilePath(jobDirectory, "") {
+			return fmt.Errorf("Dockerfile %s given by -%s not found", dockerfile, constants.DockerfileFlag)
+		}
+		return fmt.Errorf("No %s found in %s. seed build requires a %s next to %s, or one named with -%s",
+			util.DockerfileName, jobDirectory, util.DockerfileName, constants.SeedFileName,
+			constants.DockerfileFlag)
+	} else if err == nil && info.IsDir() {
+		return fmt.Errorf("Dockerfile %s is a directory", dockerfile)
+	}
+
+	base, err := util.DockerfileBaseImage(dockerfile)
+	if err != nil {
+		return err
+	}
+	// build arguments are only substituted by docker, so they cannot be checked here
+	if !strings.Contains(base, "$") && !util.IsValidImageReference(base) {
+		return fmt.Errorf("Base image %s in %s is not a valid docker image name", base, dockerfile)
+	}
+	util.Debugf("Building on base image %s\n", base)
+	return nil
+}
+
+//checkPlatform verifies platform is of the form os/arch[/variant] and that docker buildx is
+// available to build for it when it is not the platform of the docker daemon
+func checkPlatform(platform string) error {
+	if !platformRegex.MatchString(platform) {
+		return fmt.Errorf("Invalid platform %s. Platforms are given as os/arch[/variant], i.e. linux/amd64",
+			platform)
+	}
+	// Builds for the platform of the docker daemon need no emulation, so buildx is not required
+	if host, err := util.ServerPlatform(); err == nil && host == platform {
+		return nil
+	}
+	if !util.BuildxAvailable() {
+		return fmt.Errorf("Building for platform %s requires Docker BuildKit with the buildx plugin, which "+
+			"was not found. Install docker buildx (Docker 19.03 or later) and run "+
+			"'docker buildx install', then retry", platform)
+	}
+	return nil
+}
+
+//ResolveCacheFrom returns the images given for seed build -cache-from, skipping empty values.
+// Returns an error for images that are not valid image references.
+func ResolveCacheFrom(images []string) ([]string, error) {
+	var refs []string
+	for _, image := range images {
+		if image == "" {
+			continue
+		}
+		if _, err := util.ParseReference(image); err != nil {
+			return nil, fmt.Errorf("Invalid -%s image: %s", constants.CacheFromFlag, err.Error())
+		}
+		refs = append(refs, image)
+	}
+	return refs, nil
+}
+
+//checkTarget returns an error if target is not the name of a stage of dockerfile. Stage names
+// are not case sensitive.
+func checkTarget(dockerfile, target string) error {
+	stages, err := util.DockerfileStages(dockerfile)
+	if err != nil {
+		return err
+	}
+	for _, stage := range stages {
+		if strings.EqualFold(stage, target) {
+			return nil
+		}
+	}
+	if len(stages) == 0 {
+		return fmt.Errorf("Build target %s not found. The %s in %s has no named stages; name them with "+
+			"FROM image AS name", target, filepath.Base(dockerfile), filepath.Dir(dockerfile))
+	}
+	return fmt.Errorf("Build target %s not found. The stages of the %s in %s are %s", target,
+		filepath.Base(dockerfile), filepath.Dir(dockerfile), strings.Join(stages, ", "))
+}
+
+//ResolveBuildSecrets validates the build secrets given with seed build -secret, ignoring empty
+// values, and returns them for docker build --secret. A secret is given as comma separated
+// KEY=VALUE fields: an id, and either the src file or the env variable holding the secret,
+// which must exist. type=file and type=env may also be given.
+func ResolveBuildSecrets(secrets []string) ([]string, error) {
+	var resolved []string
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		fields := map[string]string{}
+		for _, field := range strings.Split(secret, ",") {
+			x := strings.SplitN(field, "=", 2)
+			if len(x) != 2 || x[1] == "" {
+				return nil, fmt.Errorf("Invalid -%s %s. Secrets are given as id=ID,src=PATH or id=ID,env=VARIABLE",
+					constants.SecretFlag, secret)
+			}
+			key := x[0]
+			if key == "source" {
+				key = "src"
+			}
+			switch key {
+			case "id", "src", "env", "type":
+				fields[key] = x[1]
+			default:
+				return nil, fmt.Errorf("Invalid -%s %s. Unknown field %s; secrets have an id, and a src or env",
+					constants.SecretFlag, secret, x[0])
+			}
+		}
+		if fields["id"] == "" {
+			return nil, fmt.Errorf("Invalid -%s %s. The secret has no id, which RUN --mount=type=secret,id=ID "+
+				"refers to it by", constants.SecretFlag, secret)
+		}
+		if t := fields["type"]; t != "" && t != "file" && t != "env" {
+			return nil, fmt.Errorf("Invalid -%s %s. The type of a secret is file or env", constants.SecretFlag,
+				secret)
+		}
+		switch {
+		case fields["src"] != "" && fields["env"] != "":
+			return nil, fmt.Errorf("Invalid -%s %s. A secret is read from a src file or an env variable, not both",
+				constants.SecretFlag, secret)
+		case fields["src"] != "":
+			if info, err := os.Stat(fields["src"]); err != nil || info.IsDir() {
+				return nil, fmt.Errorf("Invalid -%s %s. The secret file %s cannot be read", constants.SecretFlag,
+					secret, fields["src"])
+			}
+		case fields["env"] != "":
+			if _, ok := os.LookupEnv(fields["env"]); !ok {
+				return nil, fmt.Errorf("Invalid -%s %s. The environment variable %s is not set",
+					constants.SecretFlag, secret, fields["env"])
+			}
+		default:
+			return nil, fmt.Errorf("Invalid -%s %s. Give the file holding the secret with src=PATH or the "+
+				"environment variable with env=VARIABLE", constants.SecretFlag, secret)
+		}
+		resolved = append(resolved, secret)
+	}
+	return resolved, nil
+}
+
+//checkBuildKit returns an error if the image cannot be built with BuildKit, which build secrets
+// need: the legacy builder used to squash images, or BuildKit disabled with DOCKER_BUILDKIT=0 or
+// a docker version older than 18.09
+func checkBuildKit(squash bool) error {
+	if squash {
+		return fmt.Errorf("-%s cannot be used with -%s; build secrets need BuildKit, which does not squash "+
+			"images", constants.SecretFlag, constants.SquashFlag)
+	}
+	if os.Getenv("DOCKER_BUILDKIT") == "0" {
+		return fmt.Errorf("-%s requires BuildKit, which is disabled by DOCKER_BUILDKIT=0 in the environment. "+
+			"Unset DOCKER_BUILDKIT to build with secrets", constants.SecretFlag)
+	}
+	if !util.DockerVersionHasBuildKit() {
+		return fmt.Errorf("-%s requires BuildKit, which was not found. Upgrade to Docker 18.09 or later "+
+			"to build with secrets", constants.SecretFlag)
+	}
+	return nil
+}
+
+//checkSquash returns an error if the image cannot be squashed: BuildKit, used for platform
+// builds, does not support squashing and the legacy builder only squashes when the docker
+// daemon has experimental features enabled
+func checkSquash(platform string) error {
+	if platform != "" {
+		return fmt.Errorf("-%s cannot be used with -%s; platform builds use BuildKit, which does not squash "+
+			"images. Use a multi-stage Dockerfile to reduce the layers of the image instead", constants.SquashFlag,
+			constants.PlatformFlag)
+	}
+	experimental, err := util.DaemonExperimental()
+	if err != nil {
+		return fmt.Errorf("Error checking whether the docker daemon supports -%s: %s", constants.SquashFlag,
+			err.Error())
+	}
+	if !experimental {
+		return fmt.Errorf("-%s requires the docker daemon to run with experimental features enabled. Add "+
+			"\"experimental\": true to /etc/docker/daemon.json and restart docker, or build without -%s",
+			constants.SquashFlag, constants.SquashFlag)
+	}
+	return nil
+}
+
+//checkContextSize warns when the build context in jobDirectory, less any files excluded by its
+// .dockerignore, and the dockerfile are larger than constants.ContextWarnSizeMiB. Returns an
+// error if they are larger than contextLimit MiB; a limit of 0 disables the check.
+func checkContextSize(jobDirectory, dockerfile string, contextLimit int) error {
+	size, err := util.BuildContextSize(jobDirectory, dockerfile)
+	if err != nil {
+		util.Warnf("Error computing build context size: %s\n", err.Error())
+		return nil
+	}
+	sizeMiB := float64(size) / (1024.0 * 1024.0)
+	util.Debugf("Build context size is %.1f MiB\n", sizeMiB)
+
+	if contextLimit > 0 && sizeMiB > float64(contextLimit) {
+		return fmt.Errorf("Build context of %.1f MiB exceeds the limit of %d MiB. Add entries for files "+
+			"not needed in the image to %s", sizeMiB, contextLimit, util.DockerignoreFileName)
+	}
+	if sizeMiB > constants.ContextWarnSizeMiB {
+		util.Warnf("Build context is %.1f MiB. Add entries for test data and other files not needed in "+
+			"the image to %s to speed up builds\n", sizeMiB, util.DockerignoreFileName)
+	}
 	return nil
 }
 
 //PrintBuildUsage prints the seed build usage arguments, then exits the program
 func PrintBuildUsage() {
-	util.PrintUtil( "\nUsage:\tseed build [-d JOB_DIRECTORY]\n")
+	util.PrintUtil( "\nUsage:\tseed build [-d JOB_DIRECTORY] [-f DOCKERFILE] [-manifest PATH] [-no-cache] [-pull] [-compress] [-context-limit MiB]\n" +
+		"\t\t  [-platform OS/ARCH] [-t TAG]... [-squash] [-target STAGE] [-cache-from IMAGE]... [-pull-cache]\n"+
+		"\t\t  [-secret id=ID,src=PATH]... [-force] [-q]\n")
 	util.PrintUtil( "\nOptions:\n")
 	util.PrintUtil(
 		"  -%s  -%s\tDirectory containing Seed spec and Dockerfile (default is current directory)\n",
 		constants.ShortJobDirectoryFlag, constants.JobDirectoryFlag)
+	util.PrintUtil("  -%s -%s\tDockerfile to build with, relative to the job directory (default is %s)\n",
+		constants.ShortDockerfileFlag, constants.DockerfileFlag, util.DockerfileName)
+	util.PrintUtil("  -%s\tSeed manifest to build with, or a directory containing one, instead of the manifest in\n"+
+		"\t\tthe job directory. The job directory is still the build context\n", constants.ManifestFlag)
 	util.PrintUtil( "  -%s -%s\tUsername to login if needed to pull images (default anonymous).\n",
 		constants.ShortUserFlag, constants.UserFlag)
 	util.PrintUtil( "  -%s -%s\tPassword to login if needed to pull images (default anonymous).\n",
 		constants.ShortPassFlag, constants.PassFlag)
+	util.PrintUtil("  -%s\tDo not use the docker layer cache when building the image\n",
+		constants.NoCacheFlag)
+	util.PrintUtil("  -%s\t\tAlways attempt to pull a newer version of the base image\n",
+		constants.PullFlag)
+	util.PrintUtil("  -%s\tFail if the build context, less files excluded by %s, exceeds this size in MiB (default is no limit)\n",
+		constants.ContextLimitFlag, util.DockerignoreFileName)
+	util.PrintUtil("  -%s\tGzip the build context before sending it to the docker daemon; useful for remote daemons\n",
+		constants.CompressFlag)
+	util.PrintUtil("  -%s\tBuild for the given platform, i.e. linux/amd64, using BuildKit (requires docker buildx)\n",
+		constants.PlatformFlag)
+	util.PrintUtil("  -%s -%s\t\tApply an extra tag to the image, i.e. latest, or an image reference, i.e.\n\t\tregistry.example.com/org/my-job:dev. May be repeated; the seed image name is always applied\n",
+		constants.ShortTagFlag, constants.TagFlag)
+	util.PrintUtil("  -%s\t\tSquash the new layers of the image into one, reducing its size at the cost of layer\n"+
+		"\t\tcaching and sharing. Requires a docker daemon with experimental features enabled\n",
+		constants.SquashFlag)
+	util.PrintUtil("  -%s\t\tBuild the named stage of a multi-stage Dockerfile instead of the last stage. The\n"+
+		"\t\timage is still labeled with the seed manifest and tagged with the seed image name\n",
+		constants.TargetFlag)
+	util.PrintUtil("  -%s\tReuse the layers of this image, i.e. the last published version of the job; may be\n"+
+		"\t\trepeated. Only local images are used unless -%s is given\n", constants.CacheFromFlag,
+		constants.PullCacheFlag)
+	util.PrintUtil("  -%s\tPull the -%s images before building; an image that cannot be pulled is skipped\n",
+		constants.PullCacheFlag, constants.CacheFromFlag)
+	util.PrintUtil("  -%s\tBuildKit secret RUN --mount=type=secret steps can read without it being stored in\n"+
+		"\t\tthe image, given as id=ID,src=PATH or id=ID,env=VARIABLE; may be repeated. Requires BuildKit\n",
+		constants.SecretFlag)
+	util.PrintUtil("  -%s\t\tBuild even if the manifest, Dockerfile, build context and options are unchanged\n"+
+		"\t\tsince the last successful build, recorded in the %s directory of the job\n",
+		constants.ForceFlag, constants.BuildCacheDir)
+	util.PrintUtil("  -%s -%s\tSuppress docker build progress output; errors are still reported\n",
+		constants.ShortQuietFlag, constants.QuietFlag)
+	util.PrintUtil("  -%s\tDisplay docker build progress as %s output (default) or a consolidated %s showing\n"+
+		"\t\tpercent complete and the current step\n", constants.ProgressFlag, constants.ProgressPlain,
+		constants.ProgressBar)
 	panic(util.Exit{0})
 }
@@ -1,10 +1,19 @@
 package commands
 
 import (
+	"archive/tar"
+	"bytes"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
+	"github.com/ngageoint/seed-cli/constants"
 	"github.com/ngageoint/seed-cli/objects"
 	"github.com/ngageoint/seed-cli/util"
 )
@@ -25,7 +34,11 @@ func TestDockerBuild(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		err := DockerBuild(c.directory, "", "")
+		dir := c.directory
+		if dir != "" {
+			dir = copyJob(t, dir)
+		}
+		err := DockerBuild(BuildOptions{JobDirectory: dir})
 		success := err == nil
 		if success != c.expected {
 			t.Errorf("DockerBuild(%q) == %v, expected %v", c.directory, success, c.expected)
@@ -38,6 +51,231 @@ func TestDockerBuild(t *testing.T) {
 	}
 }
 
+func TestDockerBuildCommand(t *testing.T) {
+	fake := &util.FakeDockerRunner{Responses: []util.FakeDockerResponse{
+		{Args: []string{"version"}, Stdout: "20.10.7\n"},
+	}}
+	runner := &contextRunner{FakeDockerRunner: fake}
+	defer util.SetDockerRunner(util.SetDockerRunner(runner))
+
+	// Build a copy of the job, as builds record themselves in its .seed directory
+	job := copyJob(t, "../examples/addition-job/")
+
+	err := DockerBuild(BuildOptions{JobDirectory: job, NoCache: true})
+	if err != nil {
+		t.Fatalf("DockerBuild with a fake docker returned %v", err)
+	}
+	calls := fake.Calls()
+	build := calls[len(calls)-1]
+	expected := []string{"build", "-t", "addition-job-0.0.1-seed:1.0.0", job, "--no-cache", "--label"}
+	if len(build) != len(expected)+1 || !reflect.DeepEqual(build[:len(expected)], expected) ||
+		!strings.HasPrefix(build[len(expected)], "com.ngageoint.seed.manifest=") {
+		t.Errorf("DockerBuild ran docker %q, expected %q and the manifest label", build, expected)
+	}
+
+	err = DockerBuild(BuildOptions{JobDirectory: job, Dockerfile: "Dockerfile.missing"})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("DockerBuild with a missing -file Dockerfile returned %v, expected %v", err, ErrValidation)
+	}
+
+	dir, err := ioutil.TempDir("", "seed-build-file")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for DockerBuild -file test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	dockerfile := filepath.Join(dir, "Dockerfile.dev")
+	ioutil.WriteFile(dockerfile, []byte("FROM alpine\n"), 0644)
+	ignore := filepath.Join(job, util.DockerignoreFileName)
+	ioutil.WriteFile(ignore, []byte(constants.BuildCacheDir+"\n"), 0644)
+	err = DockerBuild(BuildOptions{JobDirectory: job, Dockerfile: dockerfile})
+	if err != nil {
+		t.Fatalf("DockerBuild with -file %s returned %v", dockerfile, err)
+	}
+	calls = fake.Calls()
+	build = calls[len(calls)-1]
+	if build[3] != job || !reflect.DeepEqual(build[4:6], []string{"-f", dockerfile}) {
+		t.Errorf("DockerBuild with -file %s ran docker %q, expected -f %s", dockerfile, build, dockerfile)
+	}
+
+	// The .seed directory of earlier builds is left out of the context, which is sent on stdin
+	// unless the .dockerignore excludes it
+	os.Remove(ignore)
+	err = DockerBuild(BuildOptions{JobDirectory: job, Dockerfile: dockerfile})
+	if err != nil {
+		t.Fatalf("DockerBuild with -file %s and a .seed directory returned %v", dockerfile, err)
+	}
+	calls = fake.Calls()
+	build = calls[len(calls)-1]
+	if build[3] != "-" || !reflect.DeepEqual(build[4:6], []string{"-f", util.ContextDockerfileName}) {
+		t.Errorf("DockerBuild with a .seed directory ran docker %q, expected the context and -f %s on stdin",
+			build, util.ContextDockerfileName)
+	}
+	sort.Strings(runner.context)
+	expectedContext := []string{util.ContextDockerfileName, "Dockerfile", "inputs.txt", "my_alg.py", "run.sh",
+		"seed.manifest.json"}
+	if !reflect.DeepEqual(runner.context, expectedContext) {
+		t.Errorf("DockerBuild with a .seed directory sent the context %q, expected %q", runner.context,
+			expectedContext)
+	}
+
+	// BuildKit writes its progress to stderr, so a secret build is judged by its exit status
+	secret := "id=token,src=" + dockerfile
+	responses := fake.Responses
+	fake.Responses = append([]util.FakeDockerResponse{{Args: []string{"build"},
+		Stderr: "#1 [internal] load build definition from Dockerfile\n#1 DONE 0.0s\n"}}, responses...)
+	err = DockerBuild(BuildOptions{JobDirectory: job, Secrets: []string{secret}})
+	fake.Responses = responses
+	if err != nil {
+		t.Fatalf("DockerBuild with -secret %s and BuildKit progress on stderr returned %v", secret, err)
+	}
+	calls = fake.Calls()
+	build = calls[len(calls)-1]
+	if !strings.Contains(strings.Join(build, " "), " --secret "+secret+" ") {
+		t.Errorf("DockerBuild with -secret %s ran docker %q, expected --secret %s", secret, build, secret)
+	}
+
+	// An unchanged build is skipped while its image is present, unless forced
+	builds := func() int {
+		n := 0
+		for _, call := range fake.Calls() {
+			if call[0] == "build" {
+				n++
+			}
+		}
+		return n
+	}
+	if err := DockerBuild(BuildOptions{JobDirectory: job}); err != nil {
+		t.Fatalf("DockerBuild returned %v", err)
+	}
+	fake.Responses = append([]util.FakeDockerResponse{{Args: []string{"images", "-q"}, Stdout: "0123456789ab\n"}},
+		responses...)
+	before := builds()
+	if err := DockerBuild(BuildOptions{JobDirectory: job}); err != nil || builds() != before {
+		t.Errorf("DockerBuild of an unchanged job returned %v and built %d times, expected it to skip the build",
+			err, builds()-before)
+	}
+	if err := DockerBuild(BuildOptions{JobDirectory: job, Force: true}); err != nil ||
+		builds() != before+1 {
+		t.Errorf("DockerBuild of an unchanged job with -force returned %v and built %d times, expected one build",
+			err, builds()-before)
+	}
+	fake.Responses = responses
+
+	fake.Responses = append([]util.FakeDockerResponse{{Args: []string{"build"}, Stderr: "no space left on device",
+		ExitCode: 1}}, fake.Responses...)
+	err = DockerBuild(BuildOptions{JobDirectory: job})
+	if !errors.Is(err, ErrDockerExec) {
+		t.Errorf("DockerBuild with a failing docker build returned %v, expected %v", err, ErrDockerExec)
+	}
+}
+
+//contextRunner is a fake docker that reads the build context docker build is given on stdin
+// and records the names of the files in it
+type contextRunner struct {
+	*util.FakeDockerRunner
+	context []string
+}
+
+//Run reads the build context of docker build, and answers commands from the fake
+func (r *contextRunner) Run(c util.DockerCommand) error {
+	if c.Args[0] == "build" && c.Stdin != nil {
+		r.context = nil
+		tr := tar.NewReader(c.Stdin)
+		for {
+			header, err := tr.Next()
+			if err != nil {
+				break
+			}
+			r.context = append(r.context, header.Name)
+		}
+	}
+	return r.FakeDockerRunner.Run(c)
+}
+
+//copyJob copies the job in dir, less its .seed directory, to a temporary directory removed when
+// the test ends, so builds do not record themselves in the source tree
+func copyJob(t *testing.T, dir string) string {
+	job, err := ioutil.TempDir("", "seed-build-job")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for job %s: %v", dir, err)
+	}
+	t.Cleanup(func() { os.RemoveAll(job) })
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == constants.BuildCacheDir {
+			return filepath.SkipDir
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(job, rel), info.Mode())
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(filepath.Join(job, rel), data, info.Mode())
+	})
+	if err != nil {
+		t.Fatalf("Error copying job %s: %v", dir, err)
+	}
+	return job
+}
+
+func TestBuildHash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seed-build-hash")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for BuildHash test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	manifest := filepath.Join(dir, constants.SeedFileName)
+	dockerfile := filepath.Join(dir, util.DockerfileName)
+	ioutil.WriteFile(manifest, []byte(`{"seedVersion": "1.0.0"}`), 0644)
+	ioutil.WriteFile(dockerfile, []byte("FROM alpine\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "main.py"), []byte("print(1)\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, util.DockerignoreFileName), []byte("*.log\n"), 0644)
+
+	hash := func(inputs ...string) string {
+		h, err := BuildHash(dir, manifest, dockerfile, inputs)
+		if err != nil {
+			t.Fatalf("BuildHash returned %v", err)
+		}
+		return h
+	}
+	original := hash()
+	if h := hash(); h != original {
+		t.Errorf("BuildHash of an unchanged job == %s, expected %s", h, original)
+	}
+
+	// The build cache directory and ignored files do not change the hash
+	writeBuildHash(dir, original)
+	ioutil.WriteFile(filepath.Join(dir, "build.log"), []byte("built\n"), 0644)
+	if h := hash(); h != original {
+		t.Errorf("BuildHash after writing %s and an ignored file == %s, expected %s", constants.BuildCacheDir, h,
+			original)
+	}
+	if recorded, _ := ioutil.ReadFile(buildHashFile(dir)); !strings.Contains(string(recorded), original) {
+		t.Errorf("writeBuildHash did not record %s in %s", original, buildHashFile(dir))
+	}
+
+	if hash("target=dev") == original {
+		t.Errorf("BuildHash with different options == %s, expected it to change", original)
+	}
+	ioutil.WriteFile(dockerfile, []byte("FROM alpine:3\n"), 0644)
+	changed := hash()
+	if changed == original {
+		t.Errorf("BuildHash after changing the Dockerfile == %s, expected it to change", original)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "main.py"), []byte("print(12)\n"), 0644)
+	if hash() == changed {
+		t.Errorf("BuildHash after changing a context file == %s, expected it to change", changed)
+	}
+}
+
 func TestSeedLabel(t *testing.T) {
 	cases := []struct {
 		directory        string
@@ -50,7 +288,7 @@ func TestSeedLabel(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		DockerBuild(c.directory, "", "")
+		DockerBuild(BuildOptions{JobDirectory: c.directory})
 		seedFileName, exist, _ := util.GetSeedFileName(c.directory)
 		if !exist {
 			t.Errorf("ERROR: %s cannot be found.\n",
@@ -59,9 +297,12 @@ func TestSeedLabel(t *testing.T) {
 		}
 
 		// retrieve seed from seed manifest
-		seed := objects.SeedFromManifestFile(seedFileName)
+		seed, err := objects.SeedFromManifestFile(seedFileName)
+		if err != nil {
+			t.Fatalf("Error reading seed manifest: %v", err)
+		}
 
-		seed2 := objects.SeedFromImageLabel(c.imageName)
+		seed2, _ := objects.SeedFromImageLabel(c.imageName)
 		seedStr1 := fmt.Sprintf("%v", seed)
 		seedStr2 := fmt.Sprintf("%v", seed2)
 
@@ -86,7 +327,10 @@ func TestImageName(t *testing.T) {
 
 		seedFileName := util.GetFullPath(c.filename, "")
 		// retrieve seed from seed manifest
-		seed := objects.SeedFromManifestFile(seedFileName)
+		seed, err := objects.SeedFromManifestFile(seedFileName)
+		if err != nil {
+			t.Fatalf("Error reading seed manifest: %v", err)
+		}
 
 		// Retrieve docker image name
 		imageName := objects.BuildImageName(&seed)
@@ -96,3 +340,387 @@ func TestImageName(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckContextSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seed-context")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for CheckContextSize test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "testdata", "keep"), os.ModePerm)
+	ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "testdata", "big.dat"), make([]byte, 2*1024*1024), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "testdata", "keep", "small.dat"), make([]byte, 1024), 0644)
+
+	cases := []struct {
+		dockerignore     string
+		contextLimit     int
+		expectedErrorMsg string
+	}{
+		{"", 0, ""},
+		{"", 1, "exceeds the limit of 1 MiB"},
+		{"testdata", 1, ""},
+		{"# comment\n/testdata/*.dat\n", 1, ""},
+		{"**/*.dat\n!testdata/big.dat\n", 1, "exceeds the limit of 1 MiB"},
+		{"*.dat\n", 1, "exceeds the limit of 1 MiB"},
+	}
+
+	for _, c := range cases {
+		ioutil.WriteFile(filepath.Join(dir, util.DockerignoreFileName), []byte(c.dockerignore), 0644)
+		err := checkContextSize(dir, filepath.Join(dir, util.DockerfileName), c.contextLimit)
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("checkContextSize(%q, %d) == %v, expected %v", c.dockerignore, c.contextLimit, err.Error(), c.expectedErrorMsg)
+			}
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("checkContextSize(%q, %d) returned no error, expected %v", c.dockerignore, c.contextLimit, c.expectedErrorMsg)
+		}
+	}
+
+	// docker sends the Dockerfile even when it is outside the context or excluded from it
+	outside := filepath.Join(dir, "testdata", "big.dat")
+	ioutil.WriteFile(filepath.Join(dir, util.DockerignoreFileName), []byte("testdata\n"), 0644)
+	if err := checkContextSize(dir, outside, 1); err == nil {
+		t.Errorf("checkContextSize with an excluded 2 MiB Dockerfile returned no error, expected the limit exceeded")
+	}
+	if err := checkContextSize(filepath.Join(dir, "testdata", "keep"), outside, 1); err == nil {
+		t.Errorf("checkContextSize with a 2 MiB Dockerfile outside the context returned no error, expected the limit exceeded")
+	}
+}
+
+func TestContextCompression(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seed-compression")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for ContextCompression test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "testdata"), os.ModePerm)
+	ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "zeros.dat"), make([]byte, 1024*1024), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "testdata", "ignored.dat"), make([]byte, 4*1024*1024), 0644)
+	ioutil.WriteFile(filepath.Join(dir, util.DockerignoreFileName), []byte("testdata\n"), 0644)
+
+	raw, compressed, _, err := util.ContextCompression(dir)
+	if err != nil {
+		t.Fatalf("ContextCompression returned an error: %v", err)
+	}
+	// The tar holds the 1 MiB file and headers, but not the ignored 4 MiB one
+	if raw < 1024*1024 || raw > 2*1024*1024 {
+		t.Errorf("ContextCompression measured %d bytes before compression, expected a little over 1 MiB", raw)
+	}
+	if compressed <= 0 || compressed >= raw/10 {
+		t.Errorf("ContextCompression measured %d bytes after compressing %d bytes of zeros, expected far fewer",
+			compressed, raw)
+	}
+
+	if _, _, _, err := util.ContextCompression(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("ContextCompression of a missing directory returned no error")
+	}
+}
+
+func TestCheckDockerfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seed-dockerfile")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for CheckDockerfile test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		dockerfile       string
+		file             string
+		expectedErrorMsg string
+	}{
+		{"", "", "No Dockerfile found"},
+		{"", "Dockerfile.gpu", "Dockerfile.gpu given by -file not found"},
+		{"FROM alpine\n", "Dockerfile.gpu", "Dockerfile.gpu given by -file not found"},
+		{"FROM alpine\n", ".", "is a directory"},
+		{"FROM alpine\n", "", ""},
+		{"# syntax comment\nARG VERSION=3.7\n\nFROM --platform=linux/amd64 alpine:${VERSION} AS base\n", "", ""},
+		{"FROM \\\n  registry.example.com:5000/geoint/base:1.0\nRUN true\n", "", ""},
+		{"RUN apk add gdal\nFROM alpine\n", "", "must start with a FROM instruction"},
+		{"FROM\n", "", "without a base image"},
+		{"FROM Alpine:latest\n", "", "not a valid docker image name"},
+		{"# empty\n", "", "has no FROM instruction"},
+	}
+
+	for _, c := range cases {
+		os.Remove(filepath.Join(dir, util.DockerfileName))
+		if c.dockerfile != "" {
+			ioutil.WriteFile(filepath.Join(dir, util.DockerfileName), []byte(c.dockerfile), 0644)
+		}
+		err := checkDockerfile(dir, util.DockerfilePath(dir, c.file))
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("checkDockerfile(%q, %q) == %v, expected %v", c.dockerfile, c.file, err.Error(), c.expectedErrorMsg)
+			}
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("checkDockerfile(%q, %q) returned no error, expected %v", c.dockerfile, c.file, c.expectedErrorMsg)
+		}
+	}
+}
+
+func TestResolveCacheFrom(t *testing.T) {
+	cases := []struct {
+		images           []string
+		expected         string
+		expectedErrorMsg string
+	}{
+		{[]string{""}, "[]", ""},
+		{[]string{"registry.example.com/geoint/my-job-0.1.0-seed:1.0.0", "", "my-job-0.1.0-seed"},
+			"[registry.example.com/geoint/my-job-0.1.0-seed:1.0.0 my-job-0.1.0-seed]", ""},
+		{[]string{"my-job-0.1.0-seed", "My-Job:latest"}, "", "Invalid -cache-from image"},
+	}
+
+	for _, c := range cases {
+		refs, err := ResolveCacheFrom(c.images)
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("ResolveCacheFrom(%v) == %v, expected %v", c.images, err.Error(), c.expectedErrorMsg)
+			}
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("ResolveCacheFrom(%v) returned no error, expected %v", c.images, c.expectedErrorMsg)
+		} else if result := fmt.Sprintf("%v", refs); result != c.expected {
+			t.Errorf("ResolveCacheFrom(%v) == %v, expected %v", c.images, result, c.expected)
+		}
+	}
+}
+
+func TestBuildManifestFile(t *testing.T) {
+	cases := []struct {
+		jobDirectory string
+		manifest     string
+		expected     string
+		notExist     bool
+	}{
+		{"../testdata/complete", "", "../testdata/complete/seed.manifest.json", false},
+		{"../testdata/dummy-scratch", "../testdata/complete/seed.manifest.json",
+			"../testdata/complete/seed.manifest.json", false},
+		{"../testdata/dummy-scratch", "../testdata/complete", "../testdata/complete/seed.manifest.json", false},
+		{"../testdata/complete", "../testdata/missing/seed.manifest.json",
+			"../testdata/missing/seed.manifest.json", true},
+	}
+
+	for _, c := range cases {
+		result, err := BuildManifestFile(c.jobDirectory, c.manifest)
+		if expected := util.GetFullPath(c.expected, ""); result != expected {
+			t.Errorf("BuildManifestFile(%q, %q) == %q, expected %q", c.jobDirectory, c.manifest, result, expected)
+		}
+		if os.IsNotExist(err) != c.notExist {
+			t.Errorf("BuildManifestFile(%q, %q) returned error %v, expected not exist %v", c.jobDirectory,
+				c.manifest, err, c.notExist)
+		}
+	}
+}
+
+func TestCheckTarget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seed-target")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for CheckTarget test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		dockerfile       string
+		target           string
+		expectedErrorMsg string
+	}{
+		{"FROM golang AS build\nRUN go build\n\nFROM alpine as runtime\nCOPY --from=build /app /app\n", "build", ""},
+		{"FROM golang AS build\nFROM alpine as runtime\n", "Runtime", ""},
+		{"FROM --platform=linux/amd64 \\\n  golang:1.13 AS build\n", "build", ""},
+		{"FROM golang AS build\nFROM alpine as runtime\n", "test", "stages of the Dockerfile in " + dir + " are build, runtime"},
+		{"FROM alpine\n# FROM golang AS build\n", "build", "has no named stages"},
+		{"", "build", "no such file"},
+	}
+
+	for _, c := range cases {
+		os.Remove(filepath.Join(dir, util.DockerfileName))
+		if c.dockerfile != "" {
+			ioutil.WriteFile(filepath.Join(dir, util.DockerfileName), []byte(c.dockerfile), 0644)
+		}
+		err := checkTarget(filepath.Join(dir, util.DockerfileName), c.target)
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("checkTarget(%q, %q) == %v, expected %v", c.dockerfile, c.target, err.Error(),
+					c.expectedErrorMsg)
+			}
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("checkTarget(%q, %q) returned no error, expected %v", c.dockerfile, c.target,
+				c.expectedErrorMsg)
+		}
+	}
+}
+
+func TestResolveBuildSecrets(t *testing.T) {
+	os.Setenv("SEED_TEST_TOKEN", "abc")
+	defer os.Unsetenv("SEED_TEST_TOKEN")
+	token := "../testdata/complete/seed.manifest.json"
+
+	cases := []struct {
+		secrets  []string
+		expected []string
+		errMsg   string
+	}{
+		{[]string{""}, nil, ""},
+		{[]string{"id=token,src=" + token, "", "type=env,id=pip,env=SEED_TEST_TOKEN"},
+			[]string{"id=token,src=" + token, "type=env,id=pip,env=SEED_TEST_TOKEN"}, ""},
+		{[]string{"id=token,source=" + token}, []string{"id=token,source=" + token}, ""},
+		{[]string{"src=" + token}, nil, "The secret has no id"},
+		{[]string{"id=token"}, nil, "Give the file holding the secret"},
+		{[]string{"id=token,src=../testdata/missing"}, nil, "The secret file ../testdata/missing cannot be read"},
+		{[]string{"id=token,env=SEED_TEST_MISSING"}, nil, "The environment variable SEED_TEST_MISSING is not set"},
+		{[]string{"id=token,src=" + token + ",env=SEED_TEST_TOKEN"}, nil, "not both"},
+		{[]string{"id=token,required=true,src=" + token}, nil, "Unknown field required"},
+		{[]string{"id=token,type=ssh,src=" + token}, nil, "The type of a secret is file or env"},
+		{[]string{"token"}, nil, "Invalid -secret token"},
+	}
+
+	for _, c := range cases {
+		secrets, err := ResolveBuildSecrets(c.secrets)
+		if !reflect.DeepEqual(secrets, c.expected) {
+			t.Errorf("ResolveBuildSecrets(%q) == %q, expected %q", c.secrets, secrets, c.expected)
+		}
+		if (err == nil) != (c.errMsg == "") || (err != nil && !strings.Contains(err.Error(), c.errMsg)) {
+			t.Errorf("ResolveBuildSecrets(%q) returned error %v, expected %q", c.secrets, err, c.errMsg)
+		}
+	}
+
+	if err := checkBuildKit(true); err == nil || !strings.Contains(err.Error(), "cannot be used with -squash") {
+		t.Errorf("checkBuildKit with -squash returned %v, expected it to be rejected", err)
+	}
+	os.Setenv("DOCKER_BUILDKIT", "0")
+	defer os.Unsetenv("DOCKER_BUILDKIT")
+	if err := checkBuildKit(false); err == nil || !strings.Contains(err.Error(), "disabled by DOCKER_BUILDKIT=0") {
+		t.Errorf("checkBuildKit with DOCKER_BUILDKIT=0 returned %v, expected BuildKit to be required", err)
+	}
+}
+
+func TestCheckPlatform(t *testing.T) {
+	// Malformed platforms are rejected before docker buildx is looked for
+	cases := []string{"amd64", "linux/", "Linux/AMD64", "linux/arm/v7/extra"}
+
+	for _, platform := range cases {
+		err := checkPlatform(platform)
+		if err == nil || !strings.Contains(err.Error(), "Invalid platform") {
+			t.Errorf("checkPlatform(%q) == %v, expected invalid platform error", platform, err)
+		}
+	}
+
+	// buildx is only required for platforms other than the daemon's
+	fake := &util.FakeDockerRunner{Responses: []util.FakeDockerResponse{
+		{Args: []string{"version"}, Stdout: "linux/amd64\n"},
+		{Args: []string{"buildx"}, ExitCode: 1},
+	}}
+	defer util.SetDockerRunner(util.SetDockerRunner(fake))
+	if err := checkPlatform("linux/amd64"); err != nil {
+		t.Errorf("checkPlatform(linux/amd64) on a linux/amd64 daemon returned %v, expected no error", err)
+	}
+	if err := checkPlatform("linux/arm64"); err == nil || !strings.Contains(err.Error(), "buildx plugin") {
+		t.Errorf("checkPlatform(linux/arm64) without buildx returned %v, expected a buildx error", err)
+	}
+}
+
+func TestResolveBuildTags(t *testing.T) {
+	imageName := "my-job-0.1.0-seed:1.0.0"
+	cases := []struct {
+		tags             []string
+		expected         string
+		expectedErrorMsg string
+	}{
+		{[]string{""}, "[]", ""},
+		{[]string{"latest", "dev-1.2"}, "[my-job-0.1.0-seed:latest my-job-0.1.0-seed:dev-1.2]", ""},
+		{[]string{"registry.example.com:5000/org/my-job:latest"}, "[registry.example.com:5000/org/my-job:latest]", ""},
+		{[]string{"org/my-job"}, "[org/my-job]", ""},
+		{[]string{"latest", "-bad"}, "", "Invalid tag -bad"},
+		{[]string{"Org/My-Job:latest"}, "", "Invalid tag Org/My-Job:latest"},
+		{[]string{"my job"}, "", "Invalid tag my job"},
+	}
+
+	for _, c := range cases {
+		refs, err := ResolveBuildTags(imageName, c.tags)
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("ResolveBuildTags(%v) == %v, expected %v", c.tags, err.Error(), c.expectedErrorMsg)
+			}
+			continue
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("ResolveBuildTags(%v) returned no error, expected %v", c.tags, c.expectedErrorMsg)
+		}
+		if result := fmt.Sprintf("%s", refs); result != c.expected {
+			t.Errorf("ResolveBuildTags(%v) == %v, expected %v", c.tags, result, c.expected)
+		}
+	}
+}
+
+func TestDockerignoreMatches(t *testing.T) {
+	cases := []struct {
+		path     string
+		patterns []string
+		expected bool
+	}{
+		{"testdata/big.dat", []string{"testdata"}, true},
+		{"testdata/big.dat", []string{"*.dat"}, false},
+		{"testdata/big.dat", []string{"*/*.dat"}, true},
+		{"testdata/keep/small.dat", []string{"**/*.dat"}, true},
+		{"small.dat", []string{"**/*.dat"}, true},
+		{"testdata/keep/small.dat", []string{"testdata", "!testdata/keep"}, false},
+		{"testdata/big.dat", []string{"testdata", "!testdata/keep"}, true},
+		{"file?.txt", []string{"file[?].txt"}, true},
+		{"file1.txt", []string{"file?.txt"}, true},
+		{"Dockerfile", []string{"*", "!Dockerfile"}, false},
+	}
+
+	for _, c := range cases {
+		matched := util.DockerignoreMatches(c.path, c.patterns)
+		if matched != c.expected {
+			t.Errorf("DockerignoreMatches(%q, %q) == %v, expected %v", c.path, c.patterns, matched, c.expected)
+		}
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	cases := []struct {
+		output          string
+		expectedPercent int
+		expectedCurrent string
+	}{
+		{"Sending build context to Docker daemon  3.072kB\nStep 1/4 : FROM alpine\n ---> 14119a10abf4\n",
+			0, "Step 1/4: FROM alpine"},
+		{"Step 1/4 : FROM alpine\nStep 3/4 : RUN make\n", 50, "Step 3/4: RUN make"},
+		{"#5 [build 1/2] FROM docker.io/library/golang\n#6 [2/3] RUN make\n", 33, "Step 2/3: RUN make"},
+		{"latest: Pulling from library/alpine\n59bf1c3509f3: Pulling fs layer\n8ac8bfaff55a: Already exists\n",
+			50, "8ac8bfaff55a: Already exists"},
+		{"59bf1c3509f3: Downloading [=====>   ]  1.2MB/3.4MB\r59bf1c3509f3: Pull complete\n", 100,
+			"59bf1c3509f3: Pull complete"},
+		{"Step 2/2 : CMD run", 0, ""},
+	}
+
+	for _, c := range cases {
+		var out bytes.Buffer
+		bar := util.NewProgressBar(&out, "Building", false)
+		bar.Write([]byte(c.output))
+		if bar.Percent() != c.expectedPercent || bar.Current() != c.expectedCurrent {
+			t.Errorf("ProgressBar(%q) == %d%% %q, expected %d%% %q", c.output, bar.Percent(), bar.Current(),
+				c.expectedPercent, c.expectedCurrent)
+		}
+	}
+
+	var out bytes.Buffer
+	bar := util.NewProgressBar(&out, "Building", false)
+	bar.Write([]byte("Step 1/2 : FROM alpine\nStep 2/2 : RUN false\n"))
+	bar.Finish(errors.New("exit status 1"))
+	if !strings.Contains(out.String(), "Building [===============>              ]  50% Step 2/2: RUN false") ||
+		!strings.Contains(out.String(), "Last 2 lines of output:\nStep 1/2 : FROM alpine") {
+		t.Errorf("ProgressBar wrote %q, expected the bar and the last lines of output", out.String())
+	}
+
+	if err := util.SetProgressMode("fancy"); err == nil || !strings.Contains(err.Error(), "Must be bar or plain") {
+		t.Errorf("SetProgressMode(fancy) returned %v, expected an error", err)
+	}
+}
+
+func TestCheckSquash(t *testing.T) {
+	err := checkSquash("linux/amd64")
+	if err == nil || !strings.Contains(err.Error(), "-squash cannot be used with -platform") {
+		t.Errorf("checkSquash(linux/amd64) == %v, expected an error for platform builds", err)
+	}
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+//CompletionCommand describes a seed command, its flags and any subcommands or fixed
+// arguments for generating shell completion scripts
+type CompletionCommand struct {
+	Name        string
+	Flags       []string
+	Args        []string
+	Subcommands []CompletionCommand
+}
+
+//NewCompletionCommand returns the completion description of the command defined by the
+// given flag set
+func NewCompletionCommand(cmd *flag.FlagSet) CompletionCommand {
+	c := CompletionCommand{Name: cmd.Name()}
+	cmd.VisitAll(func(f *flag.Flag) {
+		c.Flags = append(c.Flags, "-"+f.Name)
+	})
+	sort.Strings(c.Flags)
+	return c
+}
+
+//SeedCompletion seed completion: Prints a completion script for the given shell covering
+// the seed commands and their flags. The script is written to stdout so it can be sourced.
+func SeedCompletion(shell string, cmds []CompletionCommand) error {
+	script, err := CompletionScript(shell, cmds)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
+	fmt.Fprint(os.Stdout, script)
+	return nil
+}
+
+//CompletionScript returns the completion script for the given shell
+func CompletionScript(shell string, cmds []CompletionCommand) (string, error) {
+	switch shell {
+	case constants.ShellBash:
+		return bashCompletion(cmds), nil
+	case constants.ShellZsh:
+		return "autoload -U +X bashcompinit && bashcompinit\n" + bashCompletion(cmds), nil
+	case constants.ShellFish:
+		return fishCompletion(cmds), nil
+	}
+	return "", errors.New("ERROR: Unsupported shell " + shell + ". Must be one of " +
+		strings.Join(constants.CompletionShells, ", ") + ".")
+}
+
+//bashCompletion returns a bash completion script. Flags are completed when the current word
+// starts with -, otherwise completion falls back to file names.
+func bashCompletion(cmds []CompletionCommand) string {
+	var buffer bytes.Buffer
+	buffer.WriteString("# bash completion for seed\n")
+	buffer.WriteString("_seed() {\n")
+	buffer.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	buffer.WriteString("    local i=1\n")
+	buffer.WriteString("    # skip global flags given before the command\n")
+	buffer.WriteString("    while [ $i -lt $COMP_CWORD ]; do\n")
+	buffer.WriteString("        case \"${COMP_WORDS[$i]}\" in\n")
+	buffer.WriteString("            -" + constants.LogLevelFlag + "|-" + constants.ConfigFlag + ") i=$((i+2)) ;;\n")
+	buffer.WriteString("            -*) i=$((i+1)) ;;\n")
+	buffer.WriteString("            *) break ;;\n")
+	buffer.WriteString("        esac\n")
+	buffer.WriteString("    done\n")
+	buffer.WriteString("    if [ $i -ge $COMP_CWORD ]; then\n")
+	buffer.WriteString("        COMPREPLY=($(compgen -W \"" + strings.Join(commandNames(cmds), " ") +
+		" -" + constants.ShortQuietFlag + " -" + constants.LogLevelFlag + " -" + constants.ConfigFlag +
+			"\" -- \"$cur\"))\n")
+	buffer.WriteString("        return\n")
+	buffer.WriteString("    fi\n")
+	buffer.WriteString("    case \"${COMP_WORDS[$i]}\" in\n")
+	for _, c := range cmds {
+		buffer.WriteString("        " + c.Name + ")\n")
+		if len(c.Subcommands) > 0 {
+			buffer.WriteString("            if [ $((i+1)) -eq $COMP_CWORD ]; then\n")
+			buffer.WriteString("                COMPREPLY=($(compgen -W \"" + strings.Join(commandNames(c.Subcommands), " ") +
+				"\" -- \"$cur\"))\n")
+			buffer.WriteString("                return\n")
+			buffer.WriteString("            fi\n")
+			buffer.WriteString("            case \"${COMP_WORDS[$((i+1))]}\" in\n")
+			for _, s := range c.Subcommands {
+				buffer.WriteString("                " + s.Name + ") _seed_words \"" + strings.Join(s.Flags, " ") + "\" \"" +
+					strings.Join(s.Args, " ") + "\" ;;\n")
+			}
+			buffer.WriteString("            esac\n")
+			buffer.WriteString("            ;;\n")
+			continue
+		}
+		buffer.WriteString("            _seed_words \"" + strings.Join(c.Flags, " ") + "\" \"" +
+			strings.Join(c.Args, " ") + "\" ;;\n")
+	}
+	buffer.WriteString("    esac\n")
+	buffer.WriteString("}\n\n")
+	buffer.WriteString("_seed_words() {\n")
+	buffer.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	buffer.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	buffer.WriteString("        COMPREPLY=($(compgen -W \"$1\" -- \"$cur\"))\n")
+	buffer.WriteString("    elif [ -n \"$2\" ]; then\n")
+	buffer.WriteString("        COMPREPLY=($(compgen -W \"$2\" -- \"$cur\"))\n")
+	buffer.WriteString("    fi\n")
+	buffer.WriteString("}\n\n")
+	buffer.WriteString("complete -o default -F _seed seed\n")
+	return buffer.String()
+}
+
+//fishCompletion returns a fish completion script. seed flags use a single dash, so they are
+// declared as old style options.
+func fishCompletion(cmds []CompletionCommand) string {
+	var buffer bytes.Buffer
+	buffer.WriteString("# fish completion for seed\n")
+	names := strings.Join(commandNames(cmds), " ")
+	buffer.WriteString("complete -c seed -n '__fish_use_subcommand' -o " + constants.ShortQuietFlag + "\n")
+	buffer.WriteString("complete -c seed -n '__fish_use_subcommand' -o " + constants.LogLevelFlag + " -x -a '" +
+		strings.Join(logLevelNames(), " ") + "'\n")
+	buffer.WriteString("complete -c seed -n '__fish_use_subcommand' -o " + constants.ConfigFlag + " -r\n")
+	buffer.WriteString("complete -c seed -f -n '__fish_use_subcommand' -a '" + names + "'\n")
+	for _, c := range cmds {
+		condition := "__fish_seen_subcommand_from " + c.Name
+		if len(c.Subcommands) > 0 {
+			buffer.WriteString("complete -c seed -f -n '" + condition + "; and not __fish_seen_subcommand_from " +
+				strings.Join(commandNames(c.Subcommands), " ") + "' -a '" +
+				strings.Join(commandNames(c.Subcommands), " ") + "'\n")
+			for _, s := range c.Subcommands {
+				fishCommand(&buffer, condition+"; and __fish_seen_subcommand_from "+s.Name, s)
+			}
+			continue
+		}
+		fishCommand(&buffer, condition, c)
+	}
+	return buffer.String()
+}
+
+//fishCommand writes the fish completions for the flags and arguments of a single command
+func fishCommand(buffer *bytes.Buffer, condition string, c CompletionCommand) {
+	for _, f := range c.Flags {
+		buffer.WriteString("complete -c seed -n '" + condition + "' -o " + strings.TrimPrefix(f, "-") + "\n")
+	}
+	if len(c.Args) > 0 {
+		buffer.WriteString("complete -c seed -f -n '" + condition + "' -a '" + strings.Join(c.Args, " ") + "'\n")
+	}
+}
+
+//commandNames returns the names of the given commands
+func commandNames(cmds []CompletionCommand) []string {
+	var names []string
+	for _, c := range cmds {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+//logLevelNames returns the names of the log levels accepted by -log-level
+func logLevelNames() []string {
+	var names []string
+	for l := util.LevelDebug; l <= util.LevelError; l++ {
+		names = append(names, l.String())
+	}
+	return names
+}
+
+//PrintCompletionUsage prints the seed completion usage information, then exits the program
+func PrintCompletionUsage() {
+	util.PrintUtil("\nUsage:\tseed completion SHELL\n")
+	util.PrintUtil("\nPrints a script to stdout that completes seed commands and flags in SHELL (%s).\n",
+		strings.Join(constants.CompletionShells, ", "))
+	util.PrintUtil("\nTo load completions:\n")
+	util.PrintUtil("  bash:\tsource <(seed completion bash)\n")
+	util.PrintUtil("  zsh: \tsource <(seed completion zsh)\n")
+	util.PrintUtil("  fish:\tseed completion fish | source\n")
+	panic(util.Exit{0})
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+func init() {
+	util.InitPrinter(false)
+}
+
+func TestCompletionScript(t *testing.T) {
+	buildCmd := flag.NewFlagSet(constants.BuildCommand, flag.ContinueOnError)
+	buildCmd.Bool(constants.NoCacheFlag, false, "")
+	buildCmd.String(constants.JobDirectoryFlag, ".", "")
+	statsCmd := flag.NewFlagSet(constants.ManifestStatsCommand, flag.ContinueOnError)
+	statsCmd.String(constants.OutputFlag, constants.OutputText, "")
+	cmds := []CompletionCommand{
+		NewCompletionCommand(buildCmd),
+		{Name: constants.ManifestCommand, Subcommands: []CompletionCommand{NewCompletionCommand(statsCmd)}},
+	}
+
+	cases := []struct {
+		shell            string
+		expected         []string
+		expectedErrorMsg string
+	}{
+		{constants.ShellBash, []string{"compgen -W \"build manifest -q -log-level -config\"",
+			"build)\n            _seed_words \"-directory -no-cache\" \"\" ;;", "stats) _seed_words \"-output\"",
+			"complete -o default -F _seed seed"}, ""},
+		{constants.ShellZsh, []string{"bashcompinit", "complete -o default -F _seed seed"}, ""},
+		{constants.ShellFish, []string{"-a 'build manifest'",
+			"complete -c seed -n '__fish_seen_subcommand_from build' -o no-cache",
+			"complete -c seed -n '__fish_seen_subcommand_from manifest; and __fish_seen_subcommand_from stats' -o output"}, ""},
+		{"tcsh", nil, "Unsupported shell tcsh"},
+	}
+
+	for _, c := range cases {
+		script, err := CompletionScript(c.shell, cmds)
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("CompletionScript(%q) == %v, expected %v", c.shell, err.Error(), c.expectedErrorMsg)
+			}
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("CompletionScript(%q) returned no error, expected %v", c.shell, c.expectedErrorMsg)
+		}
+		for _, e := range c.expected {
+			if !strings.Contains(script, e) {
+				t.Errorf("CompletionScript(%q) == \n%s\nexpected to contain %q", c.shell, script, e)
+			}
+		}
+	}
+}
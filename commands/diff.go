@@ -0,0 +1,244 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+//ManifestChange describes a single difference between two seed manifests
+type ManifestChange struct {
+	Field  string `json:"field"`
+	Change string `json:"change"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+}
+
+//ManifestDiff holds the differences between two seed manifests
+type ManifestDiff struct {
+	Old     string           `json:"old"`
+	New     string           `json:"new"`
+	Changes []ManifestChange `json:"changes"`
+}
+
+const (
+	//ChangeAdded the field or element is only in the new manifest
+	ChangeAdded = "added"
+
+	//ChangeRemoved the field or element is only in the old manifest
+	ChangeRemoved = "removed"
+
+	//ChangeModified the field has a different value in the new manifest
+	ChangeModified = "changed"
+)
+
+//namedElement is an element of a manifest list identified by its name (or code for errors)
+// with its remaining fields formatted as strings
+type namedElement struct {
+	key    string
+	fields map[string]string
+	raw    string
+}
+
+//DiffManifests returns the differences in versions, interface and resources between
+// the old and new seed manifests
+func DiffManifests(oldSeed, newSeed *objects.Seed) []ManifestChange {
+	changes := []ManifestChange{}
+
+	diffValue(&changes, "seedVersion", oldSeed.SeedVersion, newSeed.SeedVersion)
+	diffValue(&changes, "job.name", oldSeed.Job.Name, newSeed.Job.Name)
+	diffValue(&changes, "job.jobVersion", oldSeed.Job.JobVersion, newSeed.Job.JobVersion)
+	diffValue(&changes, "job.packageVersion", oldSeed.Job.PackageVersion, newSeed.Job.PackageVersion)
+	diffValue(&changes, "job.timeout", strconv.Itoa(oldSeed.Job.Timeout), strconv.Itoa(newSeed.Job.Timeout))
+
+	oldIf, newIf := oldSeed.Job.Interface, newSeed.Job.Interface
+	diffValue(&changes, "job.interface.command", oldIf.Command, newIf.Command)
+	diffElements(&changes, "job.interface.inputs.files", oldIf.Inputs.Files, newIf.Inputs.Files, "name")
+	diffElements(&changes, "job.interface.inputs.json", oldIf.Inputs.Json, newIf.Inputs.Json, "name")
+	diffElements(&changes, "job.interface.outputs.files", oldIf.Outputs.Files, newIf.Outputs.Files, "name")
+	diffElements(&changes, "job.interface.outputs.json", oldIf.Outputs.JSON, newIf.Outputs.JSON, "name")
+	diffElements(&changes, "job.interface.mounts", oldIf.Mounts, newIf.Mounts, "name")
+	diffElements(&changes, "job.interface.settings", oldIf.Settings, newIf.Settings, "name")
+
+	diffElements(&changes, "job.resources.scalar", oldSeed.Job.Resources.Scalar, newSeed.Job.Resources.Scalar, "name")
+	diffElements(&changes, "job.errors", oldSeed.Job.Errors, newSeed.Job.Errors, "code")
+
+	return changes
+}
+
+//diffValue records a change if the old and new values of field differ. Empty and zero values
+// are treated as absent
+func diffValue(changes *[]ManifestChange, field, oldValue, newValue string) {
+	switch {
+	case oldValue == newValue:
+		return
+	case oldValue == "" || oldValue == "0":
+		*changes = append(*changes, ManifestChange{Field: field, Change: ChangeAdded, New: newValue})
+	case newValue == "" || newValue == "0":
+		*changes = append(*changes, ManifestChange{Field: field, Change: ChangeRemoved, Old: oldValue})
+	default:
+		*changes = append(*changes, ManifestChange{Field: field, Change: ChangeModified, Old: oldValue, New: newValue})
+	}
+}
+
+//diffElements records the added, removed and changed elements of two manifest lists. Elements
+// are matched by the value of their key field.
+func diffElements(changes *[]ManifestChange, field string, oldList, newList interface{}, key string) {
+	oldElements := toNamedElements(oldList, key)
+	newElements := toNamedElements(newList, key)
+
+	newByKey := make(map[string]namedElement)
+	for _, e := range newElements {
+		newByKey[e.key] = e
+	}
+	oldByKey := make(map[string]namedElement)
+	for _, e := range oldElements {
+		oldByKey[e.key] = e
+	}
+
+	for _, o := range oldElements {
+		elementField := field + "[" + o.key + "]"
+		n, ok := newByKey[o.key]
+		if !ok {
+			*changes = append(*changes, ManifestChange{Field: elementField, Change: ChangeRemoved, Old: o.raw})
+			continue
+		}
+
+		var names []string
+		for name := range o.fields {
+			names = append(names, name)
+		}
+		for name := range n.fields {
+			if _, ok := o.fields[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if o.fields[name] != n.fields[name] {
+				*changes = append(*changes, ManifestChange{Field: elementField + "." + name,
+					Change: ChangeModified, Old: o.fields[name], New: n.fields[name]})
+			}
+		}
+	}
+
+	for _, n := range newElements {
+		if _, ok := oldByKey[n.key]; !ok {
+			*changes = append(*changes, ManifestChange{Field: field + "[" + n.key + "]",
+				Change: ChangeAdded, New: n.raw})
+		}
+	}
+}
+
+//toNamedElements converts a list of manifest objects into elements keyed by the given field
+func toNamedElements(list interface{}, key string) []namedElement {
+	bytes, err := json.Marshal(list)
+	if err != nil {
+		return nil
+	}
+	var objs []map[string]interface{}
+	if err := json.Unmarshal(bytes, &objs); err != nil {
+		return nil
+	}
+
+	var elements []namedElement
+	for _, obj := range objs {
+		raw, _ := json.Marshal(obj)
+		e := namedElement{key: fmt.Sprint(obj[key]), fields: make(map[string]string), raw: string(raw)}
+		for name, value := range obj {
+			if name == key {
+				continue
+			}
+			if s, ok := value.(string); ok {
+				e.fields[name] = s
+			} else {
+				v, _ := json.Marshal(value)
+				e.fields[name] = string(v)
+			}
+		}
+		elements = append(elements, e)
+	}
+	return elements
+}
+
+//SeedDiff seed diff: Prints the differences between the seed manifests at oldPath and newPath
+// in the given output format. Paths may be manifest files or directories containing one.
+func SeedDiff(oldPath, newPath, output string) error {
+	if output == "" {
+		output = constants.OutputText
+	}
+	if output != constants.OutputText && output != constants.OutputJson {
+		err := errors.New("ERROR: Unsupported output format " + output + ". Must be " +
+			constants.OutputText + " or " + constants.OutputJson + ".")
+		util.PrintError(err)
+		return err
+	}
+
+	var seeds []objects.Seed
+	var fileNames []string
+	for _, path := range []string{oldPath, newPath} {
+		seedFileName, err := manifestFileName(path)
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			return err
+		}
+		seed, err := objects.SeedFromManifestFile(seedFileName)
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			return err
+		}
+		seeds = append(seeds, seed)
+		fileNames = append(fileNames, seedFileName)
+	}
+
+	diff := ManifestDiff{Old: fileNames[0], New: fileNames[1],
+		Changes: DiffManifests(&seeds[0], &seeds[1])}
+
+	if output == constants.OutputJson {
+		bytes, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			util.Errorf("Error marshalling manifest diff: %s\n", err.Error())
+			return err
+		}
+		util.PrintUtil("%s\n", string(bytes))
+		return nil
+	}
+
+	util.PrintUtil("--- %s\n+++ %s\n", diff.Old, diff.New)
+	if len(diff.Changes) == 0 {
+		util.PrintUtil("No differences found.\n")
+		return nil
+	}
+	for _, c := range diff.Changes {
+		switch c.Change {
+		case ChangeAdded:
+			util.PrintUtil("+ %s: %s\n", c.Field, c.New)
+		case ChangeRemoved:
+			util.PrintUtil("- %s: %s\n", c.Field, c.Old)
+		default:
+			util.PrintUtil("~ %s: %s -> %s\n", c.Field, c.Old, c.New)
+		}
+	}
+	util.PrintUtil("%d changes\n", len(diff.Changes))
+
+	return nil
+}
+
+//PrintDiffUsage prints the seed diff usage information, then exits the program
+func PrintDiffUsage() {
+	util.PrintUtil("\nUsage:\tseed diff [-o json] OLD NEW\n")
+	util.PrintUtil("\nCompares two seed manifests and prints the added, removed and changed versions,\n")
+	util.PrintUtil("interface elements and resources. OLD and NEW may be manifests or directories\n")
+	util.PrintUtil("containing a %s.\n", constants.SeedFileName)
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s -%s\tOutput format, %s or %s (default is %s)\n",
+		constants.ShortOutputFlag, constants.OutputFlag, constants.OutputText, constants.OutputJson,
+		constants.OutputText)
+	panic(util.Exit{0})
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+func init() {
+	util.InitPrinter(false)
+}
+
+func TestDiffManifests(t *testing.T) {
+	oldSeed, err := objects.SeedFromManifestFile("../testdata/complete/seed.manifest.json")
+	if err != nil {
+		t.Fatalf("Error reading manifest for DiffManifests test: %v", err)
+	}
+	newSeed, _ := objects.SeedFromManifestFile("../testdata/complete/seed.manifest.json")
+	newSeed.Job.JobVersion = "0.2.0"
+	newSeed.Job.Resources.Scalar[1].Value = 2048
+	newSeed.Job.Interface.Settings = nil
+	newSeed.Job.Interface.Inputs.Json = append(newSeed.Job.Interface.Inputs.Json,
+		objects.InJson{Name: "THRESHOLD", Type: "number", Required: false})
+
+	changes := DiffManifests(&oldSeed, &newSeed)
+	expected := []ManifestChange{
+		{Field: "job.jobVersion", Change: ChangeModified, Old: "0.1.0", New: "0.2.0"},
+		{Field: "job.interface.inputs.json[THRESHOLD]", Change: ChangeAdded,
+			New: `{"name":"THRESHOLD","required":false,"type":"number"}`},
+		{Field: "job.interface.settings[DB_HOST]", Change: ChangeRemoved,
+			Old: `{"name":"DB_HOST","secret":false}`},
+		{Field: "job.resources.scalar[mem].value", Change: ChangeModified, Old: "10240", New: "2048"},
+	}
+	if fmt.Sprintf("%v", changes) != fmt.Sprintf("%v", expected) {
+		t.Errorf("DiffManifests returned \n%v, expected \n%v", changes, expected)
+	}
+
+	if changes := DiffManifests(&oldSeed, &oldSeed); len(changes) != 0 {
+		t.Errorf("DiffManifests of identical manifests returned %v, expected no changes", changes)
+	}
+
+	cases := []struct {
+		oldPath  string
+		newPath  string
+		output   string
+		expected bool
+	}{
+		{"../testdata/no-inputs/", "../testdata/complete/", constants.OutputText, true},
+		{"../testdata/complete/seed.manifest.json", "../testdata/complete/", constants.OutputJson, true},
+		{"../testdata/no-inputs/", "../testdata/complete/", "yaml", false},
+		{"../testdata/no-inputs/", "../testdata/", constants.OutputText, false},
+	}
+
+	for _, c := range cases {
+		err := SeedDiff(c.oldPath, c.newPath, c.output)
+		if c.expected != (err == nil) {
+			t.Errorf("SeedDiff(%q, %q, %q) returned %v, expected success %v", c.oldPath, c.newPath, c.output,
+				err, c.expected)
+		}
+	}
+}
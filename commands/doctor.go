@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+//DoctorStatus is the outcome of a seed doctor check
+type DoctorStatus string
+
+const (
+	//DoctorPass the check passed
+	DoctorPass DoctorStatus = "pass"
+
+	//DoctorWarn the check found a problem that only affects some commands
+	DoctorWarn DoctorStatus = "warn"
+
+	//DoctorFail the check found a problem that stops seed from working
+	DoctorFail DoctorStatus = "fail"
+)
+
+//DoctorCheck is the result of one seed doctor check, with a hint on how to fix a problem
+type DoctorCheck struct {
+	Name   string
+	Status DoctorStatus
+	Detail string
+	Hint   string
+}
+
+//SeedDoctor seed doctor: Checks the environment seed needs, i.e. that docker is installed and its
+// daemon can be reached without sudo, and prints a pass, warn or fail report with hints on how to
+// fix each problem found. Returns the checks and an error if any failed.
+func SeedDoctor() ([]DoctorCheck, error) {
+	checks := DoctorChecks()
+	failed := 0
+	for _, c := range checks {
+		util.PrintUtil("[%s] %s: %s\n", c.Status, c.Name, c.Detail)
+		if c.Hint != "" {
+			util.PrintUtil("       %s\n", c.Hint)
+		}
+		if c.Status == DoctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		err := fmt.Errorf("%d of %d checks failed", failed, len(checks))
+		util.PrintUtil("\n")
+		util.Errorf("%s\n", err.Error())
+		return checks, err
+	}
+	util.PrintUtil("\nAll required checks passed\n")
+	return checks, nil
+}
+
+//DoctorChecks runs the seed doctor checks. The docker checks stop at the first that fails, as
+// those after it depend on it.
+func DoctorChecks() []DoctorCheck {
+	checks := []DoctorCheck{checkDockerClient()}
+	if checks[0].Status != DoctorFail {
+		daemon := checkDockerDaemon()
+		checks = append(checks, daemon)
+		if daemon.Status != DoctorFail {
+			checks = append(checks, checkBuildx())
+		}
+	}
+	return append(checks, checkConfigDir(dockerConfigDir()))
+}
+
+//checkDockerClient checks that the docker client is installed and reports its version
+func checkDockerClient() DoctorCheck {
+	check := DoctorCheck{Name: "docker"}
+	path, err := exec.LookPath("docker")
+	if err != nil {
+		check.Status = DoctorFail
+		check.Detail = "docker was not found on the PATH"
+		check.Hint = "Install docker, see https://docs.docker.com/get-docker/"
+		return check
+	}
+	client, _, err := util.DockerVersions()
+	if err != nil {
+		check.Status = DoctorFail
+		check.Detail = fmt.Sprintf("%s did not report its version: %s", path, err.Error())
+		check.Hint = "Reinstall docker, see https://docs.docker.com/get-docker/"
+		return check
+	}
+	check.Status = DoctorPass
+	check.Detail = fmt.Sprintf("client %s at %s", client, path)
+	if !util.DockerVersionHasReferenceFilter() {
+		check.Status = DoctorWarn
+		check.Hint = "Seed needs docker 1.13.0 or later to list images; upgrade docker"
+	}
+	return check
+}
+
+//checkDockerDaemon checks that the docker daemon is running and can be reached without sudo
+func checkDockerDaemon() DoctorCheck {
+	check := DoctorCheck{Name: "docker daemon"}
+	infoErrors, err := util.DockerInfoErrors()
+	switch {
+	case util.PermissionDenied(infoErrors):
+		check.Status = DoctorFail
+		check.Detail = "permission denied connecting to the docker daemon; seed must be run with sudo"
+		check.Hint = "Run seed with sudo, or add your user to the docker group with " +
+			"'sudo usermod -aG docker $USER' and log in again"
+	case util.DaemonUnreachable(infoErrors):
+		check.Status = DoctorFail
+		check.Detail = "cannot connect to the docker daemon"
+		check.Hint = "Start the docker daemon, i.e. 'sudo systemctl start docker', or check DOCKER_HOST"
+	case err != nil:
+		check.Status = DoctorFail
+		check.Detail = fmt.Sprintf("docker info failed: %s", firstLine(infoErrors, err))
+		check.Hint = "Check the docker installation with 'docker info'"
+	default:
+		_, server, _ := util.DockerVersions()
+		check.Status = DoctorPass
+		check.Detail = fmt.Sprintf("daemon %s reachable without sudo", server)
+	}
+	return check
+}
+
+//checkBuildx checks for the docker buildx plugin, which seed build -platform needs
+func checkBuildx() DoctorCheck {
+	check := DoctorCheck{Name: "buildx"}
+	if !util.BuildxAvailable() {
+		check.Status = DoctorWarn
+		check.Detail = "docker buildx is not installed"
+		check.Hint = fmt.Sprintf("Only needed for seed build -%s; install docker buildx (docker 19.03 or later)",
+			constants.PlatformFlag)
+		return check
+	}
+	check.Status = DoctorPass
+	check.Detail = "docker buildx is installed"
+	return check
+}
+
+//checkConfigDir checks that the docker config directory, where registry credentials are stored,
+// is writable, or can be created if it does not exist
+func checkConfigDir(dir string) DoctorCheck {
+	check := DoctorCheck{Name: constants.DockerConfigKey}
+	hint := fmt.Sprintf("Make %s writable, or set %s to a writable directory", dir, constants.DockerConfigKey)
+
+	existing := dir
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				check.Status = DoctorFail
+				check.Detail = fmt.Sprintf("%s is not a directory", existing)
+				check.Hint = hint
+				return check
+			}
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+
+	f, err := ioutil.TempFile(existing, ".seed-doctor")
+	if err != nil {
+		check.Status = DoctorFail
+		check.Detail = fmt.Sprintf("%s is not writable: %s", existing, err.Error())
+		check.Hint = hint
+		return check
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	check.Status = DoctorPass
+	check.Detail = fmt.Sprintf("%s is writable", dir)
+	if existing != dir {
+		check.Detail = fmt.Sprintf("%s does not exist yet and can be created", dir)
+	}
+	return check
+}
+
+//dockerConfigDir returns the directory docker stores its configuration and credentials in
+func dockerConfigDir() string {
+	if dir := os.Getenv(constants.DockerConfigKey); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".docker")
+}
+
+//firstLine returns the first line of out, or the error if out is empty
+func firstLine(out string, err error) string {
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return err.Error()
+}
+
+//PrintDoctorUsage prints the seed doctor usage information, then exits the program
+func PrintDoctorUsage() {
+	util.PrintUtil("\nUsage:\tseed doctor\n")
+	util.PrintUtil("\nChecks the environment seed needs and prints a pass, warn or fail report with hints on how\n")
+	util.PrintUtil("to fix each problem: that docker is installed, its version, that the docker daemon is running\n")
+	util.PrintUtil("and can be reached without sudo, that docker buildx is installed and that the docker config\n")
+	util.PrintUtil("directory (%s) is writable. Exits non-zero if a required check fails.\n",
+		constants.DockerConfigKey)
+	panic(util.Exit{0})
+}
@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/util"
+)
+
+func TestCheckConfigDir(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "seed-doctor")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for checkConfigDir test: %v", err)
+	}
+	defer util.RemoveAllFiles(tempDir)
+	file := filepath.Join(tempDir, "config.json")
+	ioutil.WriteFile(file, []byte("{}"), 0644)
+
+	cases := []struct {
+		dir            string
+		expectedStatus DoctorStatus
+		expectedDetail string
+	}{
+		{tempDir, DoctorPass, "is writable"},
+		{filepath.Join(tempDir, "docker", "config"), DoctorPass, "does not exist yet and can be created"},
+		{file, DoctorFail, "is not a directory"},
+		{filepath.Join(file, "config"), DoctorFail, "is not a directory"},
+	}
+
+	for _, c := range cases {
+		check := checkConfigDir(c.dir)
+		if check.Status != c.expectedStatus || !strings.Contains(check.Detail, c.expectedDetail) {
+			t.Errorf("checkConfigDir(%q) == %s %q, expected %s %q", c.dir, check.Status, check.Detail,
+				c.expectedStatus, c.expectedDetail)
+		}
+		if check.Status == DoctorFail && check.Hint == "" {
+			t.Errorf("checkConfigDir(%q) failed without a hint", c.dir)
+		}
+	}
+
+	files, _ := ioutil.ReadDir(tempDir)
+	if len(files) != 1 {
+		t.Errorf("checkConfigDir left %d files in %s, expected 1", len(files), tempDir)
+	}
+}
@@ -0,0 +1,94 @@
+package commands
+
+import "errors"
+
+//The kinds of error returned by the commands package. Callers test for them with errors.Is,
+// i.e. errors.Is(err, commands.ErrManifestNotFound), or use errors.As to retrieve the
+// CommandError and its underlying error.
+var (
+	//ErrManifestNotFound means no seed manifest was found at the given path
+	ErrManifestNotFound = errors.New("seed manifest not found")
+
+	//ErrValidation means a seed manifest or metadata file is not valid against the seed schema, or
+	// the outputs of a run do not match those declared in its manifest
+	ErrValidation = errors.New("seed validation failed")
+
+	//ErrInvalidArgument means an option given to a command is invalid
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	//ErrDockerExec means a docker command failed or reported errors
+	ErrDockerExec = errors.New("docker command failed")
+
+	//ErrJobFailed means the job container exited with a non-zero exit code
+	ErrJobFailed = errors.New("seed job failed")
+
+	//ErrOutputTooLarge means the job wrote more to its output directory than allowed
+	ErrOutputTooLarge = errors.New("seed job output exceeded the size limit")
+
+	//ErrUpload means the outputs of a job could not be uploaded to the -output-upload destination
+	ErrUpload = errors.New("seed output upload failed")
+
+	//ErrDownload means an -input-url input could not be downloaded, or did not match its checksum
+	ErrDownload = errors.New("seed input download failed")
+)
+
+//CommandError is an error returned by a command, classified by one of the Err kinds. The
+// message is that of the underlying error.
+type CommandError struct {
+	Kind error
+	Err  error
+}
+
+//Error returns the message of the underlying error
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+//Is reports whether target is the kind of the error
+func (e *CommandError) Is(target error) bool {
+	return target == e.Kind
+}
+
+//Unwrap returns the underlying error
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+//errorKinds are the names of the kinds of error, as reported by seed -json-errors
+var errorKinds = []struct {
+	kind error
+	name string
+}{
+	{ErrManifestNotFound, "manifest-not-found"},
+	{ErrValidation, "validation"},
+	{ErrInvalidArgument, "invalid-argument"},
+	{ErrDockerExec, "docker-exec"},
+	{ErrJobFailed, "job-failed"},
+	{ErrOutputTooLarge, "output-too-large"},
+	{ErrUpload, "upload-failed"},
+	{ErrDownload, "download-failed"},
+}
+
+//ErrorKind returns the name of the kind of err, i.e. job-failed, or an empty string if err is
+// not classified
+func ErrorKind(err error) string {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.kind) {
+			return k.name
+		}
+	}
+	return ""
+}
+
+//wrapError classifies err as the given kind. Returns nil if err is nil and err unchanged if it
+// is already classified.
+func wrapError(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return err
+	}
+	return &CommandError{Kind: kind, Err: err}
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestCommandErrors(t *testing.T) {
+	cases := []struct {
+		name         string
+		err          error
+		expectedKind error
+	}{
+		{"build missing manifest", DockerBuild(BuildOptions{JobDirectory: "../testdata/missing"}), ErrManifestNotFound},
+		{"build missing -manifest", DockerBuild(BuildOptions{JobDirectory: "../testdata/complete",
+			Manifest: "../testdata/missing/seed.manifest.json"}), ErrManifestNotFound},
+		{"build invalid -manifest", DockerBuild(BuildOptions{JobDirectory: "../testdata/complete",
+			Manifest: "../testdata/invalid-missing-job/"}), ErrValidation},
+		{"validate missing manifest", Validate([]string{"../testdata/missing"}, ValidateOptions{}), ErrManifestNotFound},
+		{"validate invalid manifest", Validate([]string{"../testdata/invalid-missing-job/"}, ValidateOptions{}),
+			ErrValidation},
+		{"validate conflicting flags", Validate([]string{"../testdata/complete/"},
+			ValidateOptions{SchemaFile: "schema.json", SchemaVersion: "0.1.0"}), ErrInvalidArgument},
+		{"validate -check-image of several manifests", Validate([]string{"../testdata/complete/",
+			"../testdata/complete/"}, ValidateOptions{CheckImage: "my-job-0.1.0-seed:0.1.0"}), ErrInvalidArgument},
+		{"run no image", runError(RunOptions{}), ErrInvalidArgument},
+	}
+
+	kinds := []error{ErrManifestNotFound, ErrValidation, ErrInvalidArgument, ErrDockerExec, ErrJobFailed,
+		ErrOutputTooLarge, ErrUpload, ErrDownload}
+	for _, c := range cases {
+		for _, kind := range kinds {
+			if errors.Is(c.err, kind) != (kind == c.expectedKind) {
+				t.Errorf("%s: errors.Is(%v, %v) == %v, expected %v", c.name, c.err, kind, !(kind == c.expectedKind),
+					kind == c.expectedKind)
+			}
+		}
+		var cmdErr *CommandError
+		if !errors.As(c.err, &cmdErr) || cmdErr.Error() != cmdErr.Err.Error() {
+			t.Errorf("%s: errors.As(%v) did not return the underlying error", c.name, c.err)
+		}
+	}
+
+	notFound := DockerBuild(BuildOptions{JobDirectory: "../testdata/missing"})
+	if !errors.Is(notFound, os.ErrNotExist) {
+		t.Errorf("errors.Is(%v, os.ErrNotExist) == false, expected true", notFound)
+	}
+	if kind := ErrorKind(notFound); kind != "manifest-not-found" {
+		t.Errorf("ErrorKind(%v) == %q, expected manifest-not-found", notFound, kind)
+	}
+	if kind := ErrorKind(errors.New("failed")); kind != "" {
+		t.Errorf("ErrorKind of an unclassified error == %q, expected empty", kind)
+	}
+	if wrapError(ErrValidation, nil) != nil {
+		t.Errorf("wrapError(ErrValidation, nil) != nil")
+	}
+	if !errors.Is(wrapError(ErrDockerExec, wrapError(ErrJobFailed, errors.New("failed"))), ErrJobFailed) {
+		t.Errorf("wrapError reclassified an error that already had a kind")
+	}
+}
+
+//runError returns the error from running a job
+func runError(options RunOptions) error {
+	_, err := DockerRun(options)
+	return err
+}
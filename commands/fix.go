@@ -0,0 +1,277 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/constants"
+)
+
+//jsonField is a member of a JSON object
+type jsonField struct {
+	Key   string
+	Value interface{}
+}
+
+//jsonObject is a JSON object that keeps the order of its members, so a fixed manifest is
+// written back in the order it was authored
+type jsonObject []jsonField
+
+//MarshalJSON writes the members of the object in order
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for i, f := range o {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		key, _ := json.Marshal(f.Key)
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteString(":")
+		buffer.Write(value)
+	}
+	buffer.WriteString("}")
+	return buffer.Bytes(), nil
+}
+
+//index returns the position of the member with the given key, or -1
+func (o jsonObject) index(key string) int {
+	for i, f := range o {
+		if f.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+//decodeOrdered decodes the next JSON value, keeping the order of object members
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := jsonObject{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, jsonField{Key: key.(string), Value: value})
+		}
+		_, err = dec.Token()
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = dec.Token()
+		return array, err
+	}
+	return token, nil
+}
+
+//removeTrailingCommas removes commas directly before the closing brace or bracket of an
+// object or array, which JSON does not allow. Returns the number of commas removed.
+func removeTrailingCommas(data []byte) ([]byte, int) {
+	var out []byte
+	removed := 0
+	inString, escaped := false, false
+	comma := -1
+	for _, b := range data {
+		if inString {
+			out = append(out, b)
+			if escaped {
+				escaped = false
+			} else if b == '\\' {
+				escaped = true
+			} else if b == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			inString = true
+			comma = -1
+		case ',':
+			comma = len(out)
+		case '}', ']':
+			if comma >= 0 {
+				out = append(out[:comma], out[comma+1:]...)
+				removed++
+			}
+			comma = -1
+		case ' ', '\t', '\r', '\n':
+		default:
+			comma = -1
+		}
+		out = append(out, b)
+	}
+	return out, removed
+}
+
+//FixManifest applies safe automatic corrections to a seed manifest, guided by the given JSON
+// schema, and returns the corrected manifest and a description of each fix. Only unambiguous
+// corrections are made: trailing commas are removed, a missing seedVersion is set to
+// seedVersion, fields whose name differs only in case from exactly one schema property are
+// renamed and fields the schema does not allow, i.e. left over from older manifests, are
+// removed. Anything else, such as missing required fields or values of the wrong type, is left
+// for validation to report.
+func FixManifest(manifest, schema []byte, seedVersion string) ([]byte, []string, error) {
+	var fixes []string
+	manifest, removed := removeTrailingCommas(manifest)
+	if removed > 0 {
+		fixes = append(fixes, fmt.Sprintf("removed %d trailing commas", removed))
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(manifest))
+	dec.UseNumber()
+	document, err := decodeOrdered(dec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("the manifest is not valid json: %s", err.Error())
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, nil, errors.New("the manifest is not valid json: unexpected content after the manifest")
+	}
+	root, ok := document.(jsonObject)
+	if !ok {
+		return nil, nil, errors.New("the manifest is not a json object")
+	}
+
+	var schemaDocument map[string]interface{}
+	if err := json.Unmarshal(schema, &schemaDocument); err != nil {
+		return nil, nil, fmt.Errorf("error reading schema: %s", err.Error())
+	}
+
+	fixed := fixValue(root, schemaDocument, "", &fixes).(jsonObject)
+	if fixed.index("seedVersion") < 0 && seedVersion != "" {
+		fixed = append(jsonObject{{Key: "seedVersion", Value: seedVersion}}, fixed...)
+		fixes = append(fixes, "set missing seedVersion to "+seedVersion)
+	}
+
+	output, err := json.MarshalIndent(fixed, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(output, '\n'), fixes, nil
+}
+
+//fixValue corrects the field names of a value against its schema and returns the result.
+// field is the path to the value, i.e. job.interface, used to describe the fixes.
+func fixValue(value interface{}, schema map[string]interface{}, field string, fixes *[]string) interface{} {
+	switch v := value.(type) {
+	case jsonObject:
+		properties, _ := schema["properties"].(map[string]interface{})
+		if properties == nil {
+			return v
+		}
+		closed := schema["additionalProperties"] == false
+		fixed := jsonObject{}
+		for _, f := range v {
+			name := f.Key
+			if _, ok := properties[name]; !ok {
+				if match := caseInsensitiveMatch(name, properties); match != "" && v.index(match) < 0 {
+					*fixes = append(*fixes, fmt.Sprintf("renamed %s to %s", fieldPath(field, name), match))
+					name = match
+				} else if closed && match == "" {
+					*fixes = append(*fixes, fmt.Sprintf("removed unknown field %s", fieldPath(field, name)))
+					continue
+				}
+			}
+			propertySchema, _ := properties[name].(map[string]interface{})
+			fixed = append(fixed, jsonField{Key: name, Value: fixValue(f.Value, propertySchema, fieldPath(field, name),
+				fixes)})
+		}
+		return fixed
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		if items == nil {
+			return v
+		}
+		for i := range v {
+			v[i] = fixValue(v[i], items, fmt.Sprintf("%s[%d]", field, i), fixes)
+		}
+		return v
+	}
+	return value
+}
+
+//caseInsensitiveMatch returns the single schema property matching name regardless of case, or
+// an empty string if there is none or more than one
+func caseInsensitiveMatch(name string, properties map[string]interface{}) string {
+	match := ""
+	for property := range properties {
+		if strings.EqualFold(property, name) {
+			if match != "" {
+				return ""
+			}
+			match = property
+		}
+	}
+	return match
+}
+
+//fieldPath joins a field name to the path of its parent
+func fieldPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+//fixManifestFile applies FixManifest to a manifest file and writes the result to outputFile,
+// or back to the manifest if outputFile is empty. The schema is read from schemaFile, or else
+// the built in schema of version. Returns the fixes made.
+func fixManifestFile(seedFileName, outputFile, schemaFile, version string) ([]string, error) {
+	var schema []byte
+	var err error
+	if schemaFile != "" {
+		schema, err = ioutil.ReadFile(schemaFile)
+	} else {
+		schema, err = constants.Asset(path.Join(constants.SchemaDir, version, constants.ManifestSchemaName))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading schema: %s", err.Error())
+	}
+
+	manifest, err := ioutil.ReadFile(seedFileName)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %s", seedFileName, err.Error())
+	}
+	fixed, fixes, err := FixManifest(manifest, schema, version)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot fix %s: %s", seedFileName, err.Error())
+	}
+
+	if outputFile == "" {
+		if len(fixes) == 0 {
+			return nil, nil
+		}
+		outputFile = seedFileName
+	}
+	if err := ioutil.WriteFile(outputFile, fixed, 0644); err != nil {
+		return nil, fmt.Errorf("Error writing %s: %s", outputFile, err.Error())
+	}
+	return fixes, nil
+}
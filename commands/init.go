@@ -1,23 +1,119 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
 	"github.com/ngageoint/seed-cli/util"
 )
 
+//InitOptions defines the values substituted into the example seed.manifest.json. Empty
+// values leave the example defaults in place.
+type InitOptions struct {
+	Name       string
+	JobVersion string
+
+	// Maintainer is the maintainer name, optionally followed by an email in angle
+	// brackets, i.e. "Jane Doe <jdoe@example.com>"
+	Maintainer string
+
+	// FromImage is an existing docker image whose configuration is used to generate the
+	// manifest in place of the example
+	FromImage string
+
+	// Template is the name of the built in template the manifest starts from. Defaults to the
+	// first of InitTemplates.
+	Template string
+}
+
+//InitTemplate is a built in manifest seed init can start from
+type InitTemplate struct {
+	Name        string
+	Description string
+	asset       string
+}
+
+//InitTemplates are the built in manifest templates, the default first. Each uses the job name,
+// job version and maintainer of the example manifest, so the init options can be substituted.
+var InitTemplates = []InitTemplate{
+	{"single-file", "One input file, output files and JSON, a mount and a setting (the example manifest)",
+		"schema/0.1.0/seed.manifest.example.json"},
+	{"multi-input", "Several input files, one accepting multiple files and one optional",
+		"schema/0.1.0/templates/multi-input.json"},
+	{"json-params", "An input file with number, array and boolean JSON parameters and JSON output",
+		"schema/0.1.0/templates/json-params.json"},
+	{"gpu", "A job requesting a GPU and shared memory, with a read-only model mount",
+		"schema/0.1.0/templates/gpu.json"},
+}
+
+//FindInitTemplate returns the built in template with the given name, or the default if name is
+// empty
+func FindInitTemplate(name string) (InitTemplate, error) {
+	if name == "" {
+		return InitTemplates[0], nil
+	}
+	for _, t := range InitTemplates {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return InitTemplate{}, fmt.Errorf("Unknown -%s %s. Templates are %s", constants.TemplateFlag, name,
+		strings.Join(initTemplateNames(), ", "))
+}
+
+//FormatInitTemplates formats the built in templates as a table of their names and descriptions
+func FormatInitTemplates() string {
+	var rows [][]string
+	for i, t := range InitTemplates {
+		name := t.Name
+		if i == 0 {
+			name += " (default)"
+		}
+		rows = append(rows, []string{name, t.Description})
+	}
+	return util.FormatTable([]string{"TEMPLATE", "DESCRIPTION"}, []string{util.ColorCyan, ""}, rows)
+}
+
+//todoMarker prefixes generated manifest values that need human review
+const todoMarker = "TODO"
+
+//settingNameRegex matches the names the schema allows for settings
+var settingNameRegex = regexp.MustCompile(`^[a-zA-Z_-]+$`)
+
+//ignoredImageEnv lists environment variables set by most base images that are not job settings
+var ignoredImageEnv = map[string]bool{
+	"PATH": true, "HOME": true, "HOSTNAME": true, "LANG": true, "LANGUAGE": true,
+	"LC_ALL": true, "TERM": true, "SHELL": true, "PWD": true, "USER": true,
+}
+
 //SeedInit places a sample seed.manifest.json within given directory (defaults to CWD)
 // Should check for file existence in given directory
 // If file exists, warn and exit
 // If file does not exist, write sample to given directory
-func SeedInit(directory string) error {
+func SeedInit(directory string, options InitOptions) error {
+	template, err := FindInitTemplate(options.Template)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrInvalidArgument, err)
+	}
+	if options.Template != "" && options.FromImage != "" {
+		err = fmt.Errorf("-%s and -%s cannot be used together", constants.TemplateFlag, constants.FromImageFlag)
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrInvalidArgument, err)
+	}
+
 	seedFileName, exists, err := util.GetSeedFileName(directory)
 	if err != nil && exists {
 		//an error occurred other than the file not existing, i.e. permission error
-		util.PrintUtil( "ERROR: Error occurred writing example Seed manifest to %s.\n%s\n",
+		util.Errorf("Error occurred writing example Seed manifest to %s.\n%s\n",
 			seedFileName, err.Error())
 		return errors.New("Error writing example Seed manifest.")
 	} else if exists {
@@ -28,26 +124,196 @@ func SeedInit(directory string) error {
 
 
 	// TODO: We need to support init of all supported schema versions in the future
-	exampleSeedJson, _ := constants.Asset("schema/0.1.0/seed.manifest.example.json")
+	exampleSeedJson, _ := constants.Asset(template.asset)
+	exampleSeedJson = fillInitTemplate(exampleSeedJson, options)
+	if options.FromImage != "" {
+		exampleSeedJson, err = manifestFromImage(options)
+		if err != nil {
+			return err
+		}
+	}
 
 	err = ioutil.WriteFile(seedFileName, exampleSeedJson, os.ModePerm)
 	if err != nil {
-		util.PrintUtil( "ERROR: Error occurred writing example Seed manifest to %s.\n%s\n",
+		util.Errorf("Error occurred writing example Seed manifest to %s.\n%s\n",
 			seedFileName, err.Error())
 		return errors.New("Error writing example Seed manifest.")
 	}
 
+	// Make sure the substituted values produce a valid manifest
+	if options != (InitOptions{}) {
+		err = ValidateSeedFile("", seedFileName, constants.SchemaManifest)
+		if err != nil {
+			os.Remove(seedFileName)
+			util.PrintError(err)
+			return errors.New("Generated Seed manifest is not valid; check the -name, -job-version and -maintainer values.")
+		}
+	}
+
 	util.PrintUtil( "Created Seed file: %s\n", seedFileName)
 
 	return nil
 }
 
-//PrintBuildUsage prints the seed build usage arguments, then exits the program
+//fillInitTemplate substitutes the given options into the example manifest in place of
+// its job name, job version and maintainer name and email
+func fillInitTemplate(example []byte, options InitOptions) []byte {
+	replace := func(key, oldValue, newValue string) {
+		if newValue == "" {
+			return
+		}
+		oldJson, _ := json.Marshal(oldValue)
+		newJson, _ := json.Marshal(newValue)
+		example = bytes.Replace(example, []byte(`"`+key+`": `+string(oldJson)),
+			[]byte(`"`+key+`": `+string(newJson)), 1)
+	}
+
+	replace("name", "my-job", options.Name)
+	replace("jobVersion", "1.0.0", options.JobVersion)
+
+	name, email := options.Maintainer, ""
+	if i := strings.Index(name, "<"); i >= 0 && strings.HasSuffix(name, ">") {
+		name, email = strings.TrimSpace(name[:i]), name[i+1:len(name)-1]
+	}
+	replace("name", "John Doe", name)
+	replace("email", "jdoe@example.com", email)
+
+	return example
+}
+
+//manifestFromImage inspects the image given in options and returns a seed manifest
+// generated from its configuration
+func manifestFromImage(options InitOptions) ([]byte, error) {
+	if exists, err := util.ImageExists(options.FromImage); err != nil {
+		return nil, err
+	} else if !exists {
+		util.Errorf("Image %s not found. Pull or build the image before running seed init.\n",
+			options.FromImage)
+		return nil, errors.New("Image " + options.FromImage + " not found.")
+	}
+	config, err := util.InspectImageConfig(options.FromImage)
+	if err != nil {
+		util.Errorf("Error inspecting image %s.\n%s\n", options.FromImage, err.Error())
+		return nil, errors.New("Error inspecting image " + options.FromImage + ".")
+	}
+	seed := ImageSeed(options.FromImage, config, options)
+	return json.MarshalIndent(&seed, "", "  ")
+}
+
+//ImageSeed returns a best-effort seed manifest for an existing image. The command and
+// settings are taken from the image configuration; the title, description, maintainer,
+// input and output files cannot be inferred and are filled with values marked TODO.
+func ImageSeed(image string, config util.ImageConfig, options InitOptions) objects.Seed {
+	name, email := "TODO Maintainer", "todo@example.com"
+	if options.Maintainer != "" {
+		name = options.Maintainer
+		if i := strings.Index(name, "<"); i >= 0 && strings.HasSuffix(name, ">") {
+			name, email = strings.TrimSpace(name[:i]), name[i+1:len(name)-1]
+		}
+	}
+
+	description := todoMarker + ": Describe this job. Generated from image " + image
+	if len(config.Entrypoint) > 0 {
+		description += " with entrypoint \"" + strings.Join(config.Entrypoint, " ") + "\""
+	}
+	if config.WorkingDir != "" {
+		description += " in working directory " + config.WorkingDir
+	}
+	description += "; review the command, inputs, outputs, settings and resources."
+
+	// the seed command is passed as arguments to the image entrypoint, so it replaces the
+	// image command
+	command := strings.TrimSpace(strings.Join(config.Cmd, " ") + " ${" + todoMarker + "_INPUT_FILE} ${OUTPUT_DIR}")
+
+	settings := []objects.Setting{}
+	for _, env := range config.Env {
+		key := strings.SplitN(env, "=", 2)[0]
+		if ignoredImageEnv[key] || !settingNameRegex.MatchString(key) {
+			continue
+		}
+		settings = append(settings, objects.Setting{Name: key, Secret: false})
+	}
+
+	seed := objects.Seed{
+		SeedVersion: "0.1.0",
+		Job: objects.Job{
+			Name:           imageJobName(image),
+			JobVersion:     "1.0.0",
+			PackageVersion: "1.0.0",
+			Title:          todoMarker + ": Job title",
+			Description:    description,
+			Tags:           []string{},
+			Maintainer:     objects.Maintainer{Name: name, Email: email},
+			Timeout:        3600,
+			Interface: objects.Interface{
+				Command: command,
+				Inputs: objects.Inputs{Files: []objects.InFile{
+					{Name: todoMarker + "_INPUT_FILE", MediaTypes: []string{}, Required: true},
+				}},
+				Outputs: objects.Outputs{Files: []objects.OutFile{
+					{Name: todoMarker + "_OUTPUT_FILE", MediaType: "application/octet-stream", Count: "1",
+						Pattern: "*", Required: true},
+				}},
+				Settings: settings,
+			},
+			Resources: objects.Resources{Scalar: []objects.Scalar{
+				{Name: "cpu", Value: 1},
+				{Name: "mem", Value: 1024},
+				{Name: "disk", Value: 1024},
+			}},
+		},
+	}
+	if options.Name != "" {
+		seed.Job.Name = options.Name
+	}
+	if options.JobVersion != "" {
+		seed.Job.JobVersion = options.JobVersion
+	}
+	return seed
+}
+
+//imageJobName derives a job name from the repository of an image name, dropping the
+// registry, organization and tag, i.e. docker.io/geoint/my-algorithm:1.2 becomes my-algorithm
+func imageJobName(image string) string {
+	name := strings.ToLower(util.SplitReference(image).Repo)
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+	if name == "" {
+		name = "my-job"
+	}
+	return name
+}
+
+//initTemplateNames returns the names of the built in templates
+func initTemplateNames() []string {
+	var names []string
+	for _, t := range InitTemplates {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+//PrintInitUsage prints the seed init usage arguments, then exits the program
 func PrintInitUsage() {
-	util.PrintUtil( "\nUsage:\tseed init [-d JOB_DIRECTORY]\n")
+	util.PrintUtil( "\nUsage:\tseed init [-d JOB_DIRECTORY] [-name NAME] [-job-version VERSION] [-maintainer MAINTAINER]\n" +
+		"\t\t [-from-image IMAGE | -template NAME]\n")
+	util.PrintUtil("\tseed init -list-templates\n")
 	util.PrintUtil( "\nOptions:\n")
 	util.PrintUtil(
 		"  -%s  -%s\tDirectory to place seed.manifest.json example. (default is current directory)\n",
 		constants.ShortJobDirectoryFlag, constants.JobDirectoryFlag)
+	util.PrintUtil("  -%s\tJob name (default is my-job)\n", constants.JobNameFlag)
+	util.PrintUtil("  -%s\tJob version (default is 1.0.0)\n", constants.JobVersionFlag)
+	util.PrintUtil("  -%s\tMaintainer name, optionally with email, i.e. \"Jane Doe <jdoe@example.com>\"\n",
+		constants.MaintainerFlag)
+	util.PrintUtil("  -%s\tGenerate the manifest from the entrypoint, command, environment and working\n" +
+		"\t\tdirectory of an existing image. Fields marked TODO need review.\n", constants.FromImageFlag)
+	util.PrintUtil("  -%s\tBuilt in template to start from: %s (default is %s)\n", constants.TemplateFlag,
+		strings.Join(initTemplateNames(), ", "), InitTemplates[0].Name)
+	util.PrintUtil("  -%s\tList the built in templates with a description of each\n", constants.ListTemplatesFlag)
 	panic(util.Exit{0})
 }
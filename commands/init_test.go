@@ -1,11 +1,17 @@
 package commands
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
 	"github.com/ngageoint/seed-cli/util"
 )
 
@@ -23,7 +29,7 @@ func TestSeedInit(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		err := SeedInit(c.directory)
+		err := SeedInit(c.directory, InitOptions{})
 
 		if c.expectedErr == nil && err != nil {
 			t.Errorf("SeedInit(%q) == %v, expected %v", c.directory, err.Error(), c.expectedErr)
@@ -38,3 +44,120 @@ func TestSeedInit(t *testing.T) {
 	// Cleanup test file
 	os.Remove("../testdata/dummy-scratch/seed.manifest.json")
 }
+
+func TestSeedInitOptions(t *testing.T) {
+	cases := []struct {
+		options            InitOptions
+		expectedName       string
+		expectedVersion    string
+		expectedMaintainer string
+		expectedEmail      string
+		expectedErrorMsg   string
+	}{
+		{InitOptions{}, "my-job", "1.0.0", "John Doe", "jdoe@example.com", ""},
+		{InitOptions{Name: "tile-job", JobVersion: "0.2.0"}, "tile-job", "0.2.0", "John Doe", "jdoe@example.com", ""},
+		{InitOptions{Maintainer: "Jane \"JJ\" Smith"}, "my-job", "1.0.0", "Jane \"JJ\" Smith", "jdoe@example.com", ""},
+		{InitOptions{Maintainer: "Jane Smith <jsmith@example.com>"}, "my-job", "1.0.0", "Jane Smith", "jsmith@example.com", ""},
+		{InitOptions{Name: "My Job"}, "", "", "", "", "Generated Seed manifest is not valid"},
+		{InitOptions{JobVersion: "1.0"}, "", "", "", "", "Generated Seed manifest is not valid"},
+		{InitOptions{Template: "single-file"}, "my-job", "1.0.0", "John Doe", "jdoe@example.com", ""},
+		{InitOptions{Template: "multi-input", Name: "mosaic"}, "mosaic", "1.0.0", "John Doe", "jdoe@example.com", ""},
+		{InitOptions{Template: "json-params", JobVersion: "0.3.0"}, "my-job", "0.3.0", "John Doe", "jdoe@example.com", ""},
+		{InitOptions{Template: "gpu", Maintainer: "Jane Smith <jsmith@example.com>"}, "my-job", "1.0.0", "Jane Smith",
+			"jsmith@example.com", ""},
+		{InitOptions{Template: "cnn"}, "", "", "", "", "Unknown -template cnn. Templates are single-file, multi-input"},
+		{InitOptions{Template: "gpu", FromImage: "alpine"}, "", "", "", "", "cannot be used together"},
+	}
+
+	directory := "../testdata/dummy-scratch/"
+	seedFileName := directory + "seed.manifest.json"
+	for _, c := range cases {
+		err := SeedInit(directory, c.options)
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("SeedInit(%v) == %v, expected %v", c.options, err.Error(), c.expectedErrorMsg)
+			}
+			if _, statErr := os.Stat(seedFileName); !os.IsNotExist(statErr) {
+				t.Errorf("SeedInit(%v) left invalid manifest %s", c.options, seedFileName)
+			}
+			os.Remove(seedFileName)
+			continue
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("SeedInit(%v) returned no error, expected %v", c.options, c.expectedErrorMsg)
+		}
+
+		seed, err := objects.SeedFromManifestFile(seedFileName)
+		os.Remove(seedFileName)
+		if err != nil {
+			t.Errorf("SeedInit(%v) wrote unreadable manifest: %v", c.options, err)
+			continue
+		}
+		job := seed.Job
+		if job.Name != c.expectedName || job.JobVersion != c.expectedVersion ||
+			job.Maintainer.Name != c.expectedMaintainer || job.Maintainer.Email != c.expectedEmail {
+			t.Errorf("SeedInit(%v) wrote %q, %q, %q, %q, expected %q, %q, %q, %q", c.options,
+				job.Name, job.JobVersion, job.Maintainer.Name, job.Maintainer.Email,
+				c.expectedName, c.expectedVersion, c.expectedMaintainer, c.expectedEmail)
+		}
+	}
+}
+
+func TestInitTemplates(t *testing.T) {
+	for _, template := range InitTemplates {
+		if _, err := constants.Asset(template.asset); err != nil {
+			t.Errorf("Template %s asset %s is not embedded: %v", template.Name, template.asset, err)
+		}
+		if !strings.Contains(FormatInitTemplates(), template.Name) {
+			t.Errorf("FormatInitTemplates() does not list %s", template.Name)
+		}
+	}
+	if template, err := FindInitTemplate(""); err != nil || template.Name != "single-file" {
+		t.Errorf("FindInitTemplate(\"\") == %v, %v, expected the single-file template", template.Name, err)
+	}
+}
+
+func TestImageSeed(t *testing.T) {
+	config := util.ImageConfig{
+		Entrypoint: []string{"python", "/app/run.py"},
+		Cmd:        []string{"-v"},
+		Env:        []string{"PATH=/usr/bin", "DB_HOST=localhost", "GDAL_DATA=/usr/share/gdal", "LC_ALL=C.UTF-8"},
+		WorkingDir: "/app",
+		User:       "worker",
+	}
+	cases := []struct {
+		image            string
+		options          InitOptions
+		expectedName     string
+		expectedCommand  string
+		expectedSettings string
+	}{
+		{"registry.example.com/geoint/My.Algorithm:1.2", InitOptions{}, "my-algorithm",
+			"-v ${TODO_INPUT_FILE} ${OUTPUT_DIR}", "[{DB_HOST false} {GDAL_DATA false}]"},
+		{"algorithm@sha256:abc", InitOptions{Name: "tile-job"}, "tile-job",
+			"-v ${TODO_INPUT_FILE} ${OUTPUT_DIR}", "[{DB_HOST false} {GDAL_DATA false}]"},
+	}
+
+	for _, c := range cases {
+		seed := ImageSeed(c.image, config, c.options)
+		job := seed.Job
+		if job.Name != c.expectedName || job.Interface.Command != c.expectedCommand ||
+			fmt.Sprintf("%v", job.Interface.Settings) != c.expectedSettings {
+			t.Errorf("ImageSeed(%q) returned %q, %q, %v, expected %q, %q, %v", c.image, job.Name,
+				job.Interface.Command, job.Interface.Settings, c.expectedName, c.expectedCommand,
+				c.expectedSettings)
+		}
+		if !strings.Contains(job.Description, "python /app/run.py") || !strings.Contains(job.Description, "/app") {
+			t.Errorf("ImageSeed(%q) description %q missing entrypoint or working directory", c.image, job.Description)
+		}
+
+		// The generated skeleton must pass validation
+		bytes, _ := json.MarshalIndent(&seed, "", "  ")
+		dir, _ := ioutil.TempDir("", "seed-init")
+		seedFileName := filepath.Join(dir, constants.SeedFileName)
+		ioutil.WriteFile(seedFileName, bytes, 0644)
+		if err := ValidateSeedFile("", seedFileName, constants.SchemaManifest); err != nil {
+			t.Errorf("ImageSeed(%q) generated an invalid manifest: %v", c.image, err)
+		}
+		os.RemoveAll(dir)
+	}
+}
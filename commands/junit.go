@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ngageoint/seed-cli/util"
+)
+
+//junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+//junitTestSuite is a group of test cases in a JUnit XML report, i.e. the manifests validated by
+// one seed validate
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+//junitTestCase is a single test case in a JUnit XML report. Failure is nil for a test case that
+// passed.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+}
+
+//junitFailure is the failure of a test case, with a one line message and the full details
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",cdata"`
+}
+
+//junitOutput is output captured from a test case, kept as CDATA so it reads as it was printed
+type junitOutput struct {
+	Contents string `xml:",cdata"`
+}
+
+//newJUnitTestSuite returns an empty test suite started at start
+func newJUnitTestSuite(name string, start time.Time) junitTestSuite {
+	return junitTestSuite{Name: name, Timestamp: start.UTC().Format("2006-01-02T15:04:05")}
+}
+
+//addCase adds a test case taking duration to the suite, failed with err if it is not nil. output
+// is recorded as the standard output of the test case.
+func (s *junitTestSuite) addCase(name, classname string, duration time.Duration, output string, err error) {
+	c := junitTestCase{Name: name, Classname: classname, Time: junitSeconds(duration)}
+	if output != "" {
+		c.SystemOut = &junitOutput{Contents: output}
+	}
+	if err != nil {
+		details := strings.TrimSpace(err.Error())
+		message := strings.SplitN(details, "\n", 2)[0]
+		message = strings.TrimSuffix(strings.TrimSpace(strings.TrimPrefix(message, "ERROR:")), " See errors:")
+		c.Failure = &junitFailure{Message: message, Type: "ValidationError", Contents: details}
+		s.Failures++
+	}
+	s.Cases = append(s.Cases, c)
+	s.Tests++
+}
+
+//junitSeconds formats a duration as the seconds JUnit XML reports times in
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+//writeJUnitReport writes the test suite as a JUnit XML report to reportFile, or to stdout if no
+// file is given
+func writeJUnitReport(suite junitTestSuite, duration time.Duration, reportFile string) error {
+	suite.Time = junitSeconds(duration)
+	data, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append([]byte(xml.Header), append(data, '\n')...)
+
+	if reportFile == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := ioutil.WriteFile(reportFile, data, 0644); err != nil {
+		return err
+	}
+	util.PrintUtil("INFO: Wrote JUnit report to %s\n", reportFile)
+	return nil
+}
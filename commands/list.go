@@ -1,72 +1,257 @@
 package commands
 
 import (
-	"bytes"
+	"encoding/json"
 	"errors"
-	"io"
+	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 
+	"github.com/ngageoint/seed-cli/constants"
+	RegistryFactory "github.com/ngageoint/seed-cli/registry"
 	"github.com/ngageoint/seed-cli/util"
 )
 
-//DockerList - Simplified version of dockerlist - relies on name filter of
-//  docker images command to search for images ending in '-seed'
-func DockerList() (string, error) {
-	var errs, out bytes.Buffer
-	var cmd *exec.Cmd
-	reference := util.DockerVersionHasReferenceFilter()
-	if reference {
-		cmd = exec.Command("docker", "images", "--filter=reference=*-seed*")
-	} else {
-		dCmd := exec.Command("docker", "images")
-		cmd = exec.Command("grep", "-seed")
-		var dErr bytes.Buffer
-		dCmd.Stderr = &dErr
-		dOut, err := dCmd.StdoutPipe()
-		if err != nil {
-			util.PrintUtil( "ERROR: Error attaching to std output pipe. %s\n",
-				err.Error())
+//ListEntry describes a seed image found by seed list
+type ListEntry struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	ID         string `json:"id,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+	Created    string `json:"created,omitempty"`
+	Size       string `json:"size,omitempty"`
+}
+
+//ListFilter matches seed images whose field contains the value, ignoring case
+type ListFilter struct {
+	Field string
+	Value string
+}
+
+//listFilterFields are the fields seed list can filter on: the repository, its organization,
+// the job name and job version it is named with, and the tag, which is the package version
+var listFilterFields = []string{"repository", "org", "name", "version", "tag"}
+
+//DockerList lists the local seed images, those named *-seed*, as a table like docker images or,
+// if output is json, as a json array of ListEntry. Filters given as FIELD=VALUE, i.e.
+// org=geoint, limit the images listed to those matching all of them.
+func DockerList(output string, filters []string) (string, error) {
+	listFilters, err := ParseListFilters(filters)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return "", wrapError(ErrInvalidArgument, err)
+	}
+	entries, err := localSeedImages()
+	if err != nil {
+		return "", wrapError(ErrDockerExec, err)
+	}
+	return printListEntries(FilterListEntries(entries, listFilters), output)
+}
+
+//ParseListFilters parses seed list filters given as FIELD=VALUE. Empty filters are ignored.
+func ParseListFilters(filters []string) ([]ListFilter, error) {
+	var parsed []ListFilter
+	for _, f := range filters {
+		if f == "" {
+			continue
+		}
+		x := strings.SplitN(f, "=", 2)
+		field := strings.ToLower(strings.TrimSpace(x[0]))
+		if len(x) != 2 || x[1] == "" {
+			return nil, fmt.Errorf("Invalid -%s value %s. Filters are given as FIELD=VALUE, i.e. org=geoint",
+				constants.FilterFlag, f)
+		}
+		if !util.ContainsString(listFilterFields, field) {
+			return nil, fmt.Errorf("Invalid -%s field %s. Supported fields are %s", constants.FilterFlag, x[0],
+				strings.Join(listFilterFields, ", "))
 		}
+		parsed = append(parsed, ListFilter{Field: field, Value: x[1]})
+	}
+	return parsed, nil
+}
 
-		dCmd.Start()
-		if string(dErr.Bytes()) != "" {
-			util.PrintUtil( "ERROR: Error reading stderr %s\n",
-				string(dErr.Bytes()))
+//FilterListEntries returns the entries matching every filter
+func FilterListEntries(entries []ListEntry, filters []ListFilter) []ListEntry {
+	filtered := []ListEntry{}
+	for _, e := range entries {
+		match := true
+		for _, f := range filters {
+			if !strings.Contains(strings.ToLower(listEntryField(e, f.Field)), strings.ToLower(f.Value)) {
+				match = false
+				break
+			}
+		}
+		if match {
+			filtered = append(filtered, e)
 		}
+	}
+	return filtered
+}
 
-		cmd.Stdin = dOut
+//listEntryField returns the value of a filter field of an entry. The name and version are taken
+// from the seed naming convention, i.e. geoint/my-job-1.0.0-seed has the name my-job and version
+// 1.0.0; an image not named that way has its repository as its name and no version.
+func listEntryField(e ListEntry, field string) string {
+	ref := util.SplitReference(e.Repository)
+	switch field {
+	case "repository":
+		return e.Repository
+	case "org":
+		return ref.Org
+	case "tag":
+		return e.Tag
+	}
+	name, version := ref.Repo, ""
+	if m := seedJobVersionRegex.FindStringSubmatch(ref.Repo); m != nil {
+		name, version = m[1], m[2]
 	}
+	if field == "version" {
+		return version
+	}
+	return name
+}
 
-	cmd.Stderr = io.MultiWriter(os.Stderr, &errs)
-	cmd.Stdout = &out
+//localSeedImages returns the seed images on the local system
+func localSeedImages() ([]ListEntry, error) {
+	args := []string{"images", "--format", "{{.Repository}}\t{{.Tag}}\t{{.ID}}\t{{.CreatedSince}}\t{{.Size}}"}
+	util.DebugCommand("docker", args)
+	out, err := util.DockerOutput(args...)
+	if err != nil {
+		util.Errorf("Error executing docker images.\n%s\n", err.Error())
+		return nil, err
+	}
 
-	// run images
-	err := cmd.Run()
-	if reference && err != nil {
-		util.PrintUtil( "ERROR: Error executing docker images.\n%s\n",
-			err.Error())
-		return "", err
+	entries := []ListEntry{}
+	for _, line := range strings.Split(string(out), "\n") {
+		x := strings.Split(line, "\t")
+		if len(x) != 5 || !strings.Contains(x[0], "-seed") {
+			continue
+		}
+		entries = append(entries, ListEntry{Repository: x[0], Tag: x[1], ID: x[2], Created: x[3], Size: x[4]})
 	}
+	return entries, nil
+}
 
-	if errs.String() != "" {
-		util.PrintUtil( "ERROR: Error reading stderr %s\n",
-			errs.String())
-		return "", errors.New(errs.String())
+//DockerListRegistry lists the seed images on a remote registry, optionally limited to an
+// organization. Every tag of every repository is checked for the seed manifest label, so
+// images are found regardless of their name. Requires a V2 registry.
+func DockerListRegistry(url, org, username, password, output string, filters []string) ([]ListEntry, error) {
+	listFilters, err := ParseListFilters(filters)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return nil, wrapError(ErrInvalidArgument, err)
 	}
+	username, password = util.ResolveCredentials(url, username, password)
 
-	if !strings.Contains(out.String(), "seed") {
-		util.PrintUtil( "No seed images found!\n")
+	registry, err := RegistryFactory.CreateRegistry(url, username, password)
+	if registry == nil || err != nil {
+		err = errors.New(checkError(err, url, username, password))
+		util.Errorf("%s\n", err.Error())
+		return nil, err
+	}
+	if registry.Name() != "V2" {
+		err = fmt.Errorf("Listing seed images by manifest label is not supported for %s registries. Try seed search instead.",
+			registry.Name())
+		util.Errorf("%s\n", err.Error())
+		return nil, err
+	}
+
+	repositories, err := registry.Repositories(org)
+	if err != nil {
+		util.Errorf("Error listing repositories on %s: %s\n", url, err.Error())
+		return nil, err
+	}
+
+	entries := []ListEntry{}
+	for _, repo := range repositories {
+		if org != "" && !strings.HasPrefix(repo, org+"/") {
+			continue
+		}
+		tags, err := registry.Tags(repo, org)
+		if err != nil {
+			util.Warnf("Error listing tags of %s: %s\n", repo, err.Error())
+			continue
+		}
+		for _, tag := range tags {
+			if _, err := registry.ImageManifest(repo, tag); err != nil {
+				util.Debugf("Skipping %s:%s: %s\n", repo, tag, err.Error())
+				continue
+			}
+			digest, _ := registry.ImageDigest(repo, tag)
+			entries = append(entries, ListEntry{Repository: repo, Tag: tag, Digest: digest})
+		}
+	}
+	entries = FilterListEntries(entries, listFilters)
+
+	_, err = printListEntries(entries, output)
+	return entries, err
+}
+
+//printListEntries prints the entries as a table like docker images, with the digest of remote
+// images in place of the image id, or as json written to stdout
+func printListEntries(entries []ListEntry, output string) (string, error) {
+	if output == constants.OutputJson {
+		bytes, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			util.Errorf("Error marshalling seed images: %s\n", err.Error())
+			return "", err
+		}
+		fmt.Fprintln(os.Stdout, string(bytes))
+		return string(bytes), nil
+	}
+
+	if len(entries) == 0 {
+		util.PrintUtil("No seed images found!\n")
 		return "", nil
 	}
-	util.PrintUtil( "%s", out.String())
-	return out.String(), nil
+
+	table := FormatListEntries(entries)
+	util.PrintUtil("%s", table)
+	return table, nil
+}
+
+//FormatListEntries formats the entries as an aligned table. Local images are listed with their
+// image id, creation time and size and remote images with their digest.
+func FormatListEntries(entries []ListEntry) string {
+	headers := []string{"REPOSITORY", "TAG", "IMAGE ID", "CREATED", "SIZE"}
+	colors := []string{util.ColorCyan, util.ColorGreen, util.ColorFaint, "", ""}
+	remote := entries[0].Digest != "" || entries[0].ID == ""
+	if remote {
+		headers, colors = []string{"REPOSITORY", "TAG", "DIGEST"}, colors[:3]
+	}
+
+	var rows [][]string
+	for _, e := range entries {
+		if remote {
+			rows = append(rows, []string{e.Repository, e.Tag, e.Digest})
+		} else {
+			rows = append(rows, []string{e.Repository, e.Tag, e.ID, e.Created, e.Size})
+		}
+	}
+	return util.FormatTable(headers, colors, rows)
 }
 
 //PrintListUsage prints the seed list usage information, then exits the program
 func PrintListUsage() {
-	util.PrintUtil( "\nUsage:\tseed list\n")
-	util.PrintUtil( "\nLists all Seed compliant docker images residing on the local system.\n")
+	util.PrintUtil( "\nUsage:\tseed list [-r REGISTRY_NAME] [-o ORGANIZATION_NAME] [-u username] [-p password] [-filter FIELD=VALUE]...\n"+
+		"\t\t[-output json] [-no-color]\n")
+	util.PrintUtil( "\nLists all Seed compliant docker images residing on the local system, or on a remote\n")
+	util.PrintUtil("registry if one is given. Remote images are found by inspecting the seed manifest label\n")
+	util.PrintUtil("of every tag, which requires a V2 registry.\n")
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s -%s\tSpecifies a registry to list (default is the local system).\n",
+		constants.ShortRegistryFlag, constants.RegistryFlag)
+	util.PrintUtil("  -%s -%s\tSpecifies an organization to filter (default is no filter).\n",
+		constants.ShortOrgFlag, constants.OrgFlag)
+	util.PrintUtil("  -%s -%s\tUsername to login to remote registry (default is anonymous).\n",
+		constants.ShortUserFlag, constants.UserFlag)
+	util.PrintUtil("  -%s -%s\tPassword to login to remote registry (default is anonymous).\n",
+		constants.ShortPassFlag, constants.PassFlag)
+	util.PrintUtil("  -%s -%s\tList only images whose field contains the value, ignoring case; may be repeated.\n"+
+		"\t\tFields are %s.\n", constants.ShortFilterFlag, constants.FilterFlag, strings.Join(listFilterFields, ", "))
+	util.PrintUtil("  -%s\tOutput format, %s or %s (default is %s).\n",
+		constants.OutputFlag, constants.OutputText, constants.OutputJson, constants.OutputText)
+	util.PrintUtil("  -%s\tDo not color the table; color is also off when output is not a terminal or NO_COLOR is set.\n",
+		constants.NoColorFlag)
 	panic(util.Exit{0})
 }
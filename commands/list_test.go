@@ -1,10 +1,15 @@
 package commands
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/ngageoint/seed-cli/constants"
 	"github.com/ngageoint/seed-cli/util"
 )
 
@@ -25,7 +30,7 @@ func TestDockerList(t *testing.T) {
 		buildArgs := []string{"build", "-t", c.imageName, c.directory}
 		cmd := exec.Command("docker", buildArgs...)
 		cmd.Run()
-		output, err := DockerList()
+		output, err := DockerList(constants.OutputText, nil)
 		if err != nil {
 			t.Errorf("DockerList returned an error: %v", err)
 		}
@@ -34,3 +39,186 @@ func TestDockerList(t *testing.T) {
 		}
 	}
 }
+
+func TestDockerListRegistry(t *testing.T) {
+	util.RestartRegistry()
+
+	registry := "localhost:5000"
+	username := "testuser"
+	password := "testpassword"
+
+	//set config dir so we don't stomp on other users' logins with sudo
+	configDir := constants.DockerConfigDir + time.Now().Format(time.RFC3339)
+	os.Setenv(constants.DockerConfigKey, configDir)
+	defer util.RemoveAllFiles(configDir)
+	defer os.Unsetenv(constants.DockerConfigKey)
+
+	err := util.Login(registry, username, password)
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	err = DockerBuild(BuildOptions{JobDirectory: "../testdata/complete/"})
+	if err != nil {
+		t.Errorf("Error building image for DockerListRegistry test: %v", err)
+	}
+	exec.Command("docker", "build", "-t", "localhost:5000/plain-image:latest", "../testdata/dummy-scratch/").Run()
+	util.Push("localhost:5000/plain-image:latest")
+
+	// Images are identified by their seed manifest label rather than their name
+	origImg := "my-job-0.1.0-seed:0.1.0"
+	remoteImg := []string{"localhost:5000/my-job-0.1.0-seed:0.1.0", "localhost:5000/not-a-seed-name:1.0.0"}
+	for _, img := range remoteImg {
+		err := util.Tag(origImg, img)
+		if err != nil {
+			t.Errorf("Error tagging image %v for DockerListRegistry test: %v", img, err)
+		}
+
+		err = util.Push(img)
+		if err != nil {
+			t.Errorf("Error pushing image %v for DockerListRegistry test: %v", img, err)
+		}
+	}
+
+	cases := []struct {
+		registry         string
+		org              string
+		username         string
+		password         string
+		expectedResult   string
+		expectedErrorMsg string
+	}{
+		{"localhost:5000", "", "testuser", "wrongpassword",
+			"[]", "Incorrect username/password."},
+		{"localhost:5000", "", "testuser", "testpassword",
+			"[my-job-0.1.0-seed:0.1.0 not-a-seed-name:1.0.0]", ""},
+		{"localhost:5000", "geoint", "testuser", "testpassword",
+			"[]", ""},
+	}
+
+	for _, c := range cases {
+		entries, err := DockerListRegistry(c.registry, c.org, c.username, c.password, constants.OutputText, nil)
+
+		var results []string
+		for _, e := range entries {
+			results = append(results, e.Repository+":"+e.Tag)
+		}
+		resultStr := fmt.Sprintf("%s", results)
+		if resultStr != c.expectedResult {
+			t.Errorf("DockerListRegistry returned %v, expected %v\n", resultStr, c.expectedResult)
+		}
+
+		if err != nil && err.Error() != c.expectedErrorMsg {
+			t.Errorf("DockerListRegistry returned error %v, expected %v\n", err.Error(), c.expectedErrorMsg)
+		}
+	}
+}
+
+func TestFormatListEntries(t *testing.T) {
+	cases := []struct {
+		entries  []ListEntry
+		expected string
+	}{
+		{[]ListEntry{
+			{Repository: "my-job-1.0.0-seed", Tag: "1.0.0", ID: "0123456789ab", Created: "2 days ago", Size: "12MB"},
+			{Repository: "geoint/extractor-1.2.0-seed", Tag: "0.1.0", ID: "ba9876543210", Created: "3 weeks ago",
+				Size: "1.2GB"}},
+			"REPOSITORY                    TAG     IMAGE ID       CREATED       SIZE\n" +
+				"my-job-1.0.0-seed             1.0.0   0123456789ab   2 days ago    12MB\n" +
+				"geoint/extractor-1.2.0-seed   0.1.0   ba9876543210   3 weeks ago   1.2GB\n"},
+		{[]ListEntry{{Repository: "geoint/my-job-1.0.0-seed", Tag: "1.0.0", Digest: "sha256:abc"}},
+			"REPOSITORY                 TAG     DIGEST\n" +
+				"geoint/my-job-1.0.0-seed   1.0.0   sha256:abc\n"},
+	}
+
+	for _, c := range cases {
+		if table := FormatListEntries(c.entries); table != c.expected {
+			t.Errorf("FormatListEntries(%v) ==\n%s\nexpected\n%s", c.entries, table, c.expected)
+		}
+	}
+
+	// Colored columns line up with the bold header and the plain CREATED column once the escapes are removed
+	util.SetForceColor(true)
+	defer util.SetForceColor(false)
+	escapes := regexp.MustCompile("\033\\[[0-9]+m")
+	for _, c := range cases {
+		table := FormatListEntries(c.entries)
+		if !strings.Contains(table, "\033[01mREPOSITORY\033[0m") || !strings.Contains(table, "\033[36m") {
+			t.Errorf("FormatListEntries(%v) with color ==\n%q\nexpected a bold header and colored columns",
+				c.entries, table)
+		}
+		if plain := escapes.ReplaceAllString(table, ""); plain != c.expected {
+			t.Errorf("FormatListEntries(%v) with color ==\n%s\nexpected\n%s", c.entries, plain, c.expected)
+		}
+	}
+}
+
+func TestFilterListEntries(t *testing.T) {
+	entries := []ListEntry{
+		{Repository: "my-job-1.0.0-seed", Tag: "1.0.0"},
+		{Repository: "my-job-1.2.0-seed", Tag: "2.0.0"},
+		{Repository: "geoint/extractor-1.2.0-seed", Tag: "0.1.0"},
+		{Repository: "localhost:5000/geoint/Extractor-0.3.0-seed", Tag: "0.1.0"},
+		{Repository: "plain-seed-image", Tag: "latest"},
+	}
+
+	cases := []struct {
+		filters  []string
+		expected string
+		errMsg   string
+	}{
+		{nil, "[my-job-1.0.0-seed:1.0.0 my-job-1.2.0-seed:2.0.0 geoint/extractor-1.2.0-seed:0.1.0 " +
+			"localhost:5000/geoint/Extractor-0.3.0-seed:0.1.0 plain-seed-image:latest]", ""},
+		{[]string{""}, "[my-job-1.0.0-seed:1.0.0 my-job-1.2.0-seed:2.0.0 geoint/extractor-1.2.0-seed:0.1.0 " +
+			"localhost:5000/geoint/Extractor-0.3.0-seed:0.1.0 plain-seed-image:latest]", ""},
+		{[]string{"org=geoint"}, "[geoint/extractor-1.2.0-seed:0.1.0 localhost:5000/geoint/Extractor-0.3.0-seed:0.1.0]", ""},
+		{[]string{"name=extractor"}, "[geoint/extractor-1.2.0-seed:0.1.0 localhost:5000/geoint/Extractor-0.3.0-seed:0.1.0]", ""},
+		{[]string{"version=1.2"}, "[my-job-1.2.0-seed:2.0.0 geoint/extractor-1.2.0-seed:0.1.0]", ""},
+		{[]string{"version=1.2", "Org=geoint"}, "[geoint/extractor-1.2.0-seed:0.1.0]", ""},
+		{[]string{"tag=latest"}, "[plain-seed-image:latest]", ""},
+		{[]string{"name=plain"}, "[plain-seed-image:latest]", ""},
+		{[]string{"repository=localhost"}, "[localhost:5000/geoint/Extractor-0.3.0-seed:0.1.0]", ""},
+		{[]string{"org=other"}, "[]", ""},
+		{[]string{"org"}, "", "Invalid -filter value org"},
+		{[]string{"org="}, "", "Invalid -filter value org="},
+		{[]string{"size=10"}, "", "Invalid -filter field size"},
+	}
+
+	for _, c := range cases {
+		filters, err := ParseListFilters(c.filters)
+		if (err == nil) != (c.errMsg == "") || (err != nil && !strings.Contains(err.Error(), c.errMsg)) {
+			t.Errorf("ParseListFilters(%q) returned error %v, expected %q", c.filters, err, c.errMsg)
+		}
+		if err != nil {
+			continue
+		}
+		results := []string{}
+		for _, e := range FilterListEntries(entries, filters) {
+			results = append(results, e.Repository+":"+e.Tag)
+		}
+		if r := fmt.Sprintf("%s", results); r != c.expected {
+			t.Errorf("FilterListEntries(%q) == %s, expected %s", c.filters, r, c.expected)
+		}
+	}
+}
+
+func TestPrintListEntries(t *testing.T) {
+	entries := []ListEntry{{Repository: "my-job-1.0.0-seed", Tag: "1.0.0", ID: "0123456789ab"}}
+	var table string
+	stdout := captureStdout(t, func() {
+		table, _ = printListEntries(entries, constants.OutputJson)
+	})
+	expected := "[\n  {\n    \"repository\": \"my-job-1.0.0-seed\",\n    \"tag\": \"1.0.0\",\n" +
+		"    \"id\": \"0123456789ab\"\n  }\n]"
+	if stdout != expected+"\n" || table != expected {
+		t.Errorf("printListEntries with -output json wrote %q to stdout, expected %q", stdout, expected+"\n")
+	}
+
+	// The table is printed to stderr with the other messages
+	stdout = captureStdout(t, func() {
+		printListEntries(entries, constants.OutputText)
+	})
+	if stdout != "" {
+		t.Errorf("printListEntries wrote the table %q to stdout, expected it on stderr", stdout)
+	}
+}
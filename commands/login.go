@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+//dockerHubRegistry is the V2 registry API of Docker Hub, whose credentials are checked there
+// rather than at hub.docker.com
+const dockerHubRegistry = "https://registry-1.docker.io"
+
+//loginClient is the HTTP client credentials are checked with
+var loginClient = &http.Client{Timeout: 30 * time.Second}
+
+//SeedLogin validates credentials against the given registry and stores them for use by
+// later search, pull and publish commands. Credentials not given are read from the
+// SEED_REGISTRY_USER and SEED_REGISTRY_PASSWORD environment variables, or prompted for.
+func SeedLogin(registry, username, password string) error {
+	var err error
+	if username == "" {
+		username = os.Getenv(constants.RegistryUserEnv)
+	}
+	if password == "" {
+		password = os.Getenv(constants.RegistryPasswordEnv)
+	}
+	if username == "" {
+		username, err = util.Prompt("Username: ", false)
+		if err != nil {
+			util.Errorf("Error reading username: %s\n", err.Error())
+			return err
+		}
+	}
+	if password == "" {
+		password, err = util.Prompt("Password: ", true)
+		if err != nil {
+			util.Errorf("Error reading password: %s\n", err.Error())
+			return err
+		}
+	}
+	if username == "" || password == "" {
+		err = errors.New("ERROR: A username and password are required to login.")
+		util.PrintError(err)
+		return err
+	}
+
+	err = checkCredentials(registry, username, password)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
+
+	err = util.StoreCredentials(registry, username, password)
+	if err != nil {
+		util.Errorf("Error storing credentials: %s\n", err.Error())
+		return err
+	}
+
+	util.PrintUtil("Login Succeeded. Credentials for %s stored in %s\n",
+		util.RegistryKey(registry), util.CredentialsDir())
+	return nil
+}
+
+//checkCredentials checks the username and password against the V2 API of the registry, the
+// default registry if none is given. The registry is asked which authentication it requires,
+// then the credentials are sent with basic auth, or to its token service for bearer token
+// authentication, and rejected if it answers 401.
+func checkCredentials(registry, username, password string) error {
+	base := registry
+	if base == "" || util.RegistryHost(base) == util.RegistryHost(constants.DefaultRegistry) {
+		base = dockerHubRegistry
+	} else if !strings.HasPrefix(base, "http") {
+		base = "https://" + base
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	resp, err := loginClient.Get(base + "/v2/")
+	if err != nil {
+		return errors.New("Could not connect to the specified registry. Please check the url and try again.")
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		// The registry does not require a login, so any credentials are accepted
+		return nil
+	} else if resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("Registry %s answered %s. Please check the url and try again.", base, resp.Status)
+	}
+
+	// A bearer token registry is logged in to at its token service
+	target := base + "/v2/"
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		params := authParams(challenge[len("bearer "):])
+		realm, err := url.Parse(params["realm"])
+		if err != nil || realm.Host == "" {
+			return fmt.Errorf("Registry %s gave an invalid token service %q", base, params["realm"])
+		}
+		query := realm.Query()
+		if params["service"] != "" {
+			query.Set("service", params["service"])
+		}
+		query.Set("account", username)
+		realm.RawQuery = query.Encode()
+		target = realm.String()
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(username, password)
+	resp, err = loginClient.Do(req)
+	if err != nil {
+		return errors.New("Could not connect to the specified registry. Please check the url and try again.")
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return errors.New("Incorrect username/password.")
+	} else if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Registry %s answered %s when logging in.", base, resp.Status)
+	}
+	return nil
+}
+
+//authParams returns the parameters of a WWW-Authenticate challenge, i.e. the realm and service
+// of realm="https://auth.docker.io/token",service="registry.docker.io"
+func authParams(challenge string) map[string]string {
+	params := map[string]string{}
+	for challenge != "" {
+		eq := strings.Index(challenge, "=")
+		if eq < 0 {
+			break
+		}
+		name := strings.ToLower(strings.TrimSpace(challenge[:eq]))
+		rest := challenge[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			value, rest = rest[1:end+1], rest[end+1:]
+			rest = strings.TrimPrefix(rest, `"`)
+		} else if comma := strings.Index(rest, ","); comma >= 0 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[name] = value
+		challenge = strings.TrimLeft(rest, ", ")
+	}
+	return params
+}
+
+//SeedLogout removes any stored credentials for the given registry
+func SeedLogout(registry string) error {
+	found, err := util.RemoveCredentials(registry)
+	if err != nil {
+		util.Errorf("Error removing credentials: %s\n", err.Error())
+		return err
+	}
+
+	if !found {
+		util.PrintUtil("Not logged in to %s\n", util.RegistryKey(registry))
+		return nil
+	}
+
+	util.PrintUtil("Removing login credentials for %s\n", util.RegistryKey(registry))
+	return nil
+}
+
+//PrintLoginUsage prints the seed login usage information, then exits the program
+func PrintLoginUsage() {
+	util.PrintUtil("\nUsage:\tseed login [-r REGISTRY_NAME] [-u username]\n")
+	util.PrintUtil("\nValidates and stores credentials for a registry. Stored credentials are used by\n")
+	util.PrintUtil("search, pull and publish when no username is given and %s is not set.\n",
+		constants.RegistryUserEnv)
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s -%s\tSpecifies a specific registry (default is index.docker.io).\n",
+		constants.ShortRegistryFlag, constants.RegistryFlag)
+	util.PrintUtil("  -%s -%s\tUsername to login with (default is %s, else prompted for).\n",
+		constants.ShortUserFlag, constants.UserFlag, constants.RegistryUserEnv)
+	util.PrintUtil("\nThe password is read from %s, or prompted for so it is not recorded in shell history.\n",
+		constants.RegistryPasswordEnv)
+	panic(util.Exit{0})
+}
+
+//PrintLogoutUsage prints the seed logout usage information, then exits the program
+func PrintLogoutUsage() {
+	util.PrintUtil("\nUsage:\tseed logout [-r REGISTRY_NAME]\n")
+	util.PrintUtil("\nRemoves credentials stored by seed login for a registry.\n")
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s -%s\tSpecifies a specific registry (default is index.docker.io).\n",
+		constants.ShortRegistryFlag, constants.RegistryFlag)
+	panic(util.Exit{0})
+}
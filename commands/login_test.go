@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+func init() {
+	util.InitPrinter(false)
+}
+
+func TestStoredLogin(t *testing.T) {
+	home, err := ioutil.TempDir("", "seed-login")
+	if err != nil {
+		t.Fatalf("Error creating temp home directory: %v", err)
+	}
+	defer util.RemoveAllFiles(home)
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	defer os.Setenv("HOME", origHome)
+
+	err = util.StoreCredentials("http://localhost:5000/", "testuser", "testpassword")
+	if err != nil {
+		t.Fatalf("Error storing credentials: %v", err)
+	}
+
+	cases := []struct {
+		registry string
+		username string
+		password string
+		envUser  string
+		envPass  string
+		expUser  string
+		expPass  string
+	}{
+		{"localhost:5000", "", "", "", "", "testuser", "testpassword"},
+		{"https://localhost:5000", "", "", "", "", "testuser", "testpassword"},
+		{"localhost:5000", "other", "secret", "", "", "other", "secret"},
+		{"", "", "", "", "", "", ""},
+		{"localhost:5000", "", "", "ciuser", "cipassword", "ciuser", "cipassword"},
+		{"localhost:5000", "other", "secret", "ciuser", "cipassword", "other", "secret"},
+		{"localhost:5000", "other", "", "ciuser", "cipassword", "other", "cipassword"},
+	}
+
+	defer os.Unsetenv(constants.RegistryUserEnv)
+	defer os.Unsetenv(constants.RegistryPasswordEnv)
+	for _, c := range cases {
+		os.Setenv(constants.RegistryUserEnv, c.envUser)
+		os.Setenv(constants.RegistryPasswordEnv, c.envPass)
+		user, pass := util.ResolveCredentials(c.registry, c.username, c.password)
+		if user != c.expUser || pass != c.expPass {
+			t.Errorf("ResolveCredentials(%q, %q, %q) with env (%q, %q) returned (%q, %q), expected (%q, %q)",
+				c.registry, c.username, c.password, c.envUser, c.envPass, user, pass, c.expUser, c.expPass)
+		}
+	}
+
+	err = SeedLogout("localhost:5000")
+	if err != nil {
+		t.Errorf("SeedLogout returned an error: %v", err)
+	}
+	if user, _ := util.StoredCredentials("localhost:5000"); user != "" {
+		t.Errorf("Credentials for localhost:5000 still stored after logout")
+	}
+}
+
+func TestSeedLogin(t *testing.T) {
+	home, err := ioutil.TempDir("", "seed-login")
+	if err != nil {
+		t.Fatalf("Error creating temp home directory: %v", err)
+	}
+	defer util.RemoveAllFiles(home)
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	defer os.Setenv("HOME", origHome)
+
+	// A registry with basic auth, and one with bearer tokens from a token service
+	basic := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "testuser" || pass != "testpassword" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer basic.Close()
+	var token *httptest.Server
+	token = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.test"`,
+				token.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		user, pass, ok := r.BasicAuth()
+		if r.URL.Path != "/token" || r.URL.Query().Get("service") != "registry.test" || !ok ||
+			user != "testuser" || pass != "testpassword" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"token":"abc"}`)
+	}))
+	defer token.Close()
+
+	cases := []struct {
+		registry string
+		password string
+		errorMsg string
+	}{
+		{basic.URL, "testpassword", ""},
+		{basic.URL, "wrong", "Incorrect username/password."},
+		{token.URL, "testpassword", ""},
+		{token.URL, "wrong", "Incorrect username/password."},
+	}
+
+	for _, c := range cases {
+		util.RemoveCredentials(c.registry)
+		err := SeedLogin(c.registry, "testuser", c.password)
+		user, _ := util.StoredCredentials(c.registry)
+		if c.errorMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), c.errorMsg) || user != "" {
+				t.Errorf("SeedLogin(%q) with password %q returned %v and stored user %q, expected %q and nothing "+
+					"stored", c.registry, c.password, err, user, c.errorMsg)
+			}
+		} else if err != nil || user != "testuser" {
+			t.Errorf("SeedLogin(%q) returned %v and stored user %q, expected testuser", c.registry, err, user)
+		}
+	}
+}
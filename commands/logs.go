@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+//LogEntry is the JSON envelope a container log line is wrapped in with -json-logs
+type LogEntry struct {
+	Time      string `json:"time"`
+	Stream    string `json:"stream"`
+	Image     string `json:"image"`
+	Container string `json:"container,omitempty"`
+	Message   string `json:"message"`
+}
+
+//jsonLogWriter wraps each line written to it in a LogEntry and writes it to out as
+// newline delimited JSON. Lines starting with whitespace, such as stack trace frames, are
+// treated as continuations and appended to the previous entry, so an entry is only written
+// once the next entry starts or the writer is flushed.
+type jsonLogWriter struct {
+	out       io.Writer
+	mu        *sync.Mutex
+	stream    string
+	image     string
+	container func() string
+
+	partial []byte
+	pending *LogEntry
+}
+
+//newJsonLogWriter returns a writer for the given stream. Writers for the stdout and stderr of
+// a container share the mutex so their entries are not interleaved. container is called to
+// look up the container id when an entry is started.
+func newJsonLogWriter(out io.Writer, mu *sync.Mutex, stream, image string, container func() string) *jsonLogWriter {
+	return &jsonLogWriter{out: out, mu: mu, stream: stream, image: image, container: container}
+}
+
+//Write implements io.Writer
+func (w *jsonLogWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(w.partial[:i]), "\r")
+		w.partial = w.partial[i+1:]
+		if err := w.addLine(line); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+//addLine appends a continuation line to the pending entry, or writes the pending entry and
+// starts a new one
+func (w *jsonLogWriter) addLine(line string) error {
+	if w.pending != nil && w.pending.Message != "" && line != "" && (line[0] == ' ' || line[0] == '\t') {
+		w.pending.Message += "\n" + line
+		return nil
+	}
+	if err := w.writePending(); err != nil {
+		return err
+	}
+	container := ""
+	if w.container != nil {
+		container = w.container()
+	}
+	w.pending = &LogEntry{Time: time.Now().UTC().Format(time.RFC3339Nano), Stream: w.stream,
+		Image: w.image, Container: container, Message: line}
+	return nil
+}
+
+//writePending writes the pending entry, if any
+func (w *jsonLogWriter) writePending() error {
+	if w.pending == nil {
+		return nil
+	}
+	bytes, err := json.Marshal(w.pending)
+	w.pending = nil
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.out.Write(append(bytes, '\n'))
+	return err
+}
+
+//Flush writes any buffered partial line and pending entry
+func (w *jsonLogWriter) Flush() error {
+	if len(w.partial) > 0 {
+		line := string(w.partial)
+		w.partial = nil
+		if err := w.addLine(line); err != nil {
+			return err
+		}
+	}
+	return w.writePending()
+}
@@ -0,0 +1,269 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+//ManifestStats summarizes the contents of a seed manifest
+type ManifestStats struct {
+	Name           string           `json:"name"`
+	Title          string           `json:"title,omitempty"`
+	Image          string           `json:"image"`
+	SeedVersion    string           `json:"seedVersion"`
+	JobVersion     string           `json:"jobVersion"`
+	PackageVersion string           `json:"packageVersion"`
+	Command        string           `json:"command"`
+	Timeout        int              `json:"timeout,omitempty"`
+	Inputs         InterfaceCount   `json:"inputs"`
+	Outputs        InterfaceCount   `json:"outputs"`
+	Settings       int              `json:"settings"`
+	SecretSettings int              `json:"secretSettings"`
+	Mounts         int              `json:"mounts"`
+	Errors         int              `json:"errors"`
+	Resources      []objects.Scalar `json:"resources"`
+}
+
+//InterfaceCount holds the number of file and json interface elements of a manifest
+type InterfaceCount struct {
+	Files    int `json:"files"`
+	Json     int `json:"json"`
+	Required int `json:"required"`
+}
+
+//GetManifestStats returns a summary of the given seed manifest
+func GetManifestStats(seed *objects.Seed) ManifestStats {
+	job := seed.Job
+	stats := ManifestStats{
+		Name:           job.Name,
+		Title:          job.Title,
+		Image:          objects.BuildImageName(seed),
+		SeedVersion:    seed.SeedVersion,
+		JobVersion:     job.JobVersion,
+		PackageVersion: job.PackageVersion,
+		Command:        job.Interface.Command,
+		Timeout:        job.Timeout,
+		Mounts:         len(job.Interface.Mounts),
+		Errors:         len(job.Errors),
+		Resources:      job.Resources.Scalar,
+	}
+	if stats.Resources == nil {
+		stats.Resources = []objects.Scalar{}
+	}
+
+	inputs := job.Interface.Inputs
+	stats.Inputs.Files = len(inputs.Files)
+	stats.Inputs.Json = len(inputs.Json)
+	for _, f := range inputs.Files {
+		if f.Required {
+			stats.Inputs.Required++
+		}
+	}
+	for _, j := range inputs.Json {
+		if j.Required {
+			stats.Inputs.Required++
+		}
+	}
+
+	outputs := job.Interface.Outputs
+	stats.Outputs.Files = len(outputs.Files)
+	stats.Outputs.Json = len(outputs.JSON)
+	for _, f := range outputs.Files {
+		if f.Required {
+			stats.Outputs.Required++
+		}
+	}
+	for _, j := range outputs.JSON {
+		if j.Required {
+			stats.Outputs.Required++
+		}
+	}
+
+	stats.Settings = len(job.Interface.Settings)
+	for _, s := range job.Interface.Settings {
+		if s.Secret {
+			stats.SecretSettings++
+		}
+	}
+
+	return stats
+}
+
+//ManifestStatsSummary seed manifest stats: Prints a summary of the seed manifest at path
+// in the given output format. Path may be a manifest file or a directory containing one.
+func ManifestStatsSummary(path, output string) error {
+	if output == "" {
+		output = constants.OutputText
+	}
+	if output != constants.OutputText && output != constants.OutputJson {
+		err := errors.New("ERROR: Unsupported output format " + output + ". Must be " +
+			constants.OutputText + " or " + constants.OutputJson + ".")
+		util.PrintError(err)
+		return err
+	}
+
+	seedFileName, err := manifestFileName(path)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
+
+	seed, err := objects.SeedFromManifestFile(seedFileName)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
+	stats := GetManifestStats(&seed)
+
+	if output == constants.OutputJson {
+		bytes, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			util.Errorf("Error marshalling manifest stats: %s\n", err.Error())
+			return err
+		}
+		util.PrintUtil("%s\n", string(bytes))
+		return nil
+	}
+
+	util.PrintUtil("%s\n", seedFileName)
+	util.PrintUtil("  Name:     \t%s\n", stats.Name)
+	if stats.Title != "" {
+		util.PrintUtil("  Title:    \t%s\n", stats.Title)
+	}
+	util.PrintUtil("  Image:    \t%s\n", stats.Image)
+	util.PrintUtil("  Versions: \tseed %s, job %s, package %s\n",
+		stats.SeedVersion, stats.JobVersion, stats.PackageVersion)
+	util.PrintUtil("  Command:  \t%s\n", stats.Command)
+	if stats.Timeout > 0 {
+		util.PrintUtil("  Timeout:  \t%ds\n", stats.Timeout)
+	}
+	util.PrintUtil("  Inputs:   \t%d files, %d json (%d required)\n",
+		stats.Inputs.Files, stats.Inputs.Json, stats.Inputs.Required)
+	util.PrintUtil("  Outputs:  \t%d files, %d json (%d required)\n",
+		stats.Outputs.Files, stats.Outputs.Json, stats.Outputs.Required)
+	util.PrintUtil("  Settings: \t%d (%d secret)\n", stats.Settings, stats.SecretSettings)
+	util.PrintUtil("  Mounts:   \t%d\n", stats.Mounts)
+	util.PrintUtil("  Errors:   \t%d\n", stats.Errors)
+
+	var resources []string
+	for _, r := range stats.Resources {
+		resource := r.Name + "=" + strconv.FormatFloat(r.Value, 'f', -1, 64)
+		if r.InputMultiplier != 0 {
+			resource += " (+" + strconv.FormatFloat(r.InputMultiplier, 'f', -1, 64) + "x input)"
+		}
+		resources = append(resources, resource)
+	}
+	if len(resources) == 0 {
+		resources = []string{"none"}
+	}
+	util.PrintUtil("  Resources:\t%s\n", strings.Join(resources, ", "))
+
+	return nil
+}
+
+//ManifestExtract seed manifest extract: Writes the seed manifest embedded in the label of a
+// built image to outputFile as indented JSON, so it can be edited and rebuilt. Writes to stdout
+// if outputFile is -. An existing file is only overwritten if force is set.
+func ManifestExtract(imageName, outputFile string, force bool) error {
+	if outputFile == "" {
+		outputFile = constants.SeedFileName
+	}
+	if outputFile != "-" && !force {
+		if _, err := os.Stat(outputFile); err == nil {
+			err = fmt.Errorf("%s already exists. Use -%s to overwrite it", outputFile, constants.ForceFlag)
+			util.Errorf("%s\n", err.Error())
+			return err
+		}
+	}
+
+	if exists, err := util.ImageExists(imageName); !exists {
+		if err == nil {
+			err = errors.New("No local image found for " + imageName)
+		}
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
+	label, err := util.ImageLabel(imageName, constants.ManifestLabel)
+	if err != nil {
+		util.Errorf("Error reading the labels of %s: %s\n", imageName, err.Error())
+		return err
+	}
+	manifest, err := FormatManifestLabel(label)
+	if err != nil {
+		util.Errorf("Error extracting the manifest of %s: %s\n", imageName, err.Error())
+		return err
+	}
+
+	if outputFile == "-" {
+		os.Stdout.Write(manifest)
+		return nil
+	}
+	if err = ioutil.WriteFile(outputFile, manifest, 0644); err != nil {
+		util.Errorf("Error writing %s: %s\n", outputFile, err.Error())
+		return err
+	}
+	util.PrintUtil("Extracted the seed manifest of %s to %s\n", imageName, outputFile)
+	return nil
+}
+
+//FormatManifestLabel returns the seed manifest stored in a com.ngageoint.seed.manifest label as
+// JSON indented with two spaces. The fields keep the order they were declared in.
+func FormatManifestLabel(label string) ([]byte, error) {
+	if label == "" {
+		return nil, fmt.Errorf("the image has no %s label", constants.ManifestLabel)
+	}
+	var manifest bytes.Buffer
+	if err := json.Indent(&manifest, []byte(objects.UnescapeManifestLabel(label)), "", "  "); err != nil {
+		return nil, fmt.Errorf("the %s label is not valid json: %s", constants.ManifestLabel, err.Error())
+	}
+	manifest.WriteString("\n")
+	return manifest.Bytes(), nil
+}
+
+//PrintManifestUsage prints the seed manifest usage information, then exits the program
+func PrintManifestUsage() {
+	util.PrintUtil("\nUsage:\tseed manifest COMMAND\n")
+	util.PrintUtil("\nSummarizes seed manifests and extracts the manifest of built images.\n")
+	util.PrintUtil("\nCommands:\n")
+	util.PrintUtil("  %s   \tPrints a summary of the interface, resources and versions of a manifest\n",
+		constants.ManifestStatsCommand)
+	util.PrintUtil("  %s \tWrites the manifest embedded in a built image to a file\n",
+		constants.ManifestExtractCommand)
+	util.PrintUtil("\nRun 'seed manifest COMMAND --help' for more information on a command.\n")
+	panic(util.Exit{0})
+}
+
+//PrintManifestStatsUsage prints the seed manifest stats usage information, then exits the program
+func PrintManifestStatsUsage() {
+	util.PrintUtil("\nUsage:\tseed manifest stats [-o json] [FILE]\n")
+	util.PrintUtil("\nPrints counts of inputs, outputs, settings and mounts along with the declared\n")
+	util.PrintUtil("resources, command and versions of a %s. FILE may be a manifest or\n", constants.SeedFileName)
+	util.PrintUtil("a directory containing one (default is current directory).\n")
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s -%s\tOutput format, %s or %s (default is %s)\n",
+		constants.ShortOutputFlag, constants.OutputFlag, constants.OutputText, constants.OutputJson,
+		constants.OutputText)
+	panic(util.Exit{0})
+}
+
+//PrintManifestExtractUsage prints the seed manifest extract usage information, then exits the program
+func PrintManifestExtractUsage() {
+	util.PrintUtil("\nUsage:\tseed manifest extract IMAGE_NAME [-o FILE] [-force]\n")
+	util.PrintUtil("\nWrites the seed manifest embedded in the %s label of a built image\n", constants.ManifestLabel)
+	util.PrintUtil("as indented JSON, i.e. to recover a manifest whose source was lost so it can be edited and rebuilt.\n")
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s -%s\tFile to write the manifest to, or - for stdout (default is %s)\n",
+		constants.ShortOutputFlag, constants.OutputFileFlag, constants.SeedFileName)
+	util.PrintUtil("  -%s\t\tOverwrite the file if it exists\n", constants.ForceFlag)
+	panic(util.Exit{0})
+}
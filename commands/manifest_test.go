@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+func init() {
+	util.InitPrinter(false)
+}
+
+func TestManifestStats(t *testing.T) {
+	seed, err := objects.SeedFromManifestFile("../testdata/complete/seed.manifest.json")
+	if err != nil {
+		t.Fatalf("Error reading manifest for ManifestStats test: %v", err)
+	}
+
+	stats := GetManifestStats(&seed)
+	expected := ManifestStats{
+		Name:           "my-job",
+		Image:          "my-job-0.1.0-seed:0.1.0",
+		Command:        "${INPUT_FILE} ${OUTPUT_DIR}",
+		Inputs:         InterfaceCount{Files: 1, Json: 0, Required: 1},
+		Outputs:        InterfaceCount{Files: 2, Json: 1, Required: 3},
+		Settings:       1,
+		SecretSettings: 0,
+		Mounts:         1,
+		Errors:         2,
+	}
+	if stats.Name != expected.Name || stats.Image != expected.Image || stats.Command != expected.Command {
+		t.Errorf("GetManifestStats returned name %q, image %q, command %q, expected %q, %q, %q",
+			stats.Name, stats.Image, stats.Command, expected.Name, expected.Image, expected.Command)
+	}
+	if stats.Inputs != expected.Inputs || stats.Outputs != expected.Outputs {
+		t.Errorf("GetManifestStats returned inputs %v, outputs %v, expected %v, %v",
+			stats.Inputs, stats.Outputs, expected.Inputs, expected.Outputs)
+	}
+	if stats.Settings != expected.Settings || stats.SecretSettings != expected.SecretSettings ||
+		stats.Mounts != expected.Mounts || stats.Errors != expected.Errors {
+		t.Errorf("GetManifestStats returned %d settings (%d secret), %d mounts, %d errors, expected %d (%d), %d, %d",
+			stats.Settings, stats.SecretSettings, stats.Mounts, stats.Errors,
+			expected.Settings, expected.SecretSettings, expected.Mounts, expected.Errors)
+	}
+	if len(stats.Resources) != 4 {
+		t.Errorf("GetManifestStats returned %d resources, expected 4", len(stats.Resources))
+	}
+
+	cases := []struct {
+		path     string
+		output   string
+		expected bool
+	}{
+		{"../testdata/complete/", constants.OutputText, true},
+		{"../testdata/complete/seed.manifest.json", constants.OutputJson, true},
+		{"../testdata/complete/", "yaml", false},
+		{"../testdata/", constants.OutputText, false},
+	}
+
+	for _, c := range cases {
+		err := ManifestStatsSummary(c.path, c.output)
+		if (err == nil) != c.expected {
+			t.Errorf("ManifestStatsSummary(%q, %q) returned %v, expected success %v", c.path, c.output, err, c.expected)
+		}
+	}
+}
+
+func TestFormatManifestLabel(t *testing.T) {
+	label := objects.GetManifestLabel("../testdata/complete/seed.manifest.json")
+	manifest, err := FormatManifestLabel(label)
+	if err != nil {
+		t.Fatalf("FormatManifestLabel returned an error: %v", err)
+	}
+	if !strings.HasPrefix(string(manifest), "{\n  \"seedVersion\": ") || !strings.HasSuffix(string(manifest), "}\n") {
+		t.Errorf("FormatManifestLabel returned %q, expected indented JSON", string(manifest))
+	}
+	var seed objects.Seed
+	if err := json.Unmarshal(manifest, &seed); err != nil || seed.Job.Name != "my-job" {
+		t.Errorf("FormatManifestLabel returned %q, which does not parse as the my-job manifest: %v",
+			string(manifest), err)
+	}
+
+	cases := []struct {
+		label            string
+		expectedErrorMsg string
+	}{
+		{"", "has no com.ngageoint.seed.manifest label"},
+		{`"{\"seedVersion\": \"1.0.0\"`, "is not valid json"},
+	}
+	for _, c := range cases {
+		if _, err := FormatManifestLabel(c.label); err == nil || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+			t.Errorf("FormatManifestLabel(%q) returned %v, expected %v", c.label, err, c.expectedErrorMsg)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+//pipelineStageResult records where a completed pipeline stage wrote its outputs and the
+// output files declared in its manifest, for resolving references in later stages
+type pipelineStageResult struct {
+	OutputDir string
+	Outputs   []objects.OutFile
+}
+
+//SeedPipeline seed pipeline: Runs the stages of the pipeline file in order. Each stage writes to
+// its own directory, named after the stage, under outputDir (or the pipeline's outputDir), and
+// references to earlier stages in input, setting and mount values are replaced with their
+// output paths. The pipeline stops at the first stage that fails.
+func SeedPipeline(pipelineFile, outputDir string, rmFlag bool) error {
+	pipeline, err := objects.ReadPipeline(pipelineFile)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
+
+	// relative paths in the pipeline file are relative to the file
+	pipelineDir := filepath.Dir(util.GetFullPath(pipelineFile, ""))
+	if outputDir == "" {
+		outputDir = pipeline.OutputDir
+		if outputDir != "" && !filepath.IsAbs(outputDir) {
+			outputDir = filepath.Join(pipelineDir, outputDir)
+		}
+	}
+	if outputDir == "" {
+		outputDir = "pipeline-" + strings.TrimSuffix(filepath.Base(pipelineFile), filepath.Ext(pipelineFile)) +
+			"-" + strings.Replace(time.Now().Format(time.RFC3339), ":", "_", -1)
+	}
+	outputDir = util.GetFullPath(outputDir, "")
+
+	results := map[string]pipelineStageResult{}
+	for i, stage := range pipeline.Stages {
+		util.PrintUtil("Running stage %d/%d: %s (%s)\n", i+1, len(pipeline.Stages), stage.Name, stage.Image)
+
+		stageDir := filepath.Join(outputDir, stage.Name)
+		if err := createStageDir(stageDir); err != nil {
+			util.Errorf("%s\n", err.Error())
+			return err
+		}
+
+		inputs, err := pipelineArgs(stage.Inputs, results, pipelineDir)
+		if err == nil {
+			var settings, mounts []string
+			settings, err = pipelineArgs(stage.Settings, results, "")
+			if err == nil {
+				mounts, err = pipelineArgs(stage.Mounts, results, pipelineDir)
+			}
+			if err == nil {
+				var exitCode int
+				exitCode, err = DockerRun(RunOptions{
+					ImageName: stage.Image,
+					OutputDir: stageDir,
+					Inputs:    inputs,
+					Settings:  settings,
+					Mounts:    mounts,
+					RmDir:     rmFlag,
+				})
+				if err == nil && exitCode != 0 {
+					err = fmt.Errorf("exit code %d", exitCode)
+				}
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("Pipeline stopped: stage %s (%s) failed: %s", stage.Name, stage.Image, err.Error())
+			util.Errorf("%s\n", err.Error())
+			return err
+		}
+
+		seed, err := objects.SeedFromImageLabel(stage.Image)
+		if err != nil {
+			err = fmt.Errorf("Pipeline stopped: stage %s (%s) failed: %s", stage.Name, stage.Image, err.Error())
+			util.Errorf("%s\n", err.Error())
+			return err
+		}
+		results[stage.Name] = pipelineStageResult{OutputDir: stageDir, Outputs: seed.Job.Interface.Outputs.Files}
+	}
+
+	util.PrintUtil("SUCCESS: Pipeline completed %d stages. Outputs written to %s\n", len(pipeline.Stages), outputDir)
+	return nil
+}
+
+//createStageDir creates the output directory of a pipeline stage. The directory must be empty
+// so seed run writes directly to it rather than to a time-stamped subdirectory.
+func createStageDir(stageDir string) error {
+	if err := os.MkdirAll(stageDir, os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Open(stageDir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Readdirnames(1); err != io.EOF {
+		return errors.New("Stage output directory " + stageDir + " is not empty")
+	}
+	return nil
+}
+
+//pipelineArgs converts stage values to the KEY=VALUE arguments accepted by seed run, sorted by
+// key. References to earlier stages are resolved and, if relativeTo is set, remaining relative
+// paths are resolved against it.
+func pipelineArgs(values map[string]string, results map[string]pipelineStageResult, relativeTo string) ([]string, error) {
+	var args []string
+	for key, value := range values {
+		resolved, err := resolvePipelineValue(value, results)
+		if err != nil {
+			return nil, fmt.Errorf("%s=%s: %s", key, value, err.Error())
+		}
+		if relativeTo != "" && resolved == value && !filepath.IsAbs(resolved) {
+			resolved = filepath.Join(relativeTo, resolved)
+		}
+		args = append(args, key+"="+resolved)
+	}
+	sort.Strings(args)
+	return args, nil
+}
+
+//resolvePipelineValue replaces ${STAGE.OUTPUT_DIR} references with the output directory of the
+// stage and ${STAGE.OUTPUT_NAME} references with the single file in it matching the pattern of
+// that declared output
+func resolvePipelineValue(value string, results map[string]pipelineStageResult) (string, error) {
+	var err error
+	resolved := objects.PipelineReferenceRegex.ReplaceAllStringFunc(value, func(ref string) string {
+		match := objects.PipelineReferenceRegex.FindStringSubmatch(ref)
+		result, ok := results[match[1]]
+		if !ok {
+			err = errors.New("stage " + match[1] + " has not run")
+			return ref
+		}
+		if match[2] == constants.OutputDirKey {
+			return result.OutputDir
+		}
+		for _, out := range result.Outputs {
+			if out.Name != match[2] {
+				continue
+			}
+			files, _ := filepath.Glob(filepath.Join(result.OutputDir, out.Pattern))
+			if len(files) != 1 {
+				err = fmt.Errorf("output %s of stage %s matched %d files; reference ${%s.%s} to pass "+
+					"the stage output directory instead", match[2], match[1], len(files), match[1],
+					constants.OutputDirKey)
+				return ref
+			}
+			return files[0]
+		}
+		err = errors.New("stage " + match[1] + " does not declare an output named " + match[2])
+		return ref
+	})
+	return resolved, err
+}
+
+//PrintPipelineUsage prints the seed pipeline usage arguments, then exits the program
+func PrintPipelineUsage() {
+	util.PrintUtil("\nUsage:\tseed pipeline [-o OUTPUT_DIR] [-rm] PIPELINE_FILE\n")
+	util.PrintUtil("\nRuns the stages of a pipeline file in order, passing the outputs of earlier stages as inputs to\n")
+	util.PrintUtil("later ones. The pipeline stops at the first stage that fails.\n")
+	util.PrintUtil("\nPipeline files list stages with a name, image and inputs, settings and mounts by key:\n")
+	util.PrintUtil("  {\"stages\": [\n")
+	util.PrintUtil("    {\"name\": \"extract\", \"image\": \"extractor-0.1.0-seed:0.1.0\", \"inputs\": {\"ZIP\": \"seed.zip\"}},\n")
+	util.PrintUtil("    {\"name\": \"count\", \"image\": \"counter-0.1.0-seed:0.1.0\",\n")
+	util.PrintUtil("     \"inputs\": {\"INPUT_FILE\": \"${extract.output_file}\"}}\n")
+	util.PrintUtil("  ]}\n")
+	util.PrintUtil("Values may reference ${STAGE.%s} for the output directory of an earlier stage, or\n",
+		constants.OutputDirKey)
+	util.PrintUtil("${STAGE.OUTPUT_NAME} for the file matching one of its declared output files.\n")
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s -%s\tDirectory the stage output directories are created in\n",
+		constants.ShortJobOutputDirFlag, constants.JobOutputDirFlag)
+	util.PrintUtil("  -%s\t\tRemove each container when it exits\n", constants.RmFlag)
+	panic(util.Exit{0})
+}
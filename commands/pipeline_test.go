@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/objects"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+func init() {
+	util.InitPrinter(false)
+}
+
+func TestReadPipeline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seed-pipeline")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for ReadPipeline test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		pipeline         string
+		expectedErrorMsg string
+	}{
+		{`{"stages": [{"name": "extract", "image": "extractor-0.1.0-seed:0.1.0", "inputs": {"ZIP": "seed.zip"}},
+			{"name": "count", "image": "counter-0.1.0-seed:0.1.0", "inputs": {"INPUT_FILE": "${extract.output_csv}"}}]}`, ""},
+		{`{"stages": []}`, "Pipeline has no stages"},
+		{`{"stages": [{"name": "extract"}]}`, "Stage extract has no image"},
+		{`{"stages": [{"name": "my stage", "image": "a"}]}`, "invalid name"},
+		{`{"stages": [{"name": "a", "image": "a"}, {"name": "a", "image": "b"}]}`, "used more than once"},
+		{`{"stages": [{"name": "a", "image": "a", "inputs": {"IN": "${b.OUTPUT_DIR}"}}, {"name": "b", "image": "b"}]}`,
+			"not an earlier stage"},
+		{`{"stages": [{"name": "a", "img": "a"}]}`, "unknown field"},
+	}
+
+	pipelineFile := filepath.Join(dir, "pipeline.json")
+	for _, c := range cases {
+		ioutil.WriteFile(pipelineFile, []byte(c.pipeline), 0644)
+		_, err := objects.ReadPipeline(pipelineFile)
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("ReadPipeline(%q) == %v, expected %v", c.pipeline, err.Error(), c.expectedErrorMsg)
+			}
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("ReadPipeline(%q) returned no error, expected %v", c.pipeline, c.expectedErrorMsg)
+		}
+	}
+}
+
+func TestResolvePipelineValue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seed-pipeline")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for ResolvePipelineValue test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "counts.csv"), []byte("1,2\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "a.png"), []byte{}, 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b.png"), []byte{}, 0644)
+
+	results := map[string]pipelineStageResult{
+		"extract": {OutputDir: dir, Outputs: []objects.OutFile{
+			{Name: "output_csv", Pattern: "*.csv"},
+			{Name: "output_pngs", Pattern: "*.png"},
+		}},
+	}
+
+	cases := []struct {
+		value            string
+		expected         string
+		expectedErrorMsg string
+	}{
+		{"/data/in.txt", "/data/in.txt", ""},
+		{"${extract.OUTPUT_DIR}", dir, ""},
+		{"${extract.OUTPUT_DIR}/a.png", dir + "/a.png", ""},
+		{"${extract.output_csv}", filepath.Join(dir, "counts.csv"), ""},
+		{"${extract.output_pngs}", "", "matched 2 files"},
+		{"${extract.output_tiffs}", "", "does not declare an output named output_tiffs"},
+		{"${count.OUTPUT_DIR}", "", "stage count has not run"},
+	}
+
+	for _, c := range cases {
+		value, err := resolvePipelineValue(c.value, results)
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("resolvePipelineValue(%q) == %v, expected %v", c.value, err.Error(), c.expectedErrorMsg)
+			}
+			continue
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("resolvePipelineValue(%q) returned no error, expected %v", c.value, c.expectedErrorMsg)
+		}
+		if value != c.expected {
+			t.Errorf("resolvePipelineValue(%q) == %q, expected %q", c.value, value, c.expected)
+		}
+	}
+}
+
+func TestSeedPipelineStops(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seed-pipeline")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for SeedPipeline test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	pipelineFile := filepath.Join(dir, "pipeline.json")
+	ioutil.WriteFile(pipelineFile, []byte(`{"stages": [{"name": "extract", "image": "extractor-0.1.0-seed:0.1.0"},
+		{"name": "count", "image": "counter-0.1.0-seed:0.1.0"}]}`), 0644)
+
+	cases := []struct {
+		responses        []util.FakeDockerResponse
+		expectedErrorMsg string
+	}{
+		{[]util.FakeDockerResponse{{Args: []string{"images", "-q"}}},
+			"stage extract (extractor-0.1.0-seed:0.1.0) failed: Image extractor-0.1.0-seed:0.1.0 not found"},
+		{[]util.FakeDockerResponse{{Args: []string{"images", "-q"}, Stdout: "abc123"},
+			{Args: []string{"inspect"}, Stderr: "Error: No such object"}},
+			"stage extract (extractor-0.1.0-seed:0.1.0) failed: Error executing docker inspect"},
+		{[]util.FakeDockerResponse{{Args: []string{"images", "-q"}, Stdout: "abc123"},
+			{Args: []string{"inspect"}, Stdout: "not a manifest"}},
+			"stage extract (extractor-0.1.0-seed:0.1.0) failed: Error unmarshalling seed"},
+	}
+
+	for _, c := range cases {
+		fake := &util.FakeDockerRunner{Responses: c.responses}
+		restore := util.SetDockerRunner(fake)
+		err := SeedPipeline(pipelineFile, filepath.Join(dir, "out"), true)
+		util.SetDockerRunner(restore)
+		if err == nil || !strings.Contains(err.Error(), "Pipeline stopped: "+c.expectedErrorMsg) {
+			t.Errorf("SeedPipeline returned %v, expected Pipeline stopped: %v", err, c.expectedErrorMsg)
+		}
+		os.RemoveAll(filepath.Join(dir, "out"))
+	}
+}
@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+//seedJobVersionRegex splits the repository of a seed image, i.e. geoint/my-job-1.0.0-seed, into
+// the job, geoint/my-job, and the job version, 1.0.0
+var seedJobVersionRegex = regexp.MustCompile(`^(.+)-([0-9]+\.[0-9]+\.[0-9]+(?:-[0-9A-Za-z.-]+)?)-seed$`)
+
+//DockerPrune seed prune: Removes old versions of the local seed images, optionally limited to
+// an organization, keeping the keep most recent versions of each job. With dryRun the images
+// are listed but not removed. Returns the images removed, or that would be.
+func DockerPrune(org string, keep int, dryRun bool) ([]ListEntry, error) {
+	if keep < 1 {
+		err := fmt.Errorf("Invalid -%s value %d. At least one version of each image must be kept",
+			constants.KeepFlag, keep)
+		util.Errorf("%s\n", err.Error())
+		return nil, err
+	}
+
+	entries, err := localSeedImages()
+	if err != nil {
+		return nil, err
+	}
+
+	prunable := SelectPrunable(entries, org, keep)
+	if len(prunable) == 0 {
+		util.PrintUtil("No seed images to prune; at most %d versions of each job are present\n", keep)
+		return prunable, nil
+	}
+
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tTAG\tIMAGE ID")
+	for _, e := range prunable {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", e.Repository, e.Tag, e.ID)
+	}
+	w.Flush()
+
+	if dryRun {
+		util.PrintUtil("Dry run: would remove %d images\n%s", len(prunable), buffer.String())
+		return prunable, nil
+	}
+
+	util.PrintUtil("Removing %d images\n%s", len(prunable), buffer.String())
+	failed := 0
+	for _, e := range prunable {
+		if err := util.RemoveImage(e.Repository + ":" + e.Tag); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		err = fmt.Errorf("%d of %d images could not be removed", failed, len(prunable))
+		util.Errorf("%s\n", err.Error())
+		return prunable, err
+	}
+	util.PrintUtil("Removed %d images\n", len(prunable))
+	return prunable, nil
+}
+
+//SelectPrunable returns the images to remove so that only the keep most recent versions of each
+// seed job remain. Images are grouped by job, the repository without the job version, and
+// ordered by job version and then package version, the tag. If org is given only images of
+// that organization are considered. Untagged images are ignored.
+func SelectPrunable(entries []ListEntry, org string, keep int) []ListEntry {
+	type version struct {
+		entry      ListEntry
+		jobVersion string
+	}
+	jobs := map[string][]version{}
+	var names []string
+	for _, e := range entries {
+		if e.Tag == "" || e.Tag == "<none>" {
+			continue
+		}
+		ref, err := util.ParseReference(e.Repository)
+		if err != nil || (org != "" && ref.Org != org) {
+			continue
+		}
+		m := seedJobVersionRegex.FindStringSubmatch(e.Repository)
+		if m == nil {
+			continue
+		}
+		if _, ok := jobs[m[1]]; !ok {
+			names = append(names, m[1])
+		}
+		jobs[m[1]] = append(jobs[m[1]], version{entry: e, jobVersion: m[2]})
+	}
+	sort.Strings(names)
+
+	prunable := []ListEntry{}
+	for _, name := range names {
+		versions := jobs[name]
+		sort.SliceStable(versions, func(i, j int) bool {
+			if c := comparePrerelease(versions[i].jobVersion, versions[j].jobVersion); c != 0 {
+				return c > 0
+			}
+			return comparePrerelease(versions[i].entry.Tag, versions[j].entry.Tag) > 0
+		})
+		for i := keep; i < len(versions); i++ {
+			prunable = append(prunable, versions[i].entry)
+		}
+	}
+	return prunable
+}
+
+//comparePrerelease compares versions like compareVersions, except that a pre-release version,
+// i.e. 1.0.0-rc.1, is older than the release it precedes
+func comparePrerelease(a, b string) int {
+	aCore, aPre := splitPrerelease(a)
+	bCore, bPre := splitPrerelease(b)
+	if c := compareVersions(aCore, bCore); c != 0 {
+		return c
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	}
+	return strings.Compare(aPre, bPre)
+}
+
+//splitPrerelease splits a version into its dotted numeric part and pre-release, if any
+func splitPrerelease(version string) (string, string) {
+	if i := strings.Index(version, "-"); i >= 0 {
+		return version[:i], version[i+1:]
+	}
+	return version, ""
+}
+
+//PrintPruneUsage prints the seed prune usage information, then exits the program
+func PrintPruneUsage() {
+	util.PrintUtil("\nUsage:\tseed prune [-o ORGANIZATION_NAME] [-keep N] [-dry-run]\n")
+	util.PrintUtil("\nRemoves old versions of the seed images on the local system. Images are grouped by job, the\n")
+	util.PrintUtil("image name without the job version, and ordered by job version then package version. All but\n")
+	util.PrintUtil("the most recent versions of each job are removed.\n")
+	util.PrintUtil("\nOptions:\n")
+	util.PrintUtil("  -%s -%s\tOnly prune images of this organization (default is all seed images).\n",
+		constants.ShortOrgFlag, constants.OrgFlag)
+	util.PrintUtil("  -%s\tNumber of versions of each job to keep (default is %d).\n",
+		constants.KeepFlag, constants.DefaultPruneKeep)
+	util.PrintUtil("  -%s\tList the images that would be removed without removing them.\n", constants.DryRunFlag)
+	util.PrintUtil("\nExample: \tseed prune -o geoint -keep 2 -dry-run\n")
+	panic(util.Exit{0})
+}
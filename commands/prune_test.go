@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSelectPrunable(t *testing.T) {
+	entries := []ListEntry{
+		{Repository: "my-job-1.0.0-seed", Tag: "1.0.0"},
+		{Repository: "my-job-1.0.0-rc.1-seed", Tag: "1.0.0"},
+		{Repository: "my-job-1.10.0-seed", Tag: "0.1.0"},
+		{Repository: "my-job-1.2.0-seed", Tag: "2.0.0"},
+		{Repository: "my-job-1.2.0-seed", Tag: "10.0.0"},
+		{Repository: "my-job-1.2.0-seed", Tag: "<none>"},
+		{Repository: "geoint/extractor-0.1.0-seed", Tag: "0.1.0"},
+		{Repository: "localhost:5000/geoint/extractor-0.2.0-seed", Tag: "0.1.0"},
+		{Repository: "geoint/extractor-0.3.0-seed", Tag: "0.1.0"},
+		{Repository: "other/extractor-0.1.0-seed", Tag: "0.1.0"},
+		{Repository: "not-a-seed-image", Tag: "latest"},
+	}
+
+	cases := []struct {
+		org      string
+		keep     int
+		expected string
+	}{
+		{"", 2, "[{my-job-1.2.0-seed 2.0.0} {my-job-1.0.0-seed 1.0.0} {my-job-1.0.0-rc.1-seed 1.0.0}]"},
+		{"", 1, "[{geoint/extractor-0.1.0-seed 0.1.0} {my-job-1.2.0-seed 10.0.0} {my-job-1.2.0-seed 2.0.0} " +
+			"{my-job-1.0.0-seed 1.0.0} {my-job-1.0.0-rc.1-seed 1.0.0}]"},
+		{"geoint", 1, "[{geoint/extractor-0.1.0-seed 0.1.0}]"},
+		{"other", 1, "[]"},
+		{"", 5, "[]"},
+	}
+
+	for _, c := range cases {
+		var result []string
+		for _, e := range SelectPrunable(entries, c.org, c.keep) {
+			result = append(result, fmt.Sprintf("{%s %s}", e.Repository, e.Tag))
+		}
+		if r := fmt.Sprintf("%v", result); r != c.expected {
+			t.Errorf("SelectPrunable(%q, %d) == %s, expected %s", c.org, c.keep, r, c.expected)
+		}
+	}
+
+	if _, err := DockerPrune("", 0, true); err == nil {
+		t.Errorf("DockerPrune with -keep 0 returned no error, expected an error")
+	}
+}
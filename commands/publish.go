@@ -1,40 +1,146 @@
 package commands
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ngageoint/seed-cli/constants"
 	"github.com/ngageoint/seed-cli/objects"
+	RegistryFactory "github.com/ngageoint/seed-cli/registry"
 	"github.com/ngageoint/seed-cli/util"
 )
 
+//PublishOptions are the options of seed publish
+type PublishOptions struct {
+	ImageName    string
+	Registry     string
+	Org          string
+	Username     string
+	Password     string
+	JobDirectory string
+
+	// Force overwrites an existing image on the registry rather than rebuilding it with a new
+	// version
+	Force bool
+
+	// The version bumps of the rebuild when the image already exists on the registry: the major,
+	// minor or patch version of the package and of the job
+	PkgMajor bool
+	PkgMinor bool
+	PkgPatch bool
+	JobMajor bool
+	JobMinor bool
+	JobPatch bool
+
+	// Verify re-fetches the manifest of the pushed image from the registry and checks its digest
+	// and seed manifest match the local image
+	Verify bool
+
+	// DryRun resolves the image name and checks it against the registry, including any version
+	// bump, but writes, builds and pushes nothing; the planned action is printed instead
+	DryRun bool
+
+	// RetagOnly tags the local image for the registry and org and pushes it as it is, never
+	// rebuilt. It must be a seed image and fails on a conflict unless Force is set.
+	RetagOnly bool
+
+	// Tag replaces the tag of the image and bypasses the version bump; an existing image with
+	// that tag is overwritten
+	Tag string
+
+	// To is a reference giving the registry, org and tag together, in place of Registry, Org
+	// and Tag
+	To string
+
+	// DigestFile is written with the digest of the pushed image
+	DigestFile string
+
+	Sign util.SignOptions
+
+	// Build are the options of the rebuild with new versions, i.e. its Dockerfile and secrets.
+	// JobDirectory is that of the publish.
+	Build BuildOptions
+}
+
 //DockerPublish executes the seed publish command
-func DockerPublish(origImg, registry, org, username, password, jobDirectory string,
-	force, P, pm, pp, J, jm, jp bool) error {
+func DockerPublish(options PublishOptions) error {
+	origImg := options.ImageName
+	registry, org, targetTag := options.Registry, options.Org, options.Tag
+	username, password := options.Username, options.Password
+	jobDirectory := options.JobDirectory
+	force, dryRun, retagOnly, verify := options.Force, options.DryRun, options.RetagOnly, options.Verify
+	P, pm, pp := options.PkgMajor, options.PkgMinor, options.PkgPatch
+	J, jm, jp := options.JobMajor, options.JobMinor, options.JobPatch
+	to, digestFile, sign := options.To, options.DigestFile, options.Sign
 
 	if origImg == "" {
-		err := errors.New("ERROR: No input image specified.")
-		util.PrintUtil( "%s\n", err.Error())
+		err := errors.New("No input image specified.")
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
+
+	if err := sign.Check(); err != nil {
+		util.Errorf("%s\n", err.Error())
 		return err
 	}
 
+	origRef, err := util.ParseReference(origImg)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrInvalidArgument, err)
+	}
+
+	registry, org, targetTag, err = ResolvePublishTarget(origRef, to, registry, org, targetTag)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return wrapError(ErrInvalidArgument, err)
+	}
+
+	// The explicit tag replaces the package version tag of the image
+	explicitImg := ""
+	if targetTag != "" {
+		explicitImg = origRef.WithTag(targetTag).String()
+		if !util.IsValidImageReference(explicitImg) {
+			err := fmt.Errorf("Invalid -%s value %s. Tags may contain letters, digits, underscores, periods "+
+				"and dashes, may not start with a period or dash and may be up to 128 characters", constants.TagFlag,
+				targetTag)
+			util.Errorf("%s\n", err.Error())
+			return wrapError(ErrInvalidArgument, err)
+		}
+		if P || pm || pp || J || jm || jp {
+			util.Warnf("-%s %s given; the version bump flags are ignored\n", constants.TagFlag, targetTag)
+		}
+	}
+
 	if exists, err := util.ImageExists(origImg); !exists {
-		util.PrintUtil( "%s\n", err.Error())
+		if err == nil {
+			err = fmt.Errorf("Image %s not found. Build or pull the image before publishing it.", origImg)
+		}
+		util.Errorf("%s\n", err.Error())
 		return err
 	}
 
+	// Only the tag changes, so the image must already be a seed image
+	if retagOnly {
+		if err := checkSeedImage(origImg); err != nil {
+			util.Errorf("%s\n", err.Error())
+			return wrapError(ErrValidation, err)
+		}
+		if P || pm || pp || J || jm || jp {
+			util.Warnf("-%s given; the version bump flags are ignored\n", constants.RetagOnlyFlag)
+		}
+	}
+
+	username, password = util.ResolveCredentials(registry, username, password)
 
-	if username != "" {
+	if username != "" && !dryRun {
 		//set config dir so we don't stomp on other users' logins with sudo
 		configDir := constants.DockerConfigDir + time.Now().Format(time.RFC3339)
 		os.Setenv(constants.DockerConfigKey, configDir)
@@ -43,51 +149,69 @@ func DockerPublish(origImg, registry, org, username, password, jobDirectory stri
 
 		err := util.Login(registry, username, password)
 		if err != nil {
-			fmt.Println(err)
+			util.Errorf("%s\n", err.Error())
 		}
 	}
 
-	//1. Check names and verify it doesn't conflict
-	tag := ""
-	img := origImg
-
-	// docker tag if registry and/or org specified
-	if registry != "" || org != "" {
-		if org != "" {
-			tag = org + "/"
-		}
-		if registry != "" {
-			tag = registry + "/" + tag
+	//1. Check names and verify it doesn't conflict. The image is pushed to the registry and org,
+	// if specified, replacing those of the local image name
+	qualify := func(image string) string {
+		ref, err := util.ParseReference(image)
+		if err != nil {
+			return image
 		}
-
-		img = tag + img
+		return ref.Qualify(registry, org).String()
 	}
+	img := qualify(origImg)
 
 	// Check for image confliction.
-	images, err := DockerSearch(registry, org, "", username, password)
+	images, err := DockerSearch(registry, []string{org}, "", username, password, false)
 	if err != nil {
-		util.PrintUtil( "ERROR: Error searching for matching tag names.\n%s\n",
+		util.Errorf("Error searching for matching tag names.\n%s\n",
 			err.Error())
 	}
 	conflict := util.ContainsString(images, origImg)
+	if explicitImg != "" {
+		img = qualify(explicitImg)
+		conflict = util.ContainsString(images, explicitImg)
+	}
 	if conflict {
-		util.PrintUtil( "INFO: Image %s exists on registry %s\n", img, registry)
+		util.Infof("Image %s exists on registry %s\n", img, registry)
 	}
 
-	// If it conflicts, bump specified version number
-	if conflict && !force {
-		util.PrintUtil( "INFO: Force flag not specified, attempting to rebuild with new version number.\n")
+	// If it conflicts, bump specified version number. localImg is the local image tagged and
+	// pushed, the image rebuilt with the new version if there is one.
+	rebuilt := false
+	localImg := origImg
+	if explicitImg != "" {
+		if conflict && !force {
+			util.Warnf("Image %s already exists on registry %s and will be overwritten. Use -%s to "+
+				"overwrite it without this warning\n", img, registry, constants.ForcePublishFlag)
+		}
+	} else if retagOnly {
+		if conflict && !force {
+			err := fmt.Errorf("Image %s already exists on registry %s. Use -%s to overwrite it; -%s does not "+
+				"rebuild with new versions", img, registry, constants.ForcePublishFlag, constants.RetagOnlyFlag)
+			util.Errorf("%s\n", err.Error())
+			return err
+		}
+	} else if conflict && !force {
+		util.Infof("Force flag not specified, attempting to rebuild with new version number.\n")
 
 		//1. Verify we have a valid manifest (-d option or within the current directory)
 		seedFileName, err := util.SeedFileName(jobDirectory)
 		if err != nil {
-			util.PrintUtil( "ERROR: %s\n", err.Error())
+			util.Errorf("%s\n", err.Error())
 			return err
 		}
 		ValidateSeedFile("", seedFileName, constants.SchemaManifest)
-		seed := objects.SeedFromManifestFile(seedFileName)
+		seed, err := objects.SeedFromManifestFile(seedFileName)
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			return err
+		}
 
-		util.PrintUtil( "INFO: An image with the name %s already exists. ", img)
+		util.Infof("An image with the name %s already exists. ", img)
 		// Bump the package patch version
 		if pp {
 			pkgVersion := strings.Split(seed.Job.PackageVersion, ".")
@@ -152,72 +276,252 @@ func DockerPublish(origImg, registry, org, username, password, jobDirectory stri
 				seed.Job.JobVersion)
 		}
 		if !J && !jm && !jp && !P && !pm && !pp{
-			util.PrintUtil( "ERROR: No tag deconfliction method specified. Aborting seed publish.\n")
+			util.Errorf("No tag deconfliction method specified. Aborting seed publish.\n")
 			util.PrintUtil( "Exiting seed...\n")
 			return errors.New("Image exists and no tag deconfliction method specified.")
 		}
 
 		img = objects.BuildImageName(&seed)
 		util.PrintUtil( "\nNew image name: %s\n", img)
+		if util.ContainsString(images, img) {
+			util.Warnf("Image %s also exists on registry %s\n", img, registry)
+		}
+		rebuilt = true
+
+		if dryRun {
+			util.PrintUtil("%s\n", PublishPlan(origImg, qualify(img), registry, conflict, rebuilt, sign.Sign))
+			return nil
+		}
 
 		// write version back to the seed manifest
 		seedJSON, _ := json.Marshal(&seed)
 		err = ioutil.WriteFile(seedFileName, seedJSON, os.ModePerm)
 		if err != nil {
-			util.PrintUtil( "ERROR: Error occurred writing updated seed version to %s.\n%s\n",
+			util.Errorf("Error occurred writing updated seed version to %s.\n%s\n",
 				seedFileName, err.Error())
 			return errors.New("Error updating seed version in manifest.")
 		}
 
-		// Build Docker image
-		util.PrintUtil( "INFO: Building %s\n", img)
-		buildArgs := []string{"build", "-t", img, jobDirectory}
-		if util.DockerVersionHasLabel() {
-			// Set the seed.manifest.json contents as an image label
-			label := "com.ngageoint.seed.manifest=" + objects.GetManifestLabel(seedFileName)
-			buildArgs = append(buildArgs, "--label", label)
+		// Build Docker image with the Dockerfile and secrets of the build options. DockerBuild
+		// logs in to the registry of the base image with a docker config of its own, so the login
+		// to the publish registry is restored afterwards.
+		build := options.Build
+		build.JobDirectory = jobDirectory
+		configDir := os.Getenv(constants.DockerConfigKey)
+		err = DockerBuild(build)
+		if configDir != "" {
+			os.Setenv(constants.DockerConfigKey, configDir)
 		}
-		rebuildCmd := exec.Command("docker", buildArgs...)
-		var errs bytes.Buffer
-		rebuildCmd.Stderr = io.MultiWriter(os.Stderr, &errs)
-		rebuildCmd.Stdout = os.Stderr
-
-		// Run docker build
-		rebuildCmd.Run()
-
-		// check for errors on stderr
-		if errs.String() != "" {
-			util.PrintUtil( "ERROR: Error re-building image '%s':\n%s\n",
-				img, errs.String())
+		if err != nil {
+			util.Errorf("Error re-building image '%s'\n", img)
 			util.PrintUtil( "Exiting seed...\n")
-			return errors.New(errs.String())
+			return err
 		}
 
-		// Set final image name to tag + image
-		img = tag + img
+		// Push the rebuilt image to the registry and org
+		localImg = img
+		img = qualify(img)
 	}
 
-	err = util.Tag(origImg, img)
+	if dryRun {
+		util.PrintUtil("%s\n", PublishPlan(origImg, img, registry, conflict, rebuilt, sign.Sign))
+		return nil
+	}
+
+	err = util.Tag(localImg, img)
 	if err != nil {
 		return err
 	}
 
-	err = util.Push(img)
+	// Capture the digest from the push response if it is to be recorded or signed. The
+	// signed digest must be the one pushed, not one re-resolved from a tag that may have moved.
+	// Verified pushes also report the size of the pushed layers.
+	digest := ""
+	if digestFile != "" || sign.Sign || verify {
+		digest, err = util.PushDigest(img, verify)
+	} else {
+		err = util.Push(img)
+	}
 	if err != nil {
 		return err
 	}
 
+	if sign.Sign {
+		signed := util.DigestReference(img, digest)
+		util.Infof("Signing %s\n", signed)
+		err = util.SignImage(signed, sign.PrivateKey)
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			util.PrintUtil( "Exiting seed...\n")
+			return err
+		}
+		util.PrintUtil("Signed %s\n", signed)
+	}
+
+	if verify {
+		err = VerifyPublish(img, registry, username, password)
+		if err != nil {
+			util.Errorf("Error verifying published image '%s':\n%s\n", img, err.Error())
+			util.PrintUtil( "Exiting seed...\n")
+			return err
+		}
+	}
+
+	if digestFile != "" {
+		err = ioutil.WriteFile(digestFile, []byte(digest+"\n"), 0644)
+		if err != nil {
+			util.Errorf("Error writing digest file %s: %s\n", digestFile, err.Error())
+			return err
+		}
+		util.Infof("Wrote digest %s to %s\n", digest, digestFile)
+	}
+
 	err = util.RemoveImage(img)
 	if err != nil {
 		return err
 	}
 
+	util.PrintUtil("Successfully published %s\n", img)
+	return nil
+}
+
+//checkSeedImage returns an error if a local image does not have a valid seed manifest label
+func checkSeedImage(img string) error {
+	label, err := util.ImageLabel(img, constants.ManifestLabel)
+	if err != nil {
+		return err
+	}
+	if label == "" {
+		return fmt.Errorf("Image %s has no %s label and is not a seed image", img, constants.ManifestLabel)
+	}
+	schema, err := LoadSchema("", constants.SchemaManifest)
+	if err != nil {
+		return err
+	}
+	if err := checkManifestLabel(schema, label); err != nil {
+		return fmt.Errorf("Image %s is not a valid seed image. %s", img, err.Error())
+	}
+	return nil
+}
+
+//ResolvePublishTarget returns the registry, org and tag to publish the image ref to. Without a
+// to reference they are those given; otherwise they are read from to, which may not be combined
+// with a registry or org. The reference must name the registry and the repository of the image,
+// as seed image names are derived from the manifest, and may not be by digest. A tag in the
+// reference replaces the package version tag as -tag does.
+func ResolvePublishTarget(ref util.Reference, to, registry, org, tag string) (string, string, string, error) {
+	if to == "" {
+		return registry, org, tag, nil
+	}
+	if registry != "" || org != "" {
+		return "", "", "", fmt.Errorf("-%s may not be combined with -%s or -%s; give the registry and "+
+			"organization in the reference", constants.ToFlag, constants.RegistryFlag, constants.OrgFlag)
+	}
+	target, err := util.ParseReference(to)
+	if err != nil {
+		return "", "", "", fmt.Errorf("Invalid -%s value: %s", constants.ToFlag, err.Error())
+	}
+	if target.Digest != "" {
+		return "", "", "", fmt.Errorf("Invalid -%s value %s. Images are pushed to a tag, not a digest",
+			constants.ToFlag, to)
+	}
+	if target.Registry == "" {
+		return "", "", "", fmt.Errorf("Invalid -%s value %s. The reference must include the registry, "+
+			"i.e. docker.io/%s", constants.ToFlag, to, target.Name())
+	}
+	if target.Repo != ref.Repo {
+		return "", "", "", fmt.Errorf("Invalid -%s value %s. The repository must be %s, the name of the "+
+			"image, as seed image names are derived from the manifest", constants.ToFlag, to, ref.Repo)
+	}
+	if target.Org == "" && ref.Org != "" {
+		return "", "", "", fmt.Errorf("Invalid -%s value %s. The reference must include an organization "+
+			"as the image %s has one", constants.ToFlag, to, ref.String())
+	}
+	if target.Tag != "" {
+		if tag != "" && tag != target.Tag {
+			return "", "", "", fmt.Errorf("-%s %s and -%s %s give different tags", constants.ToFlag, to,
+				constants.TagFlag, tag)
+		}
+		tag = target.Tag
+	}
+	return target.Registry, target.Org, tag, nil
+}
+
+//PublishPlan describes what seed publish would do with an image: push it as img, overwrite
+// an existing image on the registry, or push a rebuild with bumped versions
+func PublishPlan(origImg, img, registry string, conflict, rebuilt, sign bool) string {
+	if registry == "" {
+		registry = "the default registry"
+	}
+	plan := "Dry run: "
+	switch {
+	case rebuilt:
+		plan += fmt.Sprintf("would write the new versions to the seed manifest, rebuild %s as %s and push it to "+
+			"%s", origImg, img, registry)
+	case conflict:
+		plan += fmt.Sprintf("would overwrite the existing %s on %s with %s", img, registry, origImg)
+	default:
+		plan += fmt.Sprintf("would tag %s as %s and push it to %s", origImg, img, registry)
+	}
+	if sign {
+		plan += ", then sign the pushed digest"
+	}
+	return plan
+}
+
+//VerifyPublish re-fetches the manifest of a pushed image from the registry and verifies
+// the digest and embedded seed manifest match the local image
+func VerifyPublish(img, registry, username, password string) error {
+	util.Infof("Verifying %s against registry %s\n", img, registry)
+
+	localDigest, err := util.ImageRepoDigest(img)
+	if err != nil {
+		return err
+	}
+
+	// The repository and tag of the image on the registry
+	ref, err := util.ParseReference(img)
+	if err != nil {
+		return err
+	}
+	repository, tag := ref.Name(), ref.TagOrDefault()
+
+	reg, err := RegistryFactory.CreateRegistry(registry, username, password)
+	if err != nil {
+		return errors.New(checkError(err, registry, username, password))
+	}
+
+	remoteDigest, err := reg.ImageDigest(repository, tag)
+	if err != nil {
+		return err
+	}
+	if remoteDigest != localDigest {
+		return fmt.Errorf("Digest mismatch: pushed %s but registry reports %s", localDigest, remoteDigest)
+	}
+
+	label, err := reg.ImageManifest(repository, tag)
+	if err != nil {
+		return err
+	}
+	remoteSeed, err := objects.ParseManifestLabel(label)
+	if err != nil {
+		return err
+	}
+	localSeed, err := objects.SeedFromImageLabel(img)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(localSeed, remoteSeed) {
+		return errors.New("Seed manifest on registry does not match the local image")
+	}
+
+	util.PrintUtil( "SUCCESS: Verified %s with digest %s\n", img, remoteDigest)
 	return nil
 }
 
 //PrintPublishUsage prints the seed publish usage information, then exits the program
 func PrintPublishUsage() {
-	util.PrintUtil( "\nUsage:\tseed publish -in IMAGE_NAME [-r REGISTRY_NAME] [-o ORG_NAME] [-u username] [-p password] [Conflict Options]\n")
+	util.PrintUtil( "\nUsage:\tseed publish -in IMAGE_NAME [-r REGISTRY_NAME] [-o ORG_NAME | -to REFERENCE] [-u username] [-p password] [Conflict Options]\n")
 	util.PrintUtil( "\nAllows for the publish of seed compliant images.\n")
 	util.PrintUtil( "\nOptions:\n")
 	util.PrintUtil( "  -%s -%s Docker image name to publish\n",
@@ -230,8 +534,29 @@ func PrintPublishUsage() {
 		constants.ShortUserFlag, constants.UserFlag)
 	util.PrintUtil( "  -%s -%s\tPassword to login if needed to publish images (default anonymous).\n",
 		constants.ShortPassFlag, constants.PassFlag)
+	util.PrintUtil("  -%s\t\tPublish to a full image reference, i.e. registry.example.com/geoint/IMAGE_NAME,\n"+
+		"\t\tin place of -%s and -%s. A tag in the reference is used as -%s is\n",
+		constants.ToFlag, constants.RegistryFlag, constants.OrgFlag, constants.TagFlag)
 	util.PrintUtil( "  -%s\t\tOverwrite remote image if publish conflict found\n",
 		constants.ForcePublishFlag)
+	util.PrintUtil("  -%s\t\tPublish the image with this tag instead of its package version, bypassing the\n"+
+		"\t\tversion bump. Warns if the tag already exists on the registry unless -%s is given\n",
+		constants.TagFlag, constants.ForcePublishFlag)
+	util.PrintUtil("  -%s\tResolve the image name and check for conflicts on the registry, then print the\n"+
+		"\t\tplanned tag and action without writing, building or pushing anything\n", constants.DryRunFlag)
+	util.PrintUtil("  -%s\tTag the local seed image for the registry and organization and push it without\n"+
+		"\t\trebuilding. Fails if the image exists on the registry unless -%s is given\n",
+		constants.RetagOnlyFlag, constants.ForcePublishFlag)
+	util.PrintUtil("  -%s\tWrite the digest of the pushed image to the given file\n",
+		constants.DigestFileFlag)
+	util.PrintUtil("  -%s\tVerify the digest and seed manifest of the image on the registry after pushing\n",
+		constants.VerifyFlag)
+	util.PrintUtil("  -%s\t\tSign the pushed digest with cosign and push the signature to the registry\n",
+		constants.SignFlag)
+	util.PrintUtil("  -%s\tCosign private key file or KMS URI used by -%s; an encrypted key's password\n"+
+		"\t\tis read from COSIGN_PASSWORD\n", constants.PrivateKeyFlag, constants.SignFlag)
+	util.PrintUtil("  -%s -%s\tSuppress docker build and push progress output; errors are still reported\n",
+		constants.ShortQuietFlag, constants.QuietFlag)
 
 	util.PrintUtil( "\nConflict Options:\n")
 	util.PrintUtil( "If the force flag (-f) is not set, the following options specify how a publish conflict is handled:\n")
@@ -249,6 +574,10 @@ func PrintPublishUsage() {
 		constants.JobVersionMinor)
 	util.PrintUtil( "  -%s\t\tForce Major version bump of 'jobVersion' in manifest on disk if publish conflict found\n",
 		constants.JobVersionMajor)
+	util.PrintUtil("  -%s\t\tDockerfile to rebuild with, relative to the job directory (default is Dockerfile)\n",
+		constants.DockerfileFlag)
+	util.PrintUtil("  -%s\t\tBuildKit secret of the rebuild given as id=ID,src=PATH or id=ID,env=VARIABLE; may be\n"+
+		"\t\trepeated\n", constants.SecretFlag)
 
 	util.PrintUtil( "\nExample: \tseed publish -in example-0.1.3-seed:0.1.3 -r hub.docker.com -o geoint -j path/to/example -jm -P\n")
 	util.PrintUtil( "\nThis will build a new image example-0.2.0-seed:1.0.0 and publish it to hub.docker.com/geoint\n")
@@ -1,9 +1,15 @@
 package commands
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os/exec"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ngageoint/seed-cli/util"
 )
@@ -19,7 +25,7 @@ func TestDockerPublish(t *testing.T) {
 	imgDirs := []string{"../testdata/complete/"}
 	imgNames := []string{"my-job-0.1.0-seed:0.1.0"}
 	for _, dir := range imgDirs {
-		err := DockerBuild(dir, "", "")
+		err := DockerBuild(BuildOptions{JobDirectory: dir})
 		if err != nil {
 			t.Errorf("Error building image %v for DockerPublish test", dir)
 		}
@@ -37,27 +43,30 @@ func TestDockerPublish(t *testing.T) {
 		jobpatch         bool
 		jobmin           bool
 		jobmaj           bool
+		verify           bool
 		expectedImgName  string
 		expected         bool
 		expectedErrorMsg string
 	}{
 		{imgDirs[0], imgNames[0], "localhost:5000", "",
-			false, false, false, false, false, false, false,
+			false, false, false, false, false, false, false, false,
 			"localhost:5000/my-job-0.1.0-seed:0.1.0", true, ""},
 		{imgDirs[0], imgNames[0], "localhost:5000", "",
-			true, false, false, false, false, false, false,
+			true, false, false, false, false, false, false, true,
 			"localhost:5000/my-job-0.1.0-seed:0.1.0", true, ""},
 		{imgDirs[0], imgNames[0], "localhost:5000", "",
-			false, false, false, false, false, false, false,
+			false, false, false, false, false, false, false, false,
 			"localhost:5000/my-job-0.1.0-seed:0.1.0", false, "Image exists and no tag deconfliction method specified."},
 		{imgDirs[0], imgNames[0], "localhost:5000", "",
-			false, false, false, true, true, false, false,
+			false, false, false, true, true, false, false, false,
 			"localhost:5000/my-job-0.1.1-seed:1.0.0", true, ""},
 	}
 
 	for _, c := range cases {
-		err := DockerPublish(c.imageName, c.registry, c.org, "testuser", "testpassword", c.directory,
-			c.force, c.pkgmaj, c.pkgmin, c.pkgpatch, c.jobmaj, c.jobmin, c.jobpatch)
+		err := DockerPublish(PublishOptions{ImageName: c.imageName, Registry: c.registry, Org: c.org,
+			Username: "testuser", Password: "testpassword", JobDirectory: c.directory, Force: c.force,
+			PkgMajor: c.pkgmaj, PkgMinor: c.pkgmin, PkgPatch: c.pkgpatch, JobMajor: c.jobmaj, JobMinor: c.jobmin,
+			JobPatch: c.jobpatch, Verify: c.verify})
 
 		if err != nil && c.expected == true {
 			t.Errorf("DockerPublish returned an error: %v\n", err)
@@ -73,3 +82,323 @@ func TestDockerPublish(t *testing.T) {
 		}
 	}
 }
+
+func TestDockerPublishRebuild(t *testing.T) {
+	// A V2 registry holding the image being published
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v2/":
+			fmt.Fprint(w, "{}")
+		case "/v2/_catalog":
+			fmt.Fprint(w, `{"repositories":["addition-job-0.0.1-seed"]}`)
+		case "/v2/addition-job-0.0.1-seed/tags/list":
+			fmt.Fprint(w, `{"name":"addition-job-0.0.1-seed","tags":["1.0.0"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registry.Close()
+
+	fake := &util.FakeDockerRunner{Responses: []util.FakeDockerResponse{
+		{Args: []string{"version"}, Stdout: "20.10.7\n"},
+		{Args: []string{"images", "-q", "addition-job-0.0.1-seed:1.0.0"}, Stdout: "0123456789ab\n"},
+	}}
+	defer util.SetDockerRunner(util.SetDockerRunner(fake))
+
+	// The conflicting image is rebuilt with the bumped version, which is tagged and pushed
+	job := copyJob(t, "../examples/addition-job/")
+	err := DockerPublish(PublishOptions{ImageName: "addition-job-0.0.1-seed:1.0.0", Registry: registry.URL,
+		JobDirectory: job, PkgPatch: true})
+	if err != nil {
+		t.Fatalf("DockerPublish of a conflicting image returned %v", err)
+	}
+	var tag, push []string
+	for _, call := range fake.Calls() {
+		switch call[0] {
+		case "tag":
+			tag = call
+		case "push":
+			push = call
+		}
+	}
+	if len(tag) != 3 || tag[1] != "addition-job-0.0.1-seed:1.0.1" ||
+		!strings.HasSuffix(tag[2], "/addition-job-0.0.1-seed:1.0.1") {
+		t.Errorf("DockerPublish ran docker %q, expected the rebuilt addition-job-0.0.1-seed:1.0.1 to be tagged", tag)
+	}
+	if len(push) < 2 || push[len(push)-1] != tag[2] {
+		t.Errorf("DockerPublish ran docker %q, expected %s to be pushed", push, tag[2])
+	}
+}
+
+func TestParsePushDigest(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab12", 16)
+	cases := []struct {
+		output           string
+		expected         string
+		expectedErrorMsg string
+	}{
+		{"The push refers to repository [localhost:5000/my-job-0.1.0-seed]\n" +
+			"5bef08742407: Pushed\n0.1.0: digest: " + digest + " size: 528\n", digest, ""},
+		{"0.1.0: digest: sha256:0000 size: 1\nlatest: digest: " + digest + " size: 528\n", digest, ""},
+		{"5bef08742407: Layer already exists\n", "", "No digest found"},
+	}
+
+	for _, c := range cases {
+		result, err := util.ParsePushDigest(c.output)
+		if result != c.expected {
+			t.Errorf("ParsePushDigest(%q) == %q, expected %q", c.output, result, c.expected)
+		}
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("ParsePushDigest(%q) == %v, expected %v", c.output, err.Error(), c.expectedErrorMsg)
+			}
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("ParsePushDigest(%q) returned no error, expected %v", c.output, c.expectedErrorMsg)
+		}
+	}
+}
+
+func TestPushSummary(t *testing.T) {
+	output := "The push refers to repository [localhost:5000/my-job-0.1.0-seed]\n" +
+		"5bef08742407: Preparing\n9a1b2c3d4e5f: Preparing\n0f0e0d0c0b0a: Preparing\n" +
+		"5bef08742407: Pushed\n9a1b2c3d4e5f: Layer already exists\n" +
+		"0f0e0d0c0b0a: Mounted from library/alpine\n0.1.0: digest: sha256:0000 size: 528\n"
+	pushed, skipped := util.ParsePushLayers(output)
+	if !reflect.DeepEqual(pushed, []string{"5bef08742407"}) || skipped != 2 {
+		t.Errorf("ParsePushLayers() == %q, %d, expected [5bef08742407], 2", pushed, skipped)
+	}
+
+	layers := []string{"sha256:0f0e0d0c0b0a" + strings.Repeat("0", 52), "sha256:9a1b2c3d4e5f" + strings.Repeat("0", 52),
+		"sha256:5bef08742407" + strings.Repeat("0", 52)}
+	cases := []struct {
+		pushed   []string
+		sizes    []int64
+		expected int64
+	}{
+		{pushed, []int64{100, 200, 3 * 1024 * 1024}, 3 * 1024 * 1024},
+		{[]string{"5bef08742407", "9a1b2c3d4e5f"}, []int64{100, 200, 300}, 500},
+		{nil, []int64{100, 200, 300}, 0},
+		{pushed, []int64{100, 200}, -1},
+		{[]string{"ffffffffffff"}, []int64{100, 200, 300}, -1},
+	}
+	for _, c := range cases {
+		if result := util.PushedBytes(c.pushed, layers, c.sizes); result != c.expected {
+			t.Errorf("PushedBytes(%q, %v) == %d, expected %d", c.pushed, c.sizes, result, c.expected)
+		}
+	}
+
+	summary := util.PushSummary{Pushed: pushed, Skipped: skipped, Bytes: 3 * 1024 * 1024, Duration: 8149 * time.Millisecond}
+	expected := "Pushed 1 layer (3.0 MiB), skipped 2 already in the registry, in 8.1s"
+	if summary.String() != expected {
+		t.Errorf("PushSummary.String() == %q, expected %q", summary.String(), expected)
+	}
+	summary.Bytes = -1
+	expected = "Pushed 1 layer, skipped 2 already in the registry, in 8.1s"
+	if summary.String() != expected {
+		t.Errorf("PushSummary.String() == %q, expected %q", summary.String(), expected)
+	}
+
+	// The registry is only asked for the layer sizes when the push is measured
+	fake := &util.FakeDockerRunner{}
+	defer util.SetDockerRunner(util.SetDockerRunner(fake))
+	output = "5bef08742407: Pushed\n9a1b2c3d4e5f: Pushed\n"
+	summary = util.NewPushSummary("localhost:5000/my-job:1.0.0", output, 8149*time.Millisecond, false)
+	expected = "Pushed 2 layers, skipped 0 already in the registry, in 8.1s"
+	if summary.String() != expected || len(fake.Calls()) != 0 {
+		t.Errorf("NewPushSummary without measuring == %q and ran docker %q, expected %q and no docker commands",
+			summary.String(), fake.Calls(), expected)
+	}
+}
+
+func TestSignOptions(t *testing.T) {
+	cases := []struct {
+		options          util.SignOptions
+		expectedErrorMsg string
+	}{
+		{util.SignOptions{}, ""},
+		{util.SignOptions{PrivateKey: "missing.key"}, ""},
+		{util.SignOptions{Sign: true}, "requires a private key"},
+		{util.SignOptions{Sign: true, PrivateKey: "missing.key"}, "Error reading private key missing.key"},
+	}
+
+	for _, c := range cases {
+		err := c.options.Check()
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("Check(%+v) == %v, expected %v", c.options, err.Error(), c.expectedErrorMsg)
+			}
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("Check(%+v) returned no error, expected %v", c.options, c.expectedErrorMsg)
+		}
+	}
+
+	if err := util.SignImage("localhost:5000/my-job-0.1.0-seed:0.1.0", "cosign.key"); err == nil ||
+		!strings.Contains(err.Error(), "must be signed by digest") {
+		t.Errorf("SignImage by tag returned %v, expected an error", err)
+	}
+
+	refs := []struct {
+		img      string
+		expected string
+	}{
+		{"localhost:5000/my-job-0.1.0-seed:0.1.0", "localhost:5000/my-job-0.1.0-seed@sha256:abc"},
+		{"localhost:5000/my-job-0.1.0-seed", "localhost:5000/my-job-0.1.0-seed@sha256:abc"},
+		{"org/my-job-0.1.0-seed@sha256:def", "org/my-job-0.1.0-seed@sha256:abc"},
+	}
+	for _, r := range refs {
+		if ref := util.DigestReference(r.img, "sha256:abc"); ref != r.expected {
+			t.Errorf("DigestReference(%q) == %q, expected %q", r.img, ref, r.expected)
+		}
+	}
+}
+
+func TestPublishPlan(t *testing.T) {
+	cases := []struct {
+		img      string
+		registry string
+		conflict bool
+		rebuilt  bool
+		sign     bool
+		expected string
+	}{
+		{"localhost:5000/my-job-0.1.0-seed:1.0.0", "localhost:5000", false, false, false,
+			"Dry run: would tag my-job-0.1.0-seed:1.0.0 as localhost:5000/my-job-0.1.0-seed:1.0.0 and push it to localhost:5000"},
+		{"localhost:5000/my-job-0.1.0-seed:1.0.0", "localhost:5000", true, false, true,
+			"Dry run: would overwrite the existing localhost:5000/my-job-0.1.0-seed:1.0.0 on localhost:5000 with " +
+				"my-job-0.1.0-seed:1.0.0, then sign the pushed digest"},
+		{"geoint/my-job-0.1.0-seed:2.0.0", "", true, true, false,
+			"Dry run: would write the new versions to the seed manifest, rebuild my-job-0.1.0-seed:1.0.0 as " +
+				"geoint/my-job-0.1.0-seed:2.0.0 and push it to the default registry"},
+	}
+
+	for _, c := range cases {
+		plan := PublishPlan("my-job-0.1.0-seed:1.0.0", c.img, c.registry, c.conflict, c.rebuilt, c.sign)
+		if plan != c.expected {
+			t.Errorf("PublishPlan(%q, %v, %v) == %q, expected %q", c.img, c.conflict, c.rebuilt, plan, c.expected)
+		}
+	}
+}
+
+func TestPublishTag(t *testing.T) {
+	cases := []struct {
+		image      string
+		repository string
+	}{
+		{"my-job-0.1.0-seed:1.0.0", "my-job-0.1.0-seed"},
+		{"localhost:5000/geoint/my-job-0.1.0-seed:1.0.0", "localhost:5000/geoint/my-job-0.1.0-seed"},
+		{"localhost:5000/my-job-0.1.0-seed", "localhost:5000/my-job-0.1.0-seed"},
+	}
+
+	for _, c := range cases {
+		ref, err := util.ParseReference(c.image)
+		if err != nil || ref.Repository() != c.repository {
+			t.Errorf("ParseReference(%q).Repository() == %q, %v, expected %q", c.image, ref.Repository(), err,
+				c.repository)
+		}
+	}
+
+	for _, tag := range []string{"-dev", ".dev", "dev:1", "dev/1"} {
+		err := DockerPublish(PublishOptions{ImageName: "my-job-0.1.0-seed:1.0.0", Registry: "localhost:5000",
+			JobDirectory: ".", DryRun: true, Tag: tag})
+		if !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("DockerPublish with -tag %q returned %v, expected an invalid argument error", tag, err)
+		}
+	}
+}
+
+func TestResolvePublishTarget(t *testing.T) {
+	cases := []struct {
+		image    string
+		to       string
+		registry string
+		org      string
+		tag      string
+		expected string
+		errorMsg string
+	}{
+		{"my-job-0.1.0-seed:1.0.0", "", "localhost:5000", "geoint", "", "localhost:5000 geoint ", ""},
+		{"my-job-0.1.0-seed:1.0.0", "registry.example.com/team/my-job-0.1.0-seed:1.2.3", "", "", "",
+			"registry.example.com team 1.2.3", ""},
+		{"my-job-0.1.0-seed:1.0.0", "localhost:5000/a/b/my-job-0.1.0-seed", "", "", "", "localhost:5000 a/b ", ""},
+		{"my-job-0.1.0-seed:1.0.0", "localhost:5000/my-job-0.1.0-seed:dev", "", "", "dev", "localhost:5000  dev", ""},
+		{"my-job-0.1.0-seed:1.0.0", "localhost:5000/geoint/my-job-0.1.0-seed", "localhost:5000", "", "", "",
+			"may not be combined"},
+		{"my-job-0.1.0-seed:1.0.0", "localhost:5000/geoint/my-job-0.1.0-seed", "", "geoint", "", "",
+			"may not be combined"},
+		{"my-job-0.1.0-seed:1.0.0", "Registry/My-Job", "", "", "", "", "Invalid image reference"},
+		{"my-job-0.1.0-seed:1.0.0", "geoint/my-job-0.1.0-seed:1.0.0", "", "", "", "", "must include the registry"},
+		{"my-job-0.1.0-seed:1.0.0", "localhost:5000/geoint/other-0.1.0-seed:1.0.0", "", "", "", "",
+			"repository must be my-job-0.1.0-seed"},
+		{"geoint/my-job-0.1.0-seed:1.0.0", "localhost:5000/my-job-0.1.0-seed:1.0.0", "", "", "", "",
+			"must include an organization"},
+		{"my-job-0.1.0-seed:1.0.0", "localhost:5000/my-job-0.1.0-seed@sha256:0123456789abcdef0123456789abcdef",
+			"", "", "", "", "not a digest"},
+		{"my-job-0.1.0-seed:1.0.0", "localhost:5000/my-job-0.1.0-seed:1.2.3", "", "", "dev", "",
+			"different tags"},
+	}
+
+	for _, c := range cases {
+		ref, _ := util.ParseReference(c.image)
+		registry, org, tag, err := ResolvePublishTarget(ref, c.to, c.registry, c.org, c.tag)
+		if c.errorMsg != "" {
+			if err == nil || !strings.Contains(err.Error(), c.errorMsg) {
+				t.Errorf("ResolvePublishTarget(%q, %q) returned %v, expected an error containing %q", c.image,
+					c.to, err, c.errorMsg)
+			}
+			continue
+		}
+		if result := registry + " " + org + " " + tag; err != nil || result != c.expected {
+			t.Errorf("ResolvePublishTarget(%q, %q) == %q, %v, expected %q", c.image, c.to, result, err,
+				c.expected)
+		}
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	cases := []struct {
+		ref           string
+		expected      util.Reference
+		expectedError bool
+	}{
+		{"my-job-0.1.0-seed:1.0.0", util.Reference{Repo: "my-job-0.1.0-seed", Tag: "1.0.0"}, false},
+		{"geoint/my-job-0.1.0-seed", util.Reference{Org: "geoint", Repo: "my-job-0.1.0-seed"}, false},
+		{"localhost/my-job", util.Reference{Registry: "localhost", Repo: "my-job"}, false},
+		{"localhost:5000/geoint/my-job-0.1.0-seed:1.0.0",
+			util.Reference{Registry: "localhost:5000", Org: "geoint", Repo: "my-job-0.1.0-seed", Tag: "1.0.0"}, false},
+		{"registry.example.com/a/b/my-job@sha256:0123456789abcdef0123456789abcdef",
+			util.Reference{Registry: "registry.example.com", Org: "a/b", Repo: "my-job",
+				Digest: "sha256:0123456789abcdef0123456789abcdef"}, false},
+		{"My-Job:1.0.0", util.Reference{}, true},
+		{"", util.Reference{}, true},
+	}
+
+	for _, c := range cases {
+		ref, err := util.ParseReference(c.ref)
+		if (err != nil) != c.expectedError || ref != c.expected {
+			t.Errorf("ParseReference(%q) == %+v, %v, expected %+v", c.ref, ref, err, c.expected)
+		}
+		if err == nil && ref.String() != c.ref {
+			t.Errorf("ParseReference(%q).String() == %q, expected %q", c.ref, ref.String(), c.ref)
+		}
+	}
+
+	ref, _ := util.ParseReference("geoint/my-job-0.1.0-seed:1.0.0")
+	qualified := []struct {
+		registry string
+		org      string
+		expected string
+	}{
+		{"", "", "geoint/my-job-0.1.0-seed:1.0.0"},
+		{"https://registry.example.com/", "", "registry.example.com/geoint/my-job-0.1.0-seed:1.0.0"},
+		{"localhost:5000", "other", "localhost:5000/other/my-job-0.1.0-seed:1.0.0"},
+	}
+	for _, q := range qualified {
+		if r := ref.Qualify(q.registry, q.org).String(); r != q.expected {
+			t.Errorf("Qualify(%q, %q) == %q, expected %q", q.registry, q.org, r, q.expected)
+		}
+	}
+	if tag := ref.WithTag("").TagOrDefault(); tag != util.DefaultTag {
+		t.Errorf("TagOrDefault() == %q, expected %q", tag, util.DefaultTag)
+	}
+}
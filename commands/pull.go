@@ -6,15 +6,40 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/ngageoint/seed-cli/constants"
 	"github.com/ngageoint/seed-cli/util"
 )
 
-//Dockerpull pulls specified image from remote repository (default docker.io)
-func DockerPull(image, registry, org, username, password string) error {
+//PullResult is the outcome of pulling one image with seed pull
+type PullResult struct {
+	Image string
+	Err   error
+}
+
+//DockerPull pulls the specified images from a remote repository (default docker.io), up to jobs
+// at a time. Transient failures are retried up to retries times. If requested, the signature of
+// each pulled image is verified before it is tagged as a local image. Every image is attempted
+// even if others fail; when more than one is given the result of each is printed at the end.
+// Returns an error if any image could not be pulled.
+func DockerPull(images []string, registry, org, username, password string, retries, jobs int,
+	verify util.VerifyOptions) error {
+	if err := verify.Check(); err != nil {
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
+	if len(images) == 0 {
+		err := errors.New("No image specified to pull.")
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
+
+	username, password = util.ResolveCredentials(registry, username, password)
 	if username != "" {
 		//set config dir so we don't stomp on other users' logins with sudo
 		configDir := constants.DockerConfigDir + time.Now().Format(time.RFC3339)
@@ -24,7 +49,7 @@ func DockerPull(image, registry, org, username, password string) error {
 
 		err := util.Login(registry, username, password)
 		if err != nil {
-			fmt.Println(err)
+			util.Errorf("%s\n", err.Error())
 			return err
 		}
 	}
@@ -33,61 +58,265 @@ func DockerPull(image, registry, org, username, password string) error {
 		registry = constants.DefaultRegistry
 	}
 
-	remoteImage := fmt.Sprintf("%s/%s", registry, image)
+	if len(images) == 1 {
+		return pullImage(images[0], registry, org, retries, verify)
+	}
 
-	if org != "" {
-		remoteImage = fmt.Sprintf("%s/%s/%s", registry, org, image)
+	if jobs < 1 {
+		jobs = 1
+	}
+	// A progress bar per concurrent pull would overwrite the others on the terminal
+	if jobs > 1 && util.ProgressMode() == constants.ProgressBar {
+		util.Infof("Showing plain progress output for %d concurrent pulls\n", jobs)
+		util.SetProgressMode(constants.ProgressPlain)
+		defer util.SetProgressMode(constants.ProgressBar)
+	}
+	results := make([]PullResult, len(images))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs && w < len(images); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = PullResult{Image: images[i], Err: pullImage(images[i], registry, org, retries, verify)}
+			}
+		}()
 	}
+	for i := range images {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
 
-	var errs, out bytes.Buffer
-	// pull image
-	pullArgs := []string{"pull", remoteImage}
-	pullCmd := exec.Command("docker", pullArgs...)
-	pullCmd.Stderr = io.MultiWriter(os.Stderr, &errs)
-	pullCmd.Stdout = &out
+	summary, failed := PullSummary(results)
+	util.PrintUtil("\n%s", summary)
+	if failed > 0 {
+		err := fmt.Errorf("%d of %d images failed to pull", failed, len(images))
+		util.Errorf("%s\n", err.Error())
+		return err
+	}
+	return nil
+}
+
+//PullSummary returns a table of the result of each pull and the number of pulls that failed
+func PullSummary(results []PullResult) (string, int) {
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tRESULT")
+	failed := 0
+	for _, r := range results {
+		result := "pulled"
+		if r.Err != nil {
+			failed++
+			result = "failed: " + strings.Join(strings.Fields(r.Err.Error()), " ")
+		}
+		fmt.Fprintf(w, "%s\t%s\n", r.Image, result)
+	}
+	w.Flush()
+	return buffer.String(), failed
+}
 
-	err := pullCmd.Run()
+//pullImage pulls a single image from the registry, optionally verifies its signature and tags it
+// as a local image
+func pullImage(image, registry, org string, retries int, verify util.VerifyOptions) error {
+	ref, err := util.ParseReference(image)
 	if err != nil {
-		util.PrintUtil( "ERROR: Error executing docker pull.\n%s\n",
-			err.Error())
+		util.Errorf("%s\n", err.Error())
 		return err
 	}
+	remoteImage := ref.Qualify(registry, org).String()
 
-	if errs.String() != "" {
-		util.PrintUtil( "ERROR: Error reading stderr %s\n",
-			errs.String())
-		return errors.New(errs.String())
+	// pull image
+	err = pullWithRetries(remoteImage, retries)
+	if err != nil {
+		return err
+	}
+
+	if verify.Verify {
+		if err := verifyPulledImage(remoteImage, verify); err != nil {
+			util.Errorf("%s\n", err.Error())
+			util.RemoveImage(remoteImage)
+			return err
+		}
 	}
 
 	// tag image
+	var errs, out bytes.Buffer
 	tagArgs := []string{"tag", remoteImage, image}
-	tagCmd := exec.Command("docker", tagArgs...)
-	tagCmd.Stderr = io.MultiWriter(os.Stderr, &errs)
-	tagCmd.Stdout = &out
-
-	err = tagCmd.Run()
+	util.DebugCommand("docker", tagArgs)
+	err = util.RunDocker(util.DockerCommand{Args: tagArgs, Stdout: &out,
+		Stderr: io.MultiWriter(util.ProgressWriter(), &errs)})
 	if err != nil {
-		util.PrintUtil( "ERROR: Error executing docker tag.\n%s\n",
+		util.Errorf("Error executing docker tag.\n%s\n",
 			err.Error())
 		return err
 	}
 
 	if errs.String() != "" {
-		util.PrintUtil( "ERROR: Error reading stderr %s\n",
+		util.Errorf("Error reading stderr %s\n",
 			errs.String())
 		return errors.New(errs.String())
 	}
 
+	util.PrintUtil("Successfully pulled %s as %s\n", remoteImage, image)
+	return nil
+}
+
+//pullRetryDelay is the wait before the first retry of a failed pull, doubled after each retry
+// up to maxPullRetryDelay
+var pullRetryDelay = 2 * time.Second
+
+const maxPullRetryDelay = 30 * time.Second
+
+//pullWithRetries runs docker pull, retrying transient failures with exponential backoff. Docker
+// keeps the layers a failed pull completed, and nothing is removed between attempts, so each
+// retry only downloads the layers that did not complete.
+func pullWithRetries(remoteImage string, retries int) error {
+	delay := pullRetryDelay
+	for attempt := 0; ; attempt++ {
+		output, err := pull(remoteImage)
+		if err == nil {
+			return nil
+		}
+
+		complete, total := PullLayerProgress(output)
+		if attempt >= retries || !IsTransientPullError(output) {
+			util.Errorf("Error executing docker pull.\n%s\n", err.Error())
+			if attempt > 0 {
+				util.Errorf("Pull of %s failed after %d attempts with %d of %d layers complete\n", remoteImage,
+					attempt+1, complete, total)
+			} else if total > 0 {
+				util.Errorf("Pull of %s failed with %d of %d layers complete\n", remoteImage, complete, total)
+			}
+			return err
+		}
+		util.Warnf("Pull of %s failed with %d of %d layers complete: %s\n", remoteImage, complete, total,
+			err.Error())
+		util.Warnf("Retrying in %s (retry %d of %d); completed layers are not downloaded again\n", delay,
+			attempt+1, retries)
+		time.Sleep(delay)
+		if delay *= 2; delay > maxPullRetryDelay {
+			delay = maxPullRetryDelay
+		}
+	}
+}
+
+//pull runs docker pull once and returns its combined output. Failures are left to the caller to
+// report, as not all of them are errors.
+func pull(remoteImage string) (string, error) {
+	var errs, output bytes.Buffer
+	util.Infof("Pulling %s\n", remoteImage)
+	pullArgs := []string{"pull", remoteImage}
+	util.DebugCommand("docker", pullArgs)
+	progress, finishProgress := util.DockerProgress("Pulling")
+	pullCmd := util.DockerCommand{Args: pullArgs, Stdout: io.MultiWriter(progress, &output),
+		Stderr: io.MultiWriter(progress, &errs, &output)}
+
+	err := util.RunDocker(pullCmd)
+	finishProgress(err)
+	if err != nil {
+		if detail := strings.TrimSpace(errs.String()); detail != "" {
+			err = errors.New(detail)
+		}
+		return output.String(), err
+	}
+
+	if errs.String() != "" {
+		return output.String(), errors.New(errs.String())
+	}
+	return output.String(), nil
+}
+
+//transientPullErrors are docker pull errors caused by the network or an overloaded registry,
+// which may succeed if retried
+var transientPullErrors = []string{
+	"timeout",
+	"timed out",
+	"connection reset",
+	"connection refused",
+	"broken pipe",
+	"unexpected eof",
+	"temporary failure",
+	"tls handshake",
+	"context deadline exceeded",
+	"toomanyrequests",
+	"too many requests",
+	"500 internal server error",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+}
+
+//IsTransientPullError reports whether docker pull output reports a failure that may succeed if
+// retried. Missing images and authorization failures are not transient.
+func IsTransientPullError(output string) bool {
+	output = strings.ToLower(output)
+	for _, e := range transientPullErrors {
+		if strings.Contains(output, e) {
+			return true
+		}
+	}
+	return false
+}
+
+//pullLayerRegex matches the status lines docker pull prints for each layer, i.e.
+// "59bf1c3509f3: Pull complete"
+var pullLayerRegex = regexp.MustCompile(`(?m)^([0-9a-f]{12}): (.*)$`)
+
+//PullLayerProgress returns the number of layers docker pull output reports as complete, either
+// downloaded or already present, and the number of layers of the image
+func PullLayerProgress(output string) (int, int) {
+	layers := map[string]bool{}
+	for _, m := range pullLayerRegex.FindAllStringSubmatch(strings.Replace(output, "\r", "\n", -1), -1) {
+		status := strings.TrimSpace(m[2])
+		layers[m[1]] = layers[m[1]] || status == "Pull complete" || status == "Already exists"
+	}
+	complete := 0
+	for _, done := range layers {
+		if done {
+			complete++
+		}
+	}
+	return complete, len(layers)
+}
+
+//verifyPulledImage verifies the signature of the image pulled as remoteImage. The image is
+// verified by the digest it was pulled at, so a tag moved after the pull is not trusted.
+func verifyPulledImage(remoteImage string, verify util.VerifyOptions) error {
+	image := remoteImage
+	if digest, err := util.ImageRepoDigest(remoteImage); err == nil {
+		image = util.DigestReference(remoteImage, digest)
+	} else {
+		util.Debugf("Verifying %s by tag: %s\n", remoteImage, err.Error())
+	}
+
+	util.Infof("Verifying signature of %s\n", image)
+	err := util.VerifyImageSignature(image, verify.PublicKey)
+	if err == util.ErrNoSignature {
+		if verify.RequireSignature {
+			return fmt.Errorf("No signature found for %s and -%s is set", remoteImage,
+				constants.RequireSignatureFlag)
+		}
+		util.Warnf("No signature found for %s; the image has not been verified\n", remoteImage)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	util.PrintUtil("Verified signature of %s\n", image)
 	return nil
 }
 
 //PrintPullUsage prints the seed pull usage information, then exits the program
 func PrintPullUsage() {
-	util.PrintUtil( "\nUsage:\tseed pull -in IMAGE_NAME [-r REGISTRY_NAME] [-o ORGANIZATION_NAME] [-u Username] [-p password]\n")
-	util.PrintUtil( "\nPulls seed image from remote repository.\n")
+	util.PrintUtil( "\nUsage:\tseed pull -in IMAGE_NAME [-r REGISTRY_NAME] [-o ORGANIZATION_NAME] [-u Username] [-p password] [-q]\n" +
+		"\t\t [-verify -public-key KEY [-require-signature]] [-j JOBS] [IMAGE_NAME...]\n")
+	util.PrintUtil( "\nPulls seed images from remote repository.\n")
 	util.PrintUtil( "\nOptions:\n")
-	util.PrintUtil( "  -%s -%s Docker image name to pull\n",
+	util.PrintUtil( "  -%s -%s Docker image name to pull. More images may be given as arguments\n",
 		constants.ShortImgNameFlag, constants.ImgNameFlag)
+	util.PrintUtil("  -%s -%s\tNumber of images to pull concurrently (default is %d)\n",
+		constants.ShortJobsFlag, constants.JobsFlag, constants.DefaultPullJobs)
 	util.PrintUtil( "  -%s -%s\tSpecifies a specific registry (default is index.docker.io).\n",
 		constants.ShortRegistryFlag, constants.RegistryFlag)
 	util.PrintUtil( "  -%s -%s\tSpecifies a specific organization (default is no organization).\n",
@@ -96,5 +325,20 @@ func PrintPullUsage() {
 		constants.ShortUserFlag, constants.UserFlag)
 	util.PrintUtil( "  -%s -%s\tPassword to login to remote registry (default anonymous).\n",
 		constants.ShortPassFlag, constants.PassFlag)
+	util.PrintUtil("  -%s -%s\tSuppress docker pull progress output; errors are still reported\n",
+		constants.ShortQuietFlag, constants.QuietFlag)
+	util.PrintUtil("  -%s\tDisplay docker pull progress as %s output (default) or a consolidated %s showing\n"+
+		"\t\tpercent complete and the current layer\n", constants.ProgressFlag, constants.ProgressPlain,
+		constants.ProgressBar)
+	util.PrintUtil("  -%s\tNumber of times to retry the pull after a network or registry failure, with increasing\n"+
+		"\t\tdelays (default is %d). Completed layers are not downloaded again\n", constants.RetriesFlag,
+		constants.DefaultPullRetries)
+	util.PrintUtil("  -%s\t\tVerify the cosign signature of the image before tagging it; unsigned images only warn\n",
+		constants.VerifySignatureFlag)
+	util.PrintUtil("  -%s\tCosign public key file, or KMS URI, to verify the signature against\n",
+		constants.PublicKeyFlag)
+	util.PrintUtil("  -%s\tFail verification of images without a signature (implies -%s)\n",
+		constants.RequireSignatureFlag, constants.VerifySignatureFlag)
+	util.PrintUtil("\nOnly cosign signatures are supported. Verification requires cosign on the PATH.\n")
 	panic(util.Exit{0})
 }
@@ -1,8 +1,10 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -38,7 +40,7 @@ func TestDockerPull(t *testing.T) {
 	remoteImg := []string{"localhost:5000/my-job-0.1.0-seed:0.1.0", "localhost:5000/my-job-1.0.0-seed:1.0.0", "localhost:5000/not-a-valid-image"}
 
 	for _, dir := range imgDirs {
-		err := DockerBuild(dir, "", "")
+		err := DockerBuild(BuildOptions{JobDirectory: dir})
 		if err != nil {
 			t.Errorf("Error building image from %v for DockerPull test: %v", dir, err)
 		}
@@ -72,7 +74,7 @@ func TestDockerPull(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		err := DockerPull(c.image, c.registry, c.org, c.username, c.password)
+		err := DockerPull([]string{c.image}, c.registry, c.org, c.username, c.password, 0, 1, util.VerifyOptions{})
 
 		success := err == nil
 		if success != c.expectedResult {
@@ -87,3 +89,143 @@ func TestDockerPull(t *testing.T) {
 		}
 	}
 }
+
+func TestVerifyOptions(t *testing.T) {
+	cases := []struct {
+		options          util.VerifyOptions
+		expectedErrorMsg string
+	}{
+		{util.VerifyOptions{}, ""},
+		{util.VerifyOptions{PublicKey: "missing.pub"}, ""},
+		{util.VerifyOptions{Verify: true}, "requires a public key"},
+		{util.VerifyOptions{Verify: true, PublicKey: "missing.pub"}, "Error reading public key missing.pub"},
+	}
+
+	for _, c := range cases {
+		err := c.options.Check()
+		if err != nil {
+			if c.expectedErrorMsg == "" || !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("Check(%+v) == %v, expected %v", c.options, err.Error(), c.expectedErrorMsg)
+			}
+		} else if c.expectedErrorMsg != "" {
+			t.Errorf("Check(%+v) returned no error, expected %v", c.options, c.expectedErrorMsg)
+		}
+	}
+
+	outputs := []struct {
+		output   string
+		expected bool
+	}{
+		{"Error: no signatures found for image\nmain.go:62: error during command execution", true},
+		{"Error: no matching signatures:\ninvalid signature when validating ASN.1 encoded signature", false},
+		{"Error: GET https://registry/v2/: UNAUTHORIZED", false},
+	}
+	for _, o := range outputs {
+		if result := util.IsNoSignatureError(o.output); result != o.expected {
+			t.Errorf("IsNoSignatureError(%q) == %v, expected %v", o.output, result, o.expected)
+		}
+	}
+}
+
+func TestPullRetries(t *testing.T) {
+	output := "latest: Pulling from geoint/extractor\n" +
+		"59bf1c3509f3: Already exists\n" +
+		"8ac8bfaff55a: Pulling fs layer\n" +
+		"8ac8bfaff55a: Downloading [=====>     ]  1.2MB/3.4MB\r8ac8bfaff55a: Pull complete\n" +
+		"0f2b6e9d5a1c: Downloading [=>         ]  12MB/1.2GB\n" +
+		"error pulling image configuration: read tcp 10.0.0.2:443: read: connection reset by peer\n"
+	if complete, total := PullLayerProgress(output); complete != 2 || total != 3 {
+		t.Errorf("PullLayerProgress returned %d of %d layers, expected 2 of 3", complete, total)
+	}
+
+	cases := []struct {
+		output   string
+		expected bool
+	}{
+		{output, true},
+		{"Error response from daemon: Get https://registry/v2/: net/http: TLS handshake timeout", true},
+		{"toomanyrequests: You have reached your pull rate limit", true},
+		{"received unexpected HTTP status: 503 Service Unavailable", true},
+		{"Error response from daemon: manifest for geoint/extractor:0.2.0 not found: manifest unknown", false},
+		{"Error response from daemon: pull access denied for extractor, repository does not exist", false},
+		{"unauthorized: authentication required", false},
+	}
+	for _, c := range cases {
+		if result := IsTransientPullError(c.output); result != c.expected {
+			t.Errorf("IsTransientPullError(%q) == %v, expected %v", c.output, result, c.expected)
+		}
+	}
+}
+
+//progressRecorder is a fake docker that records the progress mode each docker pull runs with
+type progressRecorder struct {
+	*util.FakeDockerRunner
+	mu    sync.Mutex
+	modes []string
+}
+
+//Run records the progress mode of docker pull and answers commands from the fake
+func (p *progressRecorder) Run(c util.DockerCommand) error {
+	if c.Args[0] == "pull" {
+		p.mu.Lock()
+		p.modes = append(p.modes, util.ProgressMode())
+		p.mu.Unlock()
+	}
+	return p.FakeDockerRunner.Run(c)
+}
+
+func TestDockerPullProgress(t *testing.T) {
+	defer util.SetProgressMode(constants.ProgressPlain)
+	cases := []struct {
+		images   []string
+		jobs     int
+		expected string
+	}{
+		{[]string{"extractor-0.1.0-seed:0.1.0"}, 4, constants.ProgressBar},
+		{[]string{"extractor-0.1.0-seed:0.1.0", "addition-job-0.0.1-seed:1.0.0"}, 1, constants.ProgressBar},
+		{[]string{"extractor-0.1.0-seed:0.1.0", "addition-job-0.0.1-seed:1.0.0"}, 2, constants.ProgressPlain},
+	}
+
+	for _, c := range cases {
+		util.SetProgressMode(constants.ProgressBar)
+		recorder := &progressRecorder{FakeDockerRunner: &util.FakeDockerRunner{}}
+		restore := util.SetDockerRunner(recorder)
+		err := DockerPull(c.images, "", "", "", "", 0, c.jobs, util.VerifyOptions{})
+		util.SetDockerRunner(restore)
+		if err != nil || len(recorder.modes) != len(c.images) {
+			t.Errorf("DockerPull(%q, %d jobs) returned %v after %d pulls", c.images, c.jobs, err,
+				len(recorder.modes))
+		}
+		for _, mode := range recorder.modes {
+			if mode != c.expected {
+				t.Errorf("DockerPull(%q, %d jobs) pulled with -progress %s, expected %s", c.images, c.jobs, mode,
+					c.expected)
+			}
+		}
+		if util.ProgressMode() != constants.ProgressBar {
+			t.Errorf("DockerPull(%q, %d jobs) left -progress %s, expected it restored to %s", c.images, c.jobs,
+				util.ProgressMode(), constants.ProgressBar)
+		}
+	}
+}
+
+func TestPullSummary(t *testing.T) {
+	results := []PullResult{
+		{Image: "my-job-0.1.0-seed:0.1.0"},
+		{Image: "extractor-0.1.0-seed:0.1.0", Err: errors.New("manifest unknown:\n  not found")},
+		{Image: "addition-job-0.0.1-seed:1.0.0"},
+	}
+	expected := "IMAGE                           RESULT\n" +
+		"my-job-0.1.0-seed:0.1.0         pulled\n" +
+		"extractor-0.1.0-seed:0.1.0      failed: manifest unknown: not found\n" +
+		"addition-job-0.0.1-seed:1.0.0   pulled\n"
+
+	summary, failed := PullSummary(results)
+	if summary != expected || failed != 1 {
+		t.Errorf("PullSummary() == %q, %d, expected %q, 1", summary, failed, expected)
+	}
+
+	if err := DockerPull(nil, "", "", "", "", 0, 1, util.VerifyOptions{}); err == nil {
+		t.Errorf("DockerPull with no images returned no error, expected an error")
+	}
+}
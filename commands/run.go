@@ -2,18 +2,31 @@ package commands
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math"
 	"mime"
+	"hash"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -23,26 +36,413 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 )
 
+//RunOptions defines the options for running an image described by Seed spec
+type RunOptions struct {
+	ImageName      string
+	OutputDir      string
+	MetadataSchema string
+	Inputs         []string
+	Settings       []string
+
+	// OutputTimestamp writes the outputs of each run to a new subdirectory of OutputDir named
+	// after the time the run started, so repeated runs into the same directory keep their results.
+	// Runs into a non-empty OutputDir always get one, so it only changes runs into an empty one.
+	OutputTimestamp bool
+
+	// StrictInputs fails the run if an input file is not of a media type the manifest declares for
+	// the input, rather than warning
+	StrictInputs bool
+
+	// InputURLs are inputs downloaded before the run, given as NAME=URL. The URL may carry basic
+	// auth credentials, and a fragment of sha256=HEX or sha512=HEX the file must match. URLs
+	// without credentials are downloaded with those of SEED_INPUT_TOKEN, or SEED_INPUT_USER and
+	// SEED_INPUT_PASSWORD. The downloads are removed after the run if RmDir is set.
+	InputURLs []string
+
+	// InputDir is a directory of files matched to the manifest inputs by name, or by media type if
+	// no file is named after the input. Inputs given in Inputs override the matched files.
+	InputDir string
+
+	// DirectoryInputs are the names of inputs given a directory, which is mounted and substituted
+	// into the command like a file. They must not declare mediaTypes or be multiple.
+	DirectoryInputs []string
+
+	Mounts         []string
+	RmDir          bool
+	Quiet          bool
+
+	// RestartOnFailure is the number of times the container is run again if it exits non-zero.
+	// The failed container is removed before each restart.
+	RestartOnFailure int
+
+	// KeepFailed keeps the container if it exits non-zero, overriding RmDir, so it can be
+	// inspected with docker logs or docker commit. Successful runs are still removed.
+	KeepFailed bool
+
+	// InputsRelativeTo selects whether relative input paths are resolved against
+	// the current directory (cwd) or the directory of the seed manifest (manifest)
+	InputsRelativeTo string
+	JobDirectory     string
+	User             string
+
+	// HostUser runs the container as the uid:gid of the user running seed, so output files are
+	// not owned by root. It cannot be combined with User.
+	HostUser bool
+
+	// MountReadOnly mounts input files and directories read-only so the job cannot modify
+	// the source data. The output directory is always writable.
+	MountReadOnly bool
+
+	// ReadonlyRootfs runs the container with a read-only root filesystem, so the job can only
+	// write to its output directory, read-write mounts and the temporary directories in
+	// readonlyScratchDirs, which are mounted as tmpfs
+	ReadonlyRootfs bool
+
+	// JsonLogs wraps each line of container output in a JSON envelope with the time,
+	// stream, image and container id, written to stdout as newline delimited JSON, even in quiet
+	// mode. It cannot be combined with OutputJson or ResultJson.
+	JsonLogs bool
+
+	// CaptureLogs writes the container stdout and stderr to stdout.log and stderr.log
+	// in the output directory in addition to the terminal
+	CaptureLogs bool
+
+	// Gpus exposes GPUs to the container, i.e. all, 2 or device=0,1. Defaults to the
+	// number of GPUs declared in the manifest resources.
+	Gpus string
+
+	// Cpus is a hard limit on the CPUs the container may use, i.e. 1.5, as a CPU quota rather
+	// than a relative weight. Zero is no limit.
+	Cpus float64
+
+	// Ports publishes container ports on the host, i.e. to attach a debugger, in the docker run -p
+	// form [IP:][HOST_PORT:]CONTAINER_PORT[/PROTOCOL]
+	Ports []string
+
+	// SecurityOpts are passed to docker run --security-opt, i.e. seccomp=profile.json or
+	// apparmor=PROFILE, in the KEY=VALUE form
+	SecurityOpts []string
+
+	// AddHosts are entries added to /etc/hosts of the container in the docker run --add-host
+	// form NAME:IP, i.e. to point the job at a mock service
+	AddHosts []string
+
+	// Tmpfs are in-memory scratch directories mounted in the container in the docker run --tmpfs
+	// form PATH[:OPTIONS], i.e. /scratch:size=1g
+	Tmpfs []string
+
+	// Network connects the container to a docker network: bridge, host, none or the name of
+	// a user defined network. Defaults to the docker bridge network.
+	Network string
+
+	// Pid and Ipc share the PID and IPC namespaces of the host or another container with the
+	// container, i.e. host or container:NAME, so profiling tools can see the job's processes.
+	// Defaults to namespaces of the container's own.
+	Pid string
+	Ipc string
+
+	// WorkDir overrides the working directory of the container, which relative paths in the
+	// manifest command are resolved against. Defaults to the WORKDIR of the image.
+	WorkDir string
+
+	// SettingsFile is a JSON object or KEY=VALUE file of settings. Settings given in Settings
+	// override the values in the file.
+	SettingsFile string
+
+	// NoDefaults fails the run if any setting is not given in Settings or SettingsFile, rather
+	// than using the value the image sets for it with ENV
+	NoDefaults bool
+
+	// MaxOutputSize is the largest the output directory may grow, in MiB. The container is
+	// stopped if it is exceeded during the run and the run fails if it is exceeded at the end.
+	// Zero is no limit.
+	MaxOutputSize int
+
+	// SaveInputManifest writes inputs.resolved.json to the output directory, recording the
+	// host path, container path and size bound to each input
+	SaveInputManifest bool
+
+	// EnvFile is a file of KEY=VALUE lines, as for docker run --env-file, set in the container
+	// environment. Settings given in Settings take precedence over variables of the same name.
+	EnvFile string
+
+	// OutputJson prints the values of the manifest's Outputs.Json read from seed.outputs.json
+	// to stdout as a JSON object keyed by output name
+	OutputJson bool
+
+	// ResultJson prints a RunSummary of the run to stdout as a JSON object once the container
+	// exits, whether or not the job succeeded. It cannot be combined with OutputJson.
+	ResultJson bool
+
+	// OutputUpload is an s3://BUCKET/KEY, http or https URL the output directory is uploaded to
+	// as a gzipped tar archive after the outputs are validated. Outputs of a failed run are only
+	// uploaded with UploadOnFailure.
+	OutputUpload    string
+	UploadOnFailure bool
+}
+
+//ExitReason classifies how a seed run ended
+type ExitReason string
+
+const (
+	//ExitNormal the container exited on its own with an exit code
+	ExitNormal ExitReason = "exit"
+
+	//ExitOOM the container was killed for exceeding its memory limit
+	ExitOOM ExitReason = "oom"
+
+	//ExitTimeout the container was killed for exceeding the job timeout
+	ExitTimeout ExitReason = "timeout"
+
+	//ExitSignal the container was killed by a signal
+	ExitSignal ExitReason = "signal"
+
+	//ExitInternal seed or docker failed to run the container
+	ExitInternal ExitReason = "internal"
+
+	//ExitOutputSize the job exceeded the output directory size limit given with -max-output-size
+	ExitOutputSize ExitReason = "output-size"
+)
+
+//RunResult describes the outcome of a seed run. It is written to the output directory
+// as seed.run.json
+type RunResult struct {
+	Image      string     `json:"image"`
+	ExitCode   int        `json:"exitCode"`
+	ExitReason ExitReason `json:"exitReason"`
+	ExitDetail string     `json:"exitDetail"`
+	// Attempts is the number of times the container was run, more than one if it was restarted
+	Attempts int `json:"attempts"`
+	// Error is the error declared in the manifest for a non-zero exit code, if any
+	Error *objects.ErrorMap `json:"error,omitempty"`
+}
+
+//RunSummary is the outcome of a seed run printed to stdout with -result-json: how the job
+// exited, how long it ran and the files matched by each output file the manifest declares
+type RunSummary struct {
+	Image      string     `json:"image"`
+	ExitCode   int        `json:"exitCode"`
+	ExitReason ExitReason `json:"exitReason"`
+	// Duration is in seconds, across all attempts
+	Duration float64       `json:"duration"`
+	Attempts int           `json:"attempts"`
+	Outputs  []OutputFiles `json:"outputs"`
+	// Upload is the outcome of -output-upload, if the outputs were uploaded
+	Upload *UploadSummary `json:"upload,omitempty"`
+}
+
+//UploadSummary is where the outputs were uploaded with -output-upload and the error, if the
+// upload failed
+type UploadSummary struct {
+	Destination string `json:"destination"`
+	Error       string `json:"error,omitempty"`
+}
+
+//OutputFiles lists the files in the output directory matched by an output file of the manifest
+type OutputFiles struct {
+	Name  string       `json:"name"`
+	Files []OutputFile `json:"files"`
+}
+
+//OutputFile is a file matched by an output, with its size in bytes
+type OutputFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+//outputSizeInterval is how often the output directory is measured when its size is limited
+const outputSizeInterval = 5 * time.Second
+
+//ResolvedInput records the host file or directory bound to a manifest input for a seed run.
+// The list of them is written to the output directory as inputs.resolved.json.
+type ResolvedInput struct {
+	Name          string `json:"name"`
+	HostPath      string `json:"hostPath"`
+	ContainerPath string `json:"containerPath"`
+	// Size is in bytes, the total of the files within for a directory input
+	Size int64 `json:"size"`
+}
+
 //DockerRun Runs image described by Seed spec
-func DockerRun(imageName, outputDir, metadataSchema string, inputs, settings, mounts []string, rmDir, quiet bool) (int, error) {
-	util.InitPrinter(quiet)
-	
+func DockerRun(options RunOptions) (int, error) {
+	imageName := options.ImageName
+	outputDir := options.OutputDir
+	metadataSchema := options.MetadataSchema
+	mounts := options.Mounts
+	if options.Quiet {
+		util.SetQuiet(true)
+	}
+
+	settings := options.Settings
+	if options.SettingsFile != "" {
+		fileSettings, err := ReadSettingsFile(options.SettingsFile)
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+		settings = MergeSettings(fileSettings, options.Settings)
+	}
+
+	var env []string
+	if options.EnvFile != "" {
+		var err error
+		env, err = ReadEnvFile(options.EnvFile)
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+	}
+
+	if options.ResultJson && options.OutputJson {
+		err := fmt.Errorf("-%s and -%s cannot be used together", constants.ResultJsonFlag, constants.OutputJsonFlag)
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+	if options.JsonLogs && (options.ResultJson || options.OutputJson) {
+		err := fmt.Errorf("-%s cannot be used with -%s or -%s, which also print to stdout", constants.JsonLogsFlag,
+			constants.OutputJsonFlag, constants.ResultJsonFlag)
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+
+	flagUser := options.User
+	if options.HostUser {
+		var err error
+		if options.User != "" {
+			err = fmt.Errorf("-%s and -%s cannot be used together", constants.UserFlag, constants.HostUserFlag)
+		} else if flagUser, err = util.HostUser(); err != nil {
+			err = fmt.Errorf("-%s cannot be used: %s", constants.HostUserFlag, err.Error())
+		}
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+	}
+
 	if imageName == "" {
-		return 0, errors.New("ERROR: No input image specified.")
+		return 0, wrapError(ErrInvalidArgument, errors.New("ERROR: No input image specified."))
+	}
+	if _, err := util.ParseReference(imageName); err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
 	}
 
-	if exists, err := util.ImageExists(imageName); !exists {
-		return 0, err
+	if exists, err := util.ImageExists(imageName); err != nil {
+		return 0, wrapError(ErrDockerExec, err)
+	} else if !exists {
+		err = fmt.Errorf("Image %s not found. Build it with seed build or pull it with seed pull", imageName)
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
 	}
 
 	// Parse seed information off of the label
-	seed := objects.SeedFromImageLabel(imageName)
+	seed, err := objects.SeedFromImageLabel(imageName)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrValidation, err)
+	}
+
+	checkImagePlatform(imageName)
+
+	// build docker run command. The container is removed by seed after its exit state is
+	// inspected rather than with --rm so OOM kills can be detected
+	tempDir, err := ioutil.TempDir("", "seed-run")
+	if err != nil {
+		util.Errorf("Error creating temporary directory: %s\n", err.Error())
+		return 0, err
+	}
+	cidFile := filepath.Join(tempDir, "container.id")
+	cleanup := &runCleanup{cidFile: cidFile, removeContainer: options.RmDir, dirs: []string{tempDir}}
+	defer cleanup.Run()
+	dockerArgs := []string{"run", "--cidfile", cidFile}
+
+	user, err := ResolveUser(flagUser)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+	if user != "" {
+		dockerArgs = append(dockerArgs, "--user", user)
+	}
+
+	network, err := ResolveNetwork(options.Network)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+	if network != "" {
+		dockerArgs = append(dockerArgs, "--network", network)
+	}
+
+	for _, ns := range []struct {
+		flag, mode string
+		modes      []string
+	}{
+		{constants.PidFlag, options.Pid, pidModes},
+		{constants.IpcFlag, options.Ipc, ipcModes},
+	} {
+		mode, err := ResolveNamespace(ns.flag, ns.mode, ns.modes)
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+		if mode != "" {
+			dockerArgs = append(dockerArgs, "--"+ns.flag, mode)
+		}
+	}
+
+	ports, err := ResolvePorts(options.Ports)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+	if len(ports) > 0 && network == constants.NetworkHost {
+		util.Warnf("-%s is ignored with -%s %s; the container uses the ports of the host\n",
+			constants.PublishPortFlag, constants.NetworkFlag, constants.NetworkHost)
+	}
+	for _, p := range ports {
+		dockerArgs = append(dockerArgs, "-p", p)
+	}
+
+	securityOpts, err := ResolveSecurityOpts(options.SecurityOpts)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+	for _, opt := range securityOpts {
+		dockerArgs = append(dockerArgs, "--security-opt", opt)
+	}
+
+	addHosts, err := ResolveAddHosts(options.AddHosts)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+	for _, host := range addHosts {
+		dockerArgs = append(dockerArgs, "--add-host", host)
+	}
 
-	// build docker run command
-	dockerArgs := []string{"run"}
+	if options.WorkDir != "" {
+		if !path.IsAbs(options.WorkDir) {
+			err = errors.New("Invalid -" + constants.WorkDirFlag + " value " + options.WorkDir +
+				". The working directory must be an absolute path in the container")
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+		dockerArgs = append(dockerArgs, "-w", options.WorkDir)
+	}
 
-	if rmDir {
-		dockerArgs = append(dockerArgs, "--rm")
+	tmpfs, err := ResolveTmpfs(options.Tmpfs)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+	for _, t := range tmpfs {
+		dockerArgs = append(dockerArgs, "--tmpfs", t)
+	}
+
+	if options.ReadonlyRootfs {
+		dockerArgs = append(dockerArgs, ReadonlyRootfsArgs(tmpfs)...)
 	}
 
 	var mountsArgs []string
@@ -50,67 +450,180 @@ func DockerRun(imageName, outputDir, metadataSchema string, inputs, settings, mo
 	var resourceArgs []string
 	var inputSize float64
 	var outputSize float64
+	var resolvedInputs []ResolvedInput
+
+	if err := DefineDirectoryInputs(&seed, options.DirectoryInputs); err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+
+	// download the inputs given as URLs, which count as given explicitly
+	if len(options.InputURLs) > 0 {
+		inputURLs, err := ResolveInputURLs(&seed, options.InputURLs, options.Inputs)
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+		downloadDir, err := ioutil.TempDir("", "seed-inputs")
+		if err != nil {
+			util.Errorf("Error creating temporary directory: %s\n", err.Error())
+			return 0, err
+		}
+		if options.RmDir {
+			cleanup.dirs = append(cleanup.dirs, downloadDir)
+		} else {
+			defer util.Infof("Kept the downloaded inputs in %s\n", downloadDir)
+		}
+		for _, in := range inputURLs {
+			file, err := DownloadInput(in, downloadDir)
+			if err != nil {
+				util.Errorf("%s\n", err.Error())
+				return 0, wrapError(ErrDownload, err)
+			}
+			options.Inputs = append(options.Inputs, in.Name+"="+file)
+		}
+	}
+
+	// match the files in the input directory to the inputs not given explicitly
+	if options.InputDir != "" {
+		matched, unmatched, err := MatchInputDir(&seed, options.InputDir, options.Inputs)
+		if err != nil {
+			util.Errorf("Error reading input directory %s: %s\n", options.InputDir, err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+		if len(unmatched) > 0 {
+			err = fmt.Errorf("No file in %s matches the required inputs %s. Give them with -%s KEY=PATH",
+				options.InputDir, strings.Join(unmatched, ", "), constants.ShortInputsFlag)
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+		options.Inputs = append(options.Inputs, matched...)
+	}
 
 	// expand INPUT_FILEs to specified Inputs files
 	if seed.Job.Interface.Inputs.Files != nil {
-		inMounts, size, temp, err := DefineInputs(&seed, inputs)
+		inputs, err := ResolveInputs(options.Inputs, options.InputsRelativeTo, options.JobDirectory)
+		if err != nil {
+			util.Errorf("Error occurred resolving inputs arguments.\n%s", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+		if err := CheckInputMediaTypes(&seed, inputs, options.StrictInputs); err != nil {
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+		inMounts, size, temp, err := DefineInputs(&seed, inputs, options.MountReadOnly)
 		for _, v := range temp {
-			defer util.RemoveAllFiles(v)
+			cleanup.dirs = append(cleanup.dirs, v)
 		}
 		if err != nil {
-			util.PrintUtil("ERROR: Error occurred processing inputs arguments.\n%s", err.Error())
-			util.PrintUtil("Exiting seed...\n")
-			panic(util.Exit{1})
+			util.Errorf("Error occurred processing inputs arguments.\n%s", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
 		} else if inMounts != nil {
 			mountsArgs = append(mountsArgs, inMounts...)
 			inputSize = size
 		}
+		if options.SaveInputManifest {
+			resolvedInputs = ResolveInputBindings(&seed, inputs, temp)
+		}
 	}
 
 	if len(seed.Job.Resources.Scalar) > 0 {
 		inResources, diskSize, err := DefineResources(&seed, inputSize)
 		if err != nil {
-			util.PrintUtil( "ERROR: Error occurred processing resources\n%s", err.Error())
-			util.PrintUtil( "Exiting seed...\n")
-			panic(util.Exit{1})
+			util.Errorf("Error occurred processing resources\n%s", err.Error())
+			return 0, wrapError(ErrValidation, err)
 		} else if inResources != nil {
 			resourceArgs = append(resourceArgs, inResources...)
 			outputSize = diskSize
 		}
 	}
 
+	// Expose GPUs requested with -gpus or declared in the manifest
+	gpus, err := ResolveGpus(&seed, options.Gpus)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+	if gpus != "" {
+		if !util.DockerVersionGreaterThan(19, 3, 0) {
+			err = errors.New("Exposing GPUs to the container requires Docker 19.03 or later")
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrDockerExec, err)
+		}
+		resourceArgs = append(resourceArgs, "--gpus", gpus)
+	}
+
+	cpus, err := ResolveCpus(options.Cpus)
+	if err != nil {
+		util.Errorf("%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	}
+	if cpus != "" {
+		resourceArgs = append(resourceArgs, "--cpus", cpus)
+	}
+
 	// mount the JOB_OUTPUT_DIR (outDir flag)
 	var outDir string
 	if strings.Contains(seed.Job.Interface.Command, "OUTPUT_DIR") {
-		outDir = SetOutputDir(imageName, &seed, outputDir)
+		outDir, err = SetOutputDir(imageName, &seed, outputDir, options.OutputTimestamp)
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
 		if outDir != "" {
 			mountsArgs = append(mountsArgs, "-v")
-			mountsArgs = append(mountsArgs, outDir+":"+outDir)
+			mountsArgs = append(mountsArgs, bindMount(outDir, util.ContainerPath(outDir), false))
+		}
+	}
+
+	// check the upload destination before running the job
+	var upload *url.URL
+	if options.OutputUpload != "" {
+		if outDir == "" {
+			err = fmt.Errorf("-%s requires an output directory", constants.OutputUploadFlag)
+		} else {
+			upload, err = ResolveOutputUpload(options.OutputUpload, outDir)
+		}
+		if err != nil {
+			util.Errorf("%s\n", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
+		}
+	}
+
+	if options.SaveInputManifest {
+		if outDir == "" {
+			util.Warnf("No output directory; %s will not be written\n", constants.InputsResolvedFileName)
+		} else if err := WriteResolvedInputs(outDir, resolvedInputs); err != nil {
+			util.Errorf("Error writing %s: %s\n", constants.InputsResolvedFileName, err.Error())
 		}
 	}
 
 	// Settings
 	if seed.Job.Interface.Settings != nil {
+		if !options.NoDefaults && len(MissingSettings(&seed, settings)) > 0 {
+			if config, err := util.InspectImageConfig(imageName); err != nil {
+				util.Warnf("Could not read the environment of %s for setting defaults: %s\n", imageName, err.Error())
+			} else {
+				settings = ApplySettingDefaults(&seed, settings, config.Env)
+			}
+		}
 		inSettings, err := DefineSettings(&seed, settings)
 		if err != nil {
-			util.PrintUtil( "ERROR: Error occurred processing settings arguments.\n%s", err.Error())
-			util.PrintUtil( "Exiting seed...\n")
-			panic(util.Exit{1})
+			util.Errorf("Error occurred processing settings arguments.\n%s", err.Error())
+			return 0, wrapError(ErrInvalidArgument, err)
 		} else if inSettings != nil {
 			envArgs = append(envArgs, inSettings...)
 		}
 	}
+	envArgs = append(envArgs, EnvFileArgs(env, settings)...)
 
-	// Additional Mounts defined in seed.json
-	if seed.Job.Interface.Mounts != nil {
-		inMounts, err := DefineMounts(&seed, mounts)
-		if err != nil {
-			util.PrintUtil( "ERROR: Error occurred processing mount arguments.\n%s", err.Error())
-			util.PrintUtil( "Exiting seed...\n")
-			panic(util.Exit{1})
-		} else if inMounts != nil {
-			mountsArgs = append(mountsArgs, inMounts...)
-		}
+	// Additional Mounts defined in seed.json. Mounts given that are not declared are warned about.
+	inMounts, err := DefineMounts(&seed, mounts)
+	if err != nil {
+		util.Errorf("Error occurred processing mount arguments.\n%s\n", err.Error())
+		return 0, wrapError(ErrInvalidArgument, err)
+	} else if inMounts != nil {
+		mountsArgs = append(mountsArgs, inMounts...)
 	}
 
 	// Build Docker command arguments:
@@ -129,74 +642,987 @@ func DockerRun(imageName, outputDir, metadataSchema string, inputs, settings, mo
 	args := strings.Split(seed.Job.Interface.Command, " ")
 	dockerArgs = append(dockerArgs, args...)
 
-	// Run
+	// Run. The values of secret settings are masked in the logged command.
 	var cmd bytes.Buffer
 	cmd.WriteString("docker ")
-	for _, s := range dockerArgs {
+	for _, s := range MaskSecretSettings(&seed, dockerArgs) {
 		cmd.WriteString(s + " ")
 	}
-	util.PrintUtil( "INFO: Running Docker command:\n%s\n", cmd.String())
+	util.Infof("Running Docker command:\n%s\n", cmd.String())
 
-	// Run Docker command and capture output
-	dockerRun := exec.Command("docker", dockerArgs...)
+	// Capture the output of the docker command
 	var errs bytes.Buffer
-	if !quiet {
-		dockerRun.Stderr = io.MultiWriter( &errs)
-		dockerRun.Stdout = os.Stderr
+	stdout := util.ProgressWriter()
+	var stderr io.Writer = &errs
+
+	// Wrap each line of container output in a JSON envelope, written to stdout so quiet mode
+	// keeps it. The container id is looked up again after a restart.
+	var jsonLogs []*jsonLogWriter
+	var idMu sync.Mutex
+	containerID := ""
+	if options.JsonLogs {
+		var mu sync.Mutex
+		lookupContainer := func() string {
+			idMu.Lock()
+			defer idMu.Unlock()
+			if containerID == "" {
+				if id, err := ioutil.ReadFile(cidFile); err == nil {
+					containerID = string(id)
+				}
+			}
+			return containerID
+		}
+		stdoutJson := newJsonLogWriter(os.Stdout, &mu, "stdout", imageName, lookupContainer)
+		stderrJson := newJsonLogWriter(os.Stdout, &mu, "stderr", imageName, lookupContainer)
+		jsonLogs = []*jsonLogWriter{stdoutJson, stderrJson}
+		stdout = stdoutJson
+		stderr = io.MultiWriter(&errs, stderrJson)
+	}
+
+	if options.CaptureLogs {
+		if outDir == "" {
+			util.Warnf("No output directory; container logs will not be captured\n")
+		} else {
+			stdoutLog, stderrLog, err := createLogFiles(outDir)
+			if err != nil {
+				util.Errorf("Error creating container log files: %s\n", err.Error())
+				return 0, err
+			}
+			defer closeLogFile(stdoutLog)
+			defer closeLogFile(stderrLog)
+			stdout = io.MultiWriter(stdout, stdoutLog)
+			stderr = io.MultiWriter(stderr, stderrLog)
+		}
+	}
+
+	// Kill the container if it exceeds the job timeout
+	var timedOut int32
+	if seed.Job.Timeout > 0 {
+		timer := time.AfterFunc(time.Duration(seed.Job.Timeout)*time.Second, func() {
+			atomic.StoreInt32(&timedOut, 1)
+			if id, err := ioutil.ReadFile(cidFile); err == nil {
+				util.KillContainer(string(id))
+			}
+		})
+		defer timer.Stop()
+	}
+
+	// Stop the container if its output exceeds the size limit
+	var outputExceeded int32
+	outputLimit := int64(options.MaxOutputSize) * 1024 * 1024
+	if options.MaxOutputSize > 0 && outDir == "" {
+		util.Warnf("No output directory; -%s is not enforced\n", constants.MaxOutputSizeFlag)
+	} else if options.MaxOutputSize > 0 {
+		stopWatching := util.WatchDirSize(outDir, outputLimit, outputSizeInterval, func(size int64) {
+			atomic.StoreInt32(&outputExceeded, 1)
+			util.Errorf("Output directory is %.1f MiB, over the limit of %d MiB; stopping container\n",
+				float64(size)/(1024*1024), options.MaxOutputSize)
+			if id, err := ioutil.ReadFile(cidFile); err == nil {
+				util.KillContainer(string(id))
+			}
+		})
+		defer stopWatching()
+	}
+
+	// Keep seed running on interrupt so the container is stopped and cleaned up. docker run
+	// also forwards the signal to the container.
+	var interrupted int32
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for range interrupts {
+			atomic.StoreInt32(&interrupted, 1)
+			util.Warnf("Interrupted; stopping container\n")
+			if id, err := ioutil.ReadFile(cidFile); err == nil {
+				util.KillContainer(string(id))
+			}
+		}
+	}()
+
+	// Run docker run, restarting the container if it exits non-zero, up to the restarts allowed.
+	// The job is not restarted if seed stopped it.
+	runTime := time.Now()
+	attempts := 0
+	for {
+		attempts++
+		err = util.RunDocker(util.DockerCommand{Args: dockerArgs, Stdout: stdout, Stderr: stderr})
+		stopped := atomic.LoadInt32(&timedOut) == 1 || atomic.LoadInt32(&outputExceeded) == 1 ||
+			atomic.LoadInt32(&interrupted) == 1
+		if stopped || !ShouldRestart(err, attempts, options.RestartOnFailure) {
+			break
+		}
+		util.Warnf("Attempt %d of %d failed with exit code %d; restarting\n", attempts,
+			options.RestartOnFailure+1, exitStatus(err))
+
+		// Remove the failed container so the next attempt can write the container id file
+		if id, cidErr := ioutil.ReadFile(cidFile); cidErr == nil {
+			util.RemoveContainer(string(id))
+		}
+		os.Remove(cidFile)
+		idMu.Lock()
+		containerID = ""
+		idMu.Unlock()
+		errs.Reset()
+	}
+	duration := time.Since(runTime)
+	if options.RestartOnFailure > 0 {
+		outcome := "succeeded"
+		if err != nil {
+			outcome = "failed"
+		}
+		util.PrintUtil("Job %s after %d of at most %d attempts\n", outcome, attempts, options.RestartOnFailure+1)
+	}
+	signal.Stop(interrupts)
+	close(interrupts)
+	for _, w := range jsonLogs {
+		if ferr := w.Flush(); ferr != nil {
+			util.Errorf("Error writing container logs: %s\n", ferr.Error())
+		}
+	}
+	util.TimeTrack(runTime, "INFO: "+imageName+" run")
+	exitCode := 0
+	match := false
+	var declaredError *objects.ErrorMap
+	var gpuErr error
+	if gpus != "" && strings.Contains(errs.String(), "could not select device driver") {
+		gpuErr = errors.New("GPUs were requested but the docker daemon has no GPU runtime. Install the NVIDIA " +
+			"Container Toolkit (https://github.com/NVIDIA/nvidia-docker) and restart docker, or run without -" +
+			constants.GpusFlag)
+		util.Errorf("%s\n", gpuErr.Error())
+		exitCode, _ = util.ExitCode(err)
+	} else if err != nil {
+		if code, ok := util.ExitCode(err); ok {
+			exitCode = code
+			util.PrintUtil( "Exited with error code %v\n", exitCode)
+			if e, found := objects.NewErrorMapping(seed.Job.Errors).Lookup(exitCode); found {
+				util.PrintUtil( "Title: \t %s\n", e.Title)
+				util.PrintUtil( "Description: \t %s\n", e.Description)
+				util.PrintUtil( "Category: \t %s \n \n", e.Category)
+				declaredError = &e
+				match = true
+			} else {
+				util.PrintUtil( "No matching error code found in Seed manifest: %s\n", GenericExitMessage(exitCode))
+			}
+		} else {
+			util.Errorf("error executing docker run. %s\n",
+				err.Error())
+		}
+	}
+
+	// Inspect the exit state of the container. It is removed on return if requested.
+	oomKilled := false
+	if id, cidErr := ioutil.ReadFile(cidFile); cidErr == nil {
+		oomKilled, _ = util.ContainerOOMKilled(string(id))
+	}
+	if options.KeepFailed && exitCode != 0 {
+		if id := cleanup.KeepContainer(); id != "" {
+			util.PrintUtil("Kept failed container %s for inspection, i.e. docker logs %s\n", id, id)
+			util.PrintUtil("Remove it with: docker rm -v %s\n", id)
+		}
+	}
+
+	// Explain failures from writing outside the writable directories of a read-only root filesystem
+	var readOnlyWrites []string
+	if options.ReadonlyRootfs && exitCode != 0 {
+		readOnlyWrites = ReadOnlyWrites(errs.String())
+		if len(readOnlyWrites) > 0 {
+			util.Errorf("The job tried to write outside its writable directories with -%s:\n  %s\n",
+				constants.ReadonlyRootfsFlag, strings.Join(readOnlyWrites, "\n  "))
+			scratch := readonlyScratchDirs
+			for _, t := range tmpfs {
+				if dir := tmpfsPath(t); !util.ContainsString(scratch, dir) {
+					scratch = append(scratch, dir)
+				}
+			}
+			util.PrintUtil("Only the output directory, read-write mounts and %s are writable\n",
+				strings.Join(scratch, ", "))
+		}
+	}
+
+	result := RunResult{Image: imageName, ExitCode: exitCode, Attempts: attempts, Error: declaredError}
+	result.ExitReason, result.ExitDetail = GetExitReason(&seed, exitCode, oomKilled,
+		atomic.LoadInt32(&timedOut) == 1, err)
+	if len(readOnlyWrites) > 0 {
+		result.ExitDetail += "; wrote to the read-only root filesystem"
+	}
+	if gpuErr != nil {
+		result.ExitReason, result.ExitDetail = ExitInternal, gpuErr.Error()
+	}
+
+	// Check the final size, as the output may have grown past the limit since it was last measured
+	var outputErr error
+	if options.MaxOutputSize > 0 && outDir != "" {
+		size, serr := util.DirSize(outDir)
+		if atomic.LoadInt32(&outputExceeded) == 1 || (serr == nil && size > outputLimit) {
+			outputErr = fmt.Errorf("Output directory %s is %.1f MiB, over the limit of %d MiB given with -%s",
+				outDir, float64(size)/(1024*1024), options.MaxOutputSize, constants.MaxOutputSizeFlag)
+			result.ExitReason, result.ExitDetail = ExitOutputSize, outputErr.Error()
+		}
+	}
+	util.PrintUtil("Exit reason: %s (%s)\n", result.ExitReason, result.ExitDetail)
+	if outDir != "" {
+		if werr := WriteRunResult(outDir, result); werr != nil {
+			util.Errorf("Error writing %s: %s\n", constants.RunResultsFileName, werr.Error())
+		}
+	}
+
+	// Print the summary for orchestration tools once the outputs are validated and uploaded.
+	// Container output goes to stderr, so stdout only contains the summary.
+	var uploadSummary *UploadSummary
+	if options.ResultJson {
+		defer func() {
+			summary := RunSummary{Image: imageName, ExitCode: exitCode, ExitReason: result.ExitReason,
+				Duration: duration.Seconds(), Attempts: attempts, Outputs: MatchOutputFiles(&seed, outDir),
+				Upload: uploadSummary}
+			summaryJson, _ := json.MarshalIndent(summary, "", "  ")
+			fmt.Fprintln(os.Stdout, string(summaryJson))
+		}()
+	}
+
+	uploadOutputs := func() error {
+		uerr := UploadOutputs(outDir, upload)
+		uploadSummary = &UploadSummary{Destination: upload.Redacted()}
+		if uerr != nil {
+			util.Errorf("%s\n", uerr.Error())
+			uploadSummary.Error = uerr.Error()
+		}
+		return uerr
+	}
+	// The outputs of a failed run are only uploaded if asked for. The run error takes precedence
+	// over an upload error.
+	uploadFailed := func() {
+		if upload != nil && options.UploadOnFailure {
+			uploadOutputs()
+		}
+	}
+
+	if gpuErr != nil {
+		return exitCode, wrapError(ErrDockerExec, gpuErr)
+	}
+
+	if outputErr != nil {
+		util.Errorf("%s\n", outputErr.Error())
+		uploadFailed()
+		return exitCode, wrapError(ErrOutputTooLarge, outputErr)
+	}
+
+	if match {
+		uploadFailed()
+		util.PrintUtil( "Exiting seed...\n")
+		return exitCode, wrapError(ErrJobFailed, err)
+	}
+
+	if errs.String() != "" {
+		util.Errorf("Error running image '%s':\n%s\n",
+			imageName, errs.String())
+		uploadFailed()
+		util.PrintUtil( "Exiting seed...\n")
+		return exitCode, wrapError(runErrorKind(exitCode), errors.New(errs.String()))
+	}
+
+	// Validate output against pattern
+	if seed.Job.Interface.Outputs.Files != nil ||
+		seed.Job.Interface.Outputs.JSON != nil {
+		if verr := CheckRunOutput(&seed, outDir, metadataSchema, outputSize); verr != nil && err == nil {
+			uploadFailed()
+			return exitCode, wrapError(ErrValidation, verr)
+		}
+	}
+
+	// Upload the validated outputs
+	if err != nil {
+		uploadFailed()
+	} else if upload != nil {
+		if uerr := uploadOutputs(); uerr != nil {
+			return exitCode, wrapError(ErrUpload, uerr)
+		}
+	}
+
+	// Print the output JSON values for piping into other tools. Container output goes to
+	// stderr, so stdout only contains the values.
+	if options.OutputJson {
+		values, jerr := OutputJsonValues(&seed, outDir)
+		if jerr != nil {
+			util.Errorf("%s\n", jerr.Error())
+			return exitCode, wrapError(ErrValidation, jerr)
+		}
+		valuesJson, _ := json.MarshalIndent(values, "", "  ")
+		fmt.Fprintln(os.Stdout, string(valuesJson))
+	}
+
+	return exitCode, wrapError(runErrorKind(exitCode), err)
+}
+
+//dockerRunFailedCode is the exit code of docker run when docker itself fails to run the container
+const dockerRunFailedCode = 125
+
+//ShouldRestart returns whether a job whose docker run ended with err should be run again: the
+// container exited non-zero, rather than docker failing to run it, and the attempt made is within
+// the restarts allowed
+func ShouldRestart(err error, attempt, restarts int) bool {
+	code := exitStatus(err)
+	return code > 0 && code != dockerRunFailedCode && attempt <= restarts
+}
+
+//exitStatus returns the exit code of a command that ended with err, 0 if it succeeded or -1 if
+// it did not exit
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	code, _ := util.ExitCode(err)
+	return code
+}
+
+//runErrorKind returns the kind of error for a failed run: ErrJobFailed if the job exited with
+// an error code, otherwise ErrDockerExec
+func runErrorKind(exitCode int) error {
+	if exitCode != 0 {
+		return ErrJobFailed
+	}
+	return ErrDockerExec
+}
+
+//runCleanup removes what a seed run leaves behind: the container and its anonymous volumes,
+// if removal was requested, and the temporary directories seed created for the run. It is
+// deferred as soon as the run starts so it runs on success, failure, timeout and interrupt.
+type runCleanup struct {
+	cidFile         string
+	removeContainer bool
+	dirs            []string
+}
+
+//KeepContainer stops the container from being removed, returning its id, or an empty string if
+// it was never created
+func (c *runCleanup) KeepContainer() string {
+	c.removeContainer = false
+	id, err := ioutil.ReadFile(c.cidFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(id))
+}
+
+//Run removes the container and temporary directories
+func (c *runCleanup) Run() {
+	if c.removeContainer {
+		if id, err := ioutil.ReadFile(c.cidFile); err == nil && len(id) > 0 {
+			util.RemoveContainer(string(id))
+		}
+	}
+	for i := len(c.dirs) - 1; i >= 0; i-- {
+		util.Debugf("Removing temporary directory %s\n", c.dirs[i])
+		util.RemoveAllFiles(c.dirs[i])
+	}
+}
+
+//createLogFiles creates the files the container stdout and stderr are captured to in outDir
+func createLogFiles(outDir string) (*os.File, *os.File, error) {
+	stdoutLog, err := os.Create(filepath.Join(outDir, constants.StdoutLogFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+	stderrLog, err := os.Create(filepath.Join(outDir, constants.StderrLogFileName))
+	if err != nil {
+		stdoutLog.Close()
+		return nil, nil, err
+	}
+	return stdoutLog, stderrLog, nil
+}
+
+//closeLogFile flushes a captured log file to disk and closes it
+func closeLogFile(f *os.File) {
+	if err := f.Sync(); err != nil {
+		util.Warnf("Error flushing %s: %s\n", f.Name(), err.Error())
+	}
+	if err := f.Close(); err != nil {
+		util.Warnf("Error closing %s: %s\n", f.Name(), err.Error())
+	}
+}
+
+//readonlyScratchDirs are the directories mounted as tmpfs with a read-only root filesystem, as
+// many programs and libraries write temporary files to them
+var readonlyScratchDirs = []string{"/tmp", "/var/tmp"}
+
+//ReadonlyRootfsArgs returns the docker run arguments for a read-only root filesystem with a
+// writable tmpfs mounted at each of the scratch directories not already mounted by one of the
+// given -tmpfs mounts
+func ReadonlyRootfsArgs(tmpfs []string) []string {
+	mounted := map[string]bool{}
+	for _, t := range tmpfs {
+		mounted[tmpfsPath(t)] = true
+	}
+	args := []string{"--read-only"}
+	for _, dir := range readonlyScratchDirs {
+		if !mounted[dir] {
+			args = append(args, "--tmpfs", dir)
+		}
+	}
+	return args
+}
+
+//tmpfsFlags are the mount flags a -tmpfs mount may be given
+var tmpfsFlags = []string{"rw", "ro", "exec", "noexec", "suid", "nosuid", "dev", "nodev"}
+
+//tmpfsOptionRegexes match the values of the KEY=VALUE options a -tmpfs mount may be given
+var tmpfsOptionRegexes = map[string]*regexp.Regexp{
+	"size":      regexp.MustCompile(`^[0-9]+[kKmMgG%]?$`),
+	"nr_blocks": regexp.MustCompile(`^[0-9]+[kKmMgG]?$`),
+	"nr_inodes": regexp.MustCompile(`^[0-9]+[kKmMgG]?$`),
+	"mode":      regexp.MustCompile(`^[0-7]{3,4}$`),
+	"uid":       regexp.MustCompile(`^[0-9]+$`),
+	"gid":       regexp.MustCompile(`^[0-9]+$`),
+}
+
+//ResolveTmpfs validates the tmpfs mounts given with -tmpfs, ignoring empty values, and returns
+// them for docker run --tmpfs. Mounts are given as PATH[:OPTIONS], where PATH is an absolute
+// container path other than / and OPTIONS are comma separated mount flags, i.e. noexec, and
+// size, mode, uid, gid, nr_blocks and nr_inodes values, i.e. size=1g.
+func ResolveTmpfs(specs []string) ([]string, error) {
+	var resolved []string
+	var paths []string
+	for _, spec := range specs {
+		if spec == "" {
+			continue
+		}
+		x := strings.SplitN(spec, ":", 2)
+		dir := x[0]
+		if !path.IsAbs(dir) || path.Clean(dir) == "/" {
+			return nil, fmt.Errorf("Invalid -%s value %s. The mount path must be an absolute path in the "+
+				"container other than /, i.e. /scratch", constants.TmpfsFlag, spec)
+		}
+		dir = path.Clean(dir)
+		if util.ContainsString(paths, dir) {
+			return nil, fmt.Errorf("Invalid -%s value %s. %s is already mounted by -%s", constants.TmpfsFlag,
+				spec, dir, constants.TmpfsFlag)
+		}
+		paths = append(paths, dir)
+
+		if len(x) == 1 {
+			resolved = append(resolved, dir)
+			continue
+		}
+		for _, option := range strings.Split(x[1], ",") {
+			kv := strings.SplitN(option, "=", 2)
+			if len(kv) == 1 && util.ContainsString(tmpfsFlags, option) {
+				continue
+			}
+			if regex, ok := tmpfsOptionRegexes[kv[0]]; ok && len(kv) == 2 && regex.MatchString(kv[1]) {
+				continue
+			}
+			return nil, fmt.Errorf("Invalid -%s value %s. Unknown or malformed option %s; options are %s or "+
+				"size, mode, uid, gid, nr_blocks and nr_inodes values, i.e. size=1g", constants.TmpfsFlag, spec,
+				option, strings.Join(tmpfsFlags, ", "))
+		}
+		resolved = append(resolved, dir+":"+x[1])
+	}
+	return resolved, nil
+}
+
+//tmpfsPath returns the container path of a docker run --tmpfs mount
+func tmpfsPath(tmpfs string) string {
+	return strings.SplitN(tmpfs, ":", 2)[0]
+}
+
+//ReadOnlyWrites returns the lines of container error output reporting a write to a read-only
+// file system, i.e. "touch: cannot touch '/opt/out.txt': Read-only file system"
+func ReadOnlyWrites(stderr string) []string {
+	var writes []string
+	for _, line := range strings.Split(stderr, "\n") {
+		if strings.Contains(strings.ToLower(line), "read-only file system") {
+			writes = append(writes, strings.TrimSpace(line))
+		}
+	}
+	return writes
+}
+
+//GetExitReason classifies how a container run ended from its exit code and state, and
+// returns a human readable detail message
+func GetExitReason(seed *objects.Seed, exitCode int, oomKilled, timedOut bool, runErr error) (ExitReason, string) {
+	if _, ok := util.ExitCode(runErr); runErr != nil && !ok {
+		return ExitInternal, "Error executing docker run: " + runErr.Error()
+	}
+
+	switch {
+	case timedOut:
+		return ExitTimeout, fmt.Sprintf("Exceeded job timeout of %d seconds", seed.Job.Timeout)
+	case oomKilled:
+		return ExitOOM, "Killed after exceeding the memory limit"
+	case exitCode == 125:
+		return ExitInternal, "Docker failed to start the container"
+	case exitCode > 128:
+		signal := syscall.Signal(exitCode - 128)
+		return ExitSignal, fmt.Sprintf("Killed by signal %d (%s)", exitCode-128, signal.String())
+	}
+
+	detail := fmt.Sprintf("Exited with code %d", exitCode)
+	if e, ok := objects.NewErrorMapping(seed.Job.Errors).Lookup(exitCode); ok {
+		detail += ": " + e.Title
+	}
+	return ExitNormal, detail
+}
+
+//GenericExitMessage describes an exit code that is not declared in the manifest using the
+// conventions of the shell and docker
+func GenericExitMessage(exitCode int) string {
+	switch {
+	case exitCode == 1:
+		return "General error"
+	case exitCode == 2:
+		return "Misuse of a shell builtin or invalid arguments"
+	case exitCode == 125:
+		return "Docker failed to start the container"
+	case exitCode == 126:
+		return "Command found but could not be executed"
+	case exitCode == 127:
+		return "Command not found"
+	case exitCode > 128 && exitCode < 256:
+		signal := syscall.Signal(exitCode - 128)
+		return fmt.Sprintf("Killed by signal %d (%s)", exitCode-128, signal.String())
+	}
+	return fmt.Sprintf("Job failed with undeclared error code %d", exitCode)
+}
+
+//WriteRunResult writes the run result to seed.run.json in the output directory
+func WriteRunResult(outDir string, result RunResult) error {
+	bytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(outDir, constants.RunResultsFileName), bytes, 0644)
+}
+
+//ResolveInputBindings returns the host path, container path and size bound to each input
+// given for a run, in the order given. inputs are KEY=VALUE pairs as passed to DefineInputs and
+// tempDirectories the directories it created for inputs that accept multiple files.
+func ResolveInputBindings(seed *objects.Seed, inputs []string, tempDirectories map[string]string) []ResolvedInput {
+	declared := map[string]objects.InFile{}
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		declared[f.Name] = f
+	}
+
+	resolved := []ResolvedInput{}
+	for _, in := range inputs {
+		x := strings.SplitN(in, "=", 2)
+		if len(x) != 2 {
+			continue
+		}
+		file, ok := declared[x[0]]
+		if !ok {
+			continue
+		}
+		input := ResolvedInput{Name: x[0], HostPath: util.GetFullPath(x[1], "")}
+		input.ContainerPath = util.ContainerPath(input.HostPath)
+		if dir, ok := tempDirectories[input.Name]; file.Multiple && ok {
+			input.ContainerPath = path.Join("/"+filepath.ToSlash(dir), filepath.Base(input.HostPath))
+		}
+		if info, err := os.Stat(input.HostPath); err == nil {
+			input.Size = info.Size()
+			if info.IsDir() {
+				input.Size, _ = util.DirSize(input.HostPath)
+			}
+		}
+		resolved = append(resolved, input)
+	}
+	return resolved
+}
+
+//WriteResolvedInputs writes the inputs bound for a run to inputs.resolved.json in the output
+// directory
+func WriteResolvedInputs(outDir string, inputs []ResolvedInput) error {
+	bytes, err := json.MarshalIndent(inputs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(outDir, constants.InputsResolvedFileName), bytes, 0644)
+}
+
+//checkImagePlatform warns when an image built with seed build -platform targets a different
+// os or architecture than the docker daemon, as it can only run under emulation
+func checkImagePlatform(imageName string) {
+	platform, err := util.ImageLabel(imageName, constants.PlatformLabel)
+	if err != nil || platform == "" {
+		return
+	}
+	host, err := util.ServerPlatform()
+	if err != nil {
+		util.Debugf("Error retrieving docker host platform: %s\n", err.Error())
+		return
+	}
+	if !PlatformsMatch(platform, host) {
+		util.Warnf("Image %s was built for %s but the docker host is %s. The job will fail unless emulation "+
+			"for %s is installed on the host (i.e. qemu with binfmt_misc).\n", imageName, platform, host, platform)
+	}
+}
+
+//PlatformsMatch returns if two os/arch[/variant] platforms have the same os and architecture.
+// Variants are ignored.
+func PlatformsMatch(a, b string) bool {
+	osArch := func(platform string) string {
+		parts := strings.SplitN(platform, "/", 3)
+		if len(parts) > 2 {
+			parts = parts[:2]
+		}
+		return strings.Join(parts, "/")
+	}
+	return osArch(a) == osArch(b)
+}
+
+//ResolveUser returns the user the container should run as, given with the -user flag in the
+// form user[:group]. Returns an empty string if no user is given so the image default is used.
+func ResolveUser(flagUser string) (string, error) {
+	if flagUser == "" {
+		return "", nil
+	}
+	if err := util.ValidateUser(flagUser); err != nil {
+		return "", errors.New("Invalid -" + constants.UserFlag + " value: " + err.Error())
+	}
+	return flagUser, nil
+}
+
+//InputURL is an input of a seed run downloaded from a URL, with the checksum the file must
+// match, if given
+type InputURL struct {
+	Name      string
+	URL       *url.URL
+	Algorithm string
+	Checksum  string
+}
+
+//checksumHashes are the hashes a checksum in the fragment of an -input-url may be given with
+var checksumHashes = map[string]func() hash.Hash{"sha256": sha256.New, "sha512": sha512.New}
+
+//ResolveInputURLs validates the inputs given with -input-url as NAME=URL, ignoring empty values.
+// The name must be an input file of the manifest not given in explicit, the KEY=PATH input
+// arguments, and the URL http or https. A fragment of sha256=HEX or sha512=HEX is the checksum
+// of the file; the fragment is never sent to the server.
+func ResolveInputURLs(seed *objects.Seed, specs, explicit []string) ([]InputURL, error) {
+	declared := map[string]bool{}
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		declared[f.Name] = true
+	}
+	given := inputMap(explicit)
+
+	var inputs []InputURL
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		if spec == "" {
+			continue
+		}
+		x := strings.SplitN(spec, "=", 2)
+		if len(x) != 2 || x[0] == "" {
+			return nil, fmt.Errorf("Invalid -%s value %s. Inputs are given as NAME=URL, i.e. "+
+				"IMAGE=https://data.example.com/scene.tif", constants.InputURLFlag, spec)
+		}
+		name := x[0]
+		if !declared[name] {
+			return nil, fmt.Errorf("Invalid -%s value %s. %s is not an input file of the seed manifest",
+				constants.InputURLFlag, spec, name)
+		}
+		if _, ok := given[name]; ok || seen[name] {
+			return nil, fmt.Errorf("Input %s is given more than once; give each input with either -%s or -%s",
+				name, constants.ShortInputsFlag, constants.InputURLFlag)
+		}
+		seen[name] = true
+
+		u, err := url.Parse(x[1])
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("Invalid -%s value %s. The URL must be http or https", constants.InputURLFlag,
+				name+"="+redactURL(x[1]))
+		}
+		in := InputURL{Name: name, URL: u}
+		if u.Fragment != "" {
+			c := strings.SplitN(u.Fragment, "=", 2)
+			newHash, ok := checksumHashes[c[0]]
+			if len(c) != 2 || !ok {
+				return nil, fmt.Errorf("Invalid -%s checksum #%s for input %s. Checksums are given as "+
+					"#sha256=HEX or #sha512=HEX", constants.InputURLFlag, u.Fragment, name)
+			}
+			in.Algorithm, in.Checksum = c[0], strings.ToLower(c[1])
+			if _, err := hex.DecodeString(in.Checksum); err != nil || len(in.Checksum) != 2*newHash().Size() {
+				return nil, fmt.Errorf("Invalid -%s checksum #%s for input %s. %s checksums are %d hex digits",
+					constants.InputURLFlag, u.Fragment, name, c[0], 2*newHash().Size())
+			}
+			u.Fragment = ""
+		}
+		inputs = append(inputs, in)
+	}
+	return inputs, nil
+}
+
+//redactURL returns the url with any password replaced, so it can be logged
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	return u.Redacted()
+}
+
+//DownloadInput downloads the input to a file in dir named after the last element of its URL path
+// and returns the path of the file. If the URL carries no credentials the request is sent with the
+// bearer token in SEED_INPUT_TOKEN, or basic auth from SEED_INPUT_USER and SEED_INPUT_PASSWORD.
+// The download fails if the server responds without success, fewer bytes arrive than its
+// Content-Length or the file does not match the checksum of the input.
+func DownloadInput(in InputURL, dir string) (string, error) {
+	inputDir := filepath.Join(dir, in.Name)
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		return "", err
+	}
+	name := path.Base(in.URL.Path)
+	if name == "." || name == "/" {
+		name = in.Name
+	}
+	file := filepath.Join(inputDir, name)
+
+	req, err := http.NewRequest(http.MethodGet, in.URL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	setEnvAuth(req, in.URL, constants.InputTokenEnv, constants.InputUserEnv, constants.InputPasswordEnv)
+
+	// Stop the download if seed is interrupted or the download stalls
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	source := in.URL.Redacted()
+	util.Infof("Downloading input %s from %s\n", in.Name, source)
+	resp, err := transferClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("Error downloading input %s from %s: %s", in.Name, source, err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Error downloading input %s from %s: %s", in.Name, source, resp.Status)
+	}
+
+	out, err := os.Create(file)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	var sum hash.Hash
+	var w io.Writer = out
+	if in.Algorithm != "" {
+		sum = checksumHashes[in.Algorithm]()
+		w = io.MultiWriter(out, sum)
+	}
+	body := newStallReader(resp.Body, cancel)
+	n, err := io.Copy(w, body)
+	if stalled := body.Stop(); stalled {
+		return "", fmt.Errorf("Error downloading input %s from %s: the download stalled for %s", in.Name, source,
+			stallTimeout)
+	} else if err != nil {
+		return "", fmt.Errorf("Error downloading input %s from %s: %s", in.Name, source, err.Error())
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return "", fmt.Errorf("Input %s downloaded from %s is %d bytes, expected a Content-Length of %d bytes",
+			in.Name, source, n, resp.ContentLength)
+	}
+	if sum != nil {
+		if actual := hex.EncodeToString(sum.Sum(nil)); actual != in.Checksum {
+			return "", fmt.Errorf("Input %s downloaded from %s has %s checksum %s, expected %s", in.Name,
+				source, in.Algorithm, actual, in.Checksum)
+		}
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+	util.Infof("Downloaded input %s to %s (%d bytes)\n", in.Name, file, n)
+	return file, nil
+}
+
+//MatchInputDir matches the files in dir to the manifest inputs not given in explicit, the KEY=PATH
+// input arguments, and returns KEY=PATH arguments for them and the names of the required inputs
+// left unmatched. A file or directory matches an input named after it, ignoring case and extension,
+// i.e. image.tif matches IMAGE. An input accepting multiple files matches the files of a
+// subdirectory named after it. Inputs no file is named after match a file of one of their media
+// types, if only one such file is left. Each file is matched to at most one input.
+func MatchInputDir(seed *objects.Seed, dir string, explicit []string) ([]string, []string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	dir = util.GetFullPath(dir, "")
+
+	given := inputMap(explicit)
+	claimed := map[string]bool{}
+	matched := map[string][]string{}
+	var pending []objects.InFile
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		if _, ok := given[f.Name]; ok {
+			continue
+		}
+		for _, e := range entries {
+			stem := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+			if claimed[e.Name()] || (!strings.EqualFold(e.Name(), f.Name) && !strings.EqualFold(stem, f.Name)) {
+				continue
+			}
+			path := filepath.Join(dir, e.Name())
+			switch {
+			case f.Multiple && e.IsDir():
+				files, err := ioutil.ReadDir(path)
+				if err != nil {
+					return nil, nil, err
+				}
+				for _, file := range files {
+					if !file.IsDir() {
+						matched[f.Name] = append(matched[f.Name], filepath.Join(path, file.Name()))
+					}
+				}
+			case f.Directory == e.IsDir():
+				matched[f.Name] = append(matched[f.Name], path)
+			default:
+				continue
+			}
+			claimed[e.Name()] = true
+			if !f.Multiple {
+				break
+			}
+		}
+		if len(matched[f.Name]) == 0 {
+			pending = append(pending, f)
+		}
+	}
+
+	var unmatched []string
+	for _, f := range pending {
+		var candidates []string
+		for _, e := range entries {
+			if claimed[e.Name()] || e.IsDir() || f.Directory || !matchesMediaType(e.Name(), f.MediaTypes) {
+				continue
+			}
+			candidates = append(candidates, e.Name())
+		}
+		if len(candidates) == 1 || (f.Multiple && len(candidates) > 0) {
+			for _, name := range candidates {
+				claimed[name] = true
+				matched[f.Name] = append(matched[f.Name], filepath.Join(dir, name))
+			}
+		} else if len(candidates) > 1 {
+			util.Warnf("Input %s matches %d files of its media types in %s: %s\n", f.Name, len(candidates),
+				dir, strings.Join(candidates, ", "))
+		}
+		if len(matched[f.Name]) == 0 && f.Required {
+			unmatched = append(unmatched, f.Name)
+		}
+	}
+
+	var inputs []string
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		for _, path := range matched[f.Name] {
+			util.Infof("Matched input %s to %s\n", f.Name, path)
+			inputs = append(inputs, f.Name+"="+path)
+		}
+	}
+	return inputs, unmatched, nil
+}
+
+//matchesMediaType returns whether the media type of a file, from its extension, is one of
+// mediaTypes
+func matchesMediaType(name string, mediaTypes []string) bool {
+	mType := mime.TypeByExtension(filepath.Ext(name))
+	return mType != "" && util.MediaTypeMatches(mType, mediaTypes)
+}
+
+//CheckInputMediaTypes checks the files given for each input, as KEY=PATH arguments, against the
+// media types the manifest declares for it. The media type of a file is derived from its
+// extension and sniffed from its content; it matches if either is declared. A mismatch is a
+// warning, or an error if strict is set. Directories and files whose media type cannot be
+// determined are not checked.
+func CheckInputMediaTypes(seed *objects.Seed, inputs []string, strict bool) error {
+	declared := map[string][]string{}
+	for _, f := range seed.Job.Interface.Inputs.Files {
+		if !f.Directory && len(f.MediaTypes) > 0 {
+			declared[f.Name] = f.MediaTypes
+		}
 	}
 
-	// Run docker run
-	runTime := time.Now()
-	err := dockerRun.Run()
-	util.TimeTrack(runTime, "INFO: "+imageName+" run")
-	exitCode := 0
-	if err != nil {
-		exitError, ok := err.(*exec.ExitError)
-		if ok {
-			ws := exitError.Sys().(syscall.WaitStatus)
-			exitCode = ws.ExitStatus()
-			util.PrintUtil( "Exited with error code %v\n", exitCode)
-			match := false
-			for _, e := range seed.Job.Errors {
-				if e.Code == exitCode {
-					util.PrintUtil( "Title: \t %s\n", e.Title)
-					util.PrintUtil( "Description: \t %s\n", e.Description)
-					util.PrintUtil( "Category: \t %s \n \n", e.Category)
-					match = true
-					util.PrintUtil( "Exiting seed...\n")
-					return exitCode, err
-				}
-			}
-			if !match {
-				util.PrintUtil( "No matching error code found in Seed manifest\n")
+	var mismatches []string
+	for _, in := range inputs {
+		x := strings.SplitN(in, "=", 2)
+		mediaTypes, ok := declared[x[0]]
+		if len(x) != 2 || !ok {
+			continue
+		}
+		if info, err := os.Stat(x[1]); err != nil || info.IsDir() {
+			continue
+		}
+		types, err := util.FileMediaTypes(x[1])
+		if err != nil || len(types) == 0 {
+			util.Debugf("Could not determine the media type of input %s file %s\n", x[0], x[1])
+			continue
+		}
+		matched := false
+		for _, t := range types {
+			matched = matched || util.MediaTypeMatches(t, mediaTypes)
+		}
+		if !matched {
+			mismatch := fmt.Sprintf("Input %s file %s is %s but the manifest declares %s", x[0], x[1],
+				strings.Join(types, " or "), strings.Join(mediaTypes, ", "))
+			mismatches = append(mismatches, mismatch)
+			if !strict {
+				util.Warnf("%s\n", mismatch)
 			}
-		} else {
-			util.PrintUtil( "ERROR: error executing docker run. %s\n",
-				err.Error())
 		}
 	}
+	if strict && len(mismatches) > 0 {
+		return fmt.Errorf("%s. Check the files given with -%s, or run without -%s",
+			strings.Join(mismatches, "\n"), constants.ShortInputsFlag, constants.StrictInputsFlag)
+	}
+	return nil
+}
 
-	if errs.String() != "" {
-		util.PrintUtil( "ERROR: Error running image '%s':\n%s\n",
-			imageName, errs.String())
-		util.PrintUtil( "Exiting seed...\n")
-		return exitCode, errors.New(errs.String())
+//ResolveInputs expands the paths of KEY=PATH input arguments to absolute paths. Relative
+// paths are resolved against the current directory, or against the directory containing
+// the seed manifest in jobDirectory when relativeTo is manifest.
+func ResolveInputs(inputs []string, relativeTo, jobDirectory string) ([]string, error) {
+	baseDir := ""
+	switch relativeTo {
+	case "", constants.RelativeToCwd:
+	case constants.RelativeToManifest:
+		seedFileName, err := util.SeedFileName(jobDirectory)
+		if err != nil {
+			return nil, err
+		}
+		baseDir = filepath.Dir(seedFileName)
+	default:
+		return nil, fmt.Errorf("ERROR: Unknown -%s value %q. Expected %s or %s.\n",
+			constants.InputsRelativeToFlag, relativeTo, constants.RelativeToCwd, constants.RelativeToManifest)
 	}
 
-	// Validate output against pattern
-	if seed.Job.Interface.Outputs.Files != nil ||
-		seed.Job.Interface.Outputs.JSON != nil {
-		CheckRunOutput(&seed, outDir, metadataSchema, outputSize)
+	var resolved []string
+	for _, f := range inputs {
+		x := strings.SplitN(f, "=", 2)
+		if len(x) != 2 {
+			// Leave malformed inputs for DefineInputs to report
+			resolved = append(resolved, f)
+			continue
+		}
+
+		path := x[1]
+		if baseDir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		} else {
+			path = util.GetFullPath(path, "")
+		}
+		util.Infof("Resolved input %s to %s\n", x[0], path)
+		resolved = append(resolved, x[0]+"="+path)
 	}
 
-	return exitCode, err
+	return resolved, nil
 }
 
 //DefineInputs extracts the paths to any input data given by the 'run' command
 // flags 'inputs' and sets the path in the json object. Returns:
 // 	[]string: docker command args for input files in the format:
 //	"-v /path/to/file1:/path/to/file1 -v /path/to/file2:/path/to/file2 etc"
-func DefineInputs(seed *objects.Seed, inputs []string) ([]string, float64, map[string]string, error) {
+// Inputs declared as directories in the manifest are mounted the same way and must be
+// given a directory; all other non-multiple inputs must be given a file. If readOnly is set
+// the input mounts are suffixed with :ro.
+func DefineInputs(seed *objects.Seed, inputs []string, readOnly bool) ([]string, float64, map[string]string, error) {
 	// Validate inputs given vs. inputs defined in manifest
 
 	var mountArgs []string
@@ -212,12 +1638,16 @@ func DefineInputs(seed *objects.Seed, inputs []string) ([]string, float64, map[s
 	tempDirectories = make(map[string]string)
 	for _, f := range seed.Job.Interface.Inputs.Files {
 		if f.Multiple {
-			tempDir := "temp-" + time.Now().Format(time.RFC3339)
-			tempDir = strings.Replace(tempDir, ":", "_", -1)
-			os.Mkdir(tempDir, os.ModePerm)
+			// a unique directory per input, so concurrent runs and inputs do not share one. It is
+			// created in the current directory so input files can be hard linked into it.
+			tempDir, err := ioutil.TempDir(".", "temp-")
+			if err != nil {
+				return nil, 0.0, tempDirectories, err
+			}
+			os.Chmod(tempDir, 0755)
 			tempDirectories[f.Name] = tempDir
 			mountArgs = append(mountArgs, "-v")
-			mountArgs = append(mountArgs, util.GetFullPath(tempDir, "")+":/"+tempDir)
+			mountArgs = append(mountArgs, bindMount(util.GetFullPath(tempDir, ""), "/"+tempDir, readOnly))
 		}
 		if f.Required == false {
 			unrequired = append(unrequired, f.Name)
@@ -244,8 +1674,8 @@ func DefineInputs(seed *objects.Seed, inputs []string) ([]string, float64, map[s
 	for _, f := range inputs {
 		x := strings.SplitN(f, "=", 2)
 		if len(x) != 2 {
-			util.PrintUtil( "ERROR: Input files should be specified in KEY=VALUE format.\n")
-			util.PrintUtil( "ERROR: Unknown key for input %v encountered.\n",
+			util.Errorf("Input files should be specified in KEY=VALUE format.\n")
+			util.Errorf("Unknown key for input %v encountered.\n",
 				inputs)
 			continue
 		}
@@ -259,13 +1689,29 @@ func DefineInputs(seed *objects.Seed, inputs []string) ([]string, float64, map[s
 		//get total size of input files in MiB
 		info, err := os.Stat(val)
 		if os.IsNotExist(err) {
-			util.PrintUtil( "ERROR: Input file %s not found\n", val)
+			util.Errorf("Input file %s not found\n", val)
+			return nil, 0.0, tempDirectories, err
+		}
+		size := info.Size()
+		for _, k := range seed.Job.Interface.Inputs.Files {
+			if k.Name != key {
+				continue
+			}
+			if err := checkInputType(k, val, info.IsDir()); err != nil {
+				return nil, 0.0, tempDirectories, err
+			}
+			if k.Directory {
+				size, err = util.DirSize(val)
+				if err != nil {
+					return nil, 0.0, tempDirectories, err
+				}
+			}
 		}
-		sizeMiB += (1.0 * float64(info.Size())) / (1024.0 * 1024.0) //fileinfo's Size() returns bytes, convert to MiB
+		sizeMiB += (1.0 * float64(size)) / (1024.0 * 1024.0) //fileinfo's Size() returns bytes, convert to MiB
 
 		// Replace key if found in args strings
 		// Handle replacing KEY or ${KEY} or $KEY
-		value := val
+		value := util.ContainerPath(val)
 		if directory, ok := tempDirectories[key]; ok {
 			value = directory //replace with the temp directory if multiple files
 		}
@@ -283,7 +1729,7 @@ func DefineInputs(seed *objects.Seed, inputs []string) ([]string, float64, map[s
 					os.Link(val, filepath.Join(tempDirectories[key], info.Name()))
 				} else {
 					mountArgs = append(mountArgs, "-v")
-					mountArgs = append(mountArgs, val+":"+val)
+					mountArgs = append(mountArgs, bindMount(val, util.ContainerPath(val), readOnly))
 				}
 			}
 		}
@@ -304,19 +1750,90 @@ func DefineInputs(seed *objects.Seed, inputs []string) ([]string, float64, map[s
 	return mountArgs, sizeMiB, tempDirectories, nil
 }
 
-//SetOutputDir replaces the OUTPUT_DIR argument with the given output directory.
-// Returns output directory string
-func SetOutputDir(imageName string, seed *objects.Seed, outputDir string) string {
+//bindMount returns the docker -v value mounting hostPath at containerPath. The mode is
+// appended after the container path, and on Windows the host path is converted to the
+// //c/data form, so host paths containing a drive letter are parsed correctly by docker.
+func bindMount(hostPath, containerPath string, readOnly bool) string {
+	mount := util.DockerHostPath(hostPath) + ":" + containerPath
+	if readOnly {
+		mount += ":ro"
+	}
+	return mount
+}
+
+//DefineDirectoryInputs marks the inputs named with -directory-input as taking a directory. An
+// input that declares mediaTypes takes a file, and a multiple input already takes a directory of
+// files, so neither may be given a directory.
+func DefineDirectoryInputs(seed *objects.Seed, names []string) error {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		found := false
+		for i := range seed.Job.Interface.Inputs.Files {
+			f := &seed.Job.Interface.Inputs.Files[i]
+			if f.Name != name {
+				continue
+			}
+			found = true
+			if f.Multiple {
+				return fmt.Errorf("Invalid -%s value %s. The input is multiple and is given a directory of "+
+					"files with -%s", constants.DirectoryInputFlag, name, constants.ShortInputsFlag)
+			}
+			if len(f.MediaTypes) > 0 {
+				return fmt.Errorf("Invalid -%s value %s. The input declares mediaTypes, so it takes a file",
+					constants.DirectoryInputFlag, name)
+			}
+			f.Directory = true
+		}
+		if !found {
+			return fmt.Errorf("Invalid -%s value %s. The seed manifest has no input named %s",
+				constants.DirectoryInputFlag, name, name)
+		}
+	}
+	return nil
+}
+
+//checkInputType verifies that an input path is a directory if and only if the input was given
+// with -directory-input. Multiple inputs are not checked.
+func checkInputType(file objects.InFile, path string, isDir bool) error {
+	if file.Multiple {
+		return nil
+	}
+	if file.Directory && !isDir {
+		return errors.New("ERROR: Input " + file.Name + " is given with -" + constants.DirectoryInputFlag +
+			" but " + path + " is not a directory.\n")
+	}
+	if !file.Directory && isDir {
+		return errors.New("ERROR: Input " + file.Name + " is a directory (" + path + "). Give it with -" +
+			constants.DirectoryInputFlag + " " + file.Name + " to mount a directory.\n")
+	}
+	return nil
+}
+
+//SetOutputDir replaces the OUTPUT_DIR argument with the given output directory, or a timestamped
+// subdirectory of it if it is not empty or timestamp is set. As a non-empty directory always gets
+// a subdirectory, timestamp only changes where the outputs of a run into an empty directory go.
+// Returns the output directory, or an error if the subdirectory cannot be created.
+func SetOutputDir(imageName string, seed *objects.Seed, outputDir string, timestamp bool) (string, error) {
 	if !strings.Contains(seed.Job.Interface.Command, "OUTPUT_DIR") {
-		return ""
+		return "", nil
 	}
 
 	// #37: if -o is not specified, and OUTPUT_DIR is in the command args,
 	//	auto create a time-stamped subdirectory with the name of the form:
 	//		imagename-iso8601timestamp
 	if outputDir == "" {
-		outputDir = "output-" + imageName + "-" + time.Now().Format(time.RFC3339)
+		// Name it after the repository and tag, as the registry and organization would add directories
+		ref := util.SplitReference(imageName)
+		name := ref.Repo
+		if ref.Tag != "" {
+			name += ":" + ref.Tag
+		}
+		outputDir = "output-" + name + "-" + time.Now().Format(time.RFC3339)
 		outputDir = strings.Replace(outputDir, ":", "_", -1)
+		// the default directory is already unique to the run
+		timestamp = false
 	}
 
 	outdir := util.GetFullPath(outputDir, "")
@@ -325,7 +1842,7 @@ func SetOutputDir(imageName string, seed *objects.Seed, outputDir string) string
 	if _, err := os.Stat(outdir); os.IsNotExist(err) {
 		// Create the directory
 		// Didn't find the specified directory
-		util.PrintUtil( "INFO: %s not found; creating directory...\n",
+		util.Infof("%s not found; creating directory...\n",
 			outdir)
 		os.Mkdir(outdir, os.ModePerm)
 	}
@@ -334,42 +1851,77 @@ func SetOutputDir(imageName string, seed *objects.Seed, outputDir string) string
 	f, err := os.Open(outdir)
 	if err != nil {
 		// complain
-		util.PrintUtil( "ERROR: Error with %s. %s\n", outdir, err.Error())
+		util.Errorf("Error with %s. %s\n", outdir, err.Error())
 	}
 	defer f.Close()
 	_, err = f.Readdirnames(1)
-	if err != io.EOF {
+	if timestamp {
+		if outdir, err = timestampedDir(outdir, time.Now()); err != nil {
+			return "", err
+		}
+		util.PrintUtil("Job output directory: %s\n", outdir)
+	} else if err != io.EOF {
 		// Directory is not empty
-		t := time.Now().Format("20060102_150405")
-		util.PrintUtil(
-			"INFO: Output directory %s is not empty. Creating sub-directory %s for Job Output Directory.\n",
-			outdir, t)
-		outdir = filepath.Join(outdir, t)
-		os.Mkdir(outdir, os.ModePerm)
+		sub, err := timestampedDir(outdir, time.Now())
+		if err != nil {
+			return "", err
+		}
+		util.Infof(
+			"Output directory %s is not empty. Created sub-directory %s for Job Output Directory.\n",
+			outdir, filepath.Base(sub))
+		outdir = sub
 	}
 
 	seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command,
-		"$OUTPUT_DIR", outdir, -1)
+		"$OUTPUT_DIR", util.ContainerPath(outdir), -1)
 	seed.Job.Interface.Command = strings.Replace(seed.Job.Interface.Command,
-		"${OUTPUT_DIR}", outdir, -1)
-	return outdir
+		"${OUTPUT_DIR}", util.ContainerPath(outdir), -1)
+	return outdir, nil
+}
+
+//timestampedDir creates and returns a subdirectory of dir named after t, i.e. 20060102_150405,
+// suffixed with a count if a run in the same second already created it. The directory is claimed
+// by creating it, so concurrent runs never share one.
+func timestampedDir(dir string, t time.Time) (string, error) {
+	name := filepath.Join(dir, t.Format("20060102_150405"))
+	sub := name
+	for i := 2; ; i++ {
+		err := os.Mkdir(sub, os.ModePerm)
+		if err == nil {
+			return sub, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("Error creating output directory %s: %s", sub, err.Error())
+		}
+		sub = fmt.Sprintf("%s_%d", name, i)
+	}
 }
 
-//DefineMounts defines any seed specified mounts.
+//DefineMounts defines any seed specified mounts. Every mount declared in the manifest must be
+// given a host path with -m; a read-only mount must exist and the host directory of a writable
+// mount is created if it does not. Mounts given that the manifest does not declare are ignored
+// with a warning.
 func DefineMounts(seed *objects.Seed, inputs []string) ([]string, error) {
-	inMap := inputMap(inputs)
+	var given []string
+	for _, in := range inputs {
+		if in != "" {
+			given = append(given, in)
+		}
+	}
+	inMap := inputMap(given)
 
-	// Valid by default
-	valid := true
 	var keys []string
+	var missing []string
+	declared := map[string]bool{}
 	for _, f := range seed.Job.Interface.Mounts {
 		keys = append(keys, f.Name)
-		if _, prs := inMap[f.Name]; !prs {
-			valid = false
+		declared[f.Name] = true
+		if inMap[f.Name] == "" {
+			missing = append(missing, f.Name)
 		}
 	}
 
-	if !valid {
+	if len(missing) > 0 {
 		var buffer bytes.Buffer
 		buffer.WriteString("ERROR: Incorrect mount key/values provided. -m arguments should be in the form:\n")
 		buffer.WriteString("  seed run -m MOUNT=path/to ...\n")
@@ -377,25 +1929,46 @@ func DefineMounts(seed *objects.Seed, inputs []string) ([]string, error) {
 		for _, n := range keys {
 			buffer.WriteString("  " + n + "\n")
 		}
+		buffer.WriteString("The following mounts are missing:\n")
+		for _, n := range missing {
+			buffer.WriteString("  " + n + "\n")
+		}
 		buffer.WriteString("\n")
 		return nil, errors.New(buffer.String())
 	}
 
+	var undeclared []string
+	for key := range inMap {
+		if !declared[key] {
+			undeclared = append(undeclared, key)
+		}
+	}
+	sort.Strings(undeclared)
+	for _, key := range undeclared {
+		util.Warnf("Mount %s is not declared in the seed manifest and is ignored\n", key)
+	}
+
 	var mounts []string
-	if seed.Job.Interface.Mounts != nil {
-		for _, mount := range seed.Job.Interface.Mounts {
-			mounts = append(mounts, "-v")
-			localPath := util.GetFullPath(inMap[mount.Name], "")
-			mountPath := localPath + ":" + mount.Path
-
-			if mount.Mode != "" {
-				mountPath += ":" + mount.Mode
-			} else {
-				mountPath += ":ro"
+	for _, mount := range seed.Job.Interface.Mounts {
+		localPath := util.GetFullPath(inMap[mount.Name], "")
+		mode := mount.Mode
+		if mode == "" {
+			mode = "ro"
+		}
+
+		if _, err := os.Stat(localPath); os.IsNotExist(err) {
+			if mode != "rw" {
+				return nil, fmt.Errorf("The host path %s of read-only mount %s does not exist", localPath, mount.Name)
+			}
+			util.Infof("Creating host directory %s for writable mount %s\n", localPath, mount.Name)
+			if err := os.MkdirAll(localPath, os.ModePerm); err != nil {
+				return nil, fmt.Errorf("Error creating host directory %s for mount %s: %s", localPath, mount.Name,
+					err.Error())
 			}
-			mounts = append(mounts, mountPath)
 		}
-		return mounts, nil
+
+		mounts = append(mounts, "-v")
+		mounts = append(mounts, util.DockerHostPath(localPath)+":"+mount.Path+":"+mode)
 	}
 
 	return mounts, nil
@@ -407,25 +1980,29 @@ func DefineMounts(seed *objects.Seed, inputs []string) ([]string, error) {
 func DefineSettings(seed *objects.Seed, inputs []string) ([]string, error) {
 	inMap := inputMap(inputs)
 
-	// Valid by default
-	valid := true
 	var keys []string
+	var missing []string
 	for _, s := range seed.Job.Interface.Settings {
 		keys = append(keys, s.Name)
 		if _, prs := inMap[s.Name]; !prs {
-			valid = false
+			missing = append(missing, s.Name)
 		}
 
 	}
 
-	if !valid {
+	if len(missing) > 0 {
 		var buffer bytes.Buffer
 		buffer.WriteString("ERROR: Incorrect setting key/values provided. -e arguments should be in the form:\n")
 		buffer.WriteString("  seed run -e SETTING=somevalue ...\n")
+		buffer.WriteString("or given in a file with -" + constants.SettingsFileFlag + ".\n")
 		buffer.WriteString("The following settings are expected:\n")
 		for _, n := range keys {
 			buffer.WriteString("  " + n + "\n")
 		}
+		buffer.WriteString("The following settings are missing:\n")
+		for _, n := range missing {
+			buffer.WriteString("  " + n + "\n")
+		}
 		buffer.WriteString("\n")
 		return nil, errors.New(buffer.String())
 	}
@@ -439,6 +2016,376 @@ func DefineSettings(seed *objects.Seed, inputs []string) ([]string, error) {
 	return settings, nil
 }
 
+//secretMask replaces the values of secret settings in logged commands
+const secretMask = "*****"
+
+//MaskSecretSettings returns a copy of the docker arguments with the values of the -e arguments
+// of settings the manifest marks secret replaced by *****, for logging
+func MaskSecretSettings(seed *objects.Seed, args []string) []string {
+	secrets := map[string]bool{}
+	for _, s := range seed.Job.Interface.Settings {
+		if s.Secret {
+			secrets[util.GetNormalizedVariable(s.Name)] = true
+		}
+	}
+	masked := append([]string(nil), args...)
+	for i := 1; i < len(masked); i++ {
+		if masked[i-1] != "-e" {
+			continue
+		}
+		if x := strings.SplitN(masked[i], "=", 2); len(x) == 2 && secrets[x[0]] {
+			masked[i] = x[0] + "=" + secretMask
+		}
+	}
+	return masked
+}
+
+//MissingSettings returns the names of the settings declared in the manifest that are not given
+func MissingSettings(seed *objects.Seed, settings []string) []string {
+	given := inputMap(settings)
+	var missing []string
+	for _, s := range seed.Job.Interface.Settings {
+		if _, ok := given[s.Name]; !ok {
+			missing = append(missing, s.Name)
+		}
+	}
+	return missing
+}
+
+//ApplySettingDefaults returns the settings followed by a default for each setting that is not
+// given, taken from the variable of the same name the image sets with ENV in imageEnv, so given
+// values take precedence. Settings the image does not set are left for DefineSettings to report
+// as missing.
+func ApplySettingDefaults(seed *objects.Seed, settings, imageEnv []string) []string {
+	given := inputMap(settings)
+	defaults := map[string]string{}
+	for _, env := range imageEnv {
+		if x := strings.SplitN(env, "=", 2); len(x) == 2 {
+			defaults[x[0]] = x[1]
+		}
+	}
+	for _, s := range seed.Job.Interface.Settings {
+		value, ok := defaults[util.GetNormalizedVariable(s.Name)]
+		if _, given := given[s.Name]; given || !ok {
+			continue
+		}
+		if s.Secret {
+			util.Infof("Setting %s not given; using the image default\n", s.Name)
+		} else {
+			util.Infof("Setting %s not given; using the image default %q\n", s.Name, value)
+		}
+		settings = append(settings, s.Name+"="+value)
+	}
+	return settings
+}
+
+//ReadSettingsFile reads settings from a file as KEY=VALUE pairs. The file is either a JSON
+// object of setting names to values, or holds one KEY=VALUE pair per line where blank lines and
+// lines starting with # are ignored and values may be quoted.
+func ReadSettingsFile(fileName string) ([]string, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading settings file %s: %s", fileName, err.Error())
+	}
+
+	var settings []string
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		values := map[string]interface{}{}
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		dec.UseNumber()
+		if err := dec.Decode(&values); err != nil {
+			return nil, fmt.Errorf("Error parsing settings file %s: %s", fileName, err.Error())
+		}
+		for key, value := range values {
+			switch v := value.(type) {
+			case string:
+				settings = append(settings, key+"="+v)
+			case json.Number, bool:
+				settings = append(settings, fmt.Sprintf("%s=%v", key, v))
+			default:
+				return nil, fmt.Errorf("Error parsing settings file %s: the value of %s must be a string, number "+
+					"or boolean", fileName, key)
+			}
+		}
+		sort.Strings(settings)
+		return settings, nil
+	}
+
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", fileName, n+1)
+		}
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		settings = append(settings, key+"="+value)
+	}
+	return settings, nil
+}
+
+//ReadEnvFile reads environment variables from a file in the format of docker run --env-file:
+// one KEY=VALUE pair per line, where blank lines and lines starting with # are ignored and the
+// value is taken literally, quotes included. A line with only a KEY takes the value of the
+// variable in the environment seed runs in, and is skipped if it is not set.
+func ReadEnvFile(fileName string) ([]string, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading env file %s: %s", fileName, err.Error())
+	}
+
+	var env []string
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimLeft(strings.TrimRight(line, "\r"), " \t")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, hasValue := line, "", false
+		if i := strings.Index(line, "="); i >= 0 {
+			key, value, hasValue = line[:i], line[i+1:], true
+		}
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: invalid variable name %q", fileName, n+1, key)
+		}
+		if !hasValue {
+			if value, hasValue = os.LookupEnv(key); !hasValue {
+				continue
+			}
+		}
+		env = append(env, key+"="+value)
+	}
+	return env, nil
+}
+
+//EnvFileArgs returns the docker -e arguments setting the variables read from an env file.
+// Variables with the same name as one of the given settings are left out, so settings take
+// precedence over the env file.
+func EnvFileArgs(env, settings []string) []string {
+	fromSettings := map[string]bool{}
+	for _, s := range settings {
+		fromSettings[util.GetNormalizedVariable(strings.SplitN(s, "=", 2)[0])] = true
+	}
+
+	var args []string
+	for _, e := range env {
+		key := strings.SplitN(e, "=", 2)[0]
+		if fromSettings[key] {
+			util.Debugf("Env file variable %s is overridden by a setting\n", key)
+			continue
+		}
+		args = append(args, "-e", e)
+	}
+	return args
+}
+
+//MergeSettings returns the settings from a settings file followed by those given on the
+// command line, so command line values override the file
+func MergeSettings(fileSettings, flagSettings []string) []string {
+	var settings []string
+	for _, s := range append(fileSettings, flagSettings...) {
+		if s != "" {
+			settings = append(settings, s)
+		}
+	}
+	return settings
+}
+
+//networkNameRegex matches valid docker network names
+var networkNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+//ResolveNetwork returns the docker --network value for the run. The built in bridge, host and
+// none modes are passed through; any other value must name an existing docker network.
+// Returns an empty string to use the docker default.
+func ResolveNetwork(network string) (string, error) {
+	switch network {
+	case "", constants.NetworkBridge, constants.NetworkHost, constants.NetworkNone:
+		return network, nil
+	}
+	if !networkNameRegex.MatchString(network) {
+		return "", errors.New("Invalid -" + constants.NetworkFlag + " value " + network + ". Must be " +
+			constants.NetworkBridge + ", " + constants.NetworkHost + ", " + constants.NetworkNone +
+			" or the name of a docker network")
+	}
+	if exists, err := util.NetworkExists(network); err != nil {
+		return "", err
+	} else if !exists {
+		return "", errors.New("Invalid -" + constants.NetworkFlag + " value " + network + ". No docker network named " +
+			network + " exists; use " + constants.NetworkBridge + ", " + constants.NetworkHost + ", " +
+			constants.NetworkNone + " or create it with docker network create")
+	}
+	return network, nil
+}
+
+//pidModes are the values of -pid other than container:NAME
+var pidModes = []string{"host"}
+
+//ipcModes are the values of -ipc other than container:NAME
+var ipcModes = []string{"host", "private", "shareable", "none"}
+
+//ResolveNamespace validates the mode of the namespace given with flag, either one of modes or
+// container:NAME to share that of another container, and returns it for docker run. Returns an
+// empty string to use the docker default.
+func ResolveNamespace(flag, mode string, modes []string) (string, error) {
+	if mode == "" || util.ContainsString(modes, mode) {
+		return mode, nil
+	}
+	if strings.HasPrefix(mode, constants.NamespaceContainerPrefix) &&
+		networkNameRegex.MatchString(strings.TrimPrefix(mode, constants.NamespaceContainerPrefix)) {
+		return mode, nil
+	}
+	return "", fmt.Errorf("Invalid -%s value %s. Must be %s or %sNAME to share the namespace of another "+
+		"container", flag, mode, strings.Join(modes, ", "), constants.NamespaceContainerPrefix)
+}
+
+//gpuDeviceRegex matches the docker --gpus forms other than all and a count, i.e. device=0,1
+// or "count=2,capabilities=utility"
+var gpuDeviceRegex = regexp.MustCompile(`^"?(count|device|capabilities|driver)=[^=]+(,(count|device|capabilities|driver)=[^=]+)*"?$`)
+
+//portSpecRegex matches a docker run -p port mapping, [IP:][HOST_PORT:]CONTAINER_PORT[/PROTOCOL]. The
+// IP may be an IPv6 address in brackets and the host port may be left empty for a random port.
+var portSpecRegex = regexp.MustCompile(`^(?:(?:(\[[0-9a-fA-F:.]+\]|[0-9.]+):)?([0-9]*):)?([0-9]+)(?:/(tcp|udp|sctp))?$`)
+
+//ResolveSecurityOpts validates the security options given with -security-opt, ignoring empty
+// values, and returns them for docker run --security-opt. Options are given as KEY=VALUE, or
+// KEY:VALUE as docker also accepts, i.e. label:disable, except no-new-privileges, and a seccomp
+// profile other than unconfined must be an existing file.
+func ResolveSecurityOpts(opts []string) ([]string, error) {
+	var resolved []string
+	for _, opt := range opts {
+		if opt == "" {
+			continue
+		}
+		if opt == "no-new-privileges" {
+			resolved = append(resolved, opt)
+			continue
+		}
+		// The key ends at the first = or :, so seccomp=C:\profile.json keeps its drive letter
+		var x []string
+		if i := strings.IndexAny(opt, "=:"); i >= 0 {
+			x = []string{opt[:i], opt[i+1:]}
+		}
+		if len(x) != 2 || x[0] == "" || x[1] == "" {
+			return nil, fmt.Errorf("Invalid -%s value %s. Security options are given as KEY=VALUE or "+
+				"KEY:VALUE, i.e. seccomp=profile.json or label:disable", constants.SecurityOptFlag, opt)
+		}
+		if x[0] == "seccomp" && x[1] != "unconfined" {
+			if _, err := os.Stat(x[1]); err != nil {
+				return nil, fmt.Errorf("Invalid -%s value %s. The seccomp profile %s cannot be read: %s",
+					constants.SecurityOptFlag, opt, x[1], err.Error())
+			}
+		}
+		resolved = append(resolved, opt)
+	}
+	return resolved, nil
+}
+
+//hostNameRegex matches a host name of dot separated labels, i.e. db.internal
+var hostNameRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*$`)
+
+//ResolveAddHosts validates the host entries given with -add-host, ignoring empty values, and
+// returns them for docker run --add-host. Entries are NAME:IP, where IP is an IPv4 or IPv6
+// address or host-gateway, the address of the docker host.
+func ResolveAddHosts(hosts []string) ([]string, error) {
+	var resolved []string
+	for _, host := range hosts {
+		if host == "" {
+			continue
+		}
+		x := strings.SplitN(host, ":", 2)
+		if len(x) != 2 || !hostNameRegex.MatchString(x[0]) {
+			return nil, fmt.Errorf("Invalid -%s value %s. Host entries are given as NAME:IP, i.e. "+
+				"mock-service:192.168.1.10", constants.AddHostFlag, host)
+		}
+		if x[1] != constants.HostGateway && net.ParseIP(x[1]) == nil {
+			return nil, fmt.Errorf("Invalid -%s value %s. %s is not an IP address or %s", constants.AddHostFlag,
+				host, x[1], constants.HostGateway)
+		}
+		resolved = append(resolved, host)
+	}
+	return resolved, nil
+}
+
+//ResolvePorts validates the port mappings given with -publish, ignoring empty values, and returns
+// them for docker run -p. A warning is logged for each host port that is already in use.
+func ResolvePorts(specs []string) ([]string, error) {
+	var ports []string
+	for _, spec := range specs {
+		if spec == "" {
+			continue
+		}
+		m := portSpecRegex.FindStringSubmatch(spec)
+		if m == nil {
+			return nil, fmt.Errorf("Invalid -%s value %s. Ports are given as HOST_PORT:CONTAINER_PORT, "+
+				"optionally with a host IP and protocol, i.e. 127.0.0.1:5678:5678/tcp", constants.PublishPortFlag, spec)
+		}
+		ip, hostPort, containerPort, proto := strings.Trim(m[1], "[]"), m[2], m[3], m[4]
+		if n, _ := strconv.Atoi(containerPort); n < 1 || n > 65535 {
+			return nil, fmt.Errorf("Invalid -%s value %s. Container port %s must be between 1 and 65535",
+				constants.PublishPortFlag, spec, containerPort)
+		}
+		if hostPort != "" {
+			n, _ := strconv.Atoi(hostPort)
+			if n < 1 || n > 65535 {
+				return nil, fmt.Errorf("Invalid -%s value %s. Host port %s must be between 1 and 65535",
+					constants.PublishPortFlag, spec, hostPort)
+			}
+			if util.PortInUse(ip, n, proto) {
+				util.Warnf("Host port %s requested with -%s %s is already in use; docker may fail to start "+
+					"the container\n", hostPort, constants.PublishPortFlag, spec)
+			}
+		}
+		ports = append(ports, spec)
+	}
+	return ports, nil
+}
+
+//ResolveGpus returns the docker --gpus value for the run. The -gpus flag takes precedence and
+// must be all, a positive count or a device spec. Otherwise the number of GPUs declared by a
+// gpus scalar resource in the manifest is used. Returns an empty string if no GPUs are needed.
+func ResolveGpus(seed *objects.Seed, flagGpus string) (string, error) {
+	if flagGpus != "" {
+		if flagGpus == "all" || gpuDeviceRegex.MatchString(flagGpus) {
+			return flagGpus, nil
+		}
+		if n, err := strconv.Atoi(flagGpus); err == nil && n > 0 {
+			return flagGpus, nil
+		}
+		return "", errors.New("Invalid -" + constants.GpusFlag + " value " + flagGpus +
+			". Must be all, a number of GPUs or a device spec, i.e. device=0,1")
+	}
+
+	for _, s := range seed.Job.Resources.Scalar {
+		if s.Name == constants.GpuResource && s.Value > 0 {
+			return strconv.Itoa(int(math.Ceil(s.Value))), nil
+		}
+	}
+	return "", nil
+}
+
+//ResolveCpus returns the docker --cpus value for the -cpus flag, or an empty string if no limit
+// is given. The value must be positive and no more than the CPUs of the docker host, as docker
+// refuses to start a container with a larger limit.
+func ResolveCpus(cpus float64) (string, error) {
+	if cpus == 0 {
+		return "", nil
+	}
+	if !(cpus > 0) || math.IsInf(cpus, 1) {
+		return "", fmt.Errorf("Invalid -%s value %v. Must be a positive number of CPUs, i.e. 1.5",
+			constants.CpusFlag, cpus)
+	}
+	if n, err := util.ServerCPUs(); err == nil && cpus > float64(n) {
+		return "", fmt.Errorf("Invalid -%s value %v. It is more than the %d CPUs of the docker host",
+			constants.CpusFlag, cpus, n)
+	}
+	return strconv.FormatFloat(cpus, 'f', -1, 64), nil
+}
+
 //DefineResources defines any seed specified docker resource requirements
 //based on the seed spec and the size of the input in MiB
 // returns array of arguments to pass to docker to restrict/specify the resources required
@@ -466,11 +2413,24 @@ func DefineResources(seed *objects.Seed, inputSizeMiB float64) ([]string, float6
 }
 
 //CheckRunOutput validates the output of the docker run command. Output data is
-// validated as defined in the seed.Job.Interface.Outputs.
-func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit float64) {
+// validated as defined in the seed.Job.Interface.Outputs. Every problem found is reported, and
+// an error listing them is returned if there were any.
+func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit float64) error {
+	var problems []string
+	invalid := func(format string, args ...interface{}) {
+		util.Errorf(format, args...)
+		problems = append(problems, strings.TrimSpace(fmt.Sprintf(format, args...)))
+	}
+	checked := func() error {
+		if len(problems) == 0 {
+			return nil
+		}
+		return fmt.Errorf("Output validation failed:\n  %s", strings.Join(problems, "\n  "))
+	}
+
 	// Validate any Outputs.Files
 	if seed.Job.Interface.Outputs.Files != nil {
-		util.PrintUtil( "INFO: Validating output files found under %s...\n",
+		util.Infof("Validating output files found under %s...\n",
 			outDir)
 
 		var dirSize int64
@@ -484,7 +2444,7 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 		filepath.Walk(outDir, readSize)
 		sizeMB := float64(dirSize) / (1024.0 * 1024.0)
 		if diskLimit > 0 && sizeMB > diskLimit {
-			util.PrintUtil( "ERROR: Output directory exceeds disk space limit (%f MiB vs. %f MiB)\n", sizeMB, diskLimit)
+			invalid("Output directory exceeds disk space limit (%f MiB vs. %f MiB)\n", sizeMB, diskLimit)
 		}
 
 		// For each defined Outputs file:
@@ -492,29 +2452,19 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 		// 	#2 Check file names match output pattern
 		//  #3 Check number of files (if defined)
 		for _, f := range seed.Job.Interface.Outputs.Files {
-			// find all pattern matches in OUTPUT_DIR
-			matches, _ := filepath.Glob(path.Join(outDir, f.Pattern))
-
-			// Check media type of matches
-			count := 0
+			// find all pattern matches of the media type in OUTPUT_DIR
 			var matchList []string
-			for _, match := range matches {
-				ext := filepath.Ext(match)
-				mType := mime.TypeByExtension(ext)
-				if strings.Contains(mType, f.MediaType) ||
-					strings.Contains(f.MediaType, mType) {
-					count++
-					matchList = append(matchList, "\t"+match+"\n")
-					metadata := match + ".metadata.json"
-					if _, err := os.Stat(metadata); err == nil {
-						schema := metadataSchema
-						if schema != "" {
-							schema = util.GetFullPath(schema, "")
-						}
-						err := ValidateSeedFile(schema, metadata, constants.SchemaMetadata)
-						if err != nil {
-							util.PrintUtil( "ERROR: Side-car metadata file %s validation error: %s", metadata, err.Error())
-						}
+			for _, match := range outputMatches(f, outDir) {
+				matchList = append(matchList, "\t"+match+"\n")
+				metadata := match + ".metadata.json"
+				if _, err := os.Stat(metadata); err == nil {
+					schema := metadataSchema
+					if schema != "" {
+						schema = util.GetFullPath(schema, "")
+					}
+					err := ValidateSeedFile(schema, metadata, constants.SchemaMetadata)
+					if err != nil {
+						invalid("Side-car metadata file %s validation error: %s\n", metadata, err.Error())
 					}
 				}
 			}
@@ -523,7 +2473,7 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 			if f.Count != "" && f.Count != "*" && f.Required {
 				count, _ := strconv.Atoi(f.Count)
 				if count != len(matchList) {
-					util.PrintUtil( "ERROR: %v files specified, %v found.\n",
+					invalid("%v files specified, %v found.\n",
 						f.Count, strconv.Itoa(len(matchList)))
 					if len(matchList) > 0 {
 						for _, s := range matchList {
@@ -545,30 +2495,30 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 	// Look for ResultsFileManifestName.json in the root of the OUTPUT_DIR
 	// and then validate any keys identified in Outputs exist
 	if seed.Job.Interface.Outputs.JSON != nil {
-		util.PrintUtil( "INFO: Validating %s...\n",
+		util.Infof("Validating %s...\n",
 			filepath.Join(outDir, constants.ResultsFileManifestName))
 		// look for results manifest
 		manfile := filepath.Join(outDir, constants.ResultsFileManifestName)
 		if _, err := os.Stat(manfile); os.IsNotExist(err) {
-			util.PrintUtil( "ERROR: %s specified but cannot be found. %s\n Exiting testrunner.\n",
+			invalid("%s specified but cannot be found. %s\n",
 				constants.ResultsFileManifestName, err.Error())
-			return
+			return checked()
 		}
 
 		bites, err := ioutil.ReadFile(filepath.Join(outDir,
 			constants.ResultsFileManifestName))
 		if err != nil {
-			util.PrintUtil( "ERROR: Error reading %s.%s\n",
+			invalid("Error reading %s. %s\n",
 				constants.ResultsFileManifestName, err.Error())
-			return
+			return checked()
 		}
 
 		documentLoader := gojsonschema.NewStringLoader(string(bites))
 		_, err = documentLoader.LoadJSON()
 		if err != nil {
-			util.PrintUtil( "ERROR: Error loading results manifest file: %s. %s\n Exiting testrunner.\n",
+			invalid("Error loading results manifest file: %s. %s\n",
 				constants.ResultsFileManifestName, err.Error())
-			return
+			return checked()
 		}
 
 		schemaFmt := "{ \"type\": \"object\", \"properties\": { %s }, \"required\": [ %s ] }"
@@ -601,9 +2551,9 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 		schemaLoader := gojsonschema.NewStringLoader(schema)
 		schemaResult, err := gojsonschema.Validate(schemaLoader, documentLoader)
 		if err != nil {
-			util.PrintUtil( "ERROR: Error running validator: %s\n Exiting testrunner.\n",
+			invalid("Error running validator: %s\n",
 				err.Error())
-			return
+			return checked()
 		}
 
 		if len(schemaResult.Errors()) == 0 {
@@ -611,9 +2561,108 @@ func CheckRunOutput(seed *objects.Seed, outDir, metadataSchema string, diskLimit
 		}
 
 		for _, desc := range schemaResult.Errors() {
-			util.PrintUtil( "ERROR: %s is invalid: - %s\n", constants.ResultsFileManifestName, desc)
+			invalid("%s is invalid: - %s\n", constants.ResultsFileManifestName, desc)
+		}
+	}
+
+	return checked()
+}
+
+//outputMatches returns the files in outDir matching the pattern of the output file f whose
+// extension is of its media type
+func outputMatches(f objects.OutFile, outDir string) []string {
+	matches, _ := filepath.Glob(path.Join(outDir, f.Pattern))
+	var matched []string
+	for _, match := range matches {
+		mType := mime.TypeByExtension(filepath.Ext(match))
+		if strings.Contains(mType, f.MediaType) || strings.Contains(f.MediaType, mType) {
+			matched = append(matched, match)
+		}
+	}
+	return matched
+}
+
+//MatchOutputFiles returns the files in outDir matched by each output file declared in the
+// manifest, with their sizes, in the order declared. Outputs without matches have no files.
+func MatchOutputFiles(seed *objects.Seed, outDir string) []OutputFiles {
+	outputs := []OutputFiles{}
+	for _, f := range seed.Job.Interface.Outputs.Files {
+		output := OutputFiles{Name: f.Name, Files: []OutputFile{}}
+		if outDir != "" {
+			for _, match := range outputMatches(f, outDir) {
+				info, err := os.Stat(match)
+				if err != nil || info.IsDir() {
+					continue
+				}
+				output.Files = append(output.Files, OutputFile{Path: match, Size: info.Size()})
+			}
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs
+}
+
+//OutputJsonValues reads seed.outputs.json from outDir and returns the value of each output
+// declared in seed.Job.Interface.Outputs.Json, keyed by output name. An output's key may be a
+// dot separated path into nested objects. Returns an error if no outputs are declared, the file
+// is missing or invalid, or a required key is not found; missing optional keys are omitted.
+func OutputJsonValues(seed *objects.Seed, outDir string) (map[string]interface{}, error) {
+	if len(seed.Job.Interface.Outputs.JSON) == 0 {
+		return nil, errors.New("No output JSON is declared in the Seed manifest")
+	}
+	if outDir == "" {
+		return nil, errors.New("An output directory is required to read " + constants.ResultsFileManifestName)
+	}
+
+	manfile := filepath.Join(outDir, constants.ResultsFileManifestName)
+	bites, err := ioutil.ReadFile(manfile)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("Output JSON is declared in the Seed manifest but %s was not written", manfile)
+	} else if err != nil {
+		return nil, fmt.Errorf("Error reading %s: %s", manfile, err.Error())
+	}
+	var results map[string]interface{}
+	if err := json.Unmarshal(bites, &results); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %s", manfile, err.Error())
+	}
+
+	values := map[string]interface{}{}
+	var missing []string
+	for _, out := range seed.Job.Interface.Outputs.JSON {
+		key := out.Name
+		if out.Key != "" {
+			key = out.Key
+		}
+		value, ok := jsonPathValue(results, key)
+		if !ok {
+			if out.Required {
+				missing = append(missing, key)
+			}
+			continue
 		}
+		values[out.Name] = value
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s is missing the declared keys: %s", manfile, strings.Join(missing, ", "))
+	}
+	return values, nil
+}
+
+//jsonPathValue looks up key in a parsed JSON object. A key not found as is is treated as a
+// dot separated path into nested objects.
+func jsonPathValue(obj map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := obj[key]; ok {
+		return value, true
+	}
+	i := strings.Index(key, ".")
+	if i < 0 {
+		return nil, false
+	}
+	nested, ok := obj[key[:i]].(map[string]interface{})
+	if !ok {
+		return nil, false
 	}
+	return jsonPathValue(nested, key[i+1:])
 }
 
 //PrintRunUsage prints the seed run usage arguments, then exits the program
@@ -627,15 +2676,95 @@ func PrintRunUsage() {
 		constants.ShortImgNameFlag, constants.ImgNameFlag)
 	util.PrintUtil( "  -%s  -%s Specifies the key/value input data values of the seed spec in the format INPUT_FILE_KEY=INPUT_FILE_VALUE\n",
 		constants.ShortInputsFlag, constants.InputsFlag)
+	util.PrintUtil("  -%s \t Fail rather than warn if an input file is not of a media type the manifest declares\n",
+		constants.StrictInputsFlag)
+	util.PrintUtil("  -%s \t Directory of input files, matched to the manifest inputs by name or media type;\n"+
+		"\t\t -%s values override the matched files\n", constants.InputDirFlag, constants.ShortInputsFlag)
+	util.PrintUtil("  -%s \t Name of an input that is given a directory, mounted like a file; may be repeated\n",
+		constants.DirectoryInputFlag)
+	util.PrintUtil("  -%s \t Download an input before the run, given as NAME=URL; may be repeated. A URL fragment of\n"+
+		"\t\t #sha256=HEX or #sha512=HEX is the checksum of the file. Downloads are removed with -%s\n",
+		constants.InputURLFlag, constants.RmFlag)
+	util.PrintUtil("\t\t URLs without credentials are downloaded with the bearer token in %s, or basic auth\n"+
+		"\t\t from %s and %s\n", constants.InputTokenEnv, constants.InputUserEnv, constants.InputPasswordEnv)
 	util.PrintUtil( "  -%s  -%s \t Specifies the key/value setting values of the seed spec in the format SETTING_KEY=VALUE\n",
 		constants.ShortSettingFlag, constants.SettingFlag)
 	util.PrintUtil( "  -%s  -%s \t Specifies the key/value mount values of the seed spec in the format MOUNT_KEY=HOST_PATH\n",
 		constants.ShortMountFlag, constants.MountFlag)
+	util.PrintUtil("  -%s \t File of settings, either a JSON object or KEY=VALUE lines; -%s values override the file\n",
+		constants.SettingsFileFlag, constants.ShortSettingFlag)
+	util.PrintUtil("  -%s \t Use the image ENV value of settings not given with -%s or -%s (default true). With\n"+
+		"\t\t -%s=false every setting must be given\n", constants.UseDefaultsFlag, constants.ShortSettingFlag,
+		constants.SettingsFileFlag, constants.UseDefaultsFlag)
+	util.PrintUtil("  -%s \t Stop the job and fail the run with exit code %d if the output directory exceeds this size in MiB\n",
+		constants.MaxOutputSizeFlag, constants.OutputTooLargeExitCode)
+	util.PrintUtil("  -%s \t Write %s to the output directory, recording the host path, container path and size of each input\n",
+		constants.SaveInputManifestFlag, constants.InputsResolvedFileName)
+	util.PrintUtil("  -%s \t File of KEY=VALUE lines set in the container environment; -%s settings take precedence\n",
+		constants.EnvFileFlag, constants.ShortSettingFlag)
 	util.PrintUtil( "  -%s  -%s \t Job Output Directory Location\n",
 		constants.ShortJobOutputDirFlag, constants.JobOutputDirFlag)
-	util.PrintUtil( "  -%s \t\t Automatically remove the container when it exits (docker run --rm)\n",
+	util.PrintUtil("  -%s \t Write the outputs of each run to a new timestamped subdirectory of the output directory\n",
+		constants.OutputTimestampFlag)
+	util.PrintUtil("  -%s \t Resolve relative input paths against the current directory (%s, default) or the seed manifest directory (%s)\n",
+		constants.InputsRelativeToFlag, constants.RelativeToCwd, constants.RelativeToManifest)
+	util.PrintUtil("  -%s  -%s \t Directory containing the seed manifest used with -%s %s (default is current directory)\n",
+		constants.ShortJobDirectoryFlag, constants.JobDirectoryFlag, constants.InputsRelativeToFlag, constants.RelativeToManifest)
+	util.PrintUtil("  -%s  -%s \t User to run the container as, in the form user[:group] (default is the image user)\n",
+		constants.ShortUserFlag, constants.UserFlag)
+	util.PrintUtil("  -%s \t Run the container as the uid:gid of the current user so output files are owned by them\n",
+		constants.HostUserFlag)
+	util.PrintUtil("  -%s \t Mount inputs read-only so the job cannot modify source data; the output directory stays writable\n",
+		constants.MountReadOnlyFlag)
+	util.PrintUtil("  -%s \t Run with a read-only root filesystem; only the output directory, read-write mounts and\n"+
+		"\t\t %s (as tmpfs) are writable\n", constants.ReadonlyRootfsFlag, strings.Join(readonlyScratchDirs, ", "))
+	util.PrintUtil("  -%s \t Print container output to stdout as newline delimited JSON entries with time, stream,\n"+
+		"\t\t image and container id; cannot be used with -%s or -%s\n", constants.JsonLogsFlag,
+		constants.OutputJsonFlag, constants.ResultJsonFlag)
+	util.PrintUtil("  -%s  -%s \t Write the container stdout and stderr to %s and %s in the output directory\n",
+		constants.CaptureLogsFlag, constants.EntrypointLogFlag, constants.StdoutLogFileName,
+		constants.StderrLogFileName)
+	util.PrintUtil("  -%s \t\t GPUs to expose to the container: all, a count or a device spec, i.e. device=0,1\n"+
+		"\t\t (default is the number of gpus declared in the manifest resources)\n", constants.GpusFlag)
+	util.PrintUtil("  -%s \t Publish a container port on the host, i.e. to attach a debugger, as HOST_PORT:CONTAINER_PORT\n"+
+		"\t\t optionally with a host IP and protocol, i.e. 127.0.0.1:5678:5678/tcp. May be repeated\n",
+		constants.PublishPortFlag)
+	util.PrintUtil("  -%s \t Security option passed to docker run --security-opt as KEY=VALUE or KEY:VALUE, i.e. a seccomp\n"+
+		"\t\t profile with seccomp=profile.json, or label:disable. May be repeated\n", constants.SecurityOptFlag)
+	util.PrintUtil("  -%s \t Mount an in-memory scratch directory in the container as PATH[:OPTIONS], i.e.\n"+
+		"\t\t /scratch:size=1g,noexec. May be repeated\n", constants.TmpfsFlag)
+	util.PrintUtil("  -%s \t Add a NAME:IP entry to /etc/hosts of the container, i.e. mock-service:192.168.1.10; the\n"+
+		"\t\t IP may be %s for the docker host. May be repeated\n", constants.AddHostFlag, constants.HostGateway)
+	util.PrintUtil("  -%s \t\t Limit the CPUs the container may use, i.e. 1.5 (default is no limit)\n", constants.CpusFlag)
+	util.PrintUtil("  -%s \t Docker network to connect the container to: %s, %s, %s or the name of a docker network\n"+
+		"\t\t (default is %s)\n", constants.NetworkFlag, constants.NetworkBridge, constants.NetworkHost,
+		constants.NetworkNone, constants.NetworkBridge)
+	util.PrintUtil("  -%s \t\t PID namespace of the container, i.e. to profile the job: %s or %sNAME to share that of\n"+
+		"\t\t another container\n", constants.PidFlag, strings.Join(pidModes, ", "), constants.NamespaceContainerPrefix)
+	util.PrintUtil("  -%s \t\t IPC namespace of the container: %s or %sNAME to share that of another container\n",
+		constants.IpcFlag, strings.Join(ipcModes, ", "), constants.NamespaceContainerPrefix)
+	util.PrintUtil("  -%s \t Working directory of the container, which relative paths in the manifest command resolve\n"+
+		"\t\t against (default is the WORKDIR of the image). Inputs and outputs are mounted at their host paths\n",
+		constants.WorkDirFlag)
+	util.PrintUtil("  -%s \t Print the values of the manifest's output JSON, read from %s, to stdout\n",
+		constants.OutputJsonFlag, constants.ResultsFileManifestName)
+	util.PrintUtil("  -%s \t Upload the output directory as a .tar.gz to an s3://BUCKET/KEY, http or https URL after\n"+
+		"\t\t the outputs are validated; a URL ending in / is a directory. S3 uses the AWS_* credentials in the\n"+
+		"\t\t environment, http PUTs %s and %s or %s\n", constants.OutputUploadFlag,
+		constants.UploadUserEnv, constants.UploadPasswordEnv, constants.UploadTokenEnv)
+	util.PrintUtil("  -%s \t Upload the outputs with -%s even if the run fails\n", constants.UploadOnFailureFlag,
+		constants.OutputUploadFlag)
+	util.PrintUtil("  -%s \t Print the exit code, duration and the files and sizes matched by each output to\n"+
+		"\t\t stdout as a JSON object once the container exits. Cannot be used with -%s\n",
+		constants.ResultJsonFlag, constants.OutputJsonFlag)
+	util.PrintUtil( "  -%s \t\t Remove the container and its anonymous volumes when it exits, including on failure,\n"+
+		"\t\t timeout or interrupt. Temporary directories are always removed.\n",
 		constants.RmFlag)
-	util.PrintUtil( "  -%s  -%s \t Suppress stdout when running docker image\n",
+	util.PrintUtil("  -%s \t Run the container again, up to this many times, if it exits non-zero (default is 0)\n",
+		constants.RestartOnFailureFlag)
+	util.PrintUtil("  -%s \t Keep the container if it exits non-zero, even with -%s, and print how to remove it\n",
+		constants.KeepFailedFlag, constants.RmFlag)
+	util.PrintUtil( "  -%s  -%s \t Suppress progress messages and output from the docker image; errors are still reported\n",
 		constants.ShortQuietFlag, constants.QuietFlag)
 	util.PrintUtil( "  -%s  -%s \t Run docker image multiple times (i.e. -rep 5 runs the image 5 times)\n",
 		constants.ShortRepeatFlag, constants.RepeatFlag)
@@ -650,8 +2779,8 @@ func inputMap(inputs []string) map[string]string {
 	for _, f := range inputs {
 		x := strings.SplitN(f, "=", 2)
 		if len(x) != 2 {
-			util.PrintUtil( "ERROR: Input should be specified in KEY=VALUE format.\n")
-			util.PrintUtil( "ERROR: Unknown key for input %v encountered.\n",
+			util.Errorf("Input should be specified in KEY=VALUE format.\n")
+			util.Errorf("Unknown key for input %v encountered.\n",
 				x)
 			continue
 		}
@@ -0,0 +1,415 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
+	"github.com/ngageoint/seed-cli/util"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+//ScanStatus is the outcome of a single seed scan check
+type ScanStatus string
+
+const (
+	//ScanPass means the image passed the check
+	ScanPass ScanStatus = "pass"
+
+	//ScanWarn means the image may not run as expected
+	ScanWarn ScanStatus = "warn"
+
+	//ScanFail means the image will not run as a seed job
+	ScanFail ScanStatus = "fail"
+)
+
+//defaultImagePath is the PATH docker uses when an image does not set one
+const defaultImagePath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+//ScanCheck is the result of a single seed scan check
+type ScanCheck struct {
+	Name   string
+	Status ScanStatus
+	Detail string
+}
+
+//ScanReport lists the checks seed scan made of an image
+type ScanReport struct {
+	Image  string
+	Checks []ScanCheck
+}
+
+//Count returns the number of checks with the given status
+func (r *ScanReport) Count(status ScanStatus) int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+//add appends a check to the report
+func (r *ScanReport) add(name string, status ScanStatus, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, ScanCheck{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+//SeedScan seed scan: Checks that a built image is seed compliant: the manifest label is present
+// and valid, the entrypoint exists in the image, the working directory is set and the image user
+// can create its output directory. Returns an error if any check fails.
+func SeedScan(imageName string) (ScanReport, error) {
+	report := ScanReport{Image: imageName}
+	config, label, err := inspectScanImage(imageName)
+	if err != nil {
+		return report, err
+	}
+	seed, ok := scanManifest(&report, label)
+
+	executable, candidates := entrypointCandidates(&seed, config)
+	if executable == "" {
+		report.add("entrypoint", ScanFail, "The image has no entrypoint and the manifest has no command")
+	} else if !ok && len(config.Entrypoint) == 0 {
+		report.add("entrypoint", ScanWarn, "Not checked; the image has no entrypoint and the manifest cannot be read")
+	} else {
+		scanEntrypoint(&report, imageName, executable, candidates)
+	}
+
+	scanWorkingDir(&report, config)
+
+	if ok {
+		scanOutputDir(&report, imageName)
+	}
+
+	PrintScanReport(report)
+	if failed := report.Count(ScanFail); failed > 0 {
+		return report, fmt.Errorf("%s failed %d of %d checks", imageName, failed, len(report.Checks))
+	}
+	return report, nil
+}
+
+//CheckImage seed validate -check-image: Cross-checks a manifest against a built image: the image
+// was built from the manifest, the executable the command starts exists in the image, the image
+// sets a working directory and the declared mounts do not hide the image's own files. Returns an
+// error if any check fails.
+func CheckImage(seed *objects.Seed, imageName string) (ScanReport, error) {
+	report := ScanReport{Image: imageName}
+	config, label, err := inspectScanImage(imageName)
+	if err != nil {
+		return report, err
+	}
+	scanManifestMatch(&report, seed, label)
+
+	found := ""
+	executable, candidates := entrypointCandidates(seed, config)
+	if executable == "" {
+		report.add("entrypoint", ScanFail, "The image has no entrypoint and the manifest has no command")
+	} else {
+		found = scanEntrypoint(&report, imageName, executable, candidates)
+		if len(config.Entrypoint) > 0 && seed.Job.Interface.Command != "" {
+			report.add("command", ScanWarn, "The image sets ENTRYPOINT %s; the manifest command is passed "+
+				"to it as arguments", strings.Join(config.Entrypoint, " "))
+		}
+	}
+
+	scanWorkingDir(&report, config)
+	scanMounts(&report, seed, config.WorkingDir, found)
+
+	PrintScanReport(report)
+	if failed := report.Count(ScanFail); failed > 0 {
+		return report, fmt.Errorf("%s does not match the manifest: failed %d of %d checks", imageName, failed,
+			len(report.Checks))
+	}
+	return report, nil
+}
+
+//inspectScanImage returns the configuration and manifest label of a local image
+func inspectScanImage(imageName string) (util.ImageConfig, string, error) {
+	if exists, err := util.ImageExists(imageName); !exists {
+		if err == nil {
+			err = errors.New("No local image found for " + imageName)
+		}
+		util.Errorf("%s\n", err.Error())
+		return util.ImageConfig{}, "", err
+	}
+
+	config, err := util.InspectImageConfig(imageName)
+	if err != nil {
+		util.Errorf("Error inspecting %s: %s\n", imageName, err.Error())
+		return config, "", err
+	}
+
+	label, err := util.ImageLabel(imageName, constants.ManifestLabel)
+	if err != nil {
+		util.Errorf("Error reading the labels of %s: %s\n", imageName, err.Error())
+		return config, "", err
+	}
+	return config, label, nil
+}
+
+//scanManifestMatch checks that the image was built from the manifest, comparing the job name,
+// versions and command of the manifest label with those of the manifest
+func scanManifestMatch(report *ScanReport, seed *objects.Seed, label string) {
+	if label == "" {
+		report.add("manifest label", ScanWarn, "The image has no %s label; it was not built with seed build",
+			constants.ManifestLabel)
+		return
+	}
+	var built objects.Seed
+	if err := json.Unmarshal([]byte(objects.UnescapeManifestLabel(label)), &built); err != nil {
+		report.add("manifest label", ScanWarn, "The %s label is not valid json: %s", constants.ManifestLabel,
+			err.Error())
+		return
+	}
+
+	fields := []struct {
+		name, manifest, image string
+	}{
+		{"name", seed.Job.Name, built.Job.Name},
+		{"jobVersion", seed.Job.JobVersion, built.Job.JobVersion},
+		{"packageVersion", seed.Job.PackageVersion, built.Job.PackageVersion},
+		{"command", seed.Job.Interface.Command, built.Job.Interface.Command},
+	}
+	var diffs []string
+	for _, f := range fields {
+		if f.manifest != f.image {
+			diffs = append(diffs, fmt.Sprintf("%s is %q in the manifest but %q in the image", f.name, f.manifest,
+				f.image))
+		}
+	}
+	if len(diffs) > 0 {
+		report.add("manifest label", ScanWarn, "The image was built from a different manifest; rebuild it: %s",
+			strings.Join(diffs, "; "))
+		return
+	}
+	report.add("manifest label", ScanPass, "The image was built from this manifest")
+}
+
+//imageSystemDirs are directories of an image a mount should not be placed over, as it would hide
+// the files the job needs to start
+var imageSystemDirs = []string{"/", "/bin", "/etc", "/lib", "/lib64", "/sbin", "/usr", "/usr/bin", "/usr/lib",
+	"/usr/local", "/usr/local/bin", "/usr/sbin"}
+
+//scanMounts checks that the declared mount paths are absolute and do not hide files of the image:
+// a system directory, the working directory or the executable found at entrypoint
+func scanMounts(report *ScanReport, seed *objects.Seed, workingDir, entrypoint string) {
+	mounts := seed.Job.Interface.Mounts
+	if len(mounts) == 0 {
+		return
+	}
+	problems := 0
+	for _, m := range mounts {
+		if !path.IsAbs(m.Path) {
+			report.add("mounts", ScanFail, "Mount %s path %s is not absolute", m.Name, m.Path)
+			problems++
+			continue
+		}
+		mountPath := path.Clean(m.Path)
+		switch {
+		case entrypoint != "" && underPath(entrypoint, mountPath):
+			report.add("mounts", ScanFail, "Mount %s at %s hides %s, which the job starts", m.Name, mountPath,
+				entrypoint)
+		case util.ContainsString(imageSystemDirs, mountPath):
+			report.add("mounts", ScanWarn, "Mount %s at %s hides the image's %s directory", m.Name, mountPath,
+				mountPath)
+		case workingDir != "" && underPath(path.Clean(workingDir), mountPath):
+			report.add("mounts", ScanWarn, "Mount %s at %s hides the working directory %s", m.Name, mountPath,
+				workingDir)
+		default:
+			continue
+		}
+		problems++
+	}
+	if problems == 0 {
+		report.add("mounts", ScanPass, "%d mounts do not hide files of the image", len(mounts))
+	}
+}
+
+//scanManifest checks that the manifest label is present, parses and is valid against the seed
+// schema. Returns the manifest and whether it could be read.
+func scanManifest(report *ScanReport, label string) (objects.Seed, bool) {
+	var seed objects.Seed
+	if label == "" {
+		report.add("manifest label", ScanFail, "The image has no %s label. Build the image with seed build",
+			constants.ManifestLabel)
+		return seed, false
+	}
+
+	manifest := objects.UnescapeManifestLabel(label)
+	if err := json.Unmarshal([]byte(manifest), &seed); err != nil {
+		report.add("manifest label", ScanFail, "The %s label is not valid json: %s", constants.ManifestLabel,
+			err.Error())
+		return seed, false
+	}
+
+	schema, err := LoadSchema("", constants.SchemaManifest)
+	if err != nil {
+		report.add("manifest label", ScanWarn, "Parsed, but the schema could not be loaded: %s", err.Error())
+		return seed, true
+	}
+	result, err := schema.Validate(gojsonschema.NewStringLoader(manifest))
+	if err != nil {
+		report.add("manifest label", ScanFail, "Error validating the manifest: %s", err.Error())
+		return seed, true
+	}
+	if !result.Valid() {
+		var errs []string
+		for _, e := range result.Errors() {
+			errs = append(errs, e.String())
+		}
+		report.add("manifest label", ScanFail, "The manifest is not valid: %s", strings.Join(errs, "; "))
+		return seed, true
+	}
+
+	report.add("manifest label", ScanPass, "Valid manifest for %s %s", seed.Job.Name, seed.Job.JobVersion)
+	return seed, true
+}
+
+//entrypointCandidates returns the executable the container starts, the image entrypoint or
+// else the first word of the manifest command, and the paths in the image it may be found at
+func entrypointCandidates(seed *objects.Seed, config util.ImageConfig) (string, []string) {
+	executable := ""
+	if len(config.Entrypoint) > 0 {
+		executable = config.Entrypoint[0]
+	} else if fields := strings.Fields(seed.Job.Interface.Command); len(fields) > 0 {
+		executable = fields[0]
+	}
+	if executable == "" {
+		return "", nil
+	}
+
+	if path.IsAbs(executable) {
+		return executable, []string{executable}
+	}
+	if strings.Contains(executable, "/") {
+		workingDir := config.WorkingDir
+		if workingDir == "" {
+			workingDir = "/"
+		}
+		return executable, []string{path.Join(workingDir, executable)}
+	}
+
+	searchPath := defaultImagePath
+	for _, env := range config.Env {
+		if strings.HasPrefix(env, "PATH=") {
+			searchPath = strings.TrimPrefix(env, "PATH=")
+		}
+	}
+	var candidates []string
+	for _, dir := range strings.Split(searchPath, ":") {
+		if path.IsAbs(dir) {
+			candidates = append(candidates, path.Join(dir, executable))
+		}
+	}
+	return executable, candidates
+}
+
+//scanEntrypoint checks that the executable the container starts exists in the image, by
+// copying each candidate path out of a container created from the image. Returns the path it
+// was found at, or an empty string.
+func scanEntrypoint(report *ScanReport, imageName, executable string, candidates []string) string {
+	createArgs := []string{"create", imageName}
+	util.DebugCommand("docker", createArgs)
+	out, err := util.DockerOutput(createArgs...)
+	if err != nil {
+		report.add("entrypoint", ScanWarn, "Not checked; error creating a container: %s", err.Error())
+		return ""
+	}
+	containerID := strings.TrimSpace(string(out))
+	defer util.RemoveContainer(containerID)
+
+	for _, candidate := range candidates {
+		cpArgs := []string{"cp", containerID + ":" + candidate, "-"}
+		util.DebugCommand("docker", cpArgs)
+		if util.RunDocker(util.DockerCommand{Args: cpArgs, Stdout: ioutil.Discard}) == nil {
+			report.add("entrypoint", ScanPass, "%s found at %s", executable, candidate)
+			return candidate
+		}
+	}
+	report.add("entrypoint", ScanFail, "%s not found in the image (looked in %s)", executable,
+		strings.Join(candidates, ", "))
+	return ""
+}
+
+//scanWorkingDir checks that the image sets a working directory
+func scanWorkingDir(report *ScanReport, config util.ImageConfig) {
+	if config.WorkingDir == "" {
+		report.add("working dir", ScanWarn, "No WORKDIR is set; relative paths in the command resolve against /")
+		return
+	}
+	report.add("working dir", ScanPass, "%s", config.WorkingDir)
+}
+
+//scanOutputDir checks that the image user can create directories in an output directory mounted
+// the same way seed run mounts it. Requires a shell in the image.
+func scanOutputDir(report *ScanReport, imageName string) {
+	outDir, err := ioutil.TempDir("", "seed-scan")
+	if err != nil {
+		report.add("output dir", ScanWarn, "Not checked; error creating a directory: %s", err.Error())
+		return
+	}
+	defer util.RemoveAllFiles(outDir)
+	// seed run creates output directories with the default permissions
+	os.Chmod(outDir, 0755)
+
+	runArgs := []string{"run", "--rm", "--entrypoint", "sh"}
+	containerDir := util.ContainerPath(outDir)
+	runArgs = append(runArgs, "-v", util.DockerHostPath(outDir)+":"+containerDir, imageName, "-c",
+		"mkdir -p "+path.Join(containerDir, "seed-scan"))
+	util.DebugCommand("docker", runArgs)
+	var errs bytes.Buffer
+	err = util.RunDocker(util.DockerCommand{Args: runArgs, Stderr: &errs})
+
+	detail := strings.TrimSpace(errs.String())
+	switch {
+	case err == nil:
+		report.add("output dir", ScanPass, "The image user can create output directories")
+	case strings.Contains(detail, "executable file not found"):
+		report.add("output dir", ScanWarn, "Not checked; the image has no shell")
+	default:
+		if detail == "" {
+			detail = err.Error()
+		}
+		report.add("output dir", ScanFail, "The image user cannot create output directories: %s", detail)
+	}
+}
+
+//underPath returns whether p is dir or a path within it
+func underPath(p, dir string) bool {
+	return p == dir || dir == "/" || strings.HasPrefix(p, dir+"/")
+}
+
+//PrintScanReport prints the checks of a seed scan as a table followed by a summary
+func PrintScanReport(report ScanReport) {
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAIL")
+	for _, c := range report.Checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Name, strings.ToUpper(string(c.Status)), c.Detail)
+	}
+	w.Flush()
+	util.PrintUtil("%s", buffer.String())
+	util.PrintUtil("\n%s: %d passed, %d warnings, %d failed\n", report.Image, report.Count(ScanPass),
+		report.Count(ScanWarn), report.Count(ScanFail))
+}
+
+//PrintScanUsage prints the seed scan usage arguments, then exits the program
+func PrintScanUsage() {
+	util.PrintUtil("\nUsage:\tseed scan IMAGE_NAME\n")
+	util.PrintUtil("\nChecks that a built image is seed compliant. Unlike validate, which checks a manifest file,\n")
+	util.PrintUtil("scan inspects the image:\n")
+	util.PrintUtil("  manifest label\tThe %s label is present and a valid seed manifest\n", constants.ManifestLabel)
+	util.PrintUtil("  entrypoint\tThe image entrypoint, or the manifest command, exists in the image\n")
+	util.PrintUtil("  working dir\tThe image sets a working directory\n")
+	util.PrintUtil("  output dir\tThe image user can create directories in a mounted output directory\n")
+	util.PrintUtil("\nEach check passes, warns or fails. The command exits with an error if any check fails.\n")
+	panic(util.Exit{0})
+}
@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ngageoint/seed-cli/objects"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+func init() {
+	util.InitPrinter(false)
+}
+
+func TestScanManifest(t *testing.T) {
+	cases := []struct {
+		label          string
+		expectedOk     bool
+		expectedStatus ScanStatus
+		expectedDetail string
+	}{
+		{objects.GetManifestLabel("../testdata/complete/seed.manifest.json"), true, ScanPass, "Valid manifest for my-job"},
+		{"", false, ScanFail, "has no com.ngageoint.seed.manifest label"},
+		{`"{\"seedVersion\": \"1.0.0\"`, false, ScanFail, "not valid json"},
+		{`"{\"seedVersion\": \"1.0.0\", \"job\": {\"name\": \"my-job\"}}"`, true, ScanFail, "not valid"},
+	}
+
+	for _, c := range cases {
+		report := ScanReport{}
+		_, ok := scanManifest(&report, c.label)
+		check := report.Checks[0]
+		if ok != c.expectedOk || check.Status != c.expectedStatus || !strings.Contains(check.Detail, c.expectedDetail) {
+			t.Errorf("scanManifest(%q) == %v, %v %q, expected %v, %v %q", c.label, ok, check.Status, check.Detail,
+				c.expectedOk, c.expectedStatus, c.expectedDetail)
+		}
+	}
+}
+
+func TestEntrypointCandidates(t *testing.T) {
+	cases := []struct {
+		command            string
+		config             util.ImageConfig
+		expectedExecutable string
+		expectedCandidates string
+	}{
+		{"python /app/run.py ${INPUT_FILE}", util.ImageConfig{Env: []string{"PATH=/opt/conda/bin:/usr/bin"}},
+			"python", "[/opt/conda/bin/python /usr/bin/python]"},
+		{"run.sh ${OUTPUT_DIR}", util.ImageConfig{Entrypoint: []string{"/entrypoint.sh"}},
+			"/entrypoint.sh", "[/entrypoint.sh]"},
+		{"./run.sh ${OUTPUT_DIR}", util.ImageConfig{WorkingDir: "/app"}, "./run.sh", "[/app/run.sh]"},
+		{"./run.sh ${OUTPUT_DIR}", util.ImageConfig{}, "./run.sh", "[/run.sh]"},
+		{"sh -c", util.ImageConfig{}, "sh",
+			"[/usr/local/sbin/sh /usr/local/bin/sh /usr/sbin/sh /usr/bin/sh /sbin/sh /bin/sh]"},
+		{"", util.ImageConfig{}, "", "[]"},
+	}
+
+	for _, c := range cases {
+		seed := objects.Seed{}
+		seed.Job.Interface.Command = c.command
+		executable, candidates := entrypointCandidates(&seed, c.config)
+		if executable != c.expectedExecutable || fmt.Sprintf("%s", candidates) != c.expectedCandidates {
+			t.Errorf("entrypointCandidates(%q, %+v) == %q, %s, expected %q, %s", c.command, c.config, executable,
+				candidates, c.expectedExecutable, c.expectedCandidates)
+		}
+	}
+}
+
+func TestScanWorkingDir(t *testing.T) {
+	report := ScanReport{Image: "my-job-0.1.0-seed:0.1.0"}
+	scanWorkingDir(&report, util.ImageConfig{WorkingDir: "/app"})
+	scanWorkingDir(&report, util.ImageConfig{})
+	if report.Count(ScanPass) != 1 || report.Count(ScanWarn) != 1 || report.Count(ScanFail) != 0 {
+		t.Errorf("scanWorkingDir returned %+v, expected one pass and one warning", report.Checks)
+	}
+}
+
+func TestScanManifestMatch(t *testing.T) {
+	label := objects.GetManifestLabel("../testdata/complete/seed.manifest.json")
+	seed, err := objects.SeedFromManifestFile("../testdata/complete/seed.manifest.json")
+	if err != nil {
+		t.Fatalf("Error reading manifest: %s", err.Error())
+	}
+	changed := seed
+	changed.Job.JobVersion = "0.2.0"
+
+	cases := []struct {
+		seed           objects.Seed
+		label          string
+		expectedStatus ScanStatus
+		expectedDetail string
+	}{
+		{seed, label, ScanPass, "built from this manifest"},
+		{changed, label, ScanWarn, `jobVersion is "0.2.0" in the manifest but "0.1.0" in the image`},
+		{seed, "", ScanWarn, "not built with seed build"},
+		{seed, `"{\"seedVersion\": \"1.0.0\"`, ScanWarn, "not valid json"},
+	}
+
+	for _, c := range cases {
+		report := ScanReport{}
+		scanManifestMatch(&report, &c.seed, c.label)
+		check := report.Checks[0]
+		if check.Status != c.expectedStatus || !strings.Contains(check.Detail, c.expectedDetail) {
+			t.Errorf("scanManifestMatch(%q) == %v %q, expected %v %q", c.label, check.Status, check.Detail,
+				c.expectedStatus, c.expectedDetail)
+		}
+	}
+}
+
+func TestScanMounts(t *testing.T) {
+	cases := []struct {
+		path           string
+		workingDir     string
+		entrypoint     string
+		expectedStatus ScanStatus
+		expectedDetail string
+	}{
+		{"/the/container/path", "/app", "/app/run.sh", ScanPass, "1 mounts do not hide files of the image"},
+		{"the/container/path", "/app", "", ScanFail, "is not absolute"},
+		{"/app", "/app", "/app/run.sh", ScanFail, "hides /app/run.sh, which the job starts"},
+		{"/usr/bin/", "/app", "/app/run.sh", ScanWarn, "hides the image's /usr/bin directory"},
+		{"/", "", "", ScanWarn, "hides the image's / directory"},
+		{"/app", "/app/src", "/usr/bin/python", ScanWarn, "hides the working directory /app/src"},
+		{"/application", "/app", "/app/run.sh", ScanPass, "do not hide"},
+	}
+
+	for _, c := range cases {
+		seed := objects.Seed{}
+		seed.Job.Interface.Mounts = []objects.Mount{{Name: "MOUNT", Path: c.path, Mode: "ro"}}
+		report := ScanReport{}
+		scanMounts(&report, &seed, c.workingDir, c.entrypoint)
+		if len(report.Checks) != 1 {
+			t.Errorf("scanMounts(%q, %q, %q) == %+v, expected one check", c.path, c.workingDir, c.entrypoint,
+				report.Checks)
+			continue
+		}
+		if check := report.Checks[0]; check.Status != c.expectedStatus || !strings.Contains(check.Detail, c.expectedDetail) {
+			t.Errorf("scanMounts(%q, %q, %q) == %+v, expected %v %q", c.path, c.workingDir, c.entrypoint,
+				report.Checks, c.expectedStatus, c.expectedDetail)
+		}
+	}
+
+	report := ScanReport{}
+	scanMounts(&report, &objects.Seed{}, "/app", "")
+	if len(report.Checks) != 0 {
+		t.Errorf("scanMounts with no mounts returned %+v, expected no checks", report.Checks)
+	}
+}
+
+func TestScanEntrypoint(t *testing.T) {
+	fake := &util.FakeDockerRunner{Responses: []util.FakeDockerResponse{
+		{Args: []string{"create"}, Stdout: "3f4e8a\n"},
+		{Args: []string{"cp", "3f4e8a:/usr/local/bin/run.sh"}, ExitCode: 1},
+	}}
+	defer util.SetDockerRunner(util.SetDockerRunner(fake))
+
+	report := ScanReport{}
+	found := scanEntrypoint(&report, "my-job-0.1.0-seed:0.1.0", "run.sh",
+		[]string{"/usr/local/bin/run.sh", "/usr/bin/run.sh"})
+	if found != "/usr/bin/run.sh" || report.Checks[0].Status != ScanPass {
+		t.Errorf("scanEntrypoint() == %q, %+v, expected /usr/bin/run.sh and a pass", found, report.Checks)
+	}
+	expected := []string{"create my-job-0.1.0-seed:0.1.0", "cp 3f4e8a:/usr/local/bin/run.sh -",
+		"cp 3f4e8a:/usr/bin/run.sh -", "rm -v 3f4e8a"}
+	if commands := fake.Commands(); fmt.Sprintf("%q", commands) != fmt.Sprintf("%q", expected) {
+		t.Errorf("scanEntrypoint ran docker %q, expected %q", commands, expected)
+	}
+}
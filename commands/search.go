@@ -1,26 +1,425 @@
 package commands
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
 	RegistryFactory "github.com/ngageoint/seed-cli/registry"
 	"github.com/ngageoint/seed-cli/util"
+	"github.com/xeipuuv/gojsonschema"
 )
 
-//DockerSearch executes the seed search command
-func DockerSearch(url, org, filter, username, password string) ([]string, error) {
+//searchConcurrency limits how many organizations are searched at once
+const searchConcurrency = 4
+
+//inspectConcurrency limits how many images are inspected in the registry at once, for their
+// manifest labels with -seed-only or their update times with -sort updated
+const inspectConcurrency = 8
+
+//orgSearchResult holds the images found in a single organization
+type orgSearchResult struct {
+	images []string
+	err    error
+}
+
+//SearchResult describes an image found by seed search
+type SearchResult struct {
+	Registry   string `json:"registry"`
+	Org        string `json:"org"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	// SeedCompliant is set when the image is named NAME-JOBVERSION-seed:PACKAGEVERSION
+	SeedCompliant bool `json:"seedCompliant"`
+	// Updated is when the image was created, set when sorting by update time and the registry
+	// records it
+	Updated *time.Time `json:"updated,omitempty"`
+}
+
+//searchSorts are the orders seed search -sort accepts
+var searchSorts = []string{constants.SortName, constants.SortUpdated, constants.SortTag}
+
+//seedRepositoryRegex matches the repository of a seed image named by seed build, i.e.
+// org/my-job-1.0.0-seed
+var seedRepositoryRegex = regexp.MustCompile(`(^|/)[a-z0-9]+(?:[._-][a-z0-9]+)*-[0-9]+\.[0-9]+\.[0-9]+(?:-[0-9A-Za-z.-]+)?-seed$`)
+
+//seedTagRegex matches the package version tag of a seed image named by seed build
+var seedTagRegex = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(?:-[0-9A-Za-z.-]+)?$`)
+
+//DockerSearch executes the seed search command, returning the REPOSITORY:TAG names of the images
+// found. When more than one organization is given each image is prefixed with its organization.
+// With seedOnly only images labeled with a valid seed manifest are returned.
+func DockerSearch(url string, orgs []string, filter, username, password string, seedOnly bool) ([]string, error) {
+	results, err := SearchImages(url, orgs, filter, username, password, seedOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchImageNames(results, len(searchOrgs(orgs)) > 1), nil
+}
+
+//searchImageNames returns the REPOSITORY:TAG names of the search results, prefixed with their
+// organization if more than one was searched
+func searchImageNames(results []SearchResult, prefixOrg bool) []string {
+	images := []string{}
+	for _, r := range results {
+		image := r.Repository
+		if r.Tag != "" {
+			image += ":" + r.Tag
+		}
+		if prefixOrg {
+			image = r.Org + "/" + image
+		}
+		images = append(images, image)
+	}
+	return images
+}
+
+//SearchImages searches the organizations of the registry at url for seed images, concurrently
+// when more than one is given. A failure in one organization is reported without aborting the
+// search of the others. With seedOnly the manifest label of each image is inspected and only
+// images labeled with a manifest valid against the seed schema are returned.
+func SearchImages(url string, orgs []string, filter, username, password string,
+	seedOnly bool) ([]SearchResult, error) {
 	_ = filter //TODO: add filter
 
 	if url == "" {
 		url = constants.DefaultRegistry
 	}
+	orgs = searchOrgs(orgs)
+
+	username, password = util.ResolveCredentials(url, username, password)
+
+	results := make([]orgSearchResult, len(orgs))
+	if len(orgs) == 1 {
+		images, err := searchOrg(url, orgs[0], username, password)
+		if err != nil {
+			return nil, err
+		}
+		results[0] = orgSearchResult{images: images}
+	} else {
+		sem := make(chan struct{}, searchConcurrency)
+		var wg sync.WaitGroup
+		for i, org := range orgs {
+			wg.Add(1)
+			go func(i int, org string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				images, err := searchOrg(url, org, username, password)
+				results[i] = orgSearchResult{images: images, err: err}
+			}(i, org)
+		}
+		wg.Wait()
+	}
+
+	var found []SearchResult
+	var failed []string
+	for i, org := range orgs {
+		if results[i].err != nil {
+			util.Errorf("Error searching organization %s: %s\n", org, results[i].err.Error())
+			failed = append(failed, org)
+			continue
+		}
+		for _, image := range results[i].images {
+			found = append(found, NewSearchResult(url, org, image))
+		}
+	}
+
+	if len(failed) == len(orgs) {
+		return nil, errors.New("Search failed for all organizations: " + strings.Join(failed, ", "))
+	}
+
+	// registries list images in no particular order
+	sortSearchResults(found, constants.SortName, false)
+
+	if seedOnly {
+		return filterSeedImages(url, username, password, found)
+	}
+	return found, nil
+}
+
+//CheckSearchOutput returns an error if output is not a format seed search -output accepts
+func CheckSearchOutput(output string) error {
+	if output == "" || output == constants.OutputText || output == constants.OutputJson {
+		return nil
+	}
+	err := fmt.Errorf("Invalid -%s %s. Output is %s or %s", constants.OutputFlag, output, constants.OutputText,
+		constants.OutputJson)
+	util.Errorf("%s\n", err.Error())
+	return wrapError(ErrInvalidArgument, err)
+}
+
+//CheckSearchSort returns an error if by is not an order seed search -sort accepts
+func CheckSearchSort(by string) error {
+	if by == "" || util.ContainsString(searchSorts, by) {
+		return nil
+	}
+	err := fmt.Errorf("Invalid -%s %s. Results may be sorted by %s", constants.SortFlag, by,
+		strings.Join(searchSorts, ", "))
+	util.Errorf("%s\n", err.Error())
+	return wrapError(ErrInvalidArgument, err)
+}
+
+//SortSearchResults sorts the search results from the registry at url by repository name, by
+// when each image was last updated, most recent first, or by tag version, reversed with
+// reverse. Sorting by update time inspects every image and requires a V2 registry; images
+// whose update time is unknown are sorted as older than the others.
+func SortSearchResults(url, username, password string, results []SearchResult, by string, reverse bool) {
+	if by == constants.SortUpdated {
+		if url == "" {
+			url = constants.DefaultRegistry
+		}
+		username, password = util.ResolveCredentials(url, username, password)
+		searchUpdateTimes(url, username, password, results)
+	}
+	sortSearchResults(results, by, reverse)
+}
+
+//sortSearchResults sorts the search results in place. Ties are broken by repository, tag and
+// organization so the order is deterministic.
+func sortSearchResults(results []SearchResult, by string, reverse bool) {
+	byName := func(a, b SearchResult) bool {
+		if a.Repository != b.Repository {
+			return a.Repository < b.Repository
+		}
+		if a.Tag != b.Tag {
+			return comparePrerelease(a.Tag, b.Tag) < 0
+		}
+		return a.Org < b.Org
+	}
+	less := byName
+	switch by {
+	case constants.SortUpdated:
+		less = func(a, b SearchResult) bool {
+			if (a.Updated == nil) != (b.Updated == nil) {
+				return a.Updated != nil
+			}
+			if a.Updated != nil && !a.Updated.Equal(*b.Updated) {
+				return a.Updated.After(*b.Updated)
+			}
+			return byName(a, b)
+		}
+	case constants.SortTag:
+		less = func(a, b SearchResult) bool {
+			if c := comparePrerelease(a.Tag, b.Tag); c != 0 {
+				return c < 0
+			}
+			return byName(a, b)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if reverse {
+			return less(results[j], results[i])
+		}
+		return less(results[i], results[j])
+	})
+}
 
-	if org == "" {
-		org = constants.DefaultOrg
+//searchUpdateTimes sets the update time of the search results that the registry at url records,
+// inspecting them with a bounded pool of workers
+func searchUpdateTimes(url, username, password string, results []SearchResult) {
+	if len(results) == 0 {
+		return
 	}
+	registry, err := RegistryFactory.CreateRegistry(url, username, password)
+	if registry == nil || err != nil {
+		util.Warnf("Could not read image update times; sorting by name: %s\n",
+			checkError(err, url, username, password))
+		return
+	}
+	if registry.Name() != "V2" {
+		util.Warnf("Image update times are not available from %s registries; sorting by name\n",
+			registry.Name())
+		return
+	}
+
+	util.Infof("Inspecting %d images for their update times...\n", len(results))
+	inspectEach(len(results), func(i int) {
+		r := &results[i]
+		created, err := registry.ImageCreated(r.Repository, r.Tag)
+		if err != nil {
+			util.Debugf("No update time for %s:%s: %s\n", r.Repository, r.Tag, err.Error())
+			return
+		}
+		r.Updated = &created
+	})
+}
+
+//inspectEach calls inspect with each index below n from a pool of at most inspectConcurrency
+// workers, and returns once every call has
+func inspectEach(n int, inspect func(i int)) {
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < inspectConcurrency && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				inspect(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+//filterSeedImages returns the search results whose image is labeled with a seed manifest that
+// is valid against the seed schema. The labels are read from the registry by a bounded pool of
+// workers, one inspection per tag. Requires a V2 registry.
+func filterSeedImages(url, username, password string, results []SearchResult) ([]SearchResult, error) {
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	registry, err := RegistryFactory.CreateRegistry(url, username, password)
+	if registry == nil || err != nil {
+		err = errors.New(checkError(err, url, username, password))
+		util.Errorf("%s\n", err.Error())
+		return nil, err
+	}
+	if registry.Name() != "V2" {
+		err = fmt.Errorf("-%s is not supported for %s registries, which do not expose image labels",
+			constants.SeedOnlyFlag, registry.Name())
+		util.Errorf("%s\n", err.Error())
+		return nil, err
+	}
+
+	schema, err := LoadSchema("", constants.SchemaManifest)
+	if err != nil {
+		util.Errorf("Error loading the seed schema: %s\n", err.Error())
+		return nil, err
+	}
+
+	util.Infof("Inspecting %d images for seed manifests...\n", len(results))
+	valid := make([]bool, len(results))
+	inspectEach(len(results), func(i int) {
+		r := results[i]
+		label, err := registry.ImageManifest(r.Repository, r.Tag)
+		if err == nil {
+			err = checkManifestLabel(schema, label)
+		}
+		if err != nil {
+			util.Debugf("Skipping %s:%s: %s\n", r.Repository, r.Tag, err.Error())
+			return
+		}
+		valid[i] = true
+	})
+
+	seedImages := []SearchResult{}
+	for i, r := range results {
+		if valid[i] {
+			seedImages = append(seedImages, r)
+		}
+	}
+	return seedImages, nil
+}
+
+//checkManifestLabel returns an error if the seed manifest label of an image is not a seed
+// manifest valid against the schema
+func checkManifestLabel(schema *gojsonschema.Schema, label string) error {
+	manifest := objects.UnescapeManifestLabel(label)
+	result, err := schema.Validate(gojsonschema.NewStringLoader(manifest))
+	if err != nil {
+		return fmt.Errorf("Error reading seed manifest label: %s", err.Error())
+	}
+	if !result.Valid() {
+		var errs []string
+		for _, e := range result.Errors() {
+			errs = append(errs, e.String())
+		}
+		return fmt.Errorf("Seed manifest label is not valid: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+//searchOrgs returns the organizations to search, ignoring empty names
+func searchOrgs(orgs []string) []string {
+	var names []string
+	for _, org := range orgs {
+		if org != "" {
+			names = append(names, org)
+		}
+	}
+	if len(names) == 0 {
+		names = []string{constants.DefaultOrg}
+	}
+	return names
+}
+
+//NewSearchResult returns the search result for an image, given as REPOSITORY[:TAG], found in
+// an organization of a registry
+func NewSearchResult(registry, org, image string) SearchResult {
+	ref := util.SplitReference(image)
+	result := SearchResult{Registry: registry, Org: org, Repository: ref.Repository(), Tag: ref.Tag}
+	result.SeedCompliant = seedRepositoryRegex.MatchString(result.Repository) && seedTagRegex.MatchString(result.Tag)
+	return result
+}
+
+//PrintSearchResults prints the images found by seed search in the given organizations as a
+// table, or as a json array of SearchResult written to stdout
+func PrintSearchResults(results []SearchResult, orgs []string, output string) error {
+	if output == constants.OutputJson {
+		if results == nil {
+			results = []SearchResult{}
+		}
+		bytes, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			util.Errorf("Error marshalling search results: %s\n", err.Error())
+			return err
+		}
+		fmt.Fprintln(os.Stdout, string(bytes))
+		return nil
+	}
+
+	if len(results) > 0 {
+		util.PrintUtil( "Found %v Repositories:\n", len(results))
+		util.PrintUtil("%s", FormatSearchResults(results, len(searchOrgs(orgs)) > 1))
+	} else {
+		util.PrintUtil( "No repositories found.\n")
+	}
+	return nil
+}
+
+//FormatSearchResults formats the search results as an aligned table of repository, tag and
+// whether the image is named as seed build names images, with the organization of each image if
+// more than one was searched
+func FormatSearchResults(results []SearchResult, showOrg bool) string {
+	headers := []string{"REPOSITORY", "TAG", "SEED"}
+	colors := []string{util.ColorCyan, util.ColorGreen, ""}
+	if showOrg {
+		headers = append([]string{"ORG"}, headers...)
+		colors = append([]string{util.ColorYellow}, colors...)
+	}
+
+	var rows [][]string
+	for _, r := range results {
+		seed := "no"
+		if r.SeedCompliant {
+			seed = "yes"
+		}
+		row := []string{r.Repository, r.Tag, seed}
+		if showOrg {
+			row = append([]string{r.Org}, row...)
+		}
+		rows = append(rows, row)
+	}
+	return util.FormatTable(headers, colors, rows)
+}
 
+//searchOrg searches a single organization of the registry at url for seed images
+func searchOrg(url, org, username, password string) ([]string, error) {
 	registry, err := RegistryFactory.CreateRegistry(url, username, password)
 	if registry != nil && err == nil {
 		images, err := registry.Images(org)
@@ -34,12 +433,13 @@ func DockerSearch(url, org, filter, username, password string) ([]string, error)
 
 //PrintSearchUsage prints the seed search usage information, then exits the program
 func PrintSearchUsage() {
-	util.PrintUtil( "\nUsage:\tseed search [-r REGISTRY_NAME] [-o ORGANIZATION_NAME] [-f FILTER] [-u Username] [-p password]\n")
+	util.PrintUtil( "\nUsage:\tseed search [-r REGISTRY_NAME] [-o ORGANIZATION_NAME]... [-f FILTER] [-u Username] [-p password] [-seed-only]\n"+
+		"\t\t   [-sort name|updated|tag] [-reverse] [-output json] [-no-color]\n")
 	util.PrintUtil( "\nAllows for discovery of seed compliant images hosted within a Docker registry.\n")
 	util.PrintUtil( "\nOptions:\n")
 	util.PrintUtil( "  -%s -%s\tSpecifies a specific registry to search (default is index.docker.io).\n",
 		constants.ShortRegistryFlag, constants.RegistryFlag)
-	util.PrintUtil( "  -%s -%s\tSpecifies a specific organization to filter (default is no filter).\n",
+	util.PrintUtil( "  -%s -%s\tSpecifies a specific organization to filter (default is no filter). May be repeated to\n\t\tsearch several organizations concurrently; results are prefixed with their organization.\n",
 		constants.ShortOrgFlag, constants.OrgFlag)
 	util.PrintUtil( "  -%s -%s\tSpecifies a filter to apply (default is no filter).\n",
 		constants.ShortFilterFlag, constants.FilterFlag)
@@ -47,6 +447,16 @@ func PrintSearchUsage() {
 		constants.ShortUserFlag, constants.UserFlag)
 	util.PrintUtil( "  -%s -%s\tPassword to login to remote registry (default is anonymous).\n",
 		constants.ShortPassFlag, constants.PassFlag)
+	util.PrintUtil("  -%s\tOnly list images labeled with a valid seed manifest. Inspects the label of every image\n\t\tfound, so is slower; requires a V2 registry.\n",
+		constants.SeedOnlyFlag)
+	util.PrintUtil("  -%s\t\tSort results by %s (default), %s or %s. Sorting by update time, most recent first,\n"+
+		"\t\tinspects every image, so is slower; requires a V2 registry.\n", constants.SortFlag, constants.SortName,
+		constants.SortUpdated, constants.SortTag)
+	util.PrintUtil("  -%s\tReverse the sort order\n", constants.ReverseFlag)
+	util.PrintUtil("  -%s\tOutput format, %s or %s (default is %s). The json output lists the registry,\n\t\torganization, repository and tag of each image and whether it is seed compliant.\n",
+		constants.OutputFlag, constants.OutputText, constants.OutputJson, constants.OutputText)
+	util.PrintUtil("  -%s\tDo not color the table; color is also off when output is not a terminal or NO_COLOR is set.\n",
+		constants.NoColorFlag)
 	panic(util.Exit{0})
 }
 
@@ -1,8 +1,12 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -38,7 +42,7 @@ func TestDockerSearch(t *testing.T) {
 	validImgNames := []string{"my-job-0.1.0-seed:0.1.0", "my-job-1.0.0-seed:1.0.0"}
 	validImgNameStr := fmt.Sprintf("%s", validImgNames)
 	for _, dir := range imgDirs {
-		err := DockerBuild(dir, "", "")
+		err := DockerBuild(BuildOptions{JobDirectory: dir})
 		if err != nil {
 			t.Errorf("Error building image from %v for DockerSearch test: %v", dir, err)
 		}
@@ -73,7 +77,7 @@ func TestDockerSearch(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		results, err := DockerSearch(c.registry, c.org, "", c.username, c.password)
+		results, err := DockerSearch(c.registry, []string{c.org}, "", c.username, c.password, false)
 
 		resultStr := fmt.Sprintf("%s", results)
 		if resultStr != c.expectedResult {
@@ -88,3 +92,176 @@ func TestDockerSearch(t *testing.T) {
 		}
 	}
 }
+
+func TestDockerSearchOrgsUnreachable(t *testing.T) {
+	cases := []struct {
+		orgs             []string
+		expectedErrorMsg string
+	}{
+		{[]string{"org1"},
+			"Could not connect to the specified registry. Please check the url and try again."},
+		{[]string{"org1", "org2", "", "org3"},
+			"Search failed for all organizations: org1, org2, org3"},
+	}
+
+	for _, c := range cases {
+		results, err := DockerSearch("localhost:1", c.orgs, "", "", "", false)
+		if results != nil {
+			t.Errorf("DockerSearch(%v) returned %v, expected no results\n", c.orgs, results)
+		}
+		if err == nil || err.Error() != c.expectedErrorMsg {
+			t.Errorf("DockerSearch(%v) returned error %v, expected %v\n", c.orgs, err, c.expectedErrorMsg)
+		}
+	}
+}
+
+func TestNewSearchResult(t *testing.T) {
+	cases := []struct {
+		org                string
+		image              string
+		expectedRepository string
+		expectedTag        string
+		expectedCompliant  bool
+	}{
+		{"geoint", "my-job-0.1.0-seed:1.0.0", "my-job-0.1.0-seed", "1.0.0", true},
+		{"", "geoint/extractor-1.2.0-rc.1-seed:0.1.0-beta", "geoint/extractor-1.2.0-rc.1-seed", "0.1.0-beta", true},
+		{"geoint", "my-job-seed:latest", "my-job-seed", "latest", false},
+		{"geoint", "my-job-0.1.0-seed", "my-job-0.1.0-seed", "", false},
+		{"", "localhost:5000/my-job-0.1.0-seed", "localhost:5000/my-job-0.1.0-seed", "", false},
+	}
+
+	for _, c := range cases {
+		result := NewSearchResult("localhost:5000", c.org, c.image)
+		if result.Repository != c.expectedRepository || result.Tag != c.expectedTag ||
+			result.SeedCompliant != c.expectedCompliant || result.Org != c.org {
+			t.Errorf("NewSearchResult(%q) == %+v, expected %v %v %v", c.image, result, c.expectedRepository,
+				c.expectedTag, c.expectedCompliant)
+		}
+	}
+
+	results := []SearchResult{NewSearchResult("", "org1", "a-1.0.0-seed:1.0.0"), NewSearchResult("", "org2", "b")}
+	names := fmt.Sprintf("%s", searchImageNames(results, true))
+	if names != "[org1/a-1.0.0-seed:1.0.0 org2/b]" {
+		t.Errorf("searchImageNames returned %v, expected [org1/a-1.0.0-seed:1.0.0 org2/b]", names)
+	}
+
+	expected := "ORG    REPOSITORY     TAG     SEED\n" +
+		"org1   a-1.0.0-seed   1.0.0   yes\n" +
+		"org2   b                      no\n"
+	if table := FormatSearchResults(results, true); table != expected {
+		t.Errorf("FormatSearchResults returned\n%s\nexpected\n%s", table, expected)
+	}
+}
+
+func TestInspectEach(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	seen := make([]int, 50)
+	inspectEach(len(seen), func(i int) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		seen[i]++
+		mu.Unlock()
+	})
+	for i, n := range seen {
+		if n != 1 {
+			t.Errorf("inspectEach inspected index %d %d times, expected once", i, n)
+		}
+	}
+	if maxRunning > inspectConcurrency {
+		t.Errorf("inspectEach ran %d inspections at once, expected at most %d", maxRunning, inspectConcurrency)
+	}
+	inspectEach(0, func(i int) { t.Errorf("inspectEach(0) inspected index %d", i) })
+}
+
+func TestSortSearchResults(t *testing.T) {
+	older := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	results := func() []SearchResult {
+		r := []SearchResult{
+			NewSearchResult("", "geoint", "b-1.0.0-seed:1.10.0"),
+			NewSearchResult("", "geoint", "a-1.0.0-seed:1.2.0"),
+			NewSearchResult("", "geoint", "b-1.0.0-seed:1.9.0"),
+			NewSearchResult("", "archive", "a-1.0.0-seed:1.2.0"),
+		}
+		r[0].Updated = &older
+		r[2].Updated = &newer
+		return r
+	}
+
+	cases := []struct {
+		by       string
+		reverse  bool
+		expected string
+	}{
+		{"", false, "[archive/a-1.0.0-seed:1.2.0 geoint/a-1.0.0-seed:1.2.0 geoint/b-1.0.0-seed:1.9.0 geoint/b-1.0.0-seed:1.10.0]"},
+		{constants.SortName, true, "[geoint/b-1.0.0-seed:1.10.0 geoint/b-1.0.0-seed:1.9.0 geoint/a-1.0.0-seed:1.2.0 archive/a-1.0.0-seed:1.2.0]"},
+		{constants.SortTag, false, "[archive/a-1.0.0-seed:1.2.0 geoint/a-1.0.0-seed:1.2.0 geoint/b-1.0.0-seed:1.9.0 geoint/b-1.0.0-seed:1.10.0]"},
+		{constants.SortUpdated, false, "[geoint/b-1.0.0-seed:1.9.0 geoint/b-1.0.0-seed:1.10.0 archive/a-1.0.0-seed:1.2.0 geoint/a-1.0.0-seed:1.2.0]"},
+		{constants.SortUpdated, true, "[geoint/a-1.0.0-seed:1.2.0 archive/a-1.0.0-seed:1.2.0 geoint/b-1.0.0-seed:1.10.0 geoint/b-1.0.0-seed:1.9.0]"},
+	}
+
+	for _, c := range cases {
+		r := results()
+		sortSearchResults(r, c.by, c.reverse)
+		if names := fmt.Sprintf("%s", searchImageNames(r, true)); names != c.expected {
+			t.Errorf("sortSearchResults(%q, %v) == %v, expected %v", c.by, c.reverse, names, c.expected)
+		}
+	}
+
+	if err := CheckSearchSort(constants.SortUpdated); err != nil {
+		t.Errorf("CheckSearchSort(%q) returned %v, expected no error", constants.SortUpdated, err)
+	}
+	if err := CheckSearchSort("size"); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("CheckSearchSort(%q) returned %v, expected %v", "size", err, ErrInvalidArgument)
+	}
+	if err := CheckSearchOutput(constants.OutputJson); err != nil {
+		t.Errorf("CheckSearchOutput(%q) returned %v, expected no error", constants.OutputJson, err)
+	}
+	if err := CheckSearchOutput("yaml"); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("CheckSearchOutput(%q) returned %v, expected %v", "yaml", err, ErrInvalidArgument)
+	}
+}
+
+func TestCheckManifestLabel(t *testing.T) {
+	complete, err := ioutil.ReadFile("../testdata/complete/seed.manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	missingJob, err := ioutil.ReadFile("../testdata/invalid-missing-job/seed.manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := LoadSchema("", constants.SchemaManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		label            string
+		expectedErrorMsg string
+	}{
+		{string(complete), ""},
+		{"'" + strings.Replace(string(complete), "\"", "\\\"", -1) + "'", ""},
+		{string(missingJob), "job is required"},
+		{"not a manifest", "Error reading seed manifest label"},
+	}
+
+	for _, c := range cases {
+		err := checkManifestLabel(schema, c.label)
+		if (err == nil) != (c.expectedErrorMsg == "") || (err != nil && !strings.Contains(err.Error(), c.expectedErrorMsg)) {
+			t.Errorf("checkManifestLabel(%.40q) returned %v, expected %q", c.label, err, c.expectedErrorMsg)
+		}
+	}
+
+	if results, err := filterSeedImages("localhost:1", "", "", nil); err != nil || len(results) != 0 {
+		t.Errorf("filterSeedImages with no results returned %v %v, expected no results", results, err)
+	}
+}
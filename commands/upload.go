@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/util"
+)
+
+//defaultS3Region is the AWS region S3 uploads are signed for when none is set in the environment
+const defaultS3Region = "us-east-1"
+
+//transferClient is the HTTP client outputs are uploaded and inputs downloaded with. Connecting
+// and waiting for the response time out. Transferring the body has no overall limit, as files may
+// be large, but is abandoned if it stalls for stallTimeout; an interrupt also cancels it.
+var transferClient = &http.Client{Transport: &http.Transport{
+	Proxy:                 http.ProxyFromEnvironment,
+	DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+	TLSHandshakeTimeout:   30 * time.Second,
+	ResponseHeaderTimeout: 5 * time.Minute,
+}}
+
+//stallTimeout is how long a transfer may go without moving any data before it is abandoned
+var stallTimeout = 5 * time.Minute
+
+//stallReader reads the body of a transfer, calling stalled if no data is read for stallTimeout,
+// i.e. because the server stopped reading an upload or sending a download
+type stallReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	expired int32
+}
+
+//newStallReader returns a stallReader of r that calls stalled, which cancels the transfer
+func newStallReader(r io.Reader, stalled func()) *stallReader {
+	s := &stallReader{r: r}
+	s.timer = time.AfterFunc(stallTimeout, func() {
+		atomic.StoreInt32(&s.expired, 1)
+		stalled()
+	})
+	return s
+}
+
+//Read reads from the body, restarting the stall timer when data is read
+func (s *stallReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 {
+		s.timer.Reset(stallTimeout)
+	}
+	return n, err
+}
+
+//Stop stops the stall timer once the transfer is over. Returns whether the transfer stalled.
+func (s *stallReader) Stop() bool {
+	s.timer.Stop()
+	return atomic.LoadInt32(&s.expired) == 1
+}
+
+//ResolveOutputUpload validates the destination given with -output-upload: an s3://BUCKET/KEY,
+// http or https URL. A destination ending in / is a directory the archive is uploaded to, named
+// after the output directory, i.e. s3://results/jobs/ uploads 20200102_150405.tar.gz to
+// s3://results/jobs/20200102_150405.tar.gz.
+func ResolveOutputUpload(destination, outDir string) (*url.URL, error) {
+	u, err := url.Parse(destination)
+	if err != nil || u.Host == "" || (u.Scheme != "s3" && u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("Invalid -%s value %s. Outputs are uploaded to an s3://BUCKET/KEY, http or "+
+			"https URL", constants.OutputUploadFlag, redactURL(destination))
+	}
+	if u.Path == "" || strings.HasSuffix(u.Path, "/") {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + filepath.Base(filepath.Clean(outDir)) + ".tar.gz"
+	}
+	return u, nil
+}
+
+//UploadOutputs uploads the output directory as a gzipped tar archive to the destination, which
+// has been validated by ResolveOutputUpload. S3 destinations are signed with the AWS credentials
+// in the environment; http and https destinations are sent with an HTTP PUT, with the basic auth
+// credentials of the URL, SEED_UPLOAD_USER and SEED_UPLOAD_PASSWORD, or SEED_UPLOAD_TOKEN as a
+// bearer token.
+func UploadOutputs(outDir string, destination *url.URL) error {
+	archive, err := ioutil.TempFile("", "seed-outputs-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	// The archive is written to disk first, as S3 needs the length and hash of the body up front
+	sum := sha256.New()
+	if err := util.TarDirectory(outDir, io.MultiWriter(archive, sum)); err != nil {
+		return fmt.Errorf("Error archiving %s: %s", outDir, err.Error())
+	}
+	size, err := archive.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	// Stop the upload if seed is interrupted or the upload stalls. Waiting for the response once
+	// the archive is sent counts as stalling.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	body := newStallReader(archive, cancel)
+
+	target := destination.Redacted()
+	var req *http.Request
+	if destination.Scheme == "s3" {
+		req, err = newS3PutRequest(destination, body, hex.EncodeToString(sum.Sum(nil)), time.Now())
+	} else {
+		req, err = newHTTPPutRequest(destination, body)
+	}
+	if err != nil {
+		body.Stop()
+		return fmt.Errorf("Error uploading outputs to %s: %s", target, err.Error())
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/gzip")
+
+	util.Infof("Uploading %s (%d bytes) to %s\n", outDir, size, target)
+	uploadTime := time.Now()
+	resp, err := transferClient.Do(req.WithContext(ctx))
+	if stalled := body.Stop(); stalled {
+		return fmt.Errorf("Error uploading outputs to %s: the upload stalled for %s", target, stallTimeout)
+	} else if err != nil {
+		return fmt.Errorf("Error uploading outputs to %s: %s", target, err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Error uploading outputs to %s: %s %s", target, resp.Status,
+			strings.TrimSpace(string(body)))
+	}
+	util.PrintUtil("Uploaded outputs to %s in %s\n", target, time.Since(uploadTime).Round(time.Millisecond))
+	return nil
+}
+
+//newHTTPPutRequest returns a PUT of body to the destination, authenticated with the credentials
+// of the URL or else those in the environment
+func newHTTPPutRequest(destination *url.URL, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPut, destination.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	setEnvAuth(req, destination, constants.UploadTokenEnv, constants.UploadUserEnv, constants.UploadPasswordEnv)
+	return req, nil
+}
+
+//setEnvAuth authenticates a request to u, unless the URL carries credentials, with the bearer
+// token in the tokenEnv environment variable or else basic auth from userEnv and passwordEnv.
+// Credentials are read from the environment rather than flags so they are not visible in ps.
+func setEnvAuth(req *http.Request, u *url.URL, tokenEnv, userEnv, passwordEnv string) {
+	if u.User != nil {
+		return
+	}
+	if token := os.Getenv(tokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if user := os.Getenv(userEnv); user != "" {
+		req.SetBasicAuth(user, os.Getenv(passwordEnv))
+	}
+}
+
+//newS3PutRequest returns a PUT of body, whose SHA-256 is payloadHash, to the s3://BUCKET/KEY
+// destination, signed with AWS Signature Version 4 using the credentials in AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN for the region in AWS_REGION or
+// AWS_DEFAULT_REGION. AWS_ENDPOINT_URL points the request at an S3 compatible service, i.e.
+// MinIO, addressing the bucket in the path.
+func newS3PutRequest(destination *url.URL, body io.Reader, payloadHash string, now time.Time) (*http.Request, error) {
+	accessKey, secretKey := os.Getenv(constants.AWSAccessKeyEnv), os.Getenv(constants.AWSSecretKeyEnv)
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("%s and %s must be set to upload to S3", constants.AWSAccessKeyEnv,
+			constants.AWSSecretKeyEnv)
+	}
+	region := os.Getenv(constants.AWSRegionEnv)
+	if region == "" {
+		region = os.Getenv(constants.AWSDefaultRegionEnv)
+	}
+	if region == "" {
+		region = defaultS3Region
+	}
+
+	bucket, key := destination.Host, strings.TrimPrefix(destination.Path, "/")
+	var endpoint *url.URL
+	if e := os.Getenv(constants.AWSEndpointEnv); e != "" {
+		var err error
+		if endpoint, err = url.Parse(e); err != nil || endpoint.Host == "" {
+			return nil, fmt.Errorf("Invalid %s %s", constants.AWSEndpointEnv, e)
+		}
+		endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + bucket + "/" + key
+	} else {
+		endpoint = &url.URL{Scheme: "https", Host: bucket + ".s3." + region + ".amazonaws.com", Path: "/" + key}
+	}
+	endpoint.RawPath = awsURIEncode(endpoint.Path)
+
+	req, err := http.NewRequest(http.MethodPut, endpoint.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv(constants.AWSSessionTokenEnv); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signS3Request(req, payloadHash, accessKey, secretKey, region, now)
+	return req, nil
+}
+
+//signS3Request adds the AWS Signature Version 4 Authorization header to an S3 request, signing
+// the host, the headers already set on it and the x-amz-content-sha256 and x-amz-date headers it
+// adds. payloadHash is the hex SHA-256 of the body.
+func signS3Request(req *http.Request, payloadHash, accessKey, secretKey, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{req.Method, req.URL.EscapedPath(), req.URL.RawQuery,
+		canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+//hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+//awsURIEncode percent-encodes a path as AWS signatures require: every byte but letters, digits,
+// -, _, ., ~ and / is encoded
+func awsURIEncode(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+			strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -2,8 +2,19 @@ package commands
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
 	"github.com/ngageoint/seed-cli/constants"
 	"github.com/ngageoint/seed-cli/objects"
@@ -11,77 +22,631 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 )
 
-//Validate seed validate: Validate seed.manifest.json. Does not require docker
-func Validate(schemaFile, dir string) error {
-	var err error = nil
-	var seedFileName string
+//validationResult holds the captured output and error of validating a single manifest
+type validationResult struct {
+	output   string
+	warnings int
+	err      error
+	duration time.Duration
+}
+
+//junitClassname is the JUnit test case class of a manifest validated by seed validate
+const junitClassname = "seed.validate"
+
+//ValidateOptions defines the schema and reporting options of seed validate
+type ValidateOptions struct {
+	// SchemaFile is an external schema file overriding the built in schemas
+	SchemaFile string
+
+	// SchemaVersion selects the built in schema of a seed spec version, i.e. 0.1.0. Defaults
+	// to constants.DefaultSchemaVersion.
+	SchemaVersion string
+
+	// Jobs is the number of manifests validated concurrently
+	Jobs int
+
+	// MaxWarnings fails validation when more than this number of warnings are found in total.
+	// A negative value is no limit.
+	MaxWarnings int
+
+	// ListInputs and ListOutputs print a table of the interface of each valid manifest
+	ListInputs  bool
+	ListOutputs bool
+
+	// Fix applies safe automatic corrections to each manifest before it is validated, writing
+	// the corrected manifest back, or to FixOutput if a single manifest is validated
+	Fix       bool
+	FixOutput string
+
+	// CheckImage is a built image the manifest is cross-checked against once it is valid, i.e. that
+	// the command's executable exists in the image. Requires docker and a single manifest.
+	CheckImage string
+
+	// Output is constants.OutputText, the default, or constants.OutputJunit to also write a JUnit
+	// XML report with a test case per manifest to ReportFile, or to stdout if it is empty
+	Output     string
+	ReportFile string
+}
+
+//Validate seed validate: Validate seed.manifest.json files. Does not require docker
+// Each entry of paths may be a directory containing a seed.manifest.json or the
+// path to a manifest file. Manifests are validated by a pool of workers
+// and the results are printed in the order the paths were given.
+func Validate(paths []string, options ValidateOptions) error {
+	start := time.Now()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	if err := checkValidateOutput(options); err != nil {
+		return err
+	}
+	schemaFile := options.SchemaFile
+	jobs := options.Jobs
+	maxWarnings := options.MaxWarnings
+	listInputs := options.ListInputs
+	listOutputs := options.ListOutputs
+
+	var seedFileNames []string
+	for _, p := range paths {
+		seedFileName, err := manifestFileName(p)
+		if os.IsNotExist(err) {
+			util.PrintError(err)
+			return wrapError(ErrManifestNotFound, err)
+		} else if err != nil {
+			util.PrintError(err)
+			return err
+		}
+		seedFileNames = append(seedFileNames, seedFileName)
+	}
+
+	if schemaFile != "" && options.SchemaVersion != "" {
+		err := fmt.Errorf("ERROR: -%s and -%s cannot be used together\n", constants.SchemaFlag,
+			constants.SchemaVersionFlag)
+		util.PrintError(err)
+		return wrapError(ErrInvalidArgument, err)
+	}
+
+	if options.FixOutput != "" && (!options.Fix || len(seedFileNames) != 1) {
+		err := fmt.Errorf("ERROR: -%s requires -%s and a single manifest\n", constants.OutputFileFlag,
+			constants.FixFlag)
+		util.PrintError(err)
+		return wrapError(ErrInvalidArgument, err)
+	}
 
-	seedFileName, err = util.SeedFileName(dir)
+	if options.CheckImage != "" && len(seedFileNames) != 1 {
+		err := fmt.Errorf("ERROR: -%s requires a single manifest\n", constants.CheckImageFlag)
+		util.PrintError(err)
+		return wrapError(ErrInvalidArgument, err)
+	}
+
+	// Compile the schema once and share it across all workers
+	schema, schemaFile, err := validationSchema(options, paths[0])
 	if err != nil {
-		util.PrintUtil( "ERROR: %s\n", err.Error())
 		return err
 	}
+	schemaPath := strings.TrimPrefix(schemaFile, "file://")
+	version := options.SchemaVersion
+	if version == "" {
+		version = constants.DefaultSchemaVersion
+	}
 
-	if schemaFile != "" {
-		schemaFile = "file://" + util.GetFullPath(schemaFile, dir)
+	if options.Fix {
+		for i, seedFileName := range seedFileNames {
+			fixes, err := fixManifestFile(seedFileName, options.FixOutput, schemaPath, version)
+			if err != nil {
+				err = fmt.Errorf("ERROR: %s\n", err.Error())
+				util.PrintError(err)
+				return err
+			}
+			if options.FixOutput != "" {
+				seedFileNames[i] = util.GetFullPath(options.FixOutput, "")
+			}
+			for _, fix := range fixes {
+				util.PrintUtil("FIXED: %s: %s\n", seedFileName, fix)
+			}
+			if len(fixes) > 0 {
+				util.PrintUtil("INFO: Wrote %d fixes to %s\n", len(fixes), seedFileNames[i])
+			}
+		}
+	}
+
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	results := make([]validationResult, len(seedFileNames))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = validateManifest(schema, schemaFile, seedFileNames[i])
+			}
+		}()
+	}
+	for i := range seedFileNames {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	suite := newJUnitTestSuite(constants.ValidateCommand, start)
+	failed := 0
+	warnings := 0
+	for i, r := range results {
+		suite.addCase(seedFileNames[i], junitClassname, r.duration, r.output, r.err)
+		util.PrintUtil( "%s", r.output)
+		warnings += r.warnings
+		if r.err != nil {
+			util.PrintError(r.err)
+			err = r.err
+			failed++
+		} else if listInputs || listOutputs {
+			seed, readErr := objects.SeedFromManifestFile(seedFileNames[i])
+			if readErr == nil {
+				util.PrintUtil("%s", InterfaceTable(&seed, listInputs, listOutputs))
+			}
+		}
+	}
+
+	if len(results) > 1 {
+		util.PrintUtil( "INFO: %d of %d manifests are valid.\n", len(results)-failed, len(results))
+		if failed > 0 {
+			err = fmt.Errorf("ERROR: %d of %d manifests failed validation.\n", failed, len(results))
+		}
+	}
+
+	if maxWarnings >= 0 {
+		util.PrintUtil("INFO: %d warnings found (maximum allowed is %d).\n", warnings, maxWarnings)
+		var warningsErr error
+		if warnings > maxWarnings {
+			warningsErr = fmt.Errorf("ERROR: %d warnings found exceeds the maximum of %d.\n", warnings, maxWarnings)
+		}
+		suite.addCase("-"+constants.MaxWarningsFlag, junitClassname, 0, "", warningsErr)
+		if warningsErr != nil && err == nil {
+			err = warningsErr
+			util.PrintError(err)
+		}
+	}
+
+	if options.CheckImage != "" && err == nil {
+		seed, readErr := objects.SeedFromManifestFile(seedFileNames[0])
+		if readErr != nil {
+			util.Errorf("%s\n", readErr.Error())
+			return wrapError(ErrValidation, readErr)
+		}
+		checkStart := time.Now()
+		_, err = CheckImage(&seed, options.CheckImage)
+		suite.addCase("-"+constants.CheckImageFlag+" "+options.CheckImage, junitClassname,
+			time.Since(checkStart), "", err)
+	}
+
+	if options.Output == constants.OutputJunit {
+		if reportErr := writeJUnitReport(suite, time.Since(start), options.ReportFile); reportErr != nil {
+			util.Errorf("Error writing JUnit report: %s\n", reportErr.Error())
+			if err == nil {
+				return reportErr
+			}
+		}
 	}
 
-	err = ValidateSeedFile(schemaFile, seedFileName, constants.SchemaManifest)
+	return wrapError(ErrValidation, err)
+}
+
+//checkValidateOutput returns an error if the output format of the validate options is not text
+// or junit, or if a report file is given for text output
+func checkValidateOutput(options ValidateOptions) error {
+	var err error
+	switch options.Output {
+	case "", constants.OutputText:
+		if options.ReportFile != "" {
+			err = fmt.Errorf("ERROR: -%s requires -%s %s\n", constants.ReportFileFlag, constants.OutputFlag,
+				constants.OutputJunit)
+		}
+	case constants.OutputJunit:
+	default:
+		err = fmt.Errorf("ERROR: Invalid -%s %s. Output is %s or %s\n", constants.OutputFlag, options.Output,
+			constants.OutputText, constants.OutputJunit)
+	}
 	if err != nil {
-		util.PrintUtil( "%s", err.Error())
+		util.PrintError(err)
+		return wrapError(ErrInvalidArgument, err)
 	}
+	return nil
+}
 
+//ValidateReader seed validate -: Validates a seed manifest read from r, i.e. piped to stdin, rather
+// than a file. name identifies the manifest in messages. The manifest cannot be fixed, as there is
+// no file to write it back to, but its interface may be listed. Does not require docker.
+func ValidateReader(r io.Reader, name string, options ValidateOptions) error {
+	start := time.Now()
+	if err := checkValidateOutput(options); err != nil {
+		return err
+	}
+	err := validateReader(r, name, options)
+	if options.Output == constants.OutputJunit && !errors.Is(err, ErrInvalidArgument) {
+		suite := newJUnitTestSuite(constants.ValidateCommand, start)
+		suite.addCase(name, junitClassname, time.Since(start), "", err)
+		if reportErr := writeJUnitReport(suite, time.Since(start), options.ReportFile); reportErr != nil {
+			util.Errorf("Error writing JUnit report: %s\n", reportErr.Error())
+			if err == nil {
+				return reportErr
+			}
+		}
+	}
 	return err
 }
 
+//validateReader validates the manifest read from r for ValidateReader
+func validateReader(r io.Reader, name string, options ValidateOptions) error {
+	if options.SchemaFile != "" && options.SchemaVersion != "" {
+		err := fmt.Errorf("ERROR: -%s and -%s cannot be used together\n", constants.SchemaFlag,
+			constants.SchemaVersionFlag)
+		util.PrintError(err)
+		return wrapError(ErrInvalidArgument, err)
+	}
+	if options.Fix {
+		err := fmt.Errorf("ERROR: -%s cannot be used with a manifest read from %s\n", constants.FixFlag, name)
+		util.PrintError(err)
+		return wrapError(ErrInvalidArgument, err)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		err = fmt.Errorf("ERROR: Error reading manifest from %s: %s\n", name, err.Error())
+		util.PrintError(err)
+		return err
+	}
+
+	schema, schemaFile, err := validationSchema(options, ".")
+	if err != nil {
+		return err
+	}
+
+	warnings, err := validateSeedBytes(schema, schemaFile, name, data, constants.SchemaManifest, util.PrintUtil)
+	if err != nil {
+		util.PrintError(err)
+		return wrapError(ErrValidation, err)
+	}
+	if options.ListInputs || options.ListOutputs {
+		var seed objects.Seed
+		if json.Unmarshal(data, &seed) == nil {
+			util.PrintUtil("%s", InterfaceTable(&seed, options.ListInputs, options.ListOutputs))
+		}
+	}
+	if options.MaxWarnings >= 0 {
+		util.PrintUtil("INFO: %d warnings found (maximum allowed is %d).\n", warnings, options.MaxWarnings)
+		if warnings > options.MaxWarnings {
+			err = fmt.Errorf("ERROR: %d warnings found exceeds the maximum of %d.\n", warnings, options.MaxWarnings)
+			util.PrintError(err)
+			return wrapError(ErrValidation, err)
+		}
+	}
+	if options.CheckImage != "" {
+		var seed objects.Seed
+		if err = json.Unmarshal(data, &seed); err != nil {
+			return wrapError(ErrValidation, err)
+		}
+		_, err = CheckImage(&seed, options.CheckImage)
+		return wrapError(ErrValidation, err)
+	}
+	return nil
+}
+
+//validationSchema compiles the schema selected by the validate options, either the schema file,
+// resolved relative to baseDir, or a built in schema version. Returns the schema and the URL of
+// the schema file, if one was given.
+func validationSchema(options ValidateOptions, baseDir string) (*gojsonschema.Schema, string, error) {
+	if options.SchemaFile != "" {
+		schemaFile := "file://" + util.GetFullPath(options.SchemaFile, baseDir)
+		util.PrintUtil("INFO: Using schema file %s\n", schemaFile)
+		schema, err := LoadSchema(schemaFile, constants.SchemaManifest)
+		if err != nil {
+			err = errors.New("ERROR: Error validating seed file against schema. Error is:" + err.Error() + "\n")
+			util.PrintError(err)
+			return nil, "", err
+		}
+		return schema, schemaFile, nil
+	}
+
+	version := options.SchemaVersion
+	if version == "" {
+		version = constants.DefaultSchemaVersion
+	}
+	schema, err := LoadSchemaVersion(version, constants.SchemaManifest)
+	if err != nil {
+		err = fmt.Errorf("ERROR: %s\n", err.Error())
+		util.PrintError(err)
+		return nil, "", wrapError(ErrInvalidArgument, err)
+	}
+	util.PrintUtil("INFO: Using seed schema version %s\n", version)
+	return schema, "", nil
+}
+
+//manifestFileName returns the full path to the seed manifest given either the path to
+// a manifest file or a directory containing a seed.manifest.json
+func manifestFileName(path string) (string, error) {
+	seedFileName := util.GetFullPath(path, "")
+	if info, err := os.Stat(seedFileName); err == nil && !info.IsDir() {
+		util.Debugf("Resolved seed manifest path %s to %s\n", path, seedFileName)
+		return seedFileName, nil
+	}
+	return util.SeedFileName(path)
+}
+
+//validateManifest validates a single seed manifest against a compiled schema, capturing
+// any output so results from concurrent workers can be printed in order. Panics are
+// recovered and reported as a failed validation.
+func validateManifest(schema *gojsonschema.Schema, schemaFile, seedFileName string) (result validationResult) {
+	var buffer bytes.Buffer
+	printer := func(format string, args ...interface{}) {
+		fmt.Fprintf(&buffer, format, args...)
+	}
+
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			result.err = fmt.Errorf("ERROR: Unexpected error validating %s: %v\n", seedFileName, r)
+		}
+		result.output = buffer.String()
+		result.duration = time.Since(start)
+	}()
+
+	result.warnings, result.err = validateSeedFile(schema, schemaFile, seedFileName, constants.SchemaManifest, printer)
+	return result
+}
+
+//InterfaceTable returns a table of the inputs, settings and mounts and/or the outputs of a
+// seed manifest, giving the name, type, whether it is required and details such as media types
+func InterfaceTable(seed *objects.Seed, inputs, outputs bool) string {
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 3, ' ', 0)
+	iface := seed.Job.Interface
+	if inputs {
+		fmt.Fprintln(w, "INPUT\tTYPE\tREQUIRED\tDETAILS")
+		for _, f := range iface.Inputs.Files {
+			kind := "file"
+			if f.Multiple {
+				kind = "files"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%v\t%s\n", f.Name, kind, f.Required, strings.Join(f.MediaTypes, ", "))
+		}
+		for _, j := range iface.Inputs.Json {
+			fmt.Fprintf(w, "%s\tjson %s\t%v\t\n", j.Name, j.Type, j.Required)
+		}
+		for _, s := range iface.Settings {
+			details := ""
+			if s.Secret {
+				details = "secret"
+			}
+			fmt.Fprintf(w, "%s\tsetting\t%v\t%s\n", s.Name, true, details)
+		}
+		for _, m := range iface.Mounts {
+			fmt.Fprintf(w, "%s\tmount\t%v\t%s (%s)\n", m.Name, true, m.Path, m.Mode)
+		}
+	}
+	if inputs && outputs {
+		fmt.Fprintln(w)
+	}
+	if outputs {
+		fmt.Fprintln(w, "OUTPUT\tTYPE\tREQUIRED\tDETAILS")
+		for _, f := range iface.Outputs.Files {
+			fmt.Fprintf(w, "%s\tfile\t%v\t%s, pattern %s, count %s\n", f.Name, f.Required, f.MediaType,
+				f.Pattern, f.Count)
+		}
+		for _, j := range iface.Outputs.JSON {
+			key := j.Key
+			if key == "" {
+				key = j.Name
+			}
+			fmt.Fprintf(w, "%s\tjson %s\t%v\tkey %s\n", j.Name, j.Type, j.Required, key)
+		}
+	}
+	w.Flush()
+	return buffer.String()
+}
+
 //PrintValidateUsage prints the seed validate usage, then exits the program
 func PrintValidateUsage() {
-	util.PrintUtil( "\nUsage:\tseed validate [OPTIONS] \n")
+	util.PrintUtil( "\nUsage:\tseed validate [OPTIONS] [PATH...]\n")
+	util.PrintUtil("\tseed validate [OPTIONS] -\n")
 	util.PrintUtil( "\nValidates the given %s by verifying it is compliant with the Seed spec.\n",
 		constants.SeedFileName)
 	util.PrintUtil( "\nOptions:\n")
 	util.PrintUtil( "  -%s -%s\tSpecifies directory in which Seed is located (default is current directory)\n",
 		constants.ShortJobDirectoryFlag, constants.JobDirectoryFlag)
+	util.PrintUtil("\t\tMay be repeated, or additional directories and manifest files given as arguments\n")
+	util.PrintUtil("  -%s -%s\tNumber of manifests to validate concurrently (default is 1)\n",
+		constants.ShortJobsFlag, constants.JobsFlag)
+	util.PrintUtil("  -%s\tFail if more than N warnings are found across all manifests (default is no limit)\n",
+		constants.MaxWarningsFlag)
+	util.PrintUtil("  -%s\tFail if any warnings are found; equivalent to -%s 0\n",
+		constants.FailOnWarningFlag, constants.MaxWarningsFlag)
+	util.PrintUtil("  -%s\tPrint the name, type, required flag and media types of the inputs, settings and mounts\n",
+		constants.ListInputsFlag)
+	util.PrintUtil("  -%s\tPrint the name, type, required flag and media type of the outputs\n",
+		constants.ListOutputsFlag)
 	util.PrintUtil( "  -%s -%s   \tExternal Seed schema file; Overrides built in schema to validate Seed spec against\n",
 		constants.ShortSchemaFlag, constants.SchemaFlag)
+	util.PrintUtil("  -%s\t\tApply safe fixes before validating and write the manifest back: remove trailing commas,\n"+
+		"\t\tadd a missing seedVersion, correct the case of field names and remove unknown fields.\n"+
+		"\t\tAnything else is reported as an error\n", constants.FixFlag)
+	util.PrintUtil("  -%s\tWrite the fixed manifest to this file instead of over the manifest; requires -%s\n"+
+		"\t\tand a single manifest\n", constants.OutputFileFlag, constants.FixFlag)
+	util.PrintUtil("  -%s\tCross-check a valid manifest against this built image: the image was built from\n"+
+		"\t\tthe manifest, the command's executable exists in it, it sets a working directory and\n"+
+		"\t\tthe mounts do not hide its files. Requires docker and a single manifest\n",
+		constants.CheckImageFlag)
+	util.PrintUtil("  -%s\tOutput format, %s or %s (default is %s). The %s output is a JUnit XML report with a\n"+
+		"\t\ttest case per manifest, written to stdout or the -%s file; messages are still printed\n",
+		constants.OutputFlag, constants.OutputText, constants.OutputJunit, constants.OutputText, constants.OutputJunit,
+		constants.ReportFileFlag)
+	util.PrintUtil("  -%s\tWrite the JUnit report to this file instead of stdout; requires -%s %s\n",
+		constants.ReportFileFlag, constants.OutputFlag, constants.OutputJunit)
+	util.PrintUtil("  -%s\tRead the manifest to validate from stdin; the same as giving - as the path\n",
+		constants.FromStdinFlag)
+	util.PrintUtil("  -%s\tValidate against the built in schema of this seed spec version (default is %s;\n"+
+		"\t\tbundled versions are %s)\n", constants.SchemaVersionFlag, constants.DefaultSchemaVersion,
+		strings.Join(BundledSchemaVersions(), ", "))
 	panic(util.Exit{0})
 }
 
+//cachedSchemaEntry is a compiled schema and the modification time of the file it was compiled
+// from, empty for built in schemas
+type cachedSchemaEntry struct {
+	schema  *gojsonschema.Schema
+	modTime string
+}
+
+//schemaCache holds the compiled schemas, keyed by schema file or built in version and schema type,
+// so repeated validations do not compile them again. It holds one schema per key.
+var schemaCache = struct {
+	sync.Mutex
+	schemas map[string]cachedSchemaEntry
+}{schemas: map[string]cachedSchemaEntry{}}
+
+//cachedSchema returns the schema cached under key, compiling and caching it if it is not or if
+// it was cached with another modTime, which it replaces. Errors are not cached. Safe for
+// concurrent use; a schema is only compiled once.
+func cachedSchema(key, modTime string, compile func() (*gojsonschema.Schema, error)) (*gojsonschema.Schema, error) {
+	schemaCache.Lock()
+	defer schemaCache.Unlock()
+	if entry, ok := schemaCache.schemas[key]; ok && entry.modTime == modTime {
+		return entry.schema, nil
+	}
+	schema, err := compile()
+	if err != nil {
+		return nil, err
+	}
+	schemaCache.schemas[key] = cachedSchemaEntry{schema: schema, modTime: modTime}
+	return schema, nil
+}
+
+//LoadSchema compiles the given schema file, or the built in schema for the schema type
+// if no file is given. Compiled schemas are cached; a schema file is compiled again if it
+// has been modified.
+func LoadSchema(schemaFile string, schemaType constants.SchemaType) (*gojsonschema.Schema, error) {
+	// Load supplied schema file
+	if schemaFile != "" {
+		modTime := ""
+		if info, err := os.Stat(strings.TrimPrefix(schemaFile, "file://")); err == nil {
+			modTime = info.ModTime().String()
+		}
+		return cachedSchema("file "+schemaFile, modTime, func() (*gojsonschema.Schema, error) {
+			return gojsonschema.NewSchema(gojsonschema.NewReferenceLoader(schemaFile))
+		})
+	}
+
+	// Load baked-in schema file
+	return LoadSchemaVersion(constants.DefaultSchemaVersion, schemaType)
+}
+
+//LoadSchemaVersion compiles the built in schema of the given seed spec version for the schema
+// type. Returns an error listing the bundled versions if the version is not built in.
+func LoadSchemaVersion(version string, schemaType constants.SchemaType) (*gojsonschema.Schema, error) {
+	schemaName := constants.ManifestSchemaName
+	if schemaType == constants.SchemaMetadata {
+		schemaName = constants.MetadataSchemaName
+	}
+	schemaBytes, err := constants.Asset(path.Join(constants.SchemaDir, version, schemaName))
+	if err != nil {
+		return nil, fmt.Errorf("Seed schema version %s is not built in. Bundled versions are %s. Use -%s to "+
+			"validate against a schema file", version, strings.Join(BundledSchemaVersions(), ", "),
+			constants.SchemaFlag)
+	}
+	return cachedSchema("version "+version+" "+schemaName, "", func() (*gojsonschema.Schema, error) {
+		return gojsonschema.NewSchema(gojsonschema.NewStringLoader(string(schemaBytes)))
+	})
+}
+
+//BundledSchemaVersions returns the seed spec versions with built in schemas, oldest first
+func BundledSchemaVersions() []string {
+	var versions []string
+	for _, name := range constants.AssetNames() {
+		dir, file := path.Split(name)
+		if file == constants.ManifestSchemaName && path.Dir(path.Dir(dir)) == constants.SchemaDir {
+			versions = append(versions, path.Base(dir))
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return compareVersions(versions[i], versions[j]) < 0
+	})
+	return versions
+}
+
+//compareVersions compares dotted numeric versions, i.e. 0.1.0 and 1.0.0, returning a negative
+// number, zero or a positive number if a is older, the same or newer than b
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
 //ValidateSeedFile Validates the seed.manifest.json file based on the given schema
 func ValidateSeedFile(schemaFile string, seedFileName string, schemaType constants.SchemaType) error {
-	var result *gojsonschema.Result
-	var err error
+	schema, err := LoadSchema(schemaFile, schemaType)
+	if err != nil {
+		return errors.New("ERROR: Error validating seed file against schema. Error is:" + err.Error() + "\n")
+	}
 
+	_, err = validateSeedFile(schema, schemaFile, seedFileName, schemaType, util.PrintUtil)
+	return wrapError(ErrValidation, err)
+}
+
+//validateSeedFile Validates the seed.manifest.json file against a compiled schema, writing
+// any informational output to printer. Returns the number of warnings found.
+func validateSeedFile(schema *gojsonschema.Schema, schemaFile, seedFileName string,
+	schemaType constants.SchemaType, printer util.PrintCallback) (int, error) {
+	data, err := ioutil.ReadFile(seedFileName)
+	if err != nil {
+		return 0, errors.New("ERROR: Error validating seed file against schema. Error is:" + err.Error() + "\n")
+	}
+	return validateSeedBytes(schema, schemaFile, seedFileName, data, schemaType, printer)
+}
+
+//validateSeedBytes Validates the contents of a seed manifest or metadata file, identified in
+// messages by seedFileName, against a compiled schema. JSON errors are reported with the line
+// and column they occur at. Returns the number of warnings found.
+func validateSeedBytes(schema *gojsonschema.Schema, schemaFile, seedFileName string, data []byte,
+	schemaType constants.SchemaType, printer util.PrintCallback) (int, error) {
 	typeStr := "manifest"
 	if schemaType == constants.SchemaMetadata {
 		typeStr = "metadata"
 	}
 
-	// Load supplied schema file
 	if schemaFile != "" {
-		util.PrintUtil( "INFO: Validating seed %s file %s against schema file %s...\n",
+		printer("INFO: Validating seed %s file %s against schema file %s...\n",
 			typeStr, seedFileName, schemaFile)
-		schemaLoader := gojsonschema.NewReferenceLoader(schemaFile)
-		docLoader := gojsonschema.NewReferenceLoader("file://" + seedFileName)
-		result, err = gojsonschema.Validate(schemaLoader, docLoader)
-
-		// Load baked-in schema file
 	} else {
-		util.PrintUtil( "INFO: Validating seed %s file %s against schema...\n",
+		printer("INFO: Validating seed %s file %s against schema...\n",
 			typeStr, seedFileName)
-		// TODO: We need to support validation of all supported schema versions in the future
-		schemaBytes, _ := constants.Asset("schema/0.1.0/seed.manifest.schema.json")
-		if schemaType == constants.SchemaMetadata {
-			schemaBytes, _ = constants.Asset("schema/0.1.0/seed.metadata.schema.json")
-		}
-		schemaLoader := gojsonschema.NewStringLoader(string(schemaBytes))
-		docLoader := gojsonschema.NewReferenceLoader("file://" + seedFileName)
-		result, err = gojsonschema.Validate(schemaLoader, docLoader)
+	}
+	// Check the syntax first so errors can be reported with their position
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return 0, fmt.Errorf("ERROR: %s is not valid JSON. Error is: %s\n", seedFileName,
+			util.JSONErrorPosition(data, err))
 	}
 
-	// Error occurred loading the schema or seed.manifest.json
+	result, err := schema.Validate(gojsonschema.NewGoLoader(doc))
+
+	// Error occurred loading the seed.manifest.json
 	if err != nil {
-		return errors.New("ERROR: Error validating seed file against schema. Error is:" + err.Error() + "\n")
+		return 0, errors.New("ERROR: Error validating seed file against schema. Error is:" + err.Error() + "\n")
 	}
 
 	// Validation failed. Print results
@@ -97,17 +662,24 @@ func ValidateSeedFile(schemaFile string, seedFileName string, schemaType constan
 
 	//Identify any name collisions for the follwing reserved variables:
 	//		OUTPUT_DIR, ALLOCATED_CPUS, ALLOCATED_MEM, ALLOCATED_SHARED_MEM, ALLOCATED_STORAGE
-	util.PrintUtil( "INFO: Checking for variable name collisions...\n")
-	seed := objects.SeedFromManifestFile(seedFileName)
+	printer("INFO: Checking for variable name collisions...\n")
+	var seed objects.Seed
+	if err := json.Unmarshal(data, &seed); err != nil {
+		buffer.WriteString("ERROR: " + seedFileName + " cannot be read as a seed manifest. Error is: " +
+			util.JSONErrorPosition(data, err) + "\n")
+		return 0, errors.New(buffer.String())
+	}
 
 	//skip resource and name collision checking for metadata files
 	if schemaType != constants.SchemaManifest {
 		if buffer.String() != "" {
-			return errors.New(buffer.String())
+			return 0, errors.New(buffer.String())
 		}
-		return nil
+		return 0, nil
 	}
 
+	warnings := 0
+
 	recommendedResources := []string{"mem", "cpu", "disk"}
 	if seed.Job.Resources.Scalar != nil {
 		for _, s := range seed.Job.Resources.Scalar {
@@ -115,9 +687,10 @@ func ValidateSeedFile(schemaFile string, seedFileName string, schemaType constan
 		}
 	}
 	if len(recommendedResources) > 0 {
-		util.PrintUtil( "WARNING: %s does not specify some recommended resources\n", seedFileName)
-		util.PrintUtil("Specifying cpu, memory and disk requirements are highly recommended\n")
-		util.PrintUtil( "The following resources are not defined: %s\n", recommendedResources)
+		warnings++
+		printer("WARNING: %s does not specify some recommended resources\n", seedFileName)
+		printer("Specifying cpu, memory and disk requirements are highly recommended\n")
+		printer("The following resources are not defined: %s\n", recommendedResources)
 	}
 
 	// Grab all scalar resource names (verify none are set to OUTPUT_DIR)
@@ -217,10 +790,10 @@ func ValidateSeedFile(schemaFile string, seedFileName string, schemaType constan
 
 	// Return error if issues found
 	if buffer.String() != "" {
-		return errors.New(buffer.String())
+		return warnings, errors.New(buffer.String())
 	}
 
 	// Validation succeeded
-	util.PrintUtil( "SUCCESS: No errors found. %s is valid.\n\n", seedFileName)
-	return nil
+	printer("SUCCESS: No errors found. %s is valid.\n\n", seedFileName)
+	return warnings, nil
 }
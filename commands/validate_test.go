@@ -1,11 +1,22 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ngageoint/seed-cli/constants"
+	"github.com/ngageoint/seed-cli/objects"
 	"github.com/ngageoint/seed-cli/util"
+	"github.com/xeipuuv/gojsonschema"
 )
 
 func init() {
@@ -26,6 +37,7 @@ func TestValidate(t *testing.T) {
 			false, "name is required"},
 		{"../testdata/invalid-reserved-name/seed.manifest.json",
 			false, "Multiple Name values are assigned the same INPUT Name value. Each Name value must be unique."},
+		{"../testdata/directory-input/seed.manifest.json", true, ""},
 	}
 
 	for _, c := range cases {
@@ -42,3 +54,311 @@ func TestValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateMultiple(t *testing.T) {
+	cases := []struct {
+		paths            []string
+		jobs             int
+		maxWarnings      int
+		expected         bool
+		expectedErrorMsg string
+	}{
+		{[]string{"../examples/addition-job/", "../examples/extractor/seed.manifest.json"},
+			2, -1, true, ""},
+		{[]string{"../examples/addition-job/", "../testdata/invalid-missing-job/",
+			"../testdata/invalid-reserved-name/seed.manifest.json", "../testdata/complete/"},
+			3, -1, false, "2 of 4 manifests failed validation."},
+		{[]string{"../testdata/invalid-missing-job/"},
+			4, -1, false, "job is required"},
+		{[]string{"../testdata/missing-resources/", "../testdata/complete/"},
+			2, 1, true, ""},
+		{[]string{"../testdata/missing-resources/", "../testdata/complete/"},
+			2, 0, false, "1 warnings found exceeds the maximum of 0."},
+	}
+
+	for _, c := range cases {
+		err := Validate(c.paths, ValidateOptions{Jobs: c.jobs, MaxWarnings: c.maxWarnings})
+		success := err == nil
+		if success != c.expected {
+			t.Errorf("Validate(%q, %q, %v, %v) == %v, expected %v", "", c.paths, c.jobs, c.maxWarnings, success, c.expected)
+		}
+		if err != nil {
+			if !strings.Contains(err.Error(), c.expectedErrorMsg) {
+				t.Errorf("Validate(%q, %q, %v, %v) == %v, expected %v", "", c.paths, c.jobs, c.maxWarnings, err.Error(), c.expectedErrorMsg)
+			}
+		}
+	}
+}
+
+func TestValidateJUnit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seed-junit")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for Validate JUnit test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	reportFile := filepath.Join(dir, "report.xml")
+
+	paths := []string{"../examples/addition-job/", "../testdata/invalid-missing-job/", "../testdata/missing-resources/"}
+	err = Validate(paths, ValidateOptions{Jobs: 2, MaxWarnings: 0, Output: constants.OutputJunit,
+		ReportFile: reportFile})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Validate(%q) with -output junit returned %v, expected %v", paths, err, ErrValidation)
+	}
+
+	data, err := ioutil.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("Error reading JUnit report: %v", err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(data, &report); err != nil {
+		t.Fatalf("JUnit report is not valid XML: %v\n%s", err, data)
+	}
+	if len(report.Suites) != 1 || report.Suites[0].Tests != 4 || report.Suites[0].Failures != 2 {
+		t.Fatalf("JUnit report has suites %+v, expected one suite of 4 tests with 2 failures", report.Suites)
+	}
+	expected := []string{"", "is not valid.", "", "2 warnings found exceeds the maximum of 0."}
+	for i, c := range report.Suites[0].Cases {
+		message := ""
+		if c.Failure != nil {
+			message = c.Failure.Message
+		}
+		if !strings.HasSuffix(message, expected[i]) || (message == "") != (expected[i] == "") {
+			t.Errorf("JUnit test case %s has failure %q, expected %q", c.Name, message, expected[i])
+		}
+	}
+	if !strings.HasSuffix(report.Suites[0].Cases[1].Name, "invalid-missing-job/seed.manifest.json") ||
+		!strings.Contains(report.Suites[0].Cases[1].Failure.Contents, "job is required") {
+		t.Errorf("JUnit test case %+v missing the manifest name or validation errors", report.Suites[0].Cases[1])
+	}
+
+	cases := []ValidateOptions{
+		{MaxWarnings: -1, Output: "xml"},
+		{MaxWarnings: -1, ReportFile: reportFile},
+	}
+	for _, options := range cases {
+		if err := Validate(paths[:1], options); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("Validate with output %q and report file %q returned %v, expected %v", options.Output,
+				options.ReportFile, err, ErrInvalidArgument)
+		}
+	}
+}
+
+func TestValidateReader(t *testing.T) {
+	complete, err := ioutil.ReadFile("../testdata/complete/seed.manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	missingJob, err := ioutil.ReadFile("../testdata/invalid-missing-job/seed.manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		manifest         string
+		options          ValidateOptions
+		expected         bool
+		expectedErrorMsg string
+	}{
+		{string(complete), ValidateOptions{MaxWarnings: -1}, true, ""},
+		{string(missingJob), ValidateOptions{MaxWarnings: -1}, false, "job is required"},
+		{"{\n  \"seedVersion\": \"1.0.0\",\n  \"job\": { \"name\": x }\n}", ValidateOptions{MaxWarnings: -1}, false,
+			"line 3, column 20: invalid character 'x'"},
+		{"{\"seedVersion\": \"1.0.0\"", ValidateOptions{MaxWarnings: -1}, false, "line 1, column 23: unexpected end"},
+		{string(complete), ValidateOptions{MaxWarnings: -1, Fix: true}, false, "-fix cannot be used"},
+	}
+
+	for _, c := range cases {
+		err := ValidateReader(strings.NewReader(c.manifest), "stdin", c.options)
+		success := err == nil
+		if success != c.expected {
+			t.Errorf("ValidateReader(%q) == %v, expected %v", c.manifest, err, c.expected)
+		}
+		if err != nil && !strings.Contains(err.Error(), c.expectedErrorMsg) {
+			t.Errorf("ValidateReader(%q) == %v, expected %v", c.manifest, err.Error(), c.expectedErrorMsg)
+		}
+	}
+}
+
+func TestInterfaceTable(t *testing.T) {
+	seed, err := objects.SeedFromManifestFile("../testdata/complete/seed.manifest.json")
+	if err != nil {
+		t.Fatalf("Error reading manifest for InterfaceTable test: %v", err)
+	}
+
+	cases := []struct {
+		inputs      bool
+		outputs     bool
+		expected    []string
+		notExpected []string
+	}{
+		{true, false, []string{"INPUT", "INPUT_FILE", "image/x-hdf5-image", "DB_HOST", "setting"}, []string{"OUTPUT"}},
+		{false, true, []string{"OUTPUT", "output_file_tiffs", "outfile*.tif", "key cellCount"}, []string{"INPUT"}},
+		{true, true, []string{"INPUT_FILE", "cell_count"}, nil},
+	}
+
+	for _, c := range cases {
+		table := InterfaceTable(&seed, c.inputs, c.outputs)
+		for _, e := range c.expected {
+			if !strings.Contains(table, e) {
+				t.Errorf("InterfaceTable(%v, %v) missing %q:\n%s", c.inputs, c.outputs, e, table)
+			}
+		}
+		for _, e := range c.notExpected {
+			if strings.Contains(table, e) {
+				t.Errorf("InterfaceTable(%v, %v) unexpectedly contains %q:\n%s", c.inputs, c.outputs, e, table)
+			}
+		}
+	}
+}
+
+func TestLoadSchemaVersion(t *testing.T) {
+	cases := []struct {
+		version       string
+		schemaType    constants.SchemaType
+		expectedError string
+	}{
+		{constants.DefaultSchemaVersion, constants.SchemaManifest, ""},
+		{constants.DefaultSchemaVersion, constants.SchemaMetadata, ""},
+		{"9.9.9", constants.SchemaManifest, "Seed schema version 9.9.9 is not built in. Bundled versions are " +
+			constants.DefaultSchemaVersion + ". Use -schema"},
+		{"", constants.SchemaManifest, "is not built in"},
+	}
+
+	for _, c := range cases {
+		schema, err := LoadSchemaVersion(c.version, c.schemaType)
+		if c.expectedError == "" && (err != nil || schema == nil) {
+			t.Errorf("LoadSchemaVersion(%q, %v) returned error %v, expected a schema", c.version, c.schemaType, err)
+		}
+		if c.expectedError != "" && (err == nil || !strings.Contains(err.Error(), c.expectedError)) {
+			t.Errorf("LoadSchemaVersion(%q, %v) returned error %v, expected %q", c.version, c.schemaType, err,
+				c.expectedError)
+		}
+	}
+
+	err := Validate([]string{"../testdata/complete/"}, ValidateOptions{SchemaFile: "schema.json",
+		SchemaVersion: constants.DefaultSchemaVersion})
+	if err == nil || !strings.Contains(err.Error(), "cannot be used together") {
+		t.Errorf("Validate with -schema and -schema-version returned %v, expected an error", err)
+	}
+}
+
+func TestSchemaCache(t *testing.T) {
+	first, err := LoadSchemaVersion(constants.DefaultSchemaVersion, constants.SchemaManifest)
+	if err != nil {
+		t.Fatalf("LoadSchemaVersion returned error %v", err)
+	}
+	metadata, _ := LoadSchemaVersion(constants.DefaultSchemaVersion, constants.SchemaMetadata)
+	if metadata == first {
+		t.Errorf("LoadSchemaVersion returned the manifest schema for the metadata schema")
+	}
+
+	schemas := make(chan *gojsonschema.Schema, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(schemas); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			schema, _ := LoadSchemaVersion(constants.DefaultSchemaVersion, constants.SchemaManifest)
+			schemas <- schema
+		}()
+	}
+	wg.Wait()
+	close(schemas)
+	for schema := range schemas {
+		if schema != first {
+			t.Errorf("LoadSchemaVersion compiled the schema again, expected the cached schema")
+		}
+	}
+
+	// A modified schema file replaces its cached schema
+	dir, err := ioutil.TempDir("", "seed-schema-cache")
+	if err != nil {
+		t.Fatalf("Error creating temp dir for schema cache test: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "schema.json")
+	schemaFile := "file://" + path
+	ioutil.WriteFile(path, []byte(`{"type": "object"}`), 0644)
+	original, err := LoadSchema(schemaFile, constants.SchemaManifest)
+	if err != nil {
+		t.Fatalf("LoadSchema(%q) returned error %v", schemaFile, err)
+	}
+	if cached, _ := LoadSchema(schemaFile, constants.SchemaManifest); cached != original {
+		t.Errorf("LoadSchema(%q) compiled an unmodified schema file again, expected the cached schema", schemaFile)
+	}
+	ioutil.WriteFile(path, []byte(`{"type": "array"}`), 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	modified, err := LoadSchema(schemaFile, constants.SchemaManifest)
+	if err != nil || modified == original {
+		t.Errorf("LoadSchema(%q) of a modified schema file returned the cached schema, %v", schemaFile, err)
+	}
+	entries := 0
+	schemaCache.Lock()
+	for key := range schemaCache.schemas {
+		if strings.Contains(key, schemaFile) {
+			entries++
+		}
+	}
+	schemaCache.Unlock()
+	if entries != 1 {
+		t.Errorf("The schema cache holds %d schemas of %s, expected 1", entries, schemaFile)
+	}
+}
+
+func TestBundledSchemaVersions(t *testing.T) {
+	if versions := BundledSchemaVersions(); len(versions) == 0 || versions[0] != "0.1.0" {
+		t.Errorf("BundledSchemaVersions() == %v, expected [0.1.0 ...]", versions)
+	}
+	if compareVersions("0.10.0", "0.9.1") <= 0 || compareVersions("1.0", "1.0.0") != 0 {
+		t.Errorf("compareVersions does not order versions numerically")
+	}
+}
+
+func TestFixManifest(t *testing.T) {
+	schema, err := constants.Asset("schema/0.1.0/seed.manifest.schema.json")
+	if err != nil {
+		t.Fatalf("Error reading schema for FixManifest test: %v", err)
+	}
+
+	cases := []struct {
+		manifest      string
+		expected      string
+		expectedFixes string
+	}{
+		{`{"seedVersion": "0.1.0", "job": {"name": "my-job"}}`,
+			`{"seedVersion":"0.1.0","job":{"name":"my-job"}}`, ""},
+		{`{"job": {"name": "my-job", "JobVersion": "1.0.0"}}`,
+			`{"seedVersion":"0.1.0","job":{"name":"my-job","jobVersion":"1.0.0"}}`,
+			"renamed job.JobVersion to jobVersion; set missing seedVersion to 0.1.0"},
+		{`{"SeedVersion": "0.1.0", "job": {}}`, `{"seedVersion":"0.1.0","job":{}}`,
+			"renamed SeedVersion to seedVersion"},
+		{`{"seedVersion": "0.1.0", "job": {"name": "my-job", "dockerImage": "x",},}`,
+			`{"seedVersion":"0.1.0","job":{"name":"my-job"}}`,
+			"removed 2 trailing commas; removed unknown field job.dockerImage"},
+		{`{"seedVersion": "0.1.0", "job": {"interface": {"inputs": {"files": [{"name": "IN", "mediatypes": []}]}}}}`,
+			`{"seedVersion":"0.1.0","job":{"interface":{"inputs":{"files":[{"name":"IN","mediaTypes":[]}]}}}}`,
+			"renamed job.interface.inputs.files[0].mediatypes to mediaTypes"},
+		{`{"seedVersion": "0.1.0", "job": {"name": "a,}", "Name": "b", "timeout": 1.50}}`,
+			`{"seedVersion":"0.1.0","job":{"name":"a,}","Name":"b","timeout":1.50}}`, ""},
+	}
+
+	for _, c := range cases {
+		fixed, fixes, err := FixManifest([]byte(c.manifest), schema, "0.1.0")
+		if err != nil {
+			t.Errorf("FixManifest(%q) returned an error: %v", c.manifest, err)
+			continue
+		}
+		var compact bytes.Buffer
+		json.Compact(&compact, fixed)
+		if compact.String() != c.expected || strings.Join(fixes, "; ") != c.expectedFixes {
+			t.Errorf("FixManifest(%q) == %s, %q, expected %s, %q", c.manifest, compact.String(),
+				strings.Join(fixes, "; "), c.expected, c.expectedFixes)
+		}
+	}
+
+	if _, _, err := FixManifest([]byte(`{"job": }`), schema, "0.1.0"); err == nil ||
+		!strings.Contains(err.Error(), "not valid json") {
+		t.Errorf("FixManifest of invalid json returned %v, expected an error", err)
+	}
+}
@@ -6,27 +6,86 @@ const TrueString = "true"
 // Subcommands supported by CLI
 const BatchCommand = "batch"
 const BuildCommand = "build"
+const CompletionCommand = "completion"
+const DiffCommand = "diff"
+const DoctorCommand = "doctor"
 const InitCommand = "init"
 const ListCommand = "list"
+const LoginCommand = "login"
+const LogoutCommand = "logout"
+const ManifestCommand = "manifest"
+const PipelineCommand = "pipeline"
+const PruneCommand = "prune"
 const PublishCommand = "publish"
 const PullCommand = "pull"
 const RunCommand = "run"
+const ScanCommand = "scan"
 const SearchCommand = "search"
 const ValidateCommand = "validate"
 const VersionCommand = "version"
 
+// Subcommands supported by the manifest command
+const ManifestStatsCommand = "stats"
+
+//ManifestExtractCommand subcommand name constant
+const ManifestExtractCommand = "extract"
+
+// Shells supported by the completion command
+const ShellBash = "bash"
+const ShellZsh = "zsh"
+const ShellFish = "fish"
+
+//CompletionShells lists the shells supported by the completion command
+var CompletionShells = []string{ShellBash, ShellZsh, ShellFish}
+
 //JobDirectoryFlag defines the location of the seed spec and Dockerfile
 const JobDirectoryFlag = "directory"
 
 //ShortJobDirectoryFlag defines the shorthand location of the seed spec and Dockerfile
 const ShortJobDirectoryFlag = "d"
 
+//JobNameFlag defines the job name used by seed init
+const JobNameFlag = "name"
+
+//JobVersionFlag defines the job version used by seed init
+const JobVersionFlag = "job-version"
+
+//MaintainerFlag defines the job maintainer used by seed init
+const MaintainerFlag = "maintainer"
+
+//FromImageFlag defines the image seed init generates the manifest from
+const FromImageFlag = "from-image"
+
+//TemplateFlag defines the built in manifest template seed init starts from
+const TemplateFlag = "template"
+
+//ListTemplatesFlag defines whether seed init lists its built in manifest templates
+const ListTemplatesFlag = "list-templates"
+
 //SettingFlag defines the SettingFlag
 const SettingFlag = "setting"
 
 //ShortSettingFlag defines the shorthand SettingFlag
 const ShortSettingFlag = "e"
 
+//SettingsFileFlag defines a file of settings for seed run
+const SettingsFileFlag = "settings-file"
+
+//UseDefaultsFlag defines whether seed run uses the image ENV values of settings that are not given
+const UseDefaultsFlag = "use-defaults"
+
+//EnvFileFlag defines a file of environment variables for the seed run container
+const EnvFileFlag = "env-file"
+
+//MaxOutputSizeFlag defines the maximum size in MiB of the seed run output directory
+const MaxOutputSizeFlag = "max-output-size"
+
+//OutputTooLargeExitCode defines the exit code of seed run when the output directory exceeds -max-output-size
+const OutputTooLargeExitCode = 3
+
+//SaveInputManifestFlag defines the flag to record the inputs bound for seed run in the output directory
+const SaveInputManifestFlag = "save-input-manifest"
+
 //MountFlag defines the MountFlag
 const MountFlag = "mount"
 
@@ -39,6 +98,19 @@ const InputsFlag = "inputs"
 //ShortInputsFlag defines the shorthand input flag
 const ShortInputsFlag = "i"
 
+//StrictInputsFlag defines whether seed run fails when an input file is not of a declared media type
+const StrictInputsFlag = "strict-inputs"
+
+//InputURLFlag defines an input of seed run downloaded from a URL, as NAME=URL
+const InputURLFlag = "input-url"
+
+
+//InputDirFlag defines the directory seed run matches files to the manifest inputs from
+const InputDirFlag = "input-dir"
+
+//DirectoryInputFlag defines an input seed run mounts a directory for rather than a file
+const DirectoryInputFlag = "directory-input"
+
 //JobOutputDirFlag defines the job output directory
 const JobOutputDirFlag = "outDir"
 
@@ -51,9 +123,18 @@ const ShortImgNameFlag = "in"
 //ImgNameFlag defines image name to run
 const ImgNameFlag = "imageName"
 
+//OutputDirKey defines the name of the job output directory in seed commands and pipeline references
+const OutputDirKey = "OUTPUT_DIR"
+
 //RmFlag defines if the docker image should be removed after docker run is executed
 const RmFlag = "rm"
 
+//RestartOnFailureFlag defines how many times seed run restarts a container that exits non-zero
+const RestartOnFailureFlag = "restart-on-failure"
+
+//KeepFailedFlag defines whether seed run keeps a container that exits non-zero, overriding -rm
+const KeepFailedFlag = "keep-failed"
+
 //QuietFlag defines if output from the docker image being run should be suppressed
 const QuietFlag = "quiet"
 
@@ -78,12 +159,33 @@ const OrgFlag = "org"
 //ShortOrgFlag shorthand flag that defines organization
 const ShortOrgFlag = "o"
 
+//SortFlag defines the order of the results of seed search
+const SortFlag = "sort"
+
+//ReverseFlag defines whether seed search reverses the sort order
+const ReverseFlag = "reverse"
+
+//SortName sorts seed search results by repository name
+const SortName = "name"
+
+//SortUpdated sorts seed search results by when the image was last updated, most recent first
+const SortUpdated = "updated"
+
+//SortTag sorts seed search results by tag version
+const SortTag = "tag"
+
+//SeedOnlyFlag defines whether seed search only lists images labeled with a valid seed manifest
+const SeedOnlyFlag = "seed-only"
+
 //FilterFlag defines filter
 const FilterFlag = "filter"
 
 //ShortFilterFlag shorthand flag that defines filter
 const ShortFilterFlag = "f"
 
+//NoColorFlag defines whether seed list and seed search print their tables without color
+const NoColorFlag = "no-color"
+
 //UserFlag defines user
 const UserFlag = "user"
 
@@ -99,6 +201,15 @@ const ShortPassFlag = "p"
 //ForcePublishFlag forces a publish - don't try to deconflict
 const ForcePublishFlag = "f"
 
+//DryRunFlag defines the flag to print what seed publish or seed prune would do without doing it
+const DryRunFlag = "dry-run"
+
+//KeepFlag defines the number of versions of each job kept by seed prune
+const KeepFlag = "keep"
+
+//DefaultPruneKeep defines the number of versions of each job kept by seed prune by default
+const DefaultPruneKeep = 3
+
 //PkgVersionMinor specifies to bump package minor version
 const PkgVersionMinor = "pm"
 
@@ -129,12 +240,273 @@ const RepeatFlag = "repetitions"
 //ShortRepeatFlag - shorthand flag for repetitions
 const ShortRepeatFlag = "rep"
 
+//NoCacheFlag defines whether to disable the docker layer cache when building
+const NoCacheFlag = "no-cache"
+
+//PullFlag defines whether to always attempt to pull newer base images when building
+const PullFlag = "pull"
+
+//CompressFlag defines whether to gzip the build context sent to the docker daemon
+const CompressFlag = "compress"
+
+//ContextLimitFlag defines the maximum build context size in MiB
+const ContextLimitFlag = "context-limit"
+
+//ProgressFlag defines how seed build and seed pull display docker progress
+const ProgressFlag = "progress"
+
+//ProgressPlain copies the docker progress output as is
+const ProgressPlain = "plain"
+
+//ProgressBar consolidates the docker progress output into a progress bar
+const ProgressBar = "bar"
+
+//SecretFlag defines a BuildKit build secret of seed build and the seed publish rebuild, passed to
+// docker build --secret
+const SecretFlag = "secret"
+
+//DockerfileFlag defines the Dockerfile seed build, and the seed publish rebuild, builds with
+const DockerfileFlag = "file"
+
+//ShortDockerfileFlag - shorthand flag for file
+const ShortDockerfileFlag = "f"
+
+//TargetFlag defines the stage of a multi-stage Dockerfile seed build builds up to
+const TargetFlag = "target"
+
+//ManifestFlag defines the seed manifest file seed build uses instead of the one in the job directory
+const ManifestFlag = "manifest"
+
+//RetagOnlyFlag defines whether seed publish pushes the local image without rebuilding it
+const RetagOnlyFlag = "retag-only"
+
+//CacheFromFlag defines the images seed build may reuse layers from
+const CacheFromFlag = "cache-from"
+
+//PullCacheFlag defines whether seed build pulls the cache-from images before building
+const PullCacheFlag = "pull-cache"
+
+//SquashFlag defines whether seed build squashes the layers of the image
+const SquashFlag = "squash"
+
+//PlatformFlag defines the target platform of a seed build, i.e. linux/amd64
+const PlatformFlag = "platform"
+
+//TagFlag defines an extra tag, or image reference, applied to the image by seed build, or the
+// tag seed publish pushes the image with
+const TagFlag = "tag"
+
+//ShortTagFlag - shorthand flag for tag
+const ShortTagFlag = "t"
+
+//PlatformLabel defines the image label recording the platform an image was built for
+const PlatformLabel = "com.ngageoint.seed.platform"
+
+//ContextWarnSizeMiB defines the build context size in MiB above which seed build prints a warning
+const ContextWarnSizeMiB = 100
+
+//VerifyFlag defines whether to verify the pushed image against the registry after publishing
+const VerifyFlag = "verify-after-push"
+
+//VerifySignatureFlag defines whether seed pull verifies the signature of the pulled image
+const VerifySignatureFlag = "verify"
+
+//PublicKeyFlag defines the public key seed pull verifies image signatures against
+const PublicKeyFlag = "public-key"
+
+//RetriesFlag defines the number of times seed pull retries a failed pull
+const RetriesFlag = "retries"
+
+//DefaultPullJobs defines the number of images seed pull pulls concurrently by default
+const DefaultPullJobs = 4
+
+//DefaultPullRetries defines the number of times seed pull retries a failed pull by default
+const DefaultPullRetries = 3
+
+//SignFlag defines whether seed publish signs the pushed image
+const SignFlag = "sign"
+
+//PrivateKeyFlag defines the private key seed publish signs images with
+const PrivateKeyFlag = "private-key"
+
+//RequireSignatureFlag defines whether seed pull fails for images without a signature
+const RequireSignatureFlag = "require-signature"
+
+//ToFlag defines the full image reference seed publish pushes the image to, in place of the
+// registry, org and tag flags
+const ToFlag = "to"
+
+//DigestFileFlag defines the file to write the digest of a published image to
+const DigestFileFlag = "digest-file"
+
+//LogLevelFlag defines the minimum level of log messages to print
+const LogLevelFlag = "log-level"
+
+//ManifestLabel defines the image label containing the seed manifest
+const ManifestLabel = "com.ngageoint.seed.manifest"
+
+//JobsFlag defines the number of concurrent workers to use
+const JobsFlag = "jobs"
+
+//ShortJobsFlag - shorthand flag for jobs
+const ShortJobsFlag = "j"
+
+//MaxWarningsFlag defines the number of validation warnings allowed before failing
+const MaxWarningsFlag = "max-warnings"
+
+//FailOnWarningFlag defines whether to fail validation if any warnings are found
+const FailOnWarningFlag = "fail-on-warning"
+
+//ListInputsFlag defines whether seed validate prints the inputs, settings and mounts of valid manifests
+const ListInputsFlag = "list-inputs"
+
+//ListOutputsFlag defines whether seed validate prints the outputs of valid manifests
+const ListOutputsFlag = "list-outputs"
+
+//OutputTimestampFlag defines whether seed run writes each run's outputs to a timestamped
+// subdirectory of the output directory
+const OutputTimestampFlag = "output-timestamp"
+
+//MountReadOnlyFlag defines whether input files are mounted read-only
+const MountReadOnlyFlag = "mount-ro"
+
+//ReadonlyRootfsFlag defines whether seed run uses a read-only root filesystem for the container
+const ReadonlyRootfsFlag = "readonly-rootfs"
+
+//JsonLogsFlag defines whether to print container output as newline delimited JSON log entries
+const JsonLogsFlag = "json-logs"
+
+//CaptureLogsFlag defines whether to write container stdout/stderr to files in the output directory
+const CaptureLogsFlag = "capture-logs"
+
+//EntrypointLogFlag is another name for CaptureLogsFlag
+const EntrypointLogFlag = "entrypoint-log"
+
+//OutputJsonFlag defines whether seed run prints the values of the manifest's output JSON to stdout
+const OutputJsonFlag = "output-json"
+
+//ResultJsonFlag defines whether seed run prints a summary of the run and its output files to stdout
+const ResultJsonFlag = "result-json"
+
+//OutputUploadFlag defines the s3, http or https URL seed run uploads the output directory to
+const OutputUploadFlag = "output-upload"
+
+//UploadOnFailureFlag defines whether seed run uploads the outputs of a failed run
+const UploadOnFailureFlag = "upload-on-failure"
+
+//GpusFlag defines the GPUs exposed to the container, passed to docker run --gpus
+const GpusFlag = "gpus"
+
+//CpusFlag defines the hard limit on the CPUs the container may use, passed to docker run --cpus
+const CpusFlag = "cpus"
+
+//HostUserFlag defines whether seed run runs the container as the uid and gid of the host user
+const HostUserFlag = "host-user"
+
+//PublishPortFlag defines a HOST:CONTAINER port mapping of the container, passed to docker run -p
+const PublishPortFlag = "publish"
+
+//SecurityOptFlag defines a security option of the container, passed to docker run --security-opt
+const SecurityOptFlag = "security-opt"
+
+//TmpfsFlag defines an in-memory scratch mount of the container, passed to docker run --tmpfs
+const TmpfsFlag = "tmpfs"
+
+//AddHostFlag defines a NAME:IP entry of /etc/hosts of the container, passed to docker run --add-host
+const AddHostFlag = "add-host"
+
+//HostGateway is the docker --add-host address that resolves to the docker host
+const HostGateway = "host-gateway"
+
+//NetworkFlag defines the docker network the container is connected to, passed to docker run --network
+const NetworkFlag = "network"
+
+//NetworkBridge is the default docker bridge network
+const NetworkBridge = "bridge"
+
+//NetworkHost shares the network stack of the host with the container
+const NetworkHost = "host"
+
+//NetworkNone runs the container without networking
+const NetworkNone = "none"
+
+//PidFlag defines the PID namespace of the container, passed to docker run --pid
+const PidFlag = "pid"
+
+//IpcFlag defines the IPC namespace of the container, passed to docker run --ipc
+const IpcFlag = "ipc"
+
+//NamespaceContainerPrefix prefixes the name or id of a container whose namespace is shared, i.e.
+// container:profiler
+const NamespaceContainerPrefix = "container:"
+
+//WorkDirFlag defines the working directory of the container, passed to docker run -w
+const WorkDirFlag = "workdir"
+
+//GpuResource defines the name of the scalar resource declaring the number of GPUs a job needs
+const GpuResource = "gpus"
+
+//InputsRelativeToFlag defines how relative input paths are resolved
+const InputsRelativeToFlag = "inputs-relative-to"
+
+//RelativeToCwd resolves relative input paths against the current directory
+const RelativeToCwd = "cwd"
+
+//RelativeToManifest resolves relative input paths against the seed manifest directory
+const RelativeToManifest = "manifest"
+
+//OutputFlag defines the output format
+const OutputFlag = "output"
+
+//ShortOutputFlag - shorthand flag for output
+const ShortOutputFlag = "o"
+
+//OutputFileFlag defines the file seed manifest extract writes the manifest to
+const OutputFileFlag = "output-file"
+
+//ForceFlag defines whether an existing file is overwritten, or seed build builds an image that
+// has not changed since the last build
+const ForceFlag = "force"
+
+//JsonFlag defines whether a command prints json, the same as -output json
+const JsonFlag = "json"
+
+//OutputText prints human readable output
+const OutputText = "text"
+
+//OutputJson prints output as json
+const OutputJson = "json"
+
+//OutputJunit prints a JUnit XML report, i.e. of the manifests seed validate checked
+const OutputJunit = "junit"
+
+//ReportFileFlag defines the file seed validate writes its JUnit report to
+const ReportFileFlag = "report-file"
+
 //SeedFileName defines the filename for the seed file
 const SeedFileName = "seed.manifest.json"
 
 //ResultsFileManifestName defines the filename for the results_manifest file
 const ResultsFileManifestName = "seed.outputs.json"
 
+//BuildCacheDir defines the directory of a job seed build records the last successful build in
+const BuildCacheDir = ".seed"
+
+//BuildHashFileName defines the file in BuildCacheDir holding the hash of the last successful build
+const BuildHashFileName = "build.hash"
+
+//RunResultsFileName defines the filename of the run results written by seed to the output directory
+const RunResultsFileName = "seed.run.json"
+
+//InputsResolvedFileName defines the filename of the input bindings written by seed run -save-input-manifest
+const InputsResolvedFileName = "inputs.resolved.json"
+
+//StdoutLogFileName defines the filename the container stdout is captured to with -capture-logs
+const StdoutLogFileName = "stdout.log"
+
+//StderrLogFileName defines the filename the container stderr is captured to with -capture-logs
+const StderrLogFileName = "stderr.log"
+
 //DefaultRegistry defines the default registry address to use when searching for images
 const DefaultRegistry = "https://hub.docker.com/"
 
@@ -158,4 +530,80 @@ const (
 //each other
 const DockerConfigDir = "docker-config-"
 
-const DockerConfigKey = "DOCKER_CONFIG"
\ No newline at end of file
+const DockerConfigKey = "DOCKER_CONFIG"
+
+//RegistryUserEnv defines the environment variable holding the registry username used when
+// none is given with -u
+const RegistryUserEnv = "SEED_REGISTRY_USER"
+
+//RegistryPasswordEnv defines the environment variable holding the registry password used when
+// none is given with -p
+const RegistryPasswordEnv = "SEED_REGISTRY_PASSWORD"
+
+//UploadUserEnv and UploadPasswordEnv define the environment variables holding the basic auth
+// credentials seed run uploads outputs to an http or https -output-upload destination with
+const UploadUserEnv = "SEED_UPLOAD_USER"
+const UploadPasswordEnv = "SEED_UPLOAD_PASSWORD"
+
+//UploadTokenEnv defines the environment variable holding a bearer token seed run uploads outputs
+// to an http or https -output-upload destination with, in place of basic auth
+const UploadTokenEnv = "SEED_UPLOAD_TOKEN"
+
+//InputUserEnv and InputPasswordEnv define the environment variables holding the basic auth
+// credentials seed run downloads -input-url inputs without credentials in the URL with
+const InputUserEnv = "SEED_INPUT_USER"
+const InputPasswordEnv = "SEED_INPUT_PASSWORD"
+
+//InputTokenEnv defines the environment variable holding a bearer token seed run downloads
+// -input-url inputs with, in place of basic auth
+const InputTokenEnv = "SEED_INPUT_TOKEN"
+
+//The environment variables seed run reads the AWS credentials, region and S3 endpoint for an
+// s3:// -output-upload destination from, as the AWS CLI does
+const (
+	AWSAccessKeyEnv     = "AWS_ACCESS_KEY_ID"
+	AWSSecretKeyEnv     = "AWS_SECRET_ACCESS_KEY"
+	AWSSessionTokenEnv  = "AWS_SESSION_TOKEN"
+	AWSRegionEnv        = "AWS_REGION"
+	AWSDefaultRegionEnv = "AWS_DEFAULT_REGION"
+	AWSEndpointEnv      = "AWS_ENDPOINT_URL"
+)
+
+//SchemaDir defines the directory of the built in schemas, holding a directory per seed spec version
+const SchemaDir = "schema"
+
+//DefaultSchemaVersion defines the seed spec version of the built in schema used by default
+const DefaultSchemaVersion = "0.1.0"
+
+//ManifestSchemaName defines the file name of the seed manifest schema
+const ManifestSchemaName = "seed.manifest.schema.json"
+
+//MetadataSchemaName defines the file name of the seed metadata schema
+const MetadataSchemaName = "seed.metadata.schema.json"
+
+//FixFlag defines whether seed validate applies safe automatic corrections to manifests
+const FixFlag = "fix"
+
+//FromStdinFlag defines whether seed validate reads the manifest to validate from stdin
+const FromStdinFlag = "from-stdin"
+
+//CheckImageFlag defines the built image seed validate cross-checks the manifest against
+const CheckImageFlag = "check-image"
+
+//SchemaVersionFlag defines the seed spec version whose built in schema seed validate uses
+const SchemaVersionFlag = "schema-version"
+
+//SeedDir defines the directory under the user's home directory where seed stores its settings
+const SeedDir = ".seed"
+
+//ConfigFileName defines the name of the file in SeedDir holding default flag values
+const ConfigFileName = "config.yaml"
+
+//MachineSummaryFlag defines the global flag printing a SEED_RESULT line when a command ends
+const MachineSummaryFlag = "machine-summary"
+
+//JsonErrorsFlag defines the global flag printing the error of a failed command as JSON
+const JsonErrorsFlag = "json-errors"
+
+//ConfigFlag defines the global flag giving the config file of default flag values
+const ConfigFlag = "config"
\ No newline at end of file
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/ngageoint/seed-cli/constants"
@@ -69,6 +68,9 @@ type InFile struct {
 	MediaTypes []string `json:"mediaTypes"`
 	Multiple   bool     `json:"multiple"`
 	Required   bool     `json:"required"`
+	// Directory is set by seed run for inputs given a directory with -directory-input. It is not
+	// part of the manifest.
+	Directory bool `json:"-"`
 }
 
 func (o *InFile) UnmarshalJSON(b []byte) error {
@@ -185,6 +187,27 @@ func (o *ErrorMap) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+//ErrorMapping indexes the errors declared in a seed manifest by exit code
+type ErrorMapping map[int]ErrorMap
+
+//NewErrorMapping returns the mapping of the given manifest errors. If a code is declared
+// more than once the first declaration is used.
+func NewErrorMapping(errs []ErrorMap) ErrorMapping {
+	mapping := ErrorMapping{}
+	for _, e := range errs {
+		if _, ok := mapping[e.Code]; !ok {
+			mapping[e.Code] = e
+		}
+	}
+	return mapping
+}
+
+//Lookup returns the error declared for an exit code and whether one was declared
+func (m ErrorMapping) Lookup(code int) (ErrorMap, bool) {
+	e, ok := m[code]
+	return e, ok
+}
+
 //GetManifestLabel returns the seed.manifest.json as LABEL
 //  com.ngageoint.seed.manifest contents
 func GetManifestLabel(seedFileName string) string {
@@ -211,94 +234,76 @@ func GetManifestLabel(seedFileName string) string {
 	return seed
 }
 
-//SeedFromImageLabel returns seed parsed from the docker image LABEL
-func SeedFromImageLabel(imageName string) Seed {
-	cmdStr := "inspect -f '{{index .Config.Labels \"com.ngageoint.seed.manifest\"}}'" + imageName
-	util.PrintUtil(
-		"INFO: Retrieving seed manifest from %s LABEL=com.ngageoint.seed.manifest\n",
+//SeedFromImageLabel returns seed parsed from the docker image LABEL. Returns an error if the
+// image cannot be inspected or its label is not a seed manifest.
+func SeedFromImageLabel(imageName string) (Seed, error) {
+	util.Infof("Retrieving seed manifest from %s LABEL=com.ngageoint.seed.manifest\n",
 		imageName)
+	args := []string{"inspect", "-f", "'{{index .Config.Labels \"com.ngageoint.seed.manifest\"}}'", imageName}
+	util.DebugCommand("docker", args)
 
-	inspectCommand := exec.Command("docker", "inspect", "-f",
-		"'{{index .Config.Labels \"com.ngageoint.seed.manifest\"}}'", imageName)
-
-	errPipe, errr := inspectCommand.StderrPipe()
-	if errr != nil {
-		util.PrintUtil(
-			"ERROR: error attaching to docker inspect command stderr. %s\n",
-			errr.Error())
-	}
-
-	// Attach stdout pipe
-	outPipe, errr := inspectCommand.StdoutPipe()
-	if errr != nil {
-		util.PrintUtil(
-			"ERROR: error attaching to docker inspect command stdout. %s\n",
-			errr.Error())
-	}
+	var stdout, stderr bytes.Buffer
+	inspectCommand := util.DockerCommand{Args: args, Stdout: &stdout, Stderr: &stderr}
 
 	// Run docker inspect
-	if err := inspectCommand.Start(); err != nil {
-		util.PrintUtil( "ERROR: error executing docker %s. %s\n", cmdStr,
-			err.Error())
+	if err := util.RunDocker(inspectCommand); err != nil {
+		return Seed{}, fmt.Errorf("Error executing docker %s. %s %s", strings.Join(args, " "), err.Error(),
+			strings.TrimSpace(stderr.String()))
 	}
 
-	// Print out any std out
-	seedBytes, err := ioutil.ReadAll(outPipe)
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "ERROR: Error retrieving docker %s stdout.\n%s\n",
-			cmdStr, err.Error())
+	// check for errors on stderr
+	if stderr.Len() > 0 {
+		return Seed{}, fmt.Errorf("Error executing docker %s:\n%s", strings.Join(args, " "), stderr.String())
 	}
 
-	// check for errors on stderr
-	slurperr, _ := ioutil.ReadAll(errPipe)
-	if string(slurperr) != "" {
-		util.PrintUtil( "ERROR: Error executing docker %s:\n%s\n",
-			cmdStr, string(slurperr))
-		util.PrintUtil( "Exiting seed...\n")
-		os.Exit(1)
+	return ParseManifestLabel(stdout.String())
+}
+
+//ParseManifestLabel returns seed parsed from the contents of a com.ngageoint.seed.manifest
+// LABEL, or an error if the label is not a seed manifest
+func ParseManifestLabel(label string) (Seed, error) {
+	var seed Seed
+	if err := json.Unmarshal([]byte(UnescapeManifestLabel(label)), &seed); err != nil {
+		return Seed{}, fmt.Errorf("Error unmarshalling seed: %s", err.Error())
 	}
+	return seed, nil
+}
 
+//UnescapeManifestLabel returns the seed manifest json stored in the manifest label of an image
+func UnescapeManifestLabel(label string) string {
 	// un-escape special characters
-	seedStr := string(seedBytes)
+	seedStr := label
 	seedStr = strings.Replace(seedStr, "\\\"", "\"", -1)
 	seedStr = strings.Replace(seedStr, "\\\"", "\"", -1) //extra replace to fix extra back slashes added by docker build command
 	seedStr = strings.Replace(seedStr, "\\$", "$", -1)
 	seedStr = strings.Replace(seedStr, "\\/", "/", -1)
 	seedStr = strings.TrimSpace(seedStr)
-	seedStr = strings.TrimSuffix(strings.TrimPrefix(seedStr, "'\""), "\"'")
-
-	seed := &Seed{}
-
-	err = json.Unmarshal([]byte(seedStr), &seed)
-	if err != nil {
-		util.PrintUtil( "ERROR: Error unmarshalling seed: %s\n", err.Error())
-	}
-
-	return *seed
+	seedStr = strings.TrimSuffix(strings.TrimPrefix(seedStr, "'"), "'")
+	seedStr = strings.TrimSuffix(strings.TrimPrefix(seedStr, "\""), "\"")
+	return seedStr
 }
 
-//SeedFromManifestFile returns seed struct parsed from seed file
-func SeedFromManifestFile(seedFileName string) Seed {
+//SeedFromManifestFile returns seed struct parsed from seed file, or an error if the file
+// cannot be opened or parsed
+func SeedFromManifestFile(seedFileName string) (Seed, error) {
+	var seed Seed
 
 	// Open and parse seed file into struct
 	seedFile, err := os.Open(seedFileName)
 	if err != nil {
-		util.PrintUtil( "ERROR: Error opening %s. Error received is: %s\n",
+		return seed, fmt.Errorf("Error opening %s. Error received is: %s",
 			seedFileName, err.Error())
-		util.PrintUtil( "Exiting seed...\n")
-		os.Exit(1)
 	}
+	defer seedFile.Close()
+
 	jsonParser := json.NewDecoder(seedFile)
-	var seed Seed
 	if err = jsonParser.Decode(&seed); err != nil {
-		util.PrintUtil(
-			"ERROR: A valid %s must be present in the working directory. Error parsing %s.\nError received is: %s\n",
+		return seed, fmt.Errorf(
+			"A valid %s must be present in the working directory. Error parsing %s.\nError received is: %s",
 			constants.SeedFileName, seedFileName, err.Error())
-		util.PrintUtil( "Exiting seed...\n")
-		os.Exit(1)
 	}
 
-	return seed
+	return seed, nil
 }
 
 //BuildImageName extracts the Docker Image name from the seed.json
@@ -0,0 +1,89 @@
+package objects
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+//Pipeline defines a sequence of seed images run in order, where later stages may take the
+// outputs of earlier stages as inputs
+type Pipeline struct {
+	// OutputDir is the directory the stage output directories are created in
+	OutputDir string          `json:"outputDir,omitempty"`
+	Stages    []PipelineStage `json:"stages"`
+}
+
+//PipelineStage defines a single seed image run in a pipeline. Input, setting and mount values
+// may reference an earlier stage as ${STAGE.OUTPUT_DIR} for its output directory or
+// ${STAGE.OUTPUT_NAME} for the file matching one of its declared output files.
+type PipelineStage struct {
+	Name     string            `json:"name"`
+	Image    string            `json:"image"`
+	Inputs   map[string]string `json:"inputs,omitempty"`
+	Settings map[string]string `json:"settings,omitempty"`
+	Mounts   map[string]string `json:"mounts,omitempty"`
+}
+
+//PipelineStageNameRegex matches the names allowed for pipeline stages
+var PipelineStageNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+//PipelineReferenceRegex matches a reference to the output of an earlier stage, capturing the
+// stage name and the output name
+var PipelineReferenceRegex = regexp.MustCompile(`\$\{([a-zA-Z0-9_-]+)\.([a-zA-Z0-9_-]+)\}`)
+
+//ReadPipeline returns the pipeline parsed from the given file, or an error if the file cannot
+// be parsed or the pipeline is not valid
+func ReadPipeline(fileName string) (Pipeline, error) {
+	var pipeline Pipeline
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return pipeline, fmt.Errorf("Error opening %s. Error received is: %s", fileName, err.Error())
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&pipeline); err != nil {
+		return pipeline, fmt.Errorf("Error parsing pipeline %s.\nError received is: %s", fileName, err.Error())
+	}
+
+	return pipeline, pipeline.Validate()
+}
+
+//Validate checks that the pipeline has at least one stage, that stage names are valid and
+// unique, that every stage names an image and that references only point at earlier stages
+func (p *Pipeline) Validate() error {
+	if len(p.Stages) == 0 {
+		return fmt.Errorf("Pipeline has no stages")
+	}
+
+	seen := map[string]bool{}
+	for i, stage := range p.Stages {
+		if !PipelineStageNameRegex.MatchString(stage.Name) {
+			return fmt.Errorf("Stage %d has invalid name %q. Names may only contain letters, numbers, "+
+				"underscores and dashes", i+1, stage.Name)
+		}
+		if seen[stage.Name] {
+			return fmt.Errorf("Stage name %s is used more than once", stage.Name)
+		}
+		if stage.Image == "" {
+			return fmt.Errorf("Stage %s has no image", stage.Name)
+		}
+
+		for _, values := range []map[string]string{stage.Inputs, stage.Settings, stage.Mounts} {
+			for key, value := range values {
+				for _, ref := range PipelineReferenceRegex.FindAllStringSubmatch(value, -1) {
+					if !seen[ref[1]] {
+						return fmt.Errorf("Stage %s value %s=%s references %s, which is not an earlier stage",
+							stage.Name, key, value, ref[1])
+					}
+				}
+			}
+		}
+		seen[stage.Name] = true
+	}
+	return nil
+}
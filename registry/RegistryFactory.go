@@ -2,6 +2,7 @@ package registry
 
 import (
 	"strings"
+	"time"
 
 	"github.com/ngageoint/seed-cli/registry/containeryard"
 	"github.com/ngageoint/seed-cli/registry/dockerhub"
@@ -14,6 +15,9 @@ type RepositoryRegistry interface {
 	Repositories(org string) ([]string, error)
 	Tags(repository, org string) ([]string, error)
 	Images(org string) ([]string, error)
+	ImageDigest(repository, tag string) (string, error)
+	ImageManifest(repository, tag string) (string, error)
+	ImageCreated(repository, tag string) (time.Time, error)
 }
 
 type RepoRegistryFactory func(url, username, password string) (RepositoryRegistry, error)
@@ -1,9 +1,11 @@
 package containeryard
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/ngageoint/seed-cli/util"
 )
@@ -44,3 +46,18 @@ func (r *ContainerYardRegistry) Ping() error {
 	err := r.getContainerYardJson(url, &response)
 	return err
 }
+
+//ImageDigest is not supported by the container yard API
+func (r *ContainerYardRegistry) ImageDigest(repository, tag string) (string, error) {
+	return "", errors.New("Retrieving image digests is not supported for " + r.Name())
+}
+
+//ImageManifest is not supported by the container yard API
+func (r *ContainerYardRegistry) ImageManifest(repository, tag string) (string, error) {
+	return "", errors.New("Retrieving image manifests is not supported for " + r.Name())
+}
+
+//ImageCreated is not supported by the container yard API
+func (r *ContainerYardRegistry) ImageCreated(repository, tag string) (time.Time, error) {
+	return time.Time{}, errors.New("Retrieving image creation times is not supported for " + r.Name())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/ngageoint/seed-cli/constants"
 	"github.com/ngageoint/seed-cli/util"
@@ -50,3 +51,18 @@ func (r *DockerHubRegistry) Ping() error {
 	}
 	return err
 }
+
+//ImageDigest is not supported by the docker hub API
+func (r *DockerHubRegistry) ImageDigest(repository, tag string) (string, error) {
+	return "", errors.New("Retrieving image digests is not supported for " + r.Name())
+}
+
+//ImageManifest is not supported by the docker hub API
+func (r *DockerHubRegistry) ImageManifest(repository, tag string) (string, error) {
+	return "", errors.New("Retrieving image manifests is not supported for " + r.Name())
+}
+
+//ImageCreated is not supported by the docker hub API
+func (r *DockerHubRegistry) ImageCreated(repository, tag string) (time.Time, error) {
+	return time.Time{}, errors.New("Retrieving image creation times is not supported for " + r.Name())
+}
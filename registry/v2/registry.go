@@ -1,9 +1,15 @@
 package v2
 
 import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/heroku/docker-registry-client/registry"
+	"github.com/ngageoint/seed-cli/constants"
 	"github.com/ngageoint/seed-cli/util"
 )
 
@@ -59,3 +65,68 @@ func (r *v2registry) Images(org string) ([]string, error) {
 
 	return images, err
 }
+
+//ImageDigest returns the content digest of the schema 2 manifest for the given image
+func (r *v2registry) ImageDigest(repository, tag string) (string, error) {
+	manifest, err := r.r.ManifestV2(repository, tag)
+	if err != nil {
+		return "", err
+	}
+	_, payload, err := manifest.Payload()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(payload)), nil
+}
+
+//ImageManifest returns the seed manifest label stored in the configuration of the given image
+func (r *v2registry) ImageManifest(repository, tag string) (string, error) {
+	manifest, err := r.r.ManifestV2(repository, tag)
+	if err != nil {
+		return "", err
+	}
+	reader, err := r.r.DownloadLayer(repository, manifest.Config.Digest)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	var config struct {
+		Config struct {
+			Labels map[string]string
+		} `json:"config"`
+	}
+	if err := json.NewDecoder(reader).Decode(&config); err != nil {
+		return "", err
+	}
+
+	label, ok := config.Config.Labels[constants.ManifestLabel]
+	if !ok {
+		return "", errors.New("No seed manifest label found on " + repository + ":" + tag)
+	}
+	return label, nil
+}
+
+//ImageCreated returns when the given image was created, as recorded in its configuration
+func (r *v2registry) ImageCreated(repository, tag string) (time.Time, error) {
+	manifest, err := r.r.ManifestV2(repository, tag)
+	if err != nil {
+		return time.Time{}, err
+	}
+	reader, err := r.r.DownloadLayer(repository, manifest.Config.Digest)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer reader.Close()
+
+	var config struct {
+		Created time.Time `json:"created"`
+	}
+	if err := json.NewDecoder(reader).Decode(&config); err != nil {
+		return time.Time{}, err
+	}
+	if config.Created.IsZero() {
+		return time.Time{}, errors.New("No creation time found on " + repository + ":" + tag)
+	}
+	return config.Created, nil
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+//Colors of table output, as SGR codes
+const (
+	ColorBold   = "01"
+	ColorFaint  = "02"
+	ColorGreen  = "32"
+	ColorYellow = "33"
+	ColorCyan   = "36"
+)
+
+var noColor, forceColor bool
+
+//SetNoColor disables colored output, i.e. for the -no-color flag
+func SetNoColor(disabled bool) {
+	noColor = disabled
+}
+
+//SetForceColor enables colored output even if stdout and stderr are not terminals, i.e. for tests
+// of colored output. -no-color and NO_COLOR still disable it.
+func SetForceColor(forced bool) {
+	forceColor = forced
+}
+
+//ColorEnabled returns whether output is colored: stdout and stderr, where seed prints its
+// messages, are terminals and neither -no-color nor the NO_COLOR environment variable is set
+func ColorEnabled() bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && (forceColor || term.IsTerminal(int(os.Stdout.Fd())) &&
+		term.IsTerminal(int(os.Stderr.Fd())))
+}
+
+//Colorizer returns a function wrapping text in the escape codes of a color, or returning it
+// unchanged if colored output is disabled. Whether it is enabled is checked once, so every row
+// of a table is colored alike.
+func Colorizer() func(color, s string) string {
+	if !ColorEnabled() {
+		return func(color, s string) string { return s }
+	}
+	return func(color, s string) string {
+		return "\033[" + color + "m" + s + "\033[0m"
+	}
+}
@@ -0,0 +1,116 @@
+package util
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/constants"
+)
+
+//DefaultConfigFile returns the path of the config file read when -config is not given
+func DefaultConfigFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, constants.SeedDir, constants.ConfigFileName)
+}
+
+//ReadConfig reads flag defaults from a config file. The file holds one "flag: value" pair per
+// line using the long flag names, i.e. "registry: registry.example.com", in a flat subset of
+// YAML: blank lines and # comments are ignored and values may be quoted. A missing file
+// returns no defaults unless required is set.
+func ReadConfig(fileName string, required bool) (map[string]string, error) {
+	f, err := os.Open(fileName)
+	if os.IsNotExist(err) && !required {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	config := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("%s:%d: nested values are not supported; use one \"flag: value\" per line",
+				fileName, n)
+		}
+		i := strings.Index(line, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("%s:%d: expected \"flag: value\"", fileName, n)
+		}
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if j := strings.Index(value, " #"); j >= 0 {
+			value = strings.TrimSpace(value[:j])
+		}
+		config[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	_, pass := config[constants.PassFlag]
+	_, shortPass := config[constants.ShortPassFlag]
+	if pass || shortPass {
+		if info, err := f.Stat(); err == nil && info.Mode().Perm()&0077 != 0 {
+			Warnf("%s contains a password but is readable by other users; restrict it with chmod 600\n", fileName)
+		}
+	}
+	return config, nil
+}
+
+//ApplyConfig sets the flags of a parsed flag set to the config values of the same name, unless
+// the flag, or another name for the same flag such as its shorthand, was given on the command
+// line. Keys that are not flags of the command are ignored.
+func ApplyConfig(cmd *flag.FlagSet, config map[string]string) error {
+	given := map[uintptr]bool{}
+	cmd.Visit(func(f *flag.Flag) {
+		given[flagTarget(f)] = true
+	})
+
+	for key, value := range config {
+		f := cmd.Lookup(key)
+		if f == nil || given[flagTarget(f)] {
+			continue
+		}
+		if err := f.Value.Set(value); err != nil {
+			return fmt.Errorf("Invalid config value %s: %s for %s: %s", key, loggedConfigValue(key, value),
+				cmd.Name(), err.Error())
+		}
+		// values of flags given more than once are appended, so each is set once
+		given[flagTarget(f)] = true
+		Debugf("Using %s=%s from config\n", key, loggedConfigValue(key, value))
+	}
+	return nil
+}
+
+//loggedConfigValue returns the config value as it may be logged, with passwords masked
+func loggedConfigValue(key, value string) string {
+	if key == constants.PassFlag || key == constants.ShortPassFlag {
+		return "********"
+	}
+	return value
+}
+
+//flagTarget identifies the variable a flag is bound to. The long and short names of a flag
+// are defined on the same variable, so they share a target.
+func flagTarget(f *flag.Flag) uintptr {
+	v := reflect.ValueOf(f.Value)
+	if v.Kind() == reflect.Ptr {
+		return v.Pointer()
+	}
+	return 0
+}
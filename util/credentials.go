@@ -0,0 +1,162 @@
+package util
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"golang.org/x/term"
+)
+
+//dockerConfig represents the subset of a docker config.json used to store registry credentials
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Auth string `json:"auth"`
+}
+
+//CredentialsDir returns the directory holding credentials stored by seed login, in the
+// docker config format so it may also be used as DOCKER_CONFIG
+func CredentialsDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, constants.SeedDir, strings.TrimSuffix(constants.DockerConfigDir, "-"))
+}
+
+//RegistryKey normalizes a registry url into the key used to store its credentials
+func RegistryKey(registry string) string {
+	if registry == "" {
+		registry = constants.DefaultRegistry
+	}
+	return RegistryHost(registry)
+}
+
+func readDockerConfig() (dockerConfig, error) {
+	config := dockerConfig{Auths: map[string]dockerAuth{}}
+	bytes, err := ioutil.ReadFile(filepath.Join(CredentialsDir(), "config.json"))
+	if os.IsNotExist(err) {
+		return config, nil
+	} else if err != nil {
+		return config, err
+	}
+	err = json.Unmarshal(bytes, &config)
+	if config.Auths == nil {
+		config.Auths = map[string]dockerAuth{}
+	}
+	return config, err
+}
+
+func writeDockerConfig(config dockerConfig) error {
+	dir := CredentialsDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	bytes, err := json.MarshalIndent(config, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "config.json"), bytes, 0600)
+}
+
+//StoreCredentials saves the username and password for the given registry
+func StoreCredentials(registry, username, password string) error {
+	config, err := readDockerConfig()
+	if err != nil {
+		return err
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	config.Auths[RegistryKey(registry)] = dockerAuth{Auth: auth}
+	return writeDockerConfig(config)
+}
+
+//RemoveCredentials removes any stored credentials for the given registry. Returns
+// whether credentials were found
+func RemoveCredentials(registry string) (bool, error) {
+	config, err := readDockerConfig()
+	if err != nil {
+		return false, err
+	}
+	key := RegistryKey(registry)
+	if _, ok := config.Auths[key]; !ok {
+		return false, nil
+	}
+	delete(config.Auths, key)
+	return true, writeDockerConfig(config)
+}
+
+//StoredCredentials returns the username and password stored for the given registry.
+// Empty strings are returned if no credentials are stored.
+func StoredCredentials(registry string) (string, string) {
+	config, err := readDockerConfig()
+	if err != nil {
+		PrintUtil("WARNING: Error reading stored credentials: %s\n", err.Error())
+		return "", ""
+	}
+	auth, ok := config.Auths[RegistryKey(registry)]
+	if !ok {
+		return "", ""
+	}
+	decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+	if err != nil {
+		return "", ""
+	}
+	x := strings.SplitN(string(decoded), ":", 2)
+	if len(x) != 2 {
+		return "", ""
+	}
+	return x[0], x[1]
+}
+
+//ResolveCredentials returns the credentials to use for a registry. Credentials given as flags
+// take precedence, then the SEED_REGISTRY_USER and SEED_REGISTRY_PASSWORD environment
+// variables, then any credentials stored by seed login for the registry. A password missing
+// from the flags is read from the environment if a username was given.
+func ResolveCredentials(registry, username, password string) (string, string) {
+	envUser := os.Getenv(constants.RegistryUserEnv)
+	envPass := os.Getenv(constants.RegistryPasswordEnv)
+	if username != "" {
+		if password == "" && envPass != "" {
+			Debugf("Using password from %s\n", constants.RegistryPasswordEnv)
+			password = envPass
+		}
+		return username, password
+	}
+
+	if envUser != "" {
+		Infof("Using credentials from %s and %s\n", constants.RegistryUserEnv, constants.RegistryPasswordEnv)
+		return envUser, envPass
+	}
+
+	storedUser, storedPass := StoredCredentials(registry)
+	if storedUser != "" {
+		Infof("Using stored credentials for %s\n", RegistryKey(registry))
+	}
+	return storedUser, storedPass
+}
+
+//Prompt prints the given prompt to stderr and reads a line from stdin. If hidden is set
+// and stdin is a terminal, the input is not echoed.
+func Prompt(prompt string, hidden bool) (string, error) {
+	PrintErr("%s", prompt)
+	fd := int(os.Stdin.Fd())
+	if hidden && term.IsTerminal(fd) {
+		bytes, err := term.ReadPassword(fd)
+		PrintErr("\n")
+		return string(bytes), err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -2,41 +2,72 @@ package util
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
+//pushDigestRegex matches the digest reported by docker push
+var pushDigestRegex = regexp.MustCompile(`digest: ([a-z0-9]+:[a-f0-9]+)`)
+
 //CheckSudo Checks error for telltale sign seed command should be run as sudo
 func CheckSudo() {
-	cmd := exec.Command("docker", "info")
-
-	// attach stderr pipe
-	errPipe, err := cmd.StderrPipe()
-	if err != nil {
-		PrintUtil("ERROR: Error attaching to version command stderr. %s\n", err.Error())
-	}
-
-	// Run docker build
-	if err := cmd.Start(); err != nil {
-		PrintUtil( "ERROR: Error executing docker version. %s\n",
+	er, err := DockerInfoErrors()
+	if _, exited := ExitCode(err); err != nil && !exited {
+		Errorf("Error executing docker version. %s\n",
 			err.Error())
 	}
+	if DaemonUnreachable(er) || PermissionDenied(er) {
+		PrintUtil( "Elevated permissions are required by seed to run Docker. Try running the seed command again as sudo.\n")
+		panic(Exit{1})
+	}
+}
+
+//DockerInfoErrors runs docker info and returns what it wrote to stderr, which is empty if the
+// daemon was reached, and the error from running it
+func DockerInfoErrors() (string, error) {
+	args := []string{"info"}
+	DebugCommand("docker", args)
+	var stderr bytes.Buffer
+	err := RunDocker(DockerCommand{Args: args, Stderr: &stderr})
+	return stderr.String(), err
+}
 
-	slurperr, _ := ioutil.ReadAll(errPipe)
-	er := string(slurperr)
-	if er != "" {
-		if strings.Contains(er, "Cannot connect to the Docker daemon. Is the docker daemon running on this host?") ||
-			strings.Contains(er, "dial unix /var/run/docker.sock: connect: permission denied") {
-			PrintUtil( "Elevated permissions are required by seed to run Docker. Try running the seed command again as sudo.\n")
-			panic(Exit{1})
+//DaemonUnreachable returns whether docker info errors show the docker daemon could not be
+// connected to, because it is not running or, on some systems, for lack of permissions
+func DaemonUnreachable(infoErrors string) bool {
+	return strings.Contains(infoErrors, "Cannot connect to the Docker daemon")
+}
+
+//PermissionDenied returns whether docker info errors show the user may not use the docker socket
+func PermissionDenied(infoErrors string) bool {
+	return strings.Contains(infoErrors, "docker.sock: connect: permission denied")
+}
+
+//DockerVersions returns the version of the docker client and, if the daemon can be reached, of
+// the docker daemon
+func DockerVersions() (string, string, error) {
+	args := []string{"version", "-f", "{{.Client.Version}} {{.Server.Version}}"}
+	DebugCommand("docker", args)
+	out, err := DockerOutput(args...)
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		if err == nil {
+			err = errors.New("docker version printed no version")
 		}
+		return "", "", err
+	}
+	if len(fields) == 1 || fields[1] == "<no" {
+		return fields[0], "", nil
 	}
+	return fields[0], fields[1], nil
 }
 
 //DockerVersionHasLabel returns if the docker version is greater than 1.11.1
@@ -49,23 +80,20 @@ func DockerVersionHasReferenceFilter() bool {
 	return DockerVersionGreaterThan(1, 13, 0)
 }
 
+//DockerVersionHasBuildKit returns if the docker version is greater than 18.09.0, the first to
+// build with BuildKit
+func DockerVersionHasBuildKit() bool {
+	return DockerVersionGreaterThan(18, 9, 0)
+}
+
 //DockerVersionGreaterThan returns if the docker version is greater than the specified version
 func DockerVersionGreaterThan(major, minor, patch int) bool {
-	cmd := exec.Command("docker", "version", "-f", "{{.Client.Version}}")
-
-	// Attach stdout pipe
-	outPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		PrintUtil("ERROR: Error attaching to version command stdout. %s\n", err.Error())
-	}
-
 	// Run docker version
-	if err := cmd.Start(); err != nil {
-		PrintUtil("ERROR: Error executing docker version. %s\n", err.Error())
+	slurp, err := DockerOutput("version", "-f", "{{.Client.Version}}")
+	if err != nil {
+		Errorf("Error executing docker version. %s\n", err.Error())
 	}
 
-	// Print out any std out
-	slurp, _ := ioutil.ReadAll(outPipe)
 	if string(slurp) != "" {
 		version := strings.Split(string(slurp), ".")
 
@@ -99,19 +127,169 @@ func DockerVersionGreaterThan(major, minor, patch int) bool {
 func ImageExists(imageName string) (bool, error) {
 	// Test if image has been built; Rebuild if not
 	imgsArgs := []string{"images", "-q", imageName}
-	imgOut, err := exec.Command("docker", imgsArgs...).Output()
+	DebugCommand("docker", imgsArgs)
+	imgOut, err := DockerOutput(imgsArgs...)
 	if err != nil {
-		PrintUtil( "ERROR: Error executing docker %v\n", imgsArgs)
-		PrintUtil( "%s\n", err.Error())
+		Errorf("Error executing docker %v\n%s\n", imgsArgs, err.Error())
 		return false, err
 	} else if string(imgOut) == "" {
-		PrintUtil( "INFO: No docker image found locally for image name %s.\n",
+		Infof("No docker image found locally for image name %s.\n",
 			imageName)
 		return false, nil
 	}
 	return true, nil
 }
 
+//ImageRepoDigest returns the registry digest of a pushed image, i.e. sha256:abc123
+func ImageRepoDigest(img string) (string, error) {
+	ref, err := ParseReference(img)
+	if err != nil {
+		return "", err
+	}
+	repo := ref.Repository()
+
+	inspectArgs := []string{"inspect", "-f", "{{range .RepoDigests}}{{println .}}{{end}}", img}
+	DebugCommand("docker", inspectArgs)
+	out, err := DockerOutput(inspectArgs...)
+	if err != nil {
+		Errorf("Error executing docker %v\n", inspectArgs)
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, repo+"@") {
+			return strings.TrimPrefix(line, repo+"@"), nil
+		}
+	}
+
+	return "", errors.New("No registry digest found for image " + img)
+}
+
+//ContainerOOMKilled returns true if the container was killed for exceeding its memory limit
+func ContainerOOMKilled(containerID string) (bool, error) {
+	args := []string{"inspect", "-f", "{{.State.OOMKilled}}", containerID}
+	DebugCommand("docker", args)
+	out, err := DockerOutput(args...)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "true", nil
+}
+
+//BuildxAvailable returns if the docker buildx plugin, needed for BuildKit cross-platform
+// builds, is installed
+func BuildxAvailable() bool {
+	args := []string{"buildx", "version"}
+	DebugCommand("docker", args)
+	return RunDocker(DockerCommand{Args: args}) == nil
+}
+
+//DaemonExperimental returns whether the docker daemon has experimental features enabled
+func DaemonExperimental() (bool, error) {
+	args := []string{"version", "-f", "{{.Server.Experimental}}"}
+	DebugCommand("docker", args)
+	out, err := DockerOutput(args...)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "true", nil
+}
+
+//ServerPlatform returns the os/architecture of the docker daemon, i.e. linux/amd64
+func ServerPlatform() (string, error) {
+	args := []string{"version", "-f", "{{.Server.Os}}/{{.Server.Arch}}"}
+	DebugCommand("docker", args)
+	out, err := DockerOutput(args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+//ServerCPUs returns the number of CPUs of the docker daemon's host
+func ServerCPUs() (int, error) {
+	args := []string{"info", "-f", "{{.NCPU}}"}
+	DebugCommand("docker", args)
+	out, err := DockerOutput(args...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+//ImageLabel returns the value of the given label of a local image, or an empty string if
+// the image does not have the label
+func ImageLabel(img, label string) (string, error) {
+	args := []string{"inspect", "--type", "image", "-f", "{{index .Config.Labels \"" + label + "\"}}", img}
+	DebugCommand("docker", args)
+	out, err := DockerOutput(args...)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(out))
+	if value == "<no value>" {
+		value = ""
+	}
+	return value, nil
+}
+
+//ImageConfig holds the parts of a docker image configuration used to generate a seed manifest
+type ImageConfig struct {
+	Entrypoint []string
+	Cmd        []string
+	Env        []string
+	WorkingDir string
+	User       string
+}
+
+//InspectImageConfig returns the entrypoint, command, environment, working directory and user
+// of the given local image
+func InspectImageConfig(img string) (ImageConfig, error) {
+	var config ImageConfig
+	args := []string{"inspect", "--type", "image", "-f", "{{json .Config}}", img}
+	DebugCommand("docker", args)
+	out, err := DockerOutput(args...)
+	if err != nil {
+		Errorf("Error executing docker %v\n", args)
+		return config, err
+	}
+	err = json.Unmarshal(out, &config)
+	return config, err
+}
+
+//KillContainer kills a running container
+func KillContainer(containerID string) error {
+	args := []string{"kill", containerID}
+	DebugCommand("docker", args)
+	return RunDocker(DockerCommand{Args: args})
+}
+
+//NetworkExists returns whether a docker network with the given name or id exists
+func NetworkExists(network string) (bool, error) {
+	args := []string{"network", "inspect", "-f", "{{.Name}}", network}
+	DebugCommand("docker", args)
+	out, err := DockerCombinedOutput(args...)
+	if err == nil {
+		return true, nil
+	}
+	if strings.Contains(strings.ToLower(string(out)), "no such network") ||
+		strings.Contains(strings.ToLower(string(out)), "not found") {
+		return false, nil
+	}
+	return false, fmt.Errorf("Error inspecting docker network %s: %s", network, strings.TrimSpace(string(out)))
+}
+
+//RemoveContainer removes a stopped container and its anonymous volumes
+func RemoveContainer(containerID string) error {
+	args := []string{"rm", "-v", containerID}
+	DebugCommand("docker", args)
+	out, err := DockerCombinedOutput(args...)
+	if err != nil {
+		Errorf("Error removing container %s: %s\n", containerID, string(out))
+	}
+	return err
+}
+
 //ImageCpuUsage displays CPU usage of image
 func ImageCpuUsage(imageName string) {
 
@@ -125,14 +303,11 @@ func ImageMemoryUsage(imageName string) {
 func Login(registry, username, password string) error {
 	var errs, out bytes.Buffer
 	args := []string{"login", "-u", username, "-p", password, registry}
-	cmd := exec.Command("docker", args...)
-	cmd.Stderr = io.MultiWriter(os.Stderr, &errs)
-	cmd.Stdout = &out
-
-	err := cmd.Run()
+	DebugCommand("docker", []string{"login", "-u", username, "-p", "********", registry})
+	err := RunDocker(DockerCommand{Args: args, Stdout: &out, Stderr: io.MultiWriter(ProgressWriter(), &errs)})
 
 	if errs.String() != "" {
-		PrintUtil( "ERROR: Error reading stderr %s\n",
+		Errorf("Error reading stderr %s\n",
 			errs.String())
 		return errors.New(errs.String())
 	}
@@ -143,7 +318,7 @@ func Login(registry, username, password string) error {
 		return err
 	}
 
-	PrintUtil( "%s", out.String())
+	Infof("%s", out.String())
 	return nil
 }
 
@@ -152,17 +327,17 @@ func Tag(origImg, img string) error {
 
 	// Run docker tag
 	if img != origImg {
-		PrintUtil( "INFO: Tagging image %s as %s\n", origImg, img)
-		tagCmd := exec.Command("docker", "tag", origImg, img)
-		tagCmd.Stderr = io.MultiWriter(os.Stderr, &errs)
-		tagCmd.Stdout = os.Stderr
+		Infof("Tagging image %s as %s\n", origImg, img)
+		DebugCommand("docker", []string{"tag", origImg, img})
+		tagCmd := DockerCommand{Args: []string{"tag", origImg, img}, Stdout: ProgressWriter(),
+			Stderr: io.MultiWriter(ProgressWriter(), &errs)}
 
-		if err := tagCmd.Run(); err != nil {
-			PrintUtil( "ERROR: Error executing docker tag. %s\n",
+		if err := RunDocker(tagCmd); err != nil {
+			Errorf("Error executing docker tag. %s\n",
 				err.Error())
 		}
 		if errs.String() != "" {
-			PrintUtil( "ERROR: Error tagging image '%s':\n%s\n", origImg, errs.String())
+			Errorf("Error tagging image '%s':\n%s\n", origImg, errs.String())
 			PrintUtil( "Exiting seed...\n")
 			return errors.New(errs.String())
 		}
@@ -171,51 +346,84 @@ func Tag(origImg, img string) error {
 	return nil
 }
 
+//Push pushes the given image to its registry
 func Push(img string) error {
-	var errs bytes.Buffer
+	_, err := push(img, false)
+	return err
+}
+
+//PushDigest pushes the given image to its registry and returns the content digest reported
+// by the registry in the push response. measure reads the size of the pushed layers from the
+// registry for the push summary.
+func PushDigest(img string, measure bool) (string, error) {
+	output, err := push(img, measure)
+	if err != nil {
+		return "", err
+	}
+	return ParsePushDigest(output)
+}
+
+//push runs docker push for the given image, prints a summary of what it transferred and returns
+// its output. The size of the pushed layers is only read from the registry if measure is set or
+// at debug level, as it takes another request.
+func push(img string, measure bool) (string, error) {
+	var errs, out bytes.Buffer
 
 	// docker push
-	PrintUtil( "INFO: Performing docker push %s\n", img)
+	Infof("Performing docker push %s\n", img)
 	errs.Reset()
-	pushCmd := exec.Command("docker", "push", img)
-	pushCmd.Stderr = io.MultiWriter(os.Stderr, &errs)
-	pushCmd.Stdout = os.Stdout
+	DebugCommand("docker", []string{"push", img})
+	pushCmd := DockerCommand{Args: []string{"push", img}, Stdout: io.MultiWriter(ProgressWriter(), &out),
+		Stderr: io.MultiWriter(ProgressWriter(), &errs)}
 
 	// Run docker push
-	if err := pushCmd.Run(); err != nil {
-		PrintUtil( "ERROR: Error executing docker push. %s\n",
+	start := time.Now()
+	if err := RunDocker(pushCmd); err != nil {
+		Errorf("Error executing docker push. %s\n",
 			err.Error())
-		return err
+		return "", err
 	}
 
 	// Check for errors. Exit if error occurs
 	if errs.String() != "" {
-		PrintUtil( "ERROR: Error pushing image '%s':\n%s\n", img,
+		Errorf("Error pushing image '%s':\n%s\n", img,
 			errs.String())
 		PrintUtil( "Exiting seed...\n")
-		return errors.New(errs.String())
+		return "", errors.New(errs.String())
 	}
 
-	return nil
+	PrintUtil("%s\n", NewPushSummary(img, out.String(), time.Since(start),
+		measure || logLevel <= LevelDebug))
+	return out.String(), nil
+}
+
+//ParsePushDigest returns the digest from the output of docker push, which ends with a
+// line of the form "TAG: digest: sha256:... size: N"
+func ParsePushDigest(output string) (string, error) {
+	matches := pushDigestRegex.FindAllStringSubmatch(output, -1)
+	if len(matches) == 0 {
+		return "", errors.New("No digest found in docker push output")
+	}
+	return matches[len(matches)-1][1], nil
 }
 
 func RemoveImage(img string) error {
 	var errs bytes.Buffer
 
-	PrintUtil( "INFO: Removing local image %s\n", img)
-	rmiCmd := exec.Command("docker", "rmi", img)
-	rmiCmd.Stderr = io.MultiWriter(os.Stderr, &errs)
-	rmiCmd.Stdout = os.Stdout
+	Infof("Removing local image %s\n", img)
+	DebugCommand("docker", []string{"rmi", img})
+	rmiCmd := DockerCommand{Args: []string{"rmi", img}, Stdout: ProgressWriter(),
+		Stderr: io.MultiWriter(ProgressWriter(), &errs)}
 
-	if err := rmiCmd.Run(); err != nil {
-		PrintUtil( "ERROR: Error executing docker rmi. %s\n",
+	if err := RunDocker(rmiCmd); err != nil {
+		Errorf("Error executing docker rmi. %s\n",
 			err.Error())
 		return err
 	}
 
 	// check for errors on stderr
 	if errs.String() != "" {
-		PrintUtil( "ERROR: Error removing image '%s':\n%s\n", img,
+		Errorf("Error removing image '%s':\n%s\n", img,
 			errs.String())
 		PrintUtil( "Exiting seed...\n")
 		return errors.New(errs.String())
@@ -228,7 +436,7 @@ func RestartRegistry() error {
 	PrintUtil("RESTARTING REGISTRY........................\n.\n.\n.\n.\n.\n")
 	var errs bytes.Buffer
 
-	PrintUtil( "INFO: Restarting test registry...\n")
+	Infof("Restarting test registry...\n")
 	cmd := exec.Command("../restartRegistry.sh")
 	cmd.Stderr = io.MultiWriter(os.Stderr, &errs)
 	cmd.Stdout = os.Stdout
@@ -237,13 +445,13 @@ func RestartRegistry() error {
 
 	// check for errors on stderr first; it will likely have more explanation than cmd.Run
 	if errs.String() != "" {
-		PrintUtil( "ERROR: Error restarting registry. %s\n", errs.String())
+		Errorf("Error restarting registry. %s\n", errs.String())
 		PrintUtil( "Exiting seed...\n")
 		return errors.New(errs.String())
 	}
 
 	if err != nil {
-		PrintUtil( "ERROR: Error restarting registry. %s\n",
+		Errorf("Error restarting registry. %s\n",
 			err.Error())
 		return err
 	}
@@ -0,0 +1,103 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//DockerfileName defines the filename of the Dockerfile in a job directory
+const DockerfileName = "Dockerfile"
+
+//DockerfilePath returns the path of the Dockerfile named file for the job in dir. A relative
+// file is in dir and an empty file is the default DockerfileName.
+func DockerfilePath(dir, file string) string {
+	if file == "" {
+		file = DockerfileName
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(dir, file)
+}
+
+//DockerfileBaseImage returns the base image named by the first FROM instruction of the
+// given Dockerfile. Only ARG instructions may precede it. Returns an error if the
+// Dockerfile cannot be read or does not start with a FROM instruction.
+func DockerfileBaseImage(dockerfile string) (string, error) {
+	instructions, err := dockerfileInstructions(dockerfile)
+	if err != nil {
+		return "", err
+	}
+
+	for _, fields := range instructions {
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			continue
+		case "FROM":
+			for _, f := range fields[1:] {
+				if !strings.HasPrefix(f, "--") {
+					return f, nil
+				}
+			}
+			return "", errors.New(dockerfile + " has a FROM instruction without a base image")
+		default:
+			return "", errors.New(dockerfile + " must start with a FROM instruction, found " + fields[0])
+		}
+	}
+	return "", errors.New(dockerfile + " has no FROM instruction")
+}
+
+//DockerfileStages returns the names of the build stages of the given Dockerfile, given by
+// FROM instructions of the form FROM image AS name, in the order they are defined
+func DockerfileStages(dockerfile string) ([]string, error) {
+	instructions, err := dockerfileInstructions(dockerfile)
+	if err != nil {
+		return nil, err
+	}
+
+	var stages []string
+	for _, fields := range instructions {
+		n := len(fields)
+		if strings.ToUpper(fields[0]) == "FROM" && n >= 4 && strings.ToUpper(fields[n-2]) == "AS" {
+			stages = append(stages, fields[n-1])
+		}
+	}
+	return stages, nil
+}
+
+//dockerfileInstructions returns the fields of each instruction of a Dockerfile, skipping
+// comments and blank lines and joining lines continued with a trailing backslash
+func dockerfileInstructions(dockerfile string) ([][]string, error) {
+	file, err := os.Open(dockerfile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var instructions [][]string
+	instruction := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") || (line == "" && instruction == "") {
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			instruction += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		instruction += line
+
+		if fields := strings.Fields(instruction); len(fields) > 0 {
+			instructions = append(instructions, fields)
+		}
+		instruction = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return instructions, nil
+}
@@ -0,0 +1,247 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ngageoint/seed-cli/constants"
+)
+
+//DockerignoreFileName defines the file listing paths excluded from the docker build context
+const DockerignoreFileName = ".dockerignore"
+
+//ContextDockerfileName is the name a Dockerfile from outside the build context is given in the
+// archive written by WriteBuildContext
+const ContextDockerfileName = ".dockerfile.seed"
+
+//ReadDockerignore returns the patterns in the .dockerignore file of dir. A missing file
+// returns no patterns.
+func ReadDockerignore(dir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, DockerignoreFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		exception := strings.HasPrefix(line, "!")
+		line = filepath.ToSlash(filepath.Clean(strings.TrimPrefix(line, "!")))
+		line = strings.TrimPrefix(line, "/")
+		if exception {
+			line = "!" + line
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
+
+//DockerignoreMatches reports whether the slash separated path, relative to the build
+// context, is excluded by the .dockerignore patterns. Later patterns take precedence and
+// patterns starting with ! re-include paths. A pattern matching a directory excludes
+// everything beneath it.
+func DockerignoreMatches(name string, patterns []string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		exception := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if matched == !exception {
+			continue
+		}
+		re, err := dockerignoreRegexp(pattern)
+		if err != nil {
+			continue
+		}
+		for p := name; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			if re.MatchString(p) {
+				matched = !exception
+				break
+			}
+		}
+	}
+	return matched
+}
+
+//dockerignoreRegexp converts a .dockerignore pattern into a regular expression. ** matches
+// any number of directories, * and ? match within a single path element.
+func dockerignoreRegexp(pattern string) (*regexp.Regexp, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				i++
+				buffer.WriteString("(.*/)?")
+			} else {
+				buffer.WriteString(".*")
+			}
+		case c == '*':
+			buffer.WriteString("[^/]*")
+		case c == '?':
+			buffer.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				buffer.WriteString(`\[`)
+				continue
+			}
+			buffer.WriteString(pattern[i : i+end+1])
+			i += end
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			buffer.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			buffer.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	buffer.WriteString("$")
+	return regexp.Compile(buffer.String())
+}
+
+//walkBuildContext calls fn for each file and directory of the build context in dir that is
+// not excluded by its .dockerignore, with the path relative to dir. The seed build cache
+// directory is never part of the context.
+func walkBuildContext(dir string, fn func(path, rel string, info os.FileInfo) error) error {
+	patterns, err := ReadDockerignore(dir)
+	if err != nil {
+		return err
+	}
+	hasExceptions := false
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "!") {
+			hasExceptions = true
+		}
+	}
+
+	return filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		if rel == constants.BuildCacheDir && info.IsDir() {
+			return filepath.SkipDir
+		}
+		if rel != "." && DockerignoreMatches(filepath.ToSlash(rel), patterns) {
+			// files beneath an excluded directory may only be re-included by an exception
+			if info.IsDir() && !hasExceptions {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(file, rel, info)
+	})
+}
+
+//BuildContextSize returns the total size in bytes of the files in the build context in dir,
+// honoring its .dockerignore, and the dockerfile, which docker sends even when it is outside
+// the context or excluded by the .dockerignore
+func BuildContextSize(dir, dockerfile string) (int64, error) {
+	dockerfile, err := filepath.Abs(dockerfile)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	counted := false
+	err = walkBuildContext(dir, func(file, rel string, info os.FileInfo) error {
+		if info.Mode().IsRegular() {
+			size += info.Size()
+			if abs, err := filepath.Abs(file); err == nil && abs == dockerfile {
+				counted = true
+			}
+		}
+		return nil
+	})
+	if err != nil || counted {
+		return size, err
+	}
+	if info, err := os.Stat(dockerfile); err == nil && info.Mode().IsRegular() {
+		size += info.Size()
+	}
+	return size, nil
+}
+
+//BuildContextHash returns a hash of the list of files in the build context in dir, honoring its
+// .dockerignore, that changes when a file is added, removed or modified: the path, mode, size and
+// modification time of each file are hashed rather than its contents. Directories are not
+// hashed.
+func BuildContextHash(dir string) (string, error) {
+	h := sha256.New()
+	err := walkBuildContext(dir, func(file, rel string, info os.FileInfo) error {
+		if info.IsDir() {
+			// the modification time of a directory changes whenever a file in it does
+			return nil
+		}
+		fmt.Fprintf(h, "%s %s %d %d\n", filepath.ToSlash(rel), info.Mode(), info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+//ContextDockerfile returns the path of dockerfile within the build context in dir as docker
+// build -f takes it for a context read from stdin: relative to dir, or ContextDockerfileName if
+// it is outside dir
+func ContextDockerfile(dir, dockerfile string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return ContextDockerfileName
+	}
+	abs, err := filepath.Abs(dockerfile)
+	if err != nil {
+		return ContextDockerfileName
+	}
+	rel, err := filepath.Rel(absDir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ContextDockerfileName
+	}
+	return filepath.ToSlash(rel)
+}
+
+//WriteBuildContext writes the build context in dir to w as a tar archive, for docker build to
+// read from stdin. Files excluded by the .dockerignore and the seed build cache directory are
+// left out. The dockerfile is always included, at the path returned by ContextDockerfile, as
+// docker sends it even when it is outside the context or excluded by the .dockerignore.
+func WriteBuildContext(w io.Writer, dir, dockerfile string) error {
+	name := ContextDockerfile(dir, dockerfile)
+	return writeTar(w, dir, func(dir string, fn func(path, rel string, info os.FileInfo) error) error {
+		sent := false
+		err := walkBuildContext(dir, func(path, rel string, info os.FileInfo) error {
+			if filepath.ToSlash(rel) == name {
+				sent = true
+			}
+			return fn(path, rel, info)
+		})
+		if err != nil || sent {
+			return err
+		}
+		info, err := os.Stat(dockerfile)
+		if err != nil {
+			return err
+		}
+		return fn(dockerfile, name, info)
+	})
+}
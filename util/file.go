@@ -1,10 +1,22 @@
 package util
 
 import (
+	"archive/tar"
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ngageoint/seed-cli/constants"
 )
@@ -49,30 +61,75 @@ func GetFullPath(rFile, directory string) string {
 	return rFile
 }
 
+//DockerHostPath returns a host path in the form docker expects in a bind mount. On Windows,
+// drive letter paths such as C:\data become //c/data and UNC paths such as \\server\share
+// become //server/share. On other systems the path is returned unchanged.
+func DockerHostPath(hostPath string) string {
+	if runtime.GOOS != "windows" {
+		return hostPath
+	}
+	return WindowsHostPath(hostPath)
+}
+
+//ContainerPath returns the path in a linux container a host path is mounted at. On Windows,
+// drive letter paths such as C:\data become /c/data and UNC paths such as \\server\share
+// become /server/share. On other systems the path is returned unchanged.
+func ContainerPath(hostPath string) string {
+	if runtime.GOOS != "windows" {
+		return hostPath
+	}
+	return WindowsContainerPath(hostPath)
+}
+
+//WindowsHostPath converts an absolute Windows path to the //c/data form docker accepts in a
+// bind mount. Other paths are returned unchanged.
+func WindowsHostPath(p string) string {
+	if !isWindowsAbs(p) {
+		return p
+	}
+	return "/" + WindowsContainerPath(p)
+}
+
+//WindowsContainerPath converts an absolute Windows path to a unix path: the drive letter of
+// C:\data becomes the lower case directory /c/data and \\server\share\data becomes
+// /server/share/data. Other paths are returned unchanged.
+func WindowsContainerPath(p string) string {
+	if !isWindowsAbs(p) {
+		return p
+	}
+	slashed := strings.Replace(p, "\\", "/", -1)
+	if strings.HasPrefix(slashed, "//") {
+		return path.Clean("/" + strings.TrimLeft(slashed, "/"))
+	}
+	return path.Clean("/" + strings.ToLower(slashed[:1]) + "/" + slashed[2:])
+}
+
+//isWindowsAbs returns whether p is a Windows drive letter path, i.e. C:\data, or UNC path,
+// i.e. \\server\share
+func isWindowsAbs(p string) bool {
+	if strings.HasPrefix(p, "\\\\") {
+		return true
+	}
+	if len(p) < 2 || p[1] != ':' {
+		return false
+	}
+	drive := p[0]
+	return drive >= 'a' && drive <= 'z' || drive >= 'A' && drive <= 'Z'
+}
+
 //DockerfileRegistry attempts to find the registry for a dockerfile's base image, if any
-func DockerfileBaseRegistry(dir string) (string, error) {
+func DockerfileBaseRegistry(dockerfile string) (string, error) {
 	registry := ""
 
-	// Define the current working directory
-	curDirectory, _ := os.Getwd()
-
-	dockerfile := "Dockerfile"
-	if dir == "." {
+	if !filepath.IsAbs(dockerfile) {
+		curDirectory, _ := os.Getwd()
 		dockerfile = filepath.Join(curDirectory, dockerfile)
-	} else {
-		if filepath.IsAbs(dir) {
-			dockerfile = filepath.Join(dir, dockerfile)
-		} else {
-			dockerfile = filepath.Join(curDirectory, dir, dockerfile)
-		}
 	}
 
 	// Verify dockerfile exists within specified directory.
 	_, err := os.Stat(dockerfile)
 	if os.IsNotExist(err) {
-		PrintUtil( "ERROR: %s cannot be found.\n",
-			dockerfile)
-		PrintUtil( "Make sure you have specified the correct directory.\n")
+		Errorf("%s cannot be found.\nMake sure you have specified the correct directory.\n", dockerfile)
 	}
 
 	file, err := os.Open(dockerfile)
@@ -119,6 +176,8 @@ func GetSeedFileName(dir string) (string, bool, error) {
 		}
 	}
 
+	Debugf("Resolved seed manifest path for directory %s to %s\n", dir, seedFileName)
+
 	// Check to see if seed.manifest.json exists within specified directory.
 	_, err := os.Stat(seedFileName)
 	return seedFileName, !os.IsNotExist(err), err
@@ -128,9 +187,7 @@ func GetSeedFileName(dir string) (string, bool, error) {
 func SeedFileName(dir string) (string, error) {
 	seedFileName, exists, err := GetSeedFileName(dir)
 	if !exists {
-		PrintUtil( "ERROR: %s cannot be found.\n",
-			seedFileName)
-		PrintUtil( "Make sure you have specified the correct directory.\n")
+		Errorf("%s cannot be found.\nMake sure you have specified the correct directory.\n", seedFileName)
 	}
 
 	return seedFileName, err
@@ -162,4 +219,210 @@ func ReadLinesFromFile(filename string) ([]string, error) {
 	}
 
 	return lines, nil
-}
\ No newline at end of file
+}
+//DirSize returns the total size in bytes of the regular files under dir
+func DirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+//WatchDirSize measures the size of dir, as DirSize, every interval and calls exceeded with the
+// size the first time it is more than limit bytes. Returns a function that stops watching.
+func WatchDirSize(dir string, limit int64, interval time.Duration, exceeded func(int64)) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// files may be removed while the directory is walked; try again next tick
+				if size, err := DirSize(dir); err == nil && size > limit {
+					exceeded(size)
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+//FileMediaTypes returns the media types of a file derived from its extension and sniffed from its
+// first bytes, without parameters such as the charset. Types that cannot be determined, including
+// the application/octet-stream sniffed from unrecognized content, are omitted.
+func FileMediaTypes(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	var types []string
+	for _, t := range []string{mime.TypeByExtension(filepath.Ext(file)), http.DetectContentType(head[:n])} {
+		if i := strings.Index(t, ";"); i >= 0 {
+			t = t[:i]
+		}
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && t != "application/octet-stream" && !ContainsString(types, t) {
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+//MediaTypeMatches returns whether the media type matches one of the declared media types, which
+// may be a wildcard such as image/*. Parameters and case are ignored.
+func MediaTypeMatches(mediaType string, declared []string) bool {
+	if i := strings.Index(mediaType, ";"); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, d := range declared {
+		if i := strings.Index(d, ";"); i >= 0 {
+			d = d[:i]
+		}
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == mediaType || d == "*/*" || (strings.HasSuffix(d, "/*") && strings.HasPrefix(mediaType, d[:len(d)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+//JSONErrorPosition returns the message of an error decoding the JSON data, prefixed with the
+// line and column it occurred at if the error gives its offset, i.e. line 3, column 14: ...
+func JSONErrorPosition(data []byte, err error) string {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	default:
+		return err.Error()
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	// The offset is just past the bad token; report the position of its last byte
+	if offset > 0 {
+		offset--
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(data[:offset], '\n')
+	return fmt.Sprintf("line %d, column %d: %s", line, column, err.Error())
+}
+
+//countingWriter counts the bytes written to it
+type countingWriter struct {
+	count int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.count += int64(len(p))
+	return len(p), nil
+}
+
+//ContextCompression measures the size of the tarred build context in dir before and after
+// gzip compression, along with the time taken to compress it. Files excluded by the
+// .dockerignore are not included.
+func ContextCompression(dir string) (int64, int64, time.Duration, error) {
+	start := time.Now()
+	raw, compressed := &countingWriter{}, &countingWriter{}
+	gz := gzip.NewWriter(compressed)
+	if err := writeTar(io.MultiWriter(raw, gz), dir, walkBuildContext); err != nil {
+		return 0, 0, 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, 0, 0, err
+	}
+
+	return raw.count, compressed.count, time.Since(start), nil
+}
+
+//TarDirectory writes the files and directories beneath dir to w as a gzipped tar archive, with
+// paths relative to dir. Symbolic links are archived as links.
+func TarDirectory(dir string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	if err := writeTar(gz, dir, walkDirectory); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+//walkDirectory calls fn for each file and directory beneath dir, with the path relative to dir
+func walkDirectory(dir string, fn func(path, rel string, info os.FileInfo) error) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return fn(path, rel, info)
+	})
+}
+
+//writeTar writes the files and directories of dir visited by walk to w as a tar archive, with
+// paths relative to dir. Symbolic links are archived as links.
+func writeTar(w io.Writer, dir string, walk func(string, func(path, rel string, info os.FileInfo) error) error) error {
+	tw := tar.NewWriter(w)
+	err := walk(dir, func(path, rel string, info os.FileInfo) error {
+		if rel == "." {
+			return nil
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			var err error
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
@@ -0,0 +1,118 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+)
+
+//LogLevel defines the severity of a log message
+type LogLevel int
+
+const (
+	//LevelDebug detailed messages such as the docker commands being executed
+	LevelDebug LogLevel = iota
+
+	//LevelInfo progress messages (default)
+	LevelInfo
+
+	//LevelWarn problems that do not stop the command
+	LevelWarn
+
+	//LevelError problems that stop the command
+	LevelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+var logPrefixes = []string{"DEBUG: ", "INFO: ", "WARNING: ", "ERROR: "}
+
+var logLevel = LevelInfo
+
+//lastError is the last error message logged, and jsonErrors whether error messages are only
+// recorded, as -json-errors reports them as JSON
+var lastError string
+var jsonErrors bool
+
+//String returns the name of the log level
+func (l LogLevel) String() string {
+	return logLevelNames[l]
+}
+
+//ParseLogLevel returns the log level with the given name
+func ParseLogLevel(name string) (LogLevel, error) {
+	for i, n := range logLevelNames {
+		if strings.EqualFold(name, n) {
+			return LogLevel(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("Unknown log level %q. Must be one of %s",
+		name, strings.Join(logLevelNames, ", "))
+}
+
+//SetLogLevel sets the minimum level of messages that are printed
+func SetLogLevel(level LogLevel) {
+	logLevel = level
+}
+
+//GetLogLevel returns the minimum level of messages that are printed
+func GetLogLevel() LogLevel {
+	return logLevel
+}
+
+//logf prints the message with the prefix for its level if the level is enabled. Debug
+// and info messages are also discarded in quiet mode.
+func logf(level LogLevel, format string, args ...interface{}) {
+	if level < logLevel || (quiet && level <= LevelInfo) {
+		return
+	}
+	PrintUtil(logPrefixes[level]+format, args...)
+}
+
+//Debugf prints a debug message
+func Debugf(format string, args ...interface{}) {
+	logf(LevelDebug, format, args...)
+}
+
+//Infof prints an informational message
+func Infof(format string, args ...interface{}) {
+	logf(LevelInfo, format, args...)
+}
+
+//Warnf prints a warning message
+func Warnf(format string, args ...interface{}) {
+	logf(LevelWarn, format, args...)
+}
+
+//Errorf prints an error message
+func Errorf(format string, args ...interface{}) {
+	lastError = strings.TrimSpace(fmt.Sprintf(format, args...))
+	if jsonErrors {
+		return
+	}
+	logf(LevelError, format, args...)
+}
+
+//PrintError prints the error as an error message. Messages built with their own ERROR: prefix,
+// i.e. validation reports, are not prefixed twice.
+func PrintError(err error) {
+	Errorf("%s\n", strings.TrimSpace(strings.TrimPrefix(err.Error(), "ERROR:")))
+}
+
+//SetJsonErrors selects whether error messages are printed, or only recorded for LastError. It
+// also clears the last error.
+func SetJsonErrors(enabled bool) {
+	jsonErrors = enabled
+	lastError = ""
+}
+
+//LastError returns the last error message logged with Errorf
+func LastError() string {
+	return lastError
+}
+
+//DebugCommand prints the command being executed at debug level
+func DebugCommand(name string, args []string) {
+	if logLevel > LevelDebug {
+		return
+	}
+	Debugf("Executing %s %s\n", name, strings.Join(args, " "))
+}
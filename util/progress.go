@@ -0,0 +1,222 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ngageoint/seed-cli/constants"
+	"golang.org/x/term"
+)
+
+//progressMode is how docker build and pull progress is displayed, constants.ProgressPlain or
+// constants.ProgressBar
+var progressMode = constants.ProgressPlain
+
+//SetProgressMode selects how docker build and pull progress is displayed. Returns an error for
+// modes other than constants.ProgressPlain and constants.ProgressBar.
+func SetProgressMode(mode string) error {
+	switch mode {
+	case constants.ProgressPlain, constants.ProgressBar:
+		progressMode = mode
+		return nil
+	}
+	return fmt.Errorf("Invalid -%s value %s. Must be %s or %s", constants.ProgressFlag, mode,
+		constants.ProgressBar, constants.ProgressPlain)
+}
+
+//ProgressMode returns how docker build and pull progress is displayed
+func ProgressMode() string {
+	return progressMode
+}
+
+//DockerProgress returns the writer the output of a docker build or pull should be copied to and
+// a function to call with the command's error once it exits. In bar mode the output is
+// consolidated into a single progress bar, and the last lines of output are printed if the
+// command fails. Otherwise the output is copied to the ProgressWriter.
+func DockerProgress(action string) (io.Writer, func(error)) {
+	if quiet || progressMode != constants.ProgressBar {
+		return ProgressWriter(), func(error) {}
+	}
+	bar := NewProgressBar(os.Stderr, action, term.IsTerminal(int(os.Stderr.Fd())))
+	return bar, bar.Finish
+}
+
+//progressTailLines is the number of lines of output a progress bar keeps to print on failure
+const progressTailLines = 20
+
+//progressBarWidth is the number of characters in a rendered progress bar
+const progressBarWidth = 30
+
+var (
+	// Step 3/7 : RUN make
+	buildStepRegex = regexp.MustCompile(`^Step (\d+)/(\d+) : (.*)$`)
+
+	// #5 [2/4] RUN make, or #5 [build 2/4] RUN make for multi-stage builds
+	buildKitStepRegex = regexp.MustCompile(`^#\d+ \[(?:[^\]]* )?(\d+)/(\d+)\] (.*)$`)
+
+	// 59bf1c3509f3: Downloading [=====>     ]  1.2MB/3.4MB
+	layerRegex = regexp.MustCompile(`^([0-9a-f]{12}): ([A-Za-z][A-Za-z ]*[a-z])`)
+)
+
+//ProgressBar is an io.Writer that parses the output of docker build or docker pull and renders
+// the percent complete and current step or layer as a single progress line. Build progress is
+// measured in Dockerfile steps and pull progress in layers.
+type ProgressBar struct {
+	out      io.Writer
+	action   string
+	terminal bool
+
+	mu       sync.Mutex
+	partial  string
+	step     int
+	steps    int
+	layers   map[string]bool
+	order    []string
+	current  string
+	rendered string
+	tail     []string
+}
+
+//NewProgressBar returns a progress bar for the given action, i.e. Building, written to out.
+// On a terminal the bar is redrawn in place; otherwise a line is written each time it changes.
+func NewProgressBar(out io.Writer, action string, terminal bool) *ProgressBar {
+	return &ProgressBar{out: out, action: action, terminal: terminal, layers: map[string]bool{}}
+}
+
+//Write parses complete lines of docker output and redraws the bar
+func (p *ProgressBar) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// docker redraws pull progress on a terminal with carriage returns
+	data := strings.Replace(p.partial+string(b), "\r", "\n", -1)
+	lines := strings.Split(data, "\n")
+	p.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		if line = strings.TrimSpace(line); line != "" {
+			p.parse(line)
+		}
+	}
+	p.render()
+	return len(b), nil
+}
+
+//parse updates the progress from a line of docker output
+func (p *ProgressBar) parse(line string) {
+	p.tail = append(p.tail, line)
+	if len(p.tail) > progressTailLines {
+		p.tail = p.tail[1:]
+	}
+
+	if m := buildStepRegex.FindStringSubmatch(line); m != nil {
+		p.setStep(m[1], m[2], m[3])
+	} else if m := buildKitStepRegex.FindStringSubmatch(line); m != nil {
+		p.setStep(m[1], m[2], m[3])
+	} else if m := layerRegex.FindStringSubmatch(line); m != nil {
+		id, status := m[1], m[2]
+		if _, ok := p.layers[id]; !ok {
+			p.order = append(p.order, id)
+		}
+		p.layers[id] = status == "Pull complete" || status == "Already exists" || status == "Pushed" ||
+			status == "Layer already exists"
+		p.current = id + ": " + status
+	}
+}
+
+//setStep records the current Dockerfile step. Multi-stage BuildKit builds number the steps of
+// each stage separately, so the furthest step reached is kept.
+func (p *ProgressBar) setStep(step, steps, current string) {
+	n, _ := strconv.Atoi(step)
+	total, _ := strconv.Atoi(steps)
+	if total >= p.steps {
+		p.step, p.steps = n, total
+	}
+	p.current = "Step " + step + "/" + steps + ": " + current
+}
+
+//Percent returns the percent complete, measured in build steps if any were seen and otherwise
+// in completed layers
+func (p *ProgressBar) Percent() int {
+	if p.steps > 0 {
+		// a step is complete once the next one starts
+		return (p.step - 1) * 100 / p.steps
+	}
+	if len(p.layers) == 0 {
+		return 0
+	}
+	done := 0
+	for _, complete := range p.layers {
+		if complete {
+			done++
+		}
+	}
+	return done * 100 / len(p.layers)
+}
+
+//Current returns the step or layer currently in progress
+func (p *ProgressBar) Current() string {
+	return p.current
+}
+
+//line returns the rendered progress line for the given percent
+func (p *ProgressBar) line(percent int) string {
+	filled := percent * progressBarWidth / 100
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	line := fmt.Sprintf("%s [%s] %3d%%", p.action, bar, percent)
+	if p.current != "" && percent < 100 {
+		line += " " + p.current
+	}
+	if p.terminal {
+		if width, _, err := term.GetSize(int(os.Stderr.Fd())); err == nil && width > 0 && len(line) >= width {
+			line = line[:width-1]
+		}
+	}
+	return line
+}
+
+//render writes the progress line if it changed
+func (p *ProgressBar) render() {
+	p.draw(p.line(p.Percent()))
+}
+
+//draw writes a progress line, in place on a terminal
+func (p *ProgressBar) draw(line string) {
+	if line == p.rendered {
+		return
+	}
+	p.rendered = line
+	if p.terminal {
+		fmt.Fprintf(p.out, "\r\033[K%s", line)
+	} else {
+		fmt.Fprintln(p.out, line)
+	}
+}
+
+//Finish completes the bar once the docker command exits. If it failed the last lines of
+// output are printed, as the errors reported by docker are not shown in the bar.
+func (p *ProgressBar) Finish(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.partial != "" {
+		p.parse(strings.TrimSpace(p.partial))
+		p.partial = ""
+	}
+	if err == nil {
+		p.draw(p.line(100))
+	}
+	if p.terminal && p.rendered != "" {
+		fmt.Fprintln(p.out)
+	}
+	if err != nil && len(p.tail) > 0 {
+		fmt.Fprintf(p.out, "Last %d lines of output:\n%s\n", len(p.tail), strings.Join(p.tail, "\n"))
+	}
+}
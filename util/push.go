@@ -0,0 +1,152 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//pushLayerRegex matches the final status docker push reports for a layer, identified by the
+// first 12 characters of its diff id
+var pushLayerRegex = regexp.MustCompile(`(?m)^([a-f0-9]{12}): (Pushed|Layer already exists|Mounted from \S+)\s*$`)
+
+//PushSummary is what a docker push transferred to the registry
+type PushSummary struct {
+	// Pushed are the short diff ids of the layers uploaded
+	Pushed []string
+
+	// Skipped is the number of layers already in the registry or mounted from another repository
+	Skipped int
+
+	// Bytes is the compressed size of the uploaded layers, or -1 if the registry sizes are unknown
+	Bytes int64
+
+	// Duration is how long the push took
+	Duration time.Duration
+}
+
+//ParsePushLayers returns the layers docker push uploaded and the number it skipped, as they
+// already existed in the registry, from its output
+func ParsePushLayers(output string) ([]string, int) {
+	var pushed []string
+	skipped := 0
+	for _, m := range pushLayerRegex.FindAllStringSubmatch(output, -1) {
+		if m[2] == "Pushed" {
+			pushed = append(pushed, m[1])
+		} else {
+			skipped++
+		}
+	}
+	return pushed, skipped
+}
+
+//PushedBytes returns the total compressed size of the pushed layers. layers are the diff ids of
+// the image, in order, and sizes the sizes of the layers of its registry manifest, in the same
+// order. Returns -1 if they do not correspond or a pushed layer is not among them.
+func PushedBytes(pushed, layers []string, sizes []int64) int64 {
+	if len(layers) != len(sizes) {
+		return -1
+	}
+	layerSizes := map[string]int64{}
+	for i, layer := range layers {
+		id := strings.TrimPrefix(layer, "sha256:")
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		layerSizes[id] = sizes[i]
+	}
+	var total int64
+	for _, id := range pushed {
+		size, ok := layerSizes[id]
+		if !ok {
+			return -1
+		}
+		total += size
+	}
+	return total
+}
+
+//imageDiffIds returns the diff ids of the layers of a local image, in order
+func imageDiffIds(img string) ([]string, error) {
+	args := []string{"image", "inspect", "-f", "{{json .RootFS.Layers}}", img}
+	DebugCommand("docker", args)
+	out, err := DockerOutput(args...)
+	if err != nil {
+		return nil, err
+	}
+	var layers []string
+	err = json.Unmarshal(out, &layers)
+	return layers, err
+}
+
+//manifestLayerSizes returns the sizes of the layers of an image's manifest in its registry, in
+// order. Uses docker manifest inspect, which older clients only enable as an experimental feature.
+func manifestLayerSizes(img string) ([]int64, error) {
+	args := []string{"manifest", "inspect", img}
+	DebugCommand("docker", args)
+	var out bytes.Buffer
+	err := RunDocker(DockerCommand{Args: args, Env: []string{"DOCKER_CLI_EXPERIMENTAL=enabled"}, Stdout: &out})
+	if err != nil {
+		return nil, err
+	}
+	var manifest struct {
+		Layers []struct {
+			Size int64 `json:"size"`
+		} `json:"layers"`
+	}
+	if err = json.Unmarshal(out.Bytes(), &manifest); err != nil {
+		return nil, err
+	}
+	if len(manifest.Layers) == 0 {
+		return nil, fmt.Errorf("No layers in the manifest of %s", img)
+	}
+	var sizes []int64
+	for _, l := range manifest.Layers {
+		sizes = append(sizes, l.Size)
+	}
+	return sizes, nil
+}
+
+//NewPushSummary summarizes the push of img from the docker push output and the time it took. If
+// measure is set the size of the pushed layers is read from the registry; Bytes is -1 if it is
+// not or cannot be.
+func NewPushSummary(img, output string, duration time.Duration, measure bool) PushSummary {
+	summary := PushSummary{Bytes: -1, Duration: duration}
+	summary.Pushed, summary.Skipped = ParsePushLayers(output)
+	if len(summary.Pushed) == 0 {
+		summary.Bytes = 0
+		return summary
+	}
+	if !measure {
+		return summary
+	}
+	layers, err := imageDiffIds(img)
+	if err != nil {
+		Debugf("Error reading the layers of %s: %s\n", img, err.Error())
+		return summary
+	}
+	sizes, err := manifestLayerSizes(img)
+	if err != nil {
+		Debugf("Error reading the registry manifest of %s: %s\n", img, err.Error())
+		return summary
+	}
+	summary.Bytes = PushedBytes(summary.Pushed, layers, sizes)
+	return summary
+}
+
+//String describes the push, i.e. "Pushed 2 layers (12.3 MiB), skipped 5 already in the registry, in 8.1s"
+func (s PushSummary) String() string {
+	size := ""
+	if s.Bytes >= 0 {
+		size = fmt.Sprintf(" (%.1f MiB)", float64(s.Bytes)/(1024.0*1024.0))
+	}
+	layers := "layers"
+	if len(s.Pushed) == 1 {
+		layers = "layer"
+	}
+	return fmt.Sprintf("Pushed %d %s%s, skipped %d already in the registry, in %s", len(s.Pushed), layers, size,
+		s.Skipped, s.Duration.Round(100*time.Millisecond))
+}
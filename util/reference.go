@@ -0,0 +1,136 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//imageReferenceRegex matches a docker image reference: an optional registry host, one or more
+// lowercase path components and an optional tag and digest
+var imageReferenceRegex = regexp.MustCompile(`^` +
+	`(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+	`[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*` +
+	`(?::[\w][\w.-]{0,127})?` +
+	`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?$`)
+
+//DefaultTag is the tag docker uses for an image reference without a tag or digest
+const DefaultTag = "latest"
+
+//IsValidImageReference reports whether ref is a well formed docker image reference,
+// i.e. registry.example.com:5000/org/my-job-1.0.0-seed:1.0.0
+func IsValidImageReference(ref string) bool {
+	return imageReferenceRegex.MatchString(ref)
+}
+
+//Reference is a docker image reference split into its parts, i.e.
+// registry.example.com:5000/geoint/my-job-1.0.0-seed:1.0.0 has the registry
+// registry.example.com:5000, org geoint, repo my-job-1.0.0-seed and tag 1.0.0. Org holds all
+// the path components between the registry and the repo, so may contain slashes.
+type Reference struct {
+	Registry string
+	Org      string
+	Repo     string
+	Tag      string
+	Digest   string
+}
+
+//ParseReference parses a docker image reference, as SplitReference. Returns an error if s is not
+// a valid image reference.
+func ParseReference(s string) (Reference, error) {
+	if !IsValidImageReference(s) {
+		return Reference{}, fmt.Errorf("Invalid image reference %q. Image references have the form "+
+			"[REGISTRY/][ORG/]NAME[:TAG][@DIGEST] with a lowercase name, i.e. "+
+			"registry.example.com/geoint/my-job-1.0.0-seed:1.0.0", s)
+	}
+	return SplitReference(s), nil
+}
+
+//SplitReference splits an image reference into its parts without checking it is valid, for
+// names that only need to be taken apart, i.e. to derive a job name. The first path component
+// is the registry if it contains a . or : or is localhost, as docker decides.
+func SplitReference(s string) Reference {
+	var ref Reference
+	name := s
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) > 1 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry, parts = parts[0], parts[1:]
+	}
+	ref.Repo = parts[len(parts)-1]
+	ref.Org = strings.Join(parts[:len(parts)-1], "/")
+	return ref
+}
+
+//RegistryHost returns the host of a registry url given on the command line, i.e.
+// https://registry.example.com/ is registry.example.com
+func RegistryHost(registry string) string {
+	registry = strings.TrimPrefix(registry, "https://")
+	registry = strings.TrimPrefix(registry, "http://")
+	return strings.TrimSuffix(registry, "/")
+}
+
+//Name returns the org and repo of the reference, the path of the image on its registry
+func (r Reference) Name() string {
+	if r.Org == "" {
+		return r.Repo
+	}
+	return r.Org + "/" + r.Repo
+}
+
+//Repository returns the registry, org and repo of the reference, without the tag and digest
+func (r Reference) Repository() string {
+	if r.Registry == "" {
+		return r.Name()
+	}
+	return r.Registry + "/" + r.Name()
+}
+
+//String returns the reference in the form [REGISTRY/][ORG/]NAME[:TAG][@DIGEST]
+func (r Reference) String() string {
+	s := r.Repository()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+//TagOrDefault returns the tag of the reference, or latest, the tag docker uses, if it has none
+func (r Reference) TagOrDefault() string {
+	if r.Tag == "" {
+		return DefaultTag
+	}
+	return r.Tag
+}
+
+//WithTag returns the reference with its tag replaced and digest removed
+func (r Reference) WithTag(tag string) Reference {
+	r.Tag, r.Digest = tag, ""
+	return r
+}
+
+//WithDigest returns the reference by the given digest, without a tag
+func (r Reference) WithDigest(digest string) Reference {
+	r.Tag, r.Digest = "", digest
+	return r
+}
+
+//Qualify returns the reference on the given registry and org. A registry url is reduced to its
+// host. An empty registry or org leaves that of the reference unchanged.
+func (r Reference) Qualify(registry, org string) Reference {
+	if registry != "" {
+		r.Registry = RegistryHost(registry)
+	}
+	if org != "" {
+		r.Org = org
+	}
+	return r
+}
@@ -0,0 +1,191 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+//DockerCommand is an invocation of the docker client. Streams left nil are discarded, or empty
+// for Stdin.
+type DockerCommand struct {
+	Args []string
+
+	// Env is added to the environment of seed, i.e. DOCKER_CLI_EXPERIMENTAL=enabled
+	Env []string
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+//DockerRunner runs docker commands. Every docker command seed runs goes through the runner set
+// with SetDockerRunner, so tests can replace docker with a FakeDockerRunner and assert on the
+// arguments commands construct.
+type DockerRunner interface {
+	//Run runs the command and waits for it to exit. A command that exits non-zero returns an
+	// error with an ExitCode method, which ExitCode reads.
+	Run(cmd DockerCommand) error
+}
+
+//execDockerRunner runs the docker client found on the PATH
+type execDockerRunner struct{}
+
+//Run runs the docker client with the command's arguments, streams and environment
+func (execDockerRunner) Run(c DockerCommand) error {
+	cmd := exec.Command("docker", c.Args...)
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	if c.Stdin != nil {
+		cmd.Stdin = c.Stdin
+	}
+	if c.Stdout != nil {
+		cmd.Stdout = c.Stdout
+	}
+	if c.Stderr != nil {
+		cmd.Stderr = c.Stderr
+	}
+	return cmd.Run()
+}
+
+var dockerRunner DockerRunner = execDockerRunner{}
+
+//SetDockerRunner replaces the runner docker commands go through and returns the one it replaced,
+// so a test can restore it. A nil runner restores the docker client.
+func SetDockerRunner(runner DockerRunner) DockerRunner {
+	previous := dockerRunner
+	if runner == nil {
+		runner = execDockerRunner{}
+	}
+	dockerRunner = runner
+	return previous
+}
+
+//RunDocker runs a docker command through the current DockerRunner
+func RunDocker(cmd DockerCommand) error {
+	return dockerRunner.Run(cmd)
+}
+
+//DockerOutput runs docker with the given arguments and returns what it wrote to stdout
+func DockerOutput(args ...string) ([]byte, error) {
+	var out bytes.Buffer
+	err := RunDocker(DockerCommand{Args: args, Stdout: &out})
+	return out.Bytes(), err
+}
+
+//DockerCombinedOutput runs docker with the given arguments and returns what it wrote to stdout
+// and stderr
+func DockerCombinedOutput(args ...string) ([]byte, error) {
+	var out bytes.Buffer
+	err := RunDocker(DockerCommand{Args: args, Stdout: &out, Stderr: &out})
+	return out.Bytes(), err
+}
+
+//ExitCode returns the exit code of a command that ended with err and whether it ran to an exit,
+// rather than failing to start. The code is -1 if the command was killed by a signal.
+func ExitCode(err error) (int, bool) {
+	if exited, ok := err.(interface{ ExitCode() int }); ok {
+		return exited.ExitCode(), true
+	}
+	return -1, false
+}
+
+//DockerExitError is returned by a FakeDockerRunner for a command that exits non-zero
+type DockerExitError struct {
+	Code int
+}
+
+//Error describes the exit code, as exec.ExitError does
+func (e *DockerExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+//ExitCode returns the exit code
+func (e *DockerExitError) ExitCode() int {
+	return e.Code
+}
+
+//FakeDockerResponse is what a FakeDockerRunner does for commands starting with Args
+type FakeDockerResponse struct {
+	// Args are the leading arguments of the commands answered, i.e. {"image", "inspect"}. Empty
+	// answers every command.
+	Args []string
+
+	Stdout   string
+	Stderr   string
+	ExitCode int
+
+	// Err is returned in place of an exit code, i.e. for a docker client that cannot be started
+	Err error
+}
+
+//FakeDockerRunner records the docker commands it is given instead of running them and answers
+// each with the first response whose Args it starts with. Commands without a response succeed
+// with no output. Safe for concurrent use.
+type FakeDockerRunner struct {
+	Responses []FakeDockerResponse
+
+	mu    sync.Mutex
+	calls [][]string
+}
+
+//Run records the command and writes the output of its response
+func (f *FakeDockerRunner) Run(c DockerCommand) error {
+	f.mu.Lock()
+	f.calls = append(f.calls, append([]string(nil), c.Args...))
+	f.mu.Unlock()
+
+	for _, r := range f.Responses {
+		if !hasArgsPrefix(c.Args, r.Args) {
+			continue
+		}
+		if r.Err != nil {
+			return r.Err
+		}
+		if c.Stdout != nil {
+			io.WriteString(c.Stdout, r.Stdout)
+		}
+		if c.Stderr != nil {
+			io.WriteString(c.Stderr, r.Stderr)
+		}
+		if r.ExitCode != 0 {
+			return &DockerExitError{Code: r.ExitCode}
+		}
+		return nil
+	}
+	return nil
+}
+
+//Calls returns the arguments of each command run, in order
+func (f *FakeDockerRunner) Calls() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]string(nil), f.calls...)
+}
+
+//Commands returns each command run as a single string, i.e. "tag img localhost:5000/img"
+func (f *FakeDockerRunner) Commands() []string {
+	var commands []string
+	for _, args := range f.Calls() {
+		commands = append(commands, strings.Join(args, " "))
+	}
+	return commands
+}
+
+//hasArgsPrefix returns whether args starts with prefix
+func hasArgsPrefix(args, prefix []string) bool {
+	if len(prefix) > len(args) {
+		return false
+	}
+	for i, p := range prefix {
+		if args[i] != p {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+//tablePadding is the number of spaces between the columns of a table
+const tablePadding = 3
+
+//FormatTable formats rows as aligned columns under a bold header. Each column is colored with
+// the color of the same index, if colored output is enabled and the color is not empty. Columns
+// are padded to the width of their text, not counting color escapes, so colored and plain cells
+// line up.
+func FormatTable(headers, colors []string, rows [][]string) string {
+	widths := make([]int, len(headers))
+	for _, cells := range append([][]string{headers}, rows...) {
+		for i, cell := range cells {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	colorize := Colorizer()
+	var b strings.Builder
+	format := func(cells []string, color func(i int) string) {
+		for i, cell := range cells {
+			padding := ""
+			if i < len(cells)-1 {
+				padding = strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+tablePadding)
+			}
+			if c := color(i); c != "" {
+				cell = colorize(c, cell)
+			}
+			b.WriteString(cell + padding)
+		}
+		b.WriteString("\n")
+	}
+
+	format(headers, func(i int) string { return ColorBold })
+	for _, row := range rows {
+		format(row, func(i int) string {
+			if i < len(colors) {
+				return colors[i]
+			}
+			return ""
+		})
+	}
+	return b.String()
+}
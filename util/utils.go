@@ -2,8 +2,14 @@ package util
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"os"
+	"strconv"
 	"time"
+
+	"github.com/ngageoint/seed-cli/constants"
 )
 
 type PrintCallback func(format string, args ...interface{})
@@ -31,6 +37,40 @@ func InitPrinter(quiet bool) {
 	}
 }
 
+var quiet bool
+
+//SetQuiet toggles quiet mode. When quiet, docker progress streams and informational
+// messages are discarded while errors and final results are still printed.
+func SetQuiet(q bool) {
+	quiet = q
+}
+
+//IsQuiet returns true if quiet mode is enabled
+func IsQuiet() bool {
+	return quiet
+}
+
+//Reset restores the printer, quiet mode, log level, -json-errors, progress display and colored
+// output to their defaults and forgets the last error, so seed may be run more than once in the
+// same process
+func Reset() {
+	InitPrinter(false)
+	SetQuiet(false)
+	SetLogLevel(LevelInfo)
+	SetJsonErrors(false)
+	SetNoColor(false)
+	progressMode = constants.ProgressPlain
+	lastError = ""
+}
+
+//ProgressWriter returns the writer docker progress output should be copied to
+func ProgressWriter() io.Writer {
+	if quiet {
+		return ioutil.Discard
+	}
+	return os.Stderr
+}
+
 //TimeTrack function for timing function calls. Usage:
 // defer TimeTrack(time.Now()) at the beginning of the timed function
 func TimeTrack(start time.Time, name string) {
@@ -49,4 +89,26 @@ func HandleExit() {
 		}
 		panic(e)
 	}
-}
\ No newline at end of file
+}
+
+//PortInUse reports whether a port on the host is already in use by trying to listen on it.
+// proto is tcp, udp or sctp; sctp ports cannot be checked and are reported as free. An empty
+// ip checks all interfaces.
+func PortInUse(ip string, port int, proto string) bool {
+	address := net.JoinHostPort(ip, strconv.Itoa(port))
+	switch proto {
+	case "", "tcp":
+		l, err := net.Listen("tcp", address)
+		if err != nil {
+			return true
+		}
+		l.Close()
+	case "udp":
+		c, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return true
+		}
+		c.Close()
+	}
+	return false
+}
@@ -1,6 +1,18 @@
 package util
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+//userNameRegex matches a portable POSIX user or group name
+var userNameRegex = regexp.MustCompile(`^[a-z_][a-z0-9_.-]*[$]?$`)
+
+//userIdRegex matches a numeric user or group id
+var userIdRegex = regexp.MustCompile(`^[0-9]+$`)
 
 //GetNormalizedVariable transforms an input name into the spec required environment variable
 func GetNormalizedVariable(inputName string) string {
@@ -75,3 +87,32 @@ func ContainsString(s []string, r string) bool {
 	}
 	return false
 }
+
+//HostUser returns the uid:gid of the user running seed, for running a container as that user
+// so the files it writes are owned by them. Returns an error on systems without numeric ids,
+// i.e. windows.
+func HostUser() (string, error) {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid < 0 || gid < 0 {
+		return "", errors.New("The host user has no numeric user id on this system")
+	}
+	return fmt.Sprintf("%d:%d", uid, gid), nil
+}
+
+//ValidateUser checks that a container user in the form user[:group] is made up of
+// numeric ids or valid user and group names
+func ValidateUser(user string) error {
+	parts := strings.Split(user, ":")
+	if len(parts) > 2 {
+		return errors.New("User " + user + " must be in the form user[:group]")
+	}
+	for _, p := range parts {
+		if userIdRegex.MatchString(p) {
+			continue
+		}
+		if len(p) > 32 || !userNameRegex.MatchString(p) {
+			return errors.New("User " + user + " must be a numeric id or a valid user name")
+		}
+	}
+	return nil
+}
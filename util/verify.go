@@ -0,0 +1,147 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+//CosignCommand defines the cosign executable used to verify image signatures. Only cosign
+// signatures (https://github.com/sigstore/cosign) checked against a public key are supported;
+// Docker Content Trust (notary) signatures are not.
+const CosignCommand = "cosign"
+
+//ErrNoSignature is returned by VerifyImageSignature when the image has no signature
+var ErrNoSignature = errors.New("no signature found")
+
+//noSignatureMessage is the cosign verify error reporting that an image is not signed, as
+// opposed to "no matching signatures" for an image signed with a different key
+const noSignatureMessage = "no signatures found"
+
+//VerifyOptions defines how seed pull verifies the signature of a pulled image
+type VerifyOptions struct {
+	// Verify checks the cosign signature of the image against PublicKey
+	Verify bool
+
+	// PublicKey is the cosign public key file, or a key management service URI such as
+	// awskms://, gcpkms:// or hashivault:// understood by cosign
+	PublicKey string
+
+	// RequireSignature fails verification of images without a signature, which otherwise
+	// only produce a warning
+	RequireSignature bool
+}
+
+//Check returns an error if signature verification is requested without a public key, or the
+// public key file does not exist
+func (o VerifyOptions) Check() error {
+	if !o.Verify {
+		return nil
+	}
+	if o.PublicKey == "" {
+		return errors.New("Signature verification requires a public key")
+	}
+	if !strings.Contains(o.PublicKey, "://") {
+		if _, err := os.Stat(o.PublicKey); err != nil {
+			return fmt.Errorf("Error reading public key %s: %s", o.PublicKey, err.Error())
+		}
+	}
+	if _, err := exec.LookPath(CosignCommand); err != nil {
+		return errors.New("Signature verification requires cosign (https://github.com/sigstore/cosign) on the PATH")
+	}
+	return nil
+}
+
+//VerifyImageSignature verifies the cosign signature of a remote image against the public key.
+// The image should be given by digest so the verified image is the one that was pulled.
+// Returns ErrNoSignature if the image is not signed.
+func VerifyImageSignature(image, publicKey string) error {
+	args := []string{"verify", "--key", publicKey, image}
+	DebugCommand(CosignCommand, args)
+	cmd := exec.Command(CosignCommand, args...)
+	var errs bytes.Buffer
+	cmd.Stderr = &errs
+	cmd.Stdout = ioutil.Discard
+
+	if err := cmd.Run(); err != nil {
+		if IsNoSignatureError(errs.String()) {
+			return ErrNoSignature
+		}
+		detail := strings.TrimSpace(errs.String())
+		if detail == "" {
+			detail = err.Error()
+		}
+		return fmt.Errorf("Signature verification of %s failed: %s", image, detail)
+	}
+	return nil
+}
+
+//IsNoSignatureError reports whether cosign verify output reports that the image is not signed
+func IsNoSignatureError(output string) bool {
+	return strings.Contains(strings.ToLower(output), noSignatureMessage)
+}
+
+//SignOptions defines how seed publish signs the pushed image
+type SignOptions struct {
+	// Sign creates a cosign signature for the pushed digest with PrivateKey and pushes it to
+	// the registry
+	Sign bool
+
+	// PrivateKey is the cosign private key file, or a key management service URI understood
+	// by cosign. The password of an encrypted key is read by cosign from COSIGN_PASSWORD.
+	PrivateKey string
+}
+
+//Check returns an error if signing is requested without a private key, or the private key
+// file does not exist
+func (o SignOptions) Check() error {
+	if !o.Sign {
+		return nil
+	}
+	if o.PrivateKey == "" {
+		return errors.New("Signing requires a private key")
+	}
+	if !strings.Contains(o.PrivateKey, "://") {
+		if _, err := os.Stat(o.PrivateKey); err != nil {
+			return fmt.Errorf("Error reading private key %s: %s", o.PrivateKey, err.Error())
+		}
+	}
+	if _, err := exec.LookPath(CosignCommand); err != nil {
+		return errors.New("Signing requires cosign (https://github.com/sigstore/cosign) on the PATH")
+	}
+	return nil
+}
+
+//SignImage creates a cosign signature for a pushed image with the private key and pushes it
+// to the registry. The image must be given by digest so the signature covers exactly the
+// image that was pushed.
+func SignImage(image, privateKey string) error {
+	if !strings.Contains(image, "@") {
+		return fmt.Errorf("Refusing to sign %s; images must be signed by digest", image)
+	}
+	args := []string{"sign", "--key", privateKey, "--yes", image}
+	DebugCommand(CosignCommand, args)
+	cmd := exec.Command(CosignCommand, args...)
+	var errs bytes.Buffer
+	cmd.Stderr = &errs
+	cmd.Stdout = ioutil.Discard
+
+	if err := cmd.Run(); err != nil {
+		detail := strings.TrimSpace(errs.String())
+		if detail == "" {
+			detail = err.Error()
+		}
+		return fmt.Errorf("Signing %s failed: %s", image, detail)
+	}
+	return nil
+}
+
+//DigestReference returns the reference of an image by digest, i.e. the digest sha256:abc123
+// of registry/org/image:tag is registry/org/image@sha256:abc123
+func DigestReference(img, digest string) string {
+	return SplitReference(img).WithDigest(digest).String()
+}